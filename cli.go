@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/by-nari/temporary-voice-channel-discord-bot/tvc"
+)
+
+// runSubcommand runs the offline subcommand named by the arguments, if any,
+// and reports whether there was one. None of them connect to Discord.
+//
+//	bot validate [--config path]
+//	bot print-config [--config path]
+//	bot replay [--config path] [--speed n] [--requests] events.jsonl
+func runSubcommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	fs := flag.NewFlagSet(args[0], flag.ExitOnError)
+	config := fs.String("config", "", "`path` of a file of KEY=value lines to use on top of the environment")
+
+	var settings map[string]string
+	var run func() error
+	switch args[0] {
+	case "validate":
+		run = func() error {
+			setupLogging(settings)
+			if _, err := tvc.New(tvc.Config{Settings: settings}); err != nil {
+				return err
+			}
+			fmt.Println("The configuration is valid.")
+			return nil
+		}
+	case "print-config":
+		run = func() error {
+			m, err := tvc.New(tvc.Config{Settings: settings})
+			if err != nil {
+				return err
+			}
+			m.PrintConfig(os.Stdout)
+			return nil
+		}
+	case "replay":
+		speed := fs.Float64("speed", 0, "replay `n` times as fast as recorded; 0 doesn't wait between events")
+		requests := fs.Bool("requests", false, "print every request the bot makes")
+		run = func() error {
+			if fs.NArg() != 1 {
+				return errors.New("usage: replay [--config path] [--speed n] [--requests] events.jsonl")
+			}
+			return replay(settings, fs.Arg(0), *speed, *requests)
+		}
+	default:
+		return false
+	}
+	fs.Parse(args[1:])
+
+	settings = environ()
+	if *config != "" {
+		vars, err := readConfigFile(*config)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		maps.Copy(settings, vars)
+	}
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	return true
+}
+
+// replay runs the events recorded in path through the bot against a fake
+// Discord API, logging what it does; see tvc.Replay. The bot's stats are kept
+// in memory, so a replay doesn't touch $STATS_PATH.
+func replay(settings map[string]string, path string, speed float64, requests bool) error {
+	setupLogging(settings)
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	opts := tvc.ReplayOptions{Speed: speed}
+	if requests {
+		opts.Requests = os.Stdout
+	}
+	return tvc.Replay(ctx, tvc.Config{Settings: settings}, f, opts)
+}
+
+// readConfigFile reads KEY=value lines, as in a .env file. Blank lines and
+// lines starting with # are skipped, and values may be quoted.
+func readConfigFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	vars := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%s:%d: want KEY=value", path, n)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		vars[key] = value
+	}
+	return vars, scanner.Err()
+}
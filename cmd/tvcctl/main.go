@@ -0,0 +1,196 @@
+// Command tvcctl administers a running bot through its HTTP API, for
+// operators who would rather not use the Discord admin commands.
+//
+//	tvcctl [-addr url] [-token token] rooms [-guild id]
+//	tvcctl cleanup [-guild id]
+//	tvcctl reload
+//	tvcctl kill on|off [-guild id]
+//
+// The address defaults to $TVC_ADDR, else http://localhost:8080, and the
+// token to $API_TOKEN, which must match the bot's.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+var client = &http.Client{Timeout: time.Minute}
+
+func main() {
+	addr := flag.String("addr", envOr("TVC_ADDR", "http://localhost:8080"), "`url` of the bot's HTTP API, its $HEALTH_ADDR")
+	token := flag.String("token", os.Getenv("API_TOKEN"), "the bot's $API_TOKEN")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	c := apiClient{addr: strings.TrimSuffix(*addr, "/"), token: *token}
+	args := flag.Args()
+	var err error
+	switch args[0] {
+	case "rooms":
+		err = c.rooms(guildFlag(args))
+	case "cleanup":
+		err = c.cleanup(guildFlag(args))
+	case "reload":
+		err = c.print("POST", "/reload", nil)
+	case "kill":
+		if len(args) < 2 || (args[1] != "on" && args[1] != "off") {
+			fmt.Fprintln(os.Stderr, "usage: tvcctl kill on|off [-guild id]")
+			os.Exit(2)
+		}
+		query := url.Values{"enabled": {fmt.Sprint(args[1] == "on")}}
+		if guild := guildFlag(args[1:]); guild != "" {
+			query.Set("guild_id", guild)
+		}
+		err = c.print("POST", "/killswitch", query)
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "tvcctl:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `usage: tvcctl [flags] <command>
+
+commands:
+  rooms [-guild id]        list the live rooms and abandoned channels
+  cleanup [-guild id]      delete empty rooms now and retry failed deletions
+  reload                   reread the bot's $LOBBIES_FILE
+  kill on|off [-guild id]  stop or resume the bot, everywhere or in one guild
+
+flags:`)
+	flag.PrintDefaults()
+}
+
+// guildFlag parses the subcommand's -guild flag from args, which start with
+// the subcommand's name.
+func guildFlag(args []string) string {
+	fs := flag.NewFlagSet(args[0], flag.ExitOnError)
+	guild := fs.String("guild", "", "only this guild `id`")
+	fs.Parse(args[1:])
+	return *guild
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// apiClient makes requests to the bot's HTTP API.
+type apiClient struct {
+	addr  string
+	token string
+}
+
+// do makes a request and returns the response body, or an error with the
+// bot's answer if it didn't succeed.
+func (c apiClient) do(method, path string, query url.Values) ([]byte, error) {
+	u := c.addr + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// print makes a request and prints the answer.
+func (c apiClient) print(method, path string, query url.Values) error {
+	body, err := c.do(method, path, query)
+	if err != nil {
+		return err
+	}
+	fmt.Print(string(body))
+	return nil
+}
+
+func (c apiClient) rooms(guild string) error {
+	query := url.Values{}
+	if guild != "" {
+		query.Set("guild_id", guild)
+	}
+	body, err := c.do("GET", "/rooms", query)
+	if err != nil {
+		return err
+	}
+	var rooms []struct {
+		GuildID   string `json:"guild_id"`
+		ChannelID string `json:"channel_id"`
+		OwnerID   string `json:"owner_id"`
+		Name      string `json:"name"`
+		LobbyCode string `json:"lobby_code"`
+		Occupants int    `json:"occupants"`
+		Abandoned bool   `json:"abandoned"`
+	}
+	if err := json.Unmarshal(body, &rooms); err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "GUILD\tCHANNEL\tOWNER\tMEMBERS\tNAME\tLOBBY")
+	for _, r := range rooms {
+		if r.Abandoned {
+			// The bot failed to delete it and keeps trying.
+			r.Name = "[abandoned] " + r.Name
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n", r.GuildID, r.ChannelID, r.OwnerID, r.Occupants, r.Name, r.LobbyCode)
+	}
+	return w.Flush()
+}
+
+func (c apiClient) cleanup(guild string) error {
+	query := url.Values{}
+	if guild != "" {
+		query.Set("guild_id", guild)
+	}
+	body, err := c.do("POST", "/cleanup", query)
+	if err != nil {
+		return err
+	}
+	var result struct {
+		Deleted int `json:"deleted"`
+		Held    int `json:"held"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return err
+	}
+	fmt.Printf("Deleted %d empty rooms.\n", result.Deleted)
+	if result.Held > 0 {
+		fmt.Printf("Safe mode is holding %d more until they are confirmed in the log channel.\n", result.Held)
+	}
+	return nil
+}
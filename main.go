@@ -4,213 +4,197 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
 
-	"github.com/diamondburned/arikawa/v3/api"
-	"github.com/diamondburned/arikawa/v3/discord"
-	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/by-nari/temporary-voice-channel-discord-bot/tvc"
 	"github.com/diamondburned/arikawa/v3/state"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
 var token = os.Getenv("BOT_TOKEN")
 
+// botTokens runs several bots from one process, as name=token pairs separated
+// by commas, e.g. "eu=...,us=...". Each bot has its own state, handlers and
+// stats, in $STATS_PATH_<name>, serves HTTP on $HEALTH_ADDR_<name> and
+// $METRICS_ADDR_<name>, and labels its metrics with bot="<name>". It
+// replaces $BOT_TOKEN.
+var botTokens = os.Getenv("BOT_TOKENS")
+
+// bot is one of the bots the process runs.
+type bot struct {
+	name  string
+	token string
+}
+
 func main() {
-	if token == "" {
-		log.Fatalln("No $BOT_TOKEN given.")
+	if runSubcommand(os.Args[1:]) {
+		return
 	}
-
-	// Initialize the state
-	s := state.New("Bot " + token)
-
-	// Add intents
-	s.AddIntents(gateway.IntentGuildVoiceStates)
-
-	// Create a new handler
-	h := newHandler(s)
-
-	// Register the handler
-	s.AddHandler(h.onReady)
-	s.AddHandler(h.onVoiceStateUpdate)
+	bots := parseBots()
+	settings := environ()
+	setupLogging(settings)
 
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer cancel()
 
-	if err := s.Open(ctx); err != nil {
-		log.Fatalln("cannot connect:", err)
+	shutdownTracing, err := setupTracing(ctx)
+	if err != nil {
+		log.Fatalln("cannot set up tracing:", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			slog.Error("Failed to flush traces", "err", err)
+		}
+	}()
+
+	var states []*state.State
+	var running sync.WaitGroup
+	for _, b := range bots {
+		s, err := b.start(ctx, settings, &running)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		states = append(states, s)
 	}
 
 	<-ctx.Done()
 
-	if err := s.Close(); err != nil {
-		log.Printf("Failed to gracefully close session: %v", err)
+	for i, s := range states {
+		if err := s.Close(); err != nil {
+			slog.Error("Failed to gracefully close session", "bot", bots[i].name, "err", err)
+		}
 	}
+	// The managers write their pending stats before Run returns.
+	running.Wait()
 }
 
-type handler struct {
-	s                   *state.State
-	mu                  sync.Mutex
-	userVoiceStates     map[discord.UserID]discord.VoiceState
-	temporaryChannels   []discord.ChannelID
-	temporaryCategories []discord.ChannelID
+// parseBots returns the bots in $BOT_TOKENS, or the one in $BOT_TOKEN.
+func parseBots() []bot {
+	if botTokens == "" {
+		if token == "" {
+			log.Fatalln("No $BOT_TOKEN given.")
+		}
+		return []bot{{token: token}}
+	}
+	var bots []bot
+	seen := make(map[string]bool)
+	for i, pair := range strings.Split(botTokens, ",") {
+		name, token, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || name == "" || token == "" {
+			log.Fatalf("invalid bot #%d in $BOT_TOKENS, want name=token", i+1)
+		}
+		if seen[name] {
+			log.Fatalf("bot %q is in $BOT_TOKENS twice", name)
+		}
+		seen[name] = true
+		bots = append(bots, bot{name: name, token: token})
+	}
+	return bots
 }
 
-func newHandler(s *state.State) *handler {
-	return &handler{
-		s:               s,
-		userVoiceStates: make(map[discord.UserID]discord.VoiceState),
+// environ returns the process's environment as the settings of tvc.Config.
+func environ() map[string]string {
+	settings := make(map[string]string)
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		settings[key] = value
 	}
+	return settings
 }
 
-// onReady is called when the bot is ready
-func (h *handler) onReady(e *gateway.ReadyEvent) {
-	me, _ := h.s.Me()
-	log.Println("connected to the gateway as", me.Username)
+// setting returns the bot's value of a setting: <key>_<name> for bots of a
+// fleet, <key> otherwise.
+func (b bot) setting(settings map[string]string, key string) string {
+	if b.name != "" {
+		key += "_" + b.name
+	}
+	return settings[key]
 }
 
-// onVoiceStateUpdate handles voice state updates
-func (h *handler) onVoiceStateUpdate(evt *gateway.VoiceStateUpdateEvent) {
-	h.mu.Lock()
-	defer h.mu.Unlock()
-
-	// Get the previous state if it exists
-	before := h.userVoiceStates[evt.UserID]
-	// Update to the new state
-	h.userVoiceStates[evt.UserID] = evt.VoiceState
-
-	possibleChannelName := evt.Member.User.Username + "'s room"
-
-	fmt.Printf("User %s changed voice channel from %s to %s\n", evt.UserID, before.ChannelID, evt.ChannelID)
-
-	if before.ChannelID.String() == "" && evt.ChannelID.IsValid() {
-		// User joined a channel
-		if before.ChannelID != evt.ChannelID {
-			afterChannel, err := h.s.Channel(evt.ChannelID)
-			if err != nil {
-				log.Println("Failed to get after channel:", err)
-				return
-			}
-
-			if afterChannel.Name == "🐕 bark" {
-				tempChannel, err := h.s.CreateChannel(afterChannel.GuildID, api.CreateChannelData{
-					Name:       possibleChannelName,
-					Type:       discord.GuildVoice,
-					CategoryID: afterChannel.ParentID,
-				})
-				if err != nil {
-					log.Println("Failed to clone channel:", err)
-					return
-				}
-				err = h.s.ModifyMember(afterChannel.GuildID, evt.UserID, api.ModifyMemberData{
-					VoiceChannel: tempChannel.ID,
-				})
-				if err != nil {
-					log.Println("Failed to move member:", err)
-					return
-				}
-				h.temporaryChannels = append(h.temporaryChannels, tempChannel.ID)
-			}
-
-			if afterChannel.Name == "teams" {
-				temporaryCategory, err := h.s.CreateChannel(afterChannel.GuildID, api.CreateChannelData{
-					Name: possibleChannelName,
-					Type: discord.GuildCategory,
-				})
-				if err != nil {
-					log.Println("Failed to create category:", err)
-					return
-				}
-
-				_, err = h.s.CreateChannel(temporaryCategory.GuildID, api.CreateChannelData{
-					Name:       "text",
-					Type:       discord.GuildText,
-					CategoryID: temporaryCategory.ID,
-				})
-				if err != nil {
-					log.Println("Failed to create text channel:", err)
-					return
-				}
-
-				tempChannel, err := h.s.CreateChannel(temporaryCategory.GuildID, api.CreateChannelData{
-					Name:       "voice",
-					Type:       discord.GuildVoice,
-					CategoryID: temporaryCategory.ID,
-				})
-				if err != nil {
-					log.Println("Failed to create voice channel:", err)
-					return
-				}
-
-				err = h.s.ModifyMember(temporaryCategory.GuildID, evt.UserID, api.ModifyMemberData{
-					VoiceChannel: tempChannel.ID,
-				})
-				if err != nil {
-					log.Println("Failed to move member:", err)
-					return
-				}
-
-				h.temporaryCategories = append(h.temporaryCategories, tempChannel.ID)
-			}
-		}
+// start attaches a manager to a new state for the bot and connects it. The
+// manager runs in running until ctx is done.
+func (b bot) start(ctx context.Context, settings map[string]string, running *sync.WaitGroup) (*state.State, error) {
+	var storage tvc.Storage
+	if path := b.setting(settings, "STATS_PATH"); path != "" {
+		storage = tvc.FileStorage(path)
+	}
+	m, err := tvc.New(tvc.Config{
+		Name:        b.name,
+		Storage:     storage,
+		HealthAddr:  b.setting(settings, "HEALTH_ADDR"),
+		MetricsAddr: b.setting(settings, "METRICS_ADDR"),
+		Settings:    settings,
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if before.ChannelID.IsValid() && evt.ChannelID.String() == "" {
-		// User left a channel
-		beforeChannel, err := h.s.Channel(before.ChannelID)
-		if err != nil {
-			log.Println("Failed to get before channel:", err)
-			return
-		}
-
-		if contains(h.temporaryChannels, beforeChannel.ID) {
-			if len(beforeChannel.DMRecipients) == 0 {
-				err := h.s.DeleteChannel(beforeChannel.ID, "cleaning up")
-				if err != nil {
-					log.Println("Failed to delete channel:", err)
-				}
-				remove(&h.temporaryChannels, beforeChannel.ID)
-			}
-		}
+	// Initialize the state
+	s := state.New("Bot " + b.token)
+	m.Attach(s)
+	running.Add(1)
+	go func() {
+		defer running.Done()
+		m.Run(ctx)
+	}()
 
-		categoryID := beforeChannel.ParentID
-		if categoryID != 0 && contains(h.temporaryCategories, beforeChannel.ID) {
-			category, err := h.s.Channel(categoryID)
-			if err == nil && len(beforeChannel.DMRecipients) == 0 {
-				channels, err := h.s.Channels(category.GuildID)
-				if err != nil {
-					log.Println("Failed to fetch channels:", err)
-					return
-				}
-				for _, channel := range channels {
-					if channel.ParentID == categoryID {
-						_ = h.s.DeleteChannel(channel.ID, "cleaning up")
-					}
-				}
-				err = h.s.DeleteChannel(category.ID, "cleaning up")
-				if err != nil {
-					log.Println("Failed to delete category:", err)
-				}
-				remove(&h.temporaryCategories, categoryID)
-			}
+	if err := s.Open(ctx); err != nil {
+		if b.name != "" {
+			return nil, fmt.Errorf("cannot connect bot %s: %w", b.name, err)
 		}
+		return nil, fmt.Errorf("cannot connect: %w", err)
 	}
+	return s, nil
 }
 
-func contains(slice []discord.ChannelID, elem discord.ChannelID) bool {
-	for _, item := range slice {
-		if item == elem {
-			return true
+// setupLogging logs as text, or JSON with $LOG_FORMAT=json, from the level in
+// $LOG_LEVEL (debug, info, warn or error; info by default). The standard log
+// package goes through the same handler.
+func setupLogging(settings map[string]string) {
+	var level slog.Level
+	if v := settings["LOG_LEVEL"]; v != "" {
+		if err := level.UnmarshalText([]byte(v)); err != nil {
+			log.Fatalln("invalid $LOG_LEVEL:", err)
 		}
 	}
-	return false
+	opts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler = slog.NewTextHandler(os.Stderr, opts)
+	switch format := settings["LOG_FORMAT"]; format {
+	case "", "text":
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	default:
+		log.Fatalf("invalid $LOG_FORMAT %q, want text or json", format)
+	}
+	slog.SetDefault(slog.New(tvc.LogHandler(handler)))
 }
 
-func remove(slice *[]discord.ChannelID, elem discord.ChannelID) {
-	for i, item := range *slice {
-		if item == elem {
-			*slice = append((*slice)[:i], (*slice)[i+1:]...)
-			break
-		}
+// setupTracing exports spans over OTLP/HTTP when one of the standard
+// OTEL_EXPORTER_OTLP_*ENDPOINT variables is set. The returned function flushes
+// pending spans and is safe to call even when tracing is disabled.
+func setupTracing(ctx context.Context) (func(context.Context) error, error) {
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		return func(context.Context) error { return nil }, nil
 	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The default resource picks up OTEL_SERVICE_NAME and
+	// OTEL_RESOURCE_ATTRIBUTES from the environment.
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter))
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return tp.Shutdown, nil
 }
@@ -0,0 +1,103 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/diamondburned/arikawa/v3/utils/httputil"
+)
+
+// onPresenceUpdate schedules a status refresh of the room the user is in,
+// and a party size sync of the rooms they own.
+func (h *handler) onPresenceUpdate(evt *gateway.PresenceUpdateEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.refreshPartySize(evt.User.ID)
+
+	channelID := h.userVoiceStates[evt.User.ID].ChannelID
+	if r, ok := h.rooms[channelID]; ok {
+		h.refreshActivityStatus(channelID, r)
+	}
+}
+
+// refreshActivityStatus schedules a status update for the room, no sooner
+// than activityStatusInterval after the previous one.
+func (h *handler) refreshActivityStatus(channelID discord.ChannelID, r *room) {
+	if !h.cfg.activityStatusEnabled || r.statusTimer != nil || !h.features.enabled(r.guildID, featureActivityStatus) {
+		return
+	}
+
+	delay := time.Until(r.statusUpdatedAt.Add(h.cfg.activityStatusInterval))
+	r.statusTimer = time.AfterFunc(max(delay, 0), func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		r.statusTimer = nil
+		if h.rooms[channelID] != r {
+			return
+		}
+
+		status := r.voiceStatus(h.activityStatus(r.guildID, channelID))
+		if status == r.status {
+			return
+		}
+
+		ctx := withGuild(context.Background(), r.guildID)
+		err := h.call(ctx, "SetVoiceStatus", func(s *state.State) error {
+			return setVoiceStatus(s, channelID, status)
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to set room status", "err", err)
+			return
+		}
+		r.status = status
+		r.statusUpdatedAt = time.Now()
+	})
+}
+
+// activityStatus describes the most common game among the room's occupants,
+// e.g. "3 playing Minecraft", or returns an empty string if nobody is playing.
+func (h *handler) activityStatus(guildID discord.GuildID, channelID discord.ChannelID) string {
+	counts := make(map[string]int)
+	for userID := range h.channelMembers[channelID] {
+		p, err := h.s.Presence(guildID, userID)
+		if err != nil {
+			continue
+		}
+		for _, a := range p.Activities {
+			if a.Type == discord.GameActivity {
+				counts[a.Name]++
+				break
+			}
+		}
+	}
+
+	var game string
+	for name, n := range counts {
+		if n > counts[game] || (n == counts[game] && name < game) {
+			game = name
+		}
+	}
+	if game == "" {
+		return ""
+	}
+	return fmt.Sprintf("%d playing %s", counts[game], game)
+}
+
+// setVoiceStatus sets the status shown under a voice channel. arikawa has no
+// wrapper for this endpoint yet.
+func setVoiceStatus(s *state.State, channelID discord.ChannelID, status string) error {
+	return s.FastRequest(
+		"PUT", api.EndpointChannels+channelID.String()+"/voice-status",
+		httputil.WithJSONBody(struct {
+			Status string `json:"status"`
+		}{status}),
+	)
+}
@@ -0,0 +1,74 @@
+package tvc
+
+import (
+	"slices"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// maxRejoinGaps is how many of the latest rejoins are kept per guild.
+const maxRejoinGaps = 200
+
+// emptiedRoom is a room that was deleted after emptying out, remembered so
+// its owner coming back soon after counts as a rejoin.
+type emptiedRoom struct {
+	guildID discord.GuildID
+	at      time.Time
+}
+
+// learnedGrace is the guild's grace period learned from its rejoins, and
+// whether enough were seen to learn one.
+func (h *handler) learnedGrace(guildID discord.GuildID) (time.Duration, bool) {
+	if !h.cfg.adaptiveGrace {
+		return 0, false
+	}
+	gaps := h.stats.rejoinGaps(guildID)
+	if len(gaps) < max(h.cfg.adaptiveGraceSamples, 1) {
+		return 0, false
+	}
+	slices.Sort(gaps)
+	i := min(len(gaps)*min(max(h.cfg.adaptiveGracePercentile, 1), 100)/100, len(gaps)-1)
+	return min(max(gaps[i], h.cfg.adaptiveGraceMin), h.cfg.adaptiveGraceMax), true
+}
+
+// noteRejoin records that someone came back gap after the room emptied.
+// Gaps longer than adaptiveGraceMax say nothing about what the grace period
+// should be.
+func (h *handler) noteRejoin(guildID discord.GuildID, gap time.Duration) {
+	if !h.cfg.adaptiveGrace || gap > h.cfg.adaptiveGraceMax {
+		return
+	}
+	h.stats.recordRejoinGap(guildID, gap)
+}
+
+// noteEmptiedRoom remembers that r is being deleted after emptying out.
+// h.mu must be held.
+func (h *handler) noteEmptiedRoom(r *room) {
+	if !h.cfg.adaptiveGrace || h.roomOccupants(r) > 0 {
+		return
+	}
+	at := r.emptySince
+	if !r.emptied || at.IsZero() {
+		at = time.Now()
+	}
+	for userID, e := range h.emptiedRooms {
+		if time.Since(e.at) > h.cfg.adaptiveGraceMax {
+			delete(h.emptiedRooms, userID)
+		}
+	}
+	h.emptiedRooms[r.owner] = emptiedRoom{guildID: r.guildID, at: at}
+}
+
+// noteReturn counts a new room of userID's as a rejoin if their last room
+// was deleted for being empty shortly before. h.mu must be held.
+func (h *handler) noteReturn(guildID discord.GuildID, userID discord.UserID) {
+	e, ok := h.emptiedRooms[userID]
+	if !ok {
+		return
+	}
+	delete(h.emptiedRooms, userID)
+	if e.guildID == guildID {
+		h.noteRejoin(guildID, time.Since(e.at))
+	}
+}
@@ -0,0 +1,116 @@
+package tvc
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// adminOnly wraps an HTTP handler changing what the bot does, such as the
+// ones tvcctl calls, so it needs $API_TOKEN to be set and sent.
+func (h *handler) adminOnly(what string, serve http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if h.cfg.apiToken == "" {
+			http.Error(w, "set $API_TOKEN to "+what, http.StatusForbidden)
+			return
+		}
+		if h.authorized(w, req) {
+			serve(w, req)
+		}
+	}
+}
+
+// guildQuery reads the optional ?guild_id= of req; zero means every guild.
+func guildQuery(w http.ResponseWriter, req *http.Request) (discord.GuildID, bool) {
+	arg := req.URL.Query().Get("guild_id")
+	if arg == "" {
+		return 0, true
+	}
+	id, err := discord.ParseSnowflake(arg)
+	if err != nil {
+		http.Error(w, "invalid guild_id", http.StatusBadRequest)
+		return 0, false
+	}
+	return discord.GuildID(id), true
+}
+
+// serveCleanup serves POST /cleanup, which deletes the empty rooms of the
+// guild in ?guild_id=, or of every guild, right away instead of waiting for
+// their grace periods or the janitor, and retries failed deletions. Frozen
+// rooms and persistent booster rooms are kept, and a guild's cleanup past the
+// safe mode threshold is held like the janitor's. It answers with how many
+// rooms it deleted and how many are held.
+func (h *handler) serveCleanup(w http.ResponseWriter, req *http.Request) {
+	guildID, ok := guildQuery(w, req)
+	if !ok {
+		return
+	}
+
+	h.mu.Lock()
+	// The rooms are picked with h.mu held, and deleted without it while
+	// Discord is waited on.
+	ctx, endIO := releaseForIO(context.Background())
+	due := make(map[discord.GuildID][]*room)
+	for _, r := range h.rooms {
+		if (guildID.IsValid() && r.guildID != guildID) || h.roomOccupants(r) > 0 || h.isFrozen(r.channelID) || r.booster && h.cfg.boosterPersistent {
+			continue
+		}
+		due[r.guildID] = append(due[r.guildID], r)
+	}
+	deleted, held := 0, 0
+	// Held cleanups run after the answer is sent.
+	answered := false
+	for guildID, rooms := range due {
+		channels := 0
+		for _, r := range rooms {
+			channels += roomChannels(r)
+		}
+		ran := false
+		run := func(ctx context.Context) {
+			ran = true
+			for _, r := range rooms {
+				if h.rooms[r.channelID] != r || h.roomOccupants(r) > 0 {
+					continue
+				}
+				ch, err := h.fetchChannel(ctx, r.channelID)
+				if err != nil {
+					slog.ErrorContext(ctx, "Failed to get room to clean up", "channel_id", r.channelID, "err", err)
+					continue
+				}
+				slog.InfoContext(ctx, "Deleting empty room on request", "channel_id", r.channelID)
+				h.teardownRoom(ctx, ch)
+				if h.rooms[r.channelID] != r && !answered {
+					deleted++
+				}
+			}
+		}
+		h.cleanUp(withGuild(ctx, guildID), guildID, "A cleanup requested through the API", channels, run, nil)
+		if !ran {
+			held += len(rooms)
+		}
+	}
+	h.retryDeletes(ctx)
+	endIO()
+	answered = true
+	h.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"deleted": deleted, "held": held})
+}
+
+// serveReload serves POST /reload, which rereads $LOBBIES_FILE like SIGHUP
+// does.
+func (h *handler) serveReload(w http.ResponseWriter, req *http.Request) {
+	if h.cfg.lobbiesFile == "" {
+		http.Error(w, "there is no $LOBBIES_FILE to reload", http.StatusConflict)
+		return
+	}
+	if err := h.reloadLobbies(); err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	w.Write([]byte("ok\n"))
+}
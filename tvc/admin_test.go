@@ -0,0 +1,38 @@
+package tvc
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestServeCleanupWaitsOnDiscordWithoutHandlerLock(t *testing.T) {
+	b := newTestBot(t, "ROOM_GRACE_PERIOD=1h")
+	b.send(testGuild(nil, nil))
+	b.send(join(5, testHubID))
+	room := b.roomOf(5)
+	b.send(join(5, room), join(5, 0))
+
+	stalled, release := b.stall("/channels/" + room.String())
+	rec := httptest.NewRecorder()
+	served := make(chan struct{})
+	go func() {
+		defer close(served)
+		b.h.serveCleanup(rec, httptest.NewRequest("POST", "/cleanup", nil))
+	}()
+	<-stalled
+	finishes(t, "taking h.mu during the deletion", func() {
+		b.h.mu.Lock()
+		b.h.mu.Unlock()
+	})
+	release()
+	finishes(t, "serving the cleanup", func() { <-served })
+
+	var body struct{ Deleted, Held int }
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Deleted != 1 || body.Held != 0 || b.channelExists(room) {
+		t.Errorf("cleanup answered %+v, want the room deleted", body)
+	}
+}
@@ -0,0 +1,86 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+// createAFKChannel adds an AFK voice channel to a team category.
+func (h *handler) createAFKChannel(ctx context.Context, category *discord.Channel, region string, reason api.AuditLogReason) (discord.ChannelID, error) {
+	var afk *discord.Channel
+	err := h.call(ctx, "CreateChannel", func(s *state.State) (err error) {
+		afk, err = s.CreateChannel(category.GuildID, api.CreateChannelData{
+			Name:           "AFK",
+			Type:           discord.GuildVoice,
+			CategoryID:     category.ID,
+			RTCRegionID:    region,
+			AuditLogReason: reason,
+		})
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create AFK channel: %w", err)
+	}
+	h.created[afk.ID] = true
+	return afk.ID, nil
+}
+
+// isAFK reports whether vs is both muted and deafened, by the user or a
+// moderator.
+func isAFK(vs discord.VoiceState) bool {
+	return (vs.SelfMute || vs.Mute) && (vs.SelfDeaf || vs.Deaf)
+}
+
+// trackAFK starts or stops the AFK timer of a member of a team room.
+func (h *handler) trackAFK(before, after discord.VoiceState) {
+	if r, ok := h.rooms[before.ChannelID]; ok && (before.ChannelID != after.ChannelID || !isAFK(after)) {
+		if t, ok := r.afkTimers[after.UserID]; ok {
+			t.Stop()
+			delete(r.afkTimers, after.UserID)
+		}
+	}
+
+	r, ok := h.rooms[after.ChannelID]
+	if !ok || !r.afkChannel.IsValid() || !isAFK(after) {
+		return
+	}
+	if _, ok := r.afkTimers[after.UserID]; ok {
+		return
+	}
+
+	userID := after.UserID
+	r.afkTimers[userID] = time.AfterFunc(h.cfg.teamAFKTimeout, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		delete(r.afkTimers, userID)
+		vs := h.userVoiceStates[userID]
+		if h.rooms[r.channelID] != r || vs.ChannelID != r.channelID || !isAFK(vs) {
+			return
+		}
+
+		h.queueMove(memberMove{
+			guildID:   r.guildID,
+			userID:    userID,
+			channelID: r.afkChannel,
+			reason:    "muted and deafened in a team room",
+			failure:   "Failed to move member to AFK channel",
+		})
+	})
+}
+
+// roomChannelOf maps a team AFK channel to its room's voice channel. Other
+// channels are returned unchanged.
+func (h *handler) roomChannelOf(channelID discord.ChannelID) discord.ChannelID {
+	for id, r := range h.rooms {
+		if r.afkChannel.IsValid() && r.afkChannel == channelID {
+			return id
+		}
+	}
+	return channelID
+}
@@ -0,0 +1,87 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+func parseGuildAFKTimeoutGraces(env *environment) map[discord.GuildID]time.Duration {
+	const prefix = "AFK_TIMEOUT_GRACE_"
+	graces := make(map[discord.GuildID]time.Duration)
+	for _, kv := range env.environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		guildID, err := discord.ParseSnowflake(strings.TrimPrefix(key, prefix))
+		if err != nil {
+			env.configError("invalid guild ID in $%s: %v", key, err)
+			continue
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			env.configError("invalid $%s: %v", key, err)
+			continue
+		}
+		graces[discord.GuildID(guildID)] = d
+	}
+	return graces
+}
+
+// afkTimeoutGraceFor returns how long members moved to guildID's AFK channel
+// still count as in their room.
+func (h *handler) afkTimeoutGraceFor(guildID discord.GuildID) time.Duration {
+	if d, ok := h.cfg.guildAFKTimeoutGraces[guildID]; ok {
+		return d
+	}
+	return h.cfg.afkTimeoutGrace
+}
+
+// isGuildAFK reports whether channelID is the guild's own AFK channel, where
+// Discord moves members who have been idle for the guild's AFK timeout.
+func (h *handler) isGuildAFK(guildID discord.GuildID, channelID discord.ChannelID) bool {
+	g, err := h.s.Cabinet.Guild(guildID)
+	return err == nil && g.AFKChannelID.IsValid() && g.AFKChannelID == channelID
+}
+
+// holdAFK keeps counting a member moved to the guild's AFK channel as in r
+// for the guild's grace window, checking whether the room emptied once it is
+// over.
+func (h *handler) holdAFK(r *room, userID discord.UserID) {
+	grace := h.afkTimeoutGraceFor(r.guildID)
+	if grace <= 0 {
+		return
+	}
+	slog.Info("Member moved to the AFK channel, still counting them in their room", "guild_id", r.guildID, "channel_id", r.channelID, "user_id", userID, "grace", grace)
+	if t, ok := r.afkAway[userID]; ok {
+		t.Stop()
+	}
+	var t *time.Timer
+	t = time.AfterFunc(grace, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		if h.rooms[r.channelID] != r || r.afkAway[userID] != t {
+			return
+		}
+		delete(r.afkAway, userID)
+		h.checkEmptied(withGuild(context.Background(), r.guildID), r)
+	})
+	r.afkAway[userID] = t
+}
+
+// releaseAFK stops counting a member held by holdAFK as in their old room,
+// now that they moved on from the AFK channel.
+func (h *handler) releaseAFK(ctx context.Context, userID discord.UserID) {
+	for _, r := range h.rooms {
+		if t, ok := r.afkAway[userID]; ok {
+			t.Stop()
+			delete(r.afkAway, userID)
+			h.checkEmptied(ctx, r)
+		}
+	}
+}
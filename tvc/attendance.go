@@ -0,0 +1,82 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+// recordAttendance notes that userID joined or left the room and schedules a
+// flush of the attendance log.
+func (h *handler) recordAttendance(r *room, userID discord.UserID, joined bool) {
+	if r.hub.silent || !h.features.enabled(r.guildID, featureAttendance) {
+		return
+	}
+
+	arrow := "←"
+	if joined {
+		arrow = "→"
+	}
+	r.attendance = append(r.attendance, fmt.Sprintf("<t:%d:T> %s %s", time.Now().Unix(), arrow, userID.Mention()))
+
+	if r.attendanceTimer != nil {
+		return
+	}
+	r.attendanceTimer = time.AfterFunc(h.cfg.attendanceFlushDelay, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		r.attendanceTimer = nil
+		if h.rooms[r.channelID] != r || len(r.attendance) == 0 {
+			return
+		}
+		lines := r.attendance
+		r.attendance = nil
+
+		ctx := withGuild(context.Background(), r.guildID)
+		for _, content := range splitMessages(lines) {
+			err := h.call(ctx, "SendMessage", func(s *state.State) error {
+				_, err := s.SendMessageComplex(r.chatChannel(), api.SendMessageData{
+					Content:         content,
+					AllowedMentions: &api.AllowedMentions{},
+				})
+				return err
+			})
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to post attendance", "err", err)
+				return
+			}
+		}
+	})
+}
+
+// maxMessageLength is how many characters Discord allows in a message.
+const maxMessageLength = 2000
+
+// splitMessages joins lines into as few messages as fit Discord's length
+// limit, one line per row. Lines are short enough never to need splitting.
+func splitMessages(lines []string) []string {
+	var messages []string
+	var b strings.Builder
+	for _, line := range lines {
+		if b.Len() > 0 && utf8.RuneCountInString(b.String())+1+utf8.RuneCountInString(line) > maxMessageLength {
+			messages = append(messages, b.String())
+			b.Reset()
+		}
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(line)
+	}
+	if b.Len() > 0 {
+		messages = append(messages, b.String())
+	}
+	return messages
+}
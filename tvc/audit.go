@@ -0,0 +1,191 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/bits"
+	"slices"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// auditMembers is how many of a room's members /voiceadmin audit lists.
+const auditMembers = 10
+
+// cmdAudit handles /voiceadmin audit, which lists what @everyone, the roles
+// with overwrites, the owner, the members and the bot may do in a room, and
+// flags where its overwrites differ from its hub's permission template. It
+// is meant for "why can't X join" reports.
+func (h *handler) cmdAudit(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	r, problem := h.roomOption(data)
+	if r == nil {
+		return ephemeralData(problem)
+	}
+	ctx = withGuild(ctx, r.guildID)
+	ch, err := h.fetchChannel(ctx, r.channelID)
+	target := ch
+	if err == nil && r.roomTarget() != r.channelID {
+		target, err = h.fetchChannel(ctx, r.roomTarget())
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get room to audit", "err", err)
+		return ephemeralData("I couldn't find that room, try again.")
+	}
+	guild, err := h.s.Guild(r.guildID)
+	if err != nil {
+		return ephemeralData("I couldn't look up the server, try again.")
+	}
+	roles, err := h.s.Roles(r.guildID)
+	if err != nil {
+		return ephemeralData("I couldn't look up the server's roles, try again.")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "**Permissions in %s**\n", ch.Mention())
+
+	// Roles are shown as a member with just that role would have them.
+	roleLine := func(roleID discord.RoleID) {
+		member := discord.Member{}
+		if roleID != discord.RoleID(r.guildID) {
+			member.RoleIDs = []discord.RoleID{roleID}
+		}
+		perms := discord.CalcOverrides(*guild, *ch, member, roles)
+		fmt.Fprintf(&b, "- %s: %s\n", roleID.Mention(), describePermissions(perms))
+	}
+	roleLine(discord.RoleID(r.guildID))
+	for _, o := range ch.Overwrites {
+		if o.Type == discord.OverwriteRole && discord.GuildID(o.ID) != r.guildID {
+			roleLine(discord.RoleID(o.ID))
+		}
+	}
+
+	userLine := func(userID discord.UserID, who string) {
+		perms, err := h.s.Permissions(ch.ID, userID)
+		if err != nil {
+			fmt.Fprintf(&b, "- %s%s: couldn't be worked out\n", userID.Mention(), who)
+			return
+		}
+		fmt.Fprintf(&b, "- %s%s: %s\n", userID.Mention(), who, describePermissions(perms))
+	}
+	userLine(r.owner, " (owner)")
+	var members []discord.UserID
+	for userID := range h.channelMembers[r.channelID] {
+		if userID != r.owner {
+			members = append(members, userID)
+		}
+	}
+	slices.Sort(members)
+	for _, userID := range members[:min(len(members), auditMembers)] {
+		userLine(userID, "")
+	}
+	if len(members) > auditMembers {
+		fmt.Fprintf(&b, "- …and %s more\n", plural(len(members)-auditMembers, "member"))
+	}
+	if me, err := h.s.Me(); err == nil {
+		userLine(me.ID, " (me)")
+	}
+
+	anomalies := h.auditTemplate(r, target)
+	if len(anomalies) == 0 {
+		b.WriteString("\nThe overwrites match the hub's permission template.")
+	} else {
+		b.WriteString("\n**Differences from the hub's permission template**")
+		for _, line := range anomalies {
+			b.WriteString("\n⚠️ " + line)
+		}
+	}
+
+	content := b.String()
+	if len(content) > 1900 {
+		content = content[:strings.LastIndexByte(content[:1900], '\n')] + "\n…"
+	}
+	resp := ephemeralData(content)
+	resp.AllowedMentions = &api.AllowedMentions{}
+	return resp
+}
+
+// auditTemplate lists how the overwrites of ch, the room's channel carrying
+// its permissions, differ from its hub's permission template. Member
+// overwrites and @everyone's are left out, since rooms change those as they
+// go, e.g. when locked.
+func (h *handler) auditTemplate(r *room, ch *discord.Channel) []string {
+	var lines []string
+	for _, want := range r.hub.permissions {
+		want.ID = h.mirrored(r.guildID, want.ID)
+		if _, err := h.s.Role(r.guildID, discord.RoleID(want.ID)); err != nil {
+			continue
+		}
+		roleID := discord.RoleID(want.ID)
+		i := slices.IndexFunc(ch.Overwrites, func(o discord.Overwrite) bool { return o.ID == want.ID })
+		if i < 0 {
+			lines = append(lines, roleID.Mention()+" has no overwrite, but the template gives it one.")
+			continue
+		}
+		got := ch.Overwrites[i]
+		if missing := want.Allow &^ got.Allow; missing != 0 {
+			lines = append(lines, fmt.Sprintf("%s isn't allowed %s.", roleID.Mention(), strings.Join(permissionNames(missing), ", ")))
+		}
+		if missing := want.Deny &^ got.Deny; missing != 0 {
+			lines = append(lines, fmt.Sprintf("%s isn't denied %s.", roleID.Mention(), strings.Join(permissionNames(missing), ", ")))
+		}
+		if extra := got.Allow &^ want.Allow; extra != 0 {
+			lines = append(lines, fmt.Sprintf("%s is also allowed %s.", roleID.Mention(), describeExtra(extra)))
+		}
+		if extra := got.Deny &^ want.Deny; extra != 0 {
+			lines = append(lines, fmt.Sprintf("%s is also denied %s.", roleID.Mention(), describeExtra(extra)))
+		}
+	}
+	for _, o := range ch.Overwrites {
+		if o.Type != discord.OverwriteRole || discord.GuildID(o.ID) == r.guildID {
+			continue
+		}
+		if !slices.ContainsFunc(r.hub.permissions, func(want discord.Overwrite) bool { return h.mirrored(r.guildID, want.ID) == o.ID }) {
+			lines = append(lines, discord.RoleID(o.ID).Mention()+" has an overwrite the template doesn't give it.")
+		}
+	}
+	return lines
+}
+
+// describePermissions lists the voice permissions in perms, calling out the
+// ones needed to join.
+func describePermissions(perms discord.Permissions) string {
+	if perms.Has(discord.PermissionAdministrator) {
+		return "administrator"
+	}
+	var problems []string
+	if !perms.Has(discord.PermissionViewChannel) {
+		problems = append(problems, "can't see it")
+	} else if !perms.Has(discord.PermissionConnect) {
+		problems = append(problems, "can't join")
+	}
+	names := permissionNames(perms)
+	if len(names) == 0 {
+		names = []string{"nothing"}
+	}
+	line := strings.Join(names, ", ")
+	if len(problems) > 0 {
+		line = "**" + strings.Join(problems, ", ") + "**: " + line
+	}
+	return line
+}
+
+// describeExtra names the permissions in perms, counting those a template
+// can't set.
+func describeExtra(perms discord.Permissions) string {
+	names := permissionNames(perms)
+	var known discord.Permissions
+	for _, p := range templatePermissions {
+		known |= p.perm
+	}
+	if other := perms &^ known; other != 0 {
+		names = append(names, plural(bits.OnesCount64(uint64(other)), "other permission"))
+	}
+	return strings.Join(names, ", ")
+}
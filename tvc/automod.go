@@ -0,0 +1,80 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/diamondburned/arikawa/v3/utils/httputil"
+)
+
+// maxAutoModExemptChannels is how many channels Discord lets a rule exempt.
+const maxAutoModExemptChannels = 50
+
+// autoModRule is the part of an AutoMod rule the bot changes. arikawa has no
+// wrapper for the AutoMod endpoints yet.
+type autoModRule struct {
+	ID             string              `json:"id"`
+	Name           string              `json:"name"`
+	ExemptChannels []discord.ChannelID `json:"exempt_channels"`
+}
+
+// setAutoModExemption adds channelID to or removes it from the exempt
+// channels of the configured AutoMod rules of its guild.
+func (h *handler) setAutoModExemption(ctx context.Context, guildID discord.GuildID, channelID discord.ChannelID, exempt bool) {
+	if len(h.cfg.autoModExemptRules) == 0 {
+		return
+	}
+
+	var rules []autoModRule
+	err := h.call(ctx, "ListAutoModerationRules", func(s *state.State) error {
+		return s.RequestJSON(&rules, "GET", api.EndpointGuilds+guildID.String()+"/auto-moderation/rules")
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list AutoMod rules", "err", err)
+		return
+	}
+
+	for _, rule := range rules {
+		if !h.exemptRule(rule.ID) {
+			continue
+		}
+
+		switch listed := contains(rule.ExemptChannels, channelID); {
+		case exempt == listed:
+			continue
+		case exempt && len(rule.ExemptChannels) >= maxAutoModExemptChannels:
+			slog.Warn("Cannot exempt channel from AutoMod rule, which exempts too many channels", "channel_id", channelID, "rule", rule.Name, "max", maxAutoModExemptChannels)
+			continue
+		case exempt:
+			rule.ExemptChannels = append(rule.ExemptChannels, channelID)
+		default:
+			remove(&rule.ExemptChannels, channelID)
+		}
+
+		err := h.call(ctx, "ModifyAutoModerationRule", func(s *state.State) error {
+			return s.FastRequest(
+				"PATCH", api.EndpointGuilds+guildID.String()+"/auto-moderation/rules/"+rule.ID,
+				httputil.WithJSONBody(struct {
+					ExemptChannels []discord.ChannelID `json:"exempt_channels"`
+				}{rule.ExemptChannels}),
+				httputil.WithHeaders(api.AuditLogReason("temporary room text channel").Header()),
+			)
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to update AutoMod rule", "err", err)
+		}
+	}
+}
+
+// exemptRule reports whether rooms are exempted from the rule ruleID.
+func (h *handler) exemptRule(ruleID string) bool {
+	for _, id := range h.cfg.autoModExemptRules {
+		if id == ruleID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/diamondburned/arikawa/v3/utils/ws"
+)
+
+// intentAutoModExecution subscribes to AutoMod action events. arikawa has no
+// name for it yet.
+const intentAutoModExecution gateway.Intents = 1 << 21
+
+func parseAutoModRoomAction(env *environment, key string) string {
+	switch action := env.string(key, "rename"); action {
+	case "rename", "delete", "off":
+		return action
+	default:
+		env.configError("invalid $%s %q, want rename, delete or off", key, action)
+		return "rename"
+	}
+}
+
+// autoModActionEvent is the AUTO_MODERATION_ACTION_EXECUTION event, sent for
+// every action an AutoMod rule takes. arikawa doesn't decode it yet.
+type autoModActionEvent struct {
+	GuildID        discord.GuildID   `json:"guild_id"`
+	RuleID         string            `json:"rule_id"`
+	UserID         discord.UserID    `json:"user_id"`
+	ChannelID      discord.ChannelID `json:"channel_id"`
+	MatchedKeyword string            `json:"matched_keyword"`
+	MatchedContent string            `json:"matched_content"`
+}
+
+func (*autoModActionEvent) Op() ws.OpCode           { return 0 }
+func (*autoModActionEvent) EventType() ws.EventType { return "AUTO_MODERATION_ACTION_EXECUTION" }
+
+func init() {
+	gateway.OpUnmarshalers.Add(func() ws.Event { return new(autoModActionEvent) })
+}
+
+// matched returns what the rule caught, or "" for rules that don't match
+// text, such as mention spam.
+func (e *autoModActionEvent) matched() string {
+	if e.MatchedContent != "" {
+		return e.MatchedContent
+	}
+	return strings.Trim(e.MatchedKeyword, "*")
+}
+
+// onAutoModAction renames or deletes the room an AutoMod rule fired in if
+// what it caught is in the room's name, and alerts moderators.
+func (h *handler) onAutoModAction(e *autoModActionEvent) {
+	matched := strings.ToLower(e.matched())
+	if matched == "" {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var channelID discord.ChannelID
+	var r *room
+	for id, room := range h.rooms {
+		if id == e.ChannelID || room.textChannel == e.ChannelID || room.category == e.ChannelID {
+			channelID, r = id, room
+			break
+		}
+	}
+	// A rule has one event per action; the first one handles the room.
+	if r == nil || r.autoModFlagged == matched || !strings.Contains(strings.ToLower(r.name), matched) {
+		return
+	}
+	r.autoModFlagged = matched
+
+	ctx := withGuild(context.Background(), r.guildID)
+	slog.InfoContext(ctx, "AutoMod caught room name", "channel_id", channelID, "rule_id", e.RuleID, "action", h.cfg.autoModRoomAction)
+	reason := "AutoMod caught ||" + strings.ReplaceAll(e.matched(), "|", "") + "|| in " + channelID.Mention() +
+		", and the room of " + r.owner.Mention() + " is named `" + strings.ReplaceAll(r.name, "`", "") + "`."
+	switch h.cfg.autoModRoomAction {
+	case "rename":
+		name, err := h.defaultRoomName(ctx, r)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to look up room owner", "err", err)
+			break
+		}
+		target := channelID
+		if r.category.IsValid() {
+			// Team rooms are named by their category.
+			target = r.category
+		}
+		h.renameChannel(ctx, target, name)
+		reason += " I renamed it to `" + name + "`."
+	case "delete":
+		ch, err := h.fetchChannel(ctx, channelID)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to get room to delete", "err", err)
+			break
+		}
+		h.deleteRoom(ctx, ch)
+		if _, ok := h.rooms[channelID]; !ok {
+			h.postModAlert(ctx, r.guildID, r.owner, 0, reason+" I deleted it.")
+			return
+		}
+	}
+	h.postModAlert(ctx, r.guildID, r.owner, channelID, reason)
+}
+
+// defaultRoomName returns the name the default template gives r.
+func (h *handler) defaultRoomName(ctx context.Context, r *room) (string, error) {
+	var username string
+	err := h.call(ctx, "Member", func(s *state.State) error {
+		m, err := s.Member(r.guildID, r.owner)
+		if err == nil {
+			username = m.User.Username
+		}
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return h.decorateName(r.guildID, expandTemplate(defaultNameTemplate, templateData{Username: username, Number: r.number})), nil
+}
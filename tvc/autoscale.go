@@ -0,0 +1,71 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/diamondburned/arikawa/v3/utils/json/option"
+)
+
+// parseLimitSteps reads the user limits a hub's rooms scale through from
+// $<key>, e.g. "4,6,8". Rooms start at the first step and move to the next
+// one whenever they fill up, up to the last.
+func parseLimitSteps(env *environment, key string) []uint {
+	var steps []uint
+	for _, item := range env.list(key) {
+		n, err := strconv.Atoi(item)
+		if err != nil || n < 1 || n > 99 {
+			env.configError("invalid user limit %q in $%s, want 1 to 99", item, key)
+			continue
+		}
+		if len(steps) > 0 && uint(n) <= steps[len(steps)-1] {
+			env.configError("user limits in $%s must be increasing", key)
+			continue
+		}
+		steps = append(steps, uint(n))
+	}
+	return steps
+}
+
+// scaledLimit is the step a room with n members should be at: the smallest
+// one with space left, or the last.
+func scaledLimit(steps []uint, n int) uint {
+	for _, step := range steps {
+		if uint(n) < step {
+			return step
+		}
+	}
+	return steps[len(steps)-1]
+}
+
+// autoscale moves the room's user limit to the step that fits its members,
+// growing it as it fills and shrinking it back as people leave. Rooms whose
+// limit was changed by someone else, including party size sync, are left
+// alone from then on. Unlike renames, Discord doesn't throttle user limit
+// changes, so they don't go through the rename limiter.
+func (h *handler) autoscale(ctx context.Context, r *room) {
+	n := h.roomOccupants(r)
+	if r.scaledLimit == 0 || n == 0 {
+		// Empty rooms are on their way out anyway.
+		return
+	}
+	limit := scaledLimit(r.hub.limitSteps, n)
+	if limit == r.scaledLimit {
+		return
+	}
+
+	err := h.call(ctx, "ModifyChannel", func(s *state.State) error {
+		return s.ModifyChannel(r.channelID, api.ModifyChannelData{
+			VoiceUserLimit: option.NewNullableUint(limit),
+			AuditLogReason: "scaling the user limit with the room",
+		})
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to scale room limit", "err", err)
+		return
+	}
+	r.scaledLimit = limit
+}
@@ -0,0 +1,98 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/diamondburned/arikawa/v3/utils/json/option"
+)
+
+// bitrateStep is the bitrate a room gets once it holds members or more.
+type bitrateStep struct {
+	members int
+	bitrate uint
+}
+
+// parseBitrateSteps reads the bitrates a hub's rooms are tuned through from
+// $<key>, e.g. "1:128000,6:96000,12:64000" for full quality in small rooms
+// and less of it as they crowd. The member counts must be increasing.
+func parseBitrateSteps(env *environment, key string) []bitrateStep {
+	var steps []bitrateStep
+	for _, item := range env.list(key) {
+		members, bitrate, ok := strings.Cut(item, ":")
+		n, err1 := strconv.Atoi(members)
+		b, err2 := strconv.Atoi(bitrate)
+		if !ok || err1 != nil || err2 != nil || n < 1 || b < 8000 || b > 384000 {
+			env.configError("invalid bitrate step %q in $%s, want <members>:<bitrate from 8000 to 384000>", item, key)
+			continue
+		}
+		if len(steps) > 0 && n <= steps[len(steps)-1].members {
+			env.configError("member counts in $%s must be increasing", key)
+			continue
+		}
+		steps = append(steps, bitrateStep{members: n, bitrate: uint(b)})
+	}
+	return steps
+}
+
+// maxBitrate is the highest bitrate a voice channel may have in a guild of
+// the given boost level.
+func maxBitrate(tier discord.NitroBoost) uint {
+	switch tier {
+	case discord.NitroLevel1:
+		return 128000
+	case discord.NitroLevel2:
+		return 256000
+	case discord.NitroLevel3:
+		return 384000
+	default:
+		return 96000
+	}
+}
+
+// tuneBitrate moves the room's bitrate to the hub's step for its member
+// count, capped at what the guild's boost level allows. Rooms of hubs
+// without $<key>_BITRATE_STEPS, stage rooms and empty rooms are left alone.
+func (h *handler) tuneBitrate(ctx context.Context, r *room) {
+	steps := r.hub.bitrateSteps
+	n := h.roomOccupants(r)
+	if len(steps) == 0 || n == 0 {
+		return
+	}
+	var bitrate uint
+	for _, step := range steps {
+		if n >= step.members {
+			bitrate = step.bitrate
+		}
+	}
+	if bitrate == 0 {
+		return
+	}
+	if guild, err := h.s.Cabinet.Guild(r.guildID); err == nil {
+		bitrate = min(bitrate, maxBitrate(guild.NitroBoost))
+	}
+	if bitrate == r.tunedBitrate {
+		return
+	}
+	if ch, err := h.s.Cabinet.Channel(r.channelID); err == nil && (ch.Type == discord.GuildStageVoice || ch.VoiceBitrate == bitrate) {
+		r.tunedBitrate = ch.VoiceBitrate
+		return
+	}
+
+	err := h.call(ctx, "ModifyChannel", func(s *state.State) error {
+		return s.ModifyChannel(r.channelID, api.ModifyChannelData{
+			VoiceBitrate:   option.NewNullableUint(bitrate),
+			AuditLogReason: "tuning the bitrate to the room's size",
+		})
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to tune room bitrate", "bitrate", bitrate, "err", err)
+		return
+	}
+	r.tunedBitrate = bitrate
+}
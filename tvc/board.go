@@ -0,0 +1,193 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+// boardTitle identifies the board message so it can be found again after a
+// restart.
+const boardTitle = "Rooms"
+
+// maxBoardRooms is the number of rooms an embed has room for.
+const maxBoardRooms = 25
+
+// board is the rooms board of a guild.
+type board struct {
+	channelID discord.ChannelID
+	message   discord.MessageID
+	timer     *time.Timer
+}
+
+// noteActivity marks the room as active now because of a voice state change
+// by userID, unless they opted out of sharing their activity. Bots can't see
+// who is speaking without joining the channel, so joins, mutes, streams and
+// the like are what counts as activity.
+func (h *handler) noteActivity(r *room, userID discord.UserID) {
+	if !h.stats.private(userID) {
+		r.lastActive = time.Now()
+	}
+	h.refreshBoard(r.guildID)
+}
+
+// refreshBoard schedules an update of the guild's rooms board. Calls within
+// boardDebounce of each other are coalesced.
+func (h *handler) refreshBoard(guildID discord.GuildID) {
+	b, ok := h.boards[guildID]
+	if !ok {
+		channelID := h.boardChannelFor(guildID)
+		if !channelID.IsValid() {
+			return
+		}
+		b = &board{channelID: channelID}
+		h.boards[guildID] = b
+	}
+	if b.timer != nil {
+		return
+	}
+
+	b.timer = time.AfterFunc(h.cfg.boardDebounce, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		b.timer = nil
+		h.updateBoard(withGuild(context.Background(), guildID), guildID, b)
+	})
+}
+
+// boardChannelFor returns the guild's rooms board channel, or 0 if it has
+// none.
+func (h *handler) boardChannelFor(guildID discord.GuildID) discord.ChannelID {
+	for id := range h.cfg.boardChannels {
+		if ch, err := h.s.Channel(id); err == nil && ch.GuildID == guildID {
+			return id
+		}
+	}
+	return 0
+}
+
+// updateBoard edits the board message, posting it first if needed.
+func (h *handler) updateBoard(ctx context.Context, guildID discord.GuildID, b *board) {
+	embed := h.boardEmbed(guildID)
+
+	if !b.message.IsValid() {
+		b.message = h.findBoardMessage(ctx, b.channelID)
+	}
+	if b.message.IsValid() {
+		err := h.call(ctx, "EditMessage", func(s *state.State) error {
+			_, err := s.EditEmbeds(b.channelID, b.message, embed)
+			return err
+		})
+		if err == nil {
+			return
+		}
+		slog.ErrorContext(ctx, "Failed to update rooms board, posting a new one", "err", err)
+	}
+
+	err := h.call(ctx, "SendMessage", func(s *state.State) error {
+		msg, err := s.SendEmbeds(b.channelID, embed)
+		if err != nil {
+			return err
+		}
+		b.message = msg.ID
+		return nil
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to post rooms board", "err", err)
+	}
+}
+
+// findBoardMessage looks for a board posted before the last restart among the
+// channel's recent messages.
+func (h *handler) findBoardMessage(ctx context.Context, channelID discord.ChannelID) discord.MessageID {
+	me, err := h.s.Me()
+	if err != nil {
+		return 0
+	}
+	var msgs []discord.Message
+	err = h.call(ctx, "Messages", func(s *state.State) (err error) {
+		msgs, err = s.Messages(channelID, 50)
+		return err
+	})
+	if err != nil {
+		return 0
+	}
+	for _, msg := range msgs {
+		if msg.Author.ID == me.ID && len(msg.Embeds) > 0 && msg.Embeds[0].Title == boardTitle {
+			return msg.ID
+		}
+	}
+	return 0
+}
+
+// boardEmbed lists the guild's rooms with their occupancy and when they were
+// last active, featured rooms first and then busiest first.
+func (h *handler) boardEmbed(guildID discord.GuildID) discord.Embed {
+	type entry struct {
+		id        discord.ChannelID
+		r         *room
+		occupants int
+	}
+	var entries []entry
+	for id, r := range h.rooms {
+		if r.guildID == guildID && !r.hub.silent && !r.dnd {
+			entries = append(entries, entry{id, r, h.roomOccupants(r)})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].r.featured != entries[j].r.featured {
+			return entries[i].r.featured
+		}
+		if entries[i].occupants != entries[j].occupants {
+			return entries[i].occupants > entries[j].occupants
+		}
+		return entries[i].id < entries[j].id
+	})
+
+	embed := discord.Embed{
+		Title:     boardTitle,
+		Timestamp: discord.NowTimestamp(),
+	}
+	if len(entries) == 0 {
+		embed.Description = "No rooms right now. Join a hub to start one."
+		return embed
+	}
+	for i, e := range entries {
+		if i == maxBoardRooms {
+			embed.Footer = &discord.EmbedFooter{Text: fmt.Sprintf("and %d more", len(entries)-maxBoardRooms)}
+			break
+		}
+		value := fmt.Sprintf("%s · %d in voice", e.id.Mention(), e.occupants)
+		if !e.r.lastActive.IsZero() {
+			value += fmt.Sprintf(" · active <t:%d:R>", e.r.lastActive.Unix())
+		}
+		name := e.r.name
+		if e.r.featured {
+			name = "⭐ " + name
+		}
+		embed.Fields = append(embed.Fields, discord.EmbedField{Name: name, Value: value})
+	}
+	return embed
+}
+
+// cmdPrivacy handles /voice privacy, which opts the user in or out of
+// activity tracking.
+func (h *handler) cmdPrivacy(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	share, err := data.Options.Find("share_activity").BoolValue()
+	if err != nil {
+		return ephemeralData("Tell me whether to share your activity.")
+	}
+	h.stats.setPrivate(data.Event.SenderID(), !share)
+	if share {
+		return ephemeralData("Your voice activity counts towards room stats again.")
+	}
+	return ephemeralData("Your voice activity no longer counts towards room stats.")
+}
@@ -0,0 +1,115 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+)
+
+// boosterPerks reports whether any booster perk is configured.
+func (h *handler) boosterPerks() bool {
+	return h.cfg.boosterUserLimit > 0 || h.cfg.boosterPersistent || h.cfg.boosterEmoji != "" || h.cfg.boosterGraceExtension > 0 || h.cfg.boosterKeepAliveMax > 0
+}
+
+// roomGrace is how long r is kept once it empties out, extended for
+// boosters.
+func (h *handler) roomGrace(r *room) time.Duration {
+	grace := h.gracePeriod(r.guildID)
+	if r.booster {
+		grace += h.cfg.boosterGraceExtension
+	}
+	return grace
+}
+
+// keepAliveLimit is when the keep-alive button stops extending r's grace
+// period.
+func (h *handler) keepAliveLimit(r *room) time.Time {
+	limit := h.cfg.keepAliveMax
+	if r.booster {
+		limit = max(limit, h.cfg.boosterKeepAliveMax)
+	}
+	return r.emptySince.Add(h.roomGrace(r) + limit)
+}
+
+// isBooster reports whether userID boosts guildID.
+func (h *handler) isBooster(ctx context.Context, guildID discord.GuildID, userID discord.UserID) bool {
+	if !h.boosterPerks() {
+		return false
+	}
+	m, err := h.lookupMember(ctx, guildID, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to check boost status", "err", err)
+		return false
+	}
+	return m.BoostedSince.IsValid()
+}
+
+// boosterName prefixes name with the booster emoji.
+func (h *handler) boosterName(name string) string {
+	if h.cfg.boosterEmoji == "" || strings.HasPrefix(name, h.cfg.boosterEmoji+" ") {
+		return name
+	}
+	return h.cfg.boosterEmoji + " " + name
+}
+
+// hasBoosterRole reports whether roleIDs include the guild's booster role.
+// Member updates don't carry when someone started boosting, but Discord
+// gives boosters this role.
+func (h *handler) hasBoosterRole(guildID discord.GuildID, roleIDs []discord.RoleID) bool {
+	for _, roleID := range roleIDs {
+		if role, err := h.s.Role(guildID, roleID); err == nil && role.Tags.PremiumSubscriber {
+			return true
+		}
+	}
+	return false
+}
+
+// updateBoostPerks grants or takes away the perks of a member's live rooms
+// when they start or stop boosting.
+func (h *handler) updateBoostPerks(evt *gateway.GuildMemberUpdateEvent) {
+	if !h.boosterPerks() {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	booster := h.hasBoosterRole(evt.GuildID, evt.RoleIDs)
+	ctx := withGuild(context.Background(), evt.GuildID)
+	for id, r := range h.rooms {
+		if r.guildID != evt.GuildID || r.owner != evt.User.ID || r.booster == booster {
+			continue
+		}
+		r.booster = booster
+		slog.Info("Room owner's boost status changed", "guild_id", r.guildID, "channel_id", id, "user_id", r.owner, "booster", booster)
+
+		if h.cfg.boosterUserLimit > 0 && r.partyLimit == 0 {
+			limit := r.hub.userLimit
+			if booster {
+				limit = h.cfg.boosterUserLimit
+			}
+			h.setRoomLimit(ctx, r, limit, "room owner's boost changed")
+		}
+		if h.cfg.boosterEmoji != "" {
+			name := strings.TrimPrefix(r.name, h.cfg.boosterEmoji+" ")
+			if booster {
+				name = h.boosterName(name)
+			}
+			if name != r.name {
+				h.renameChannel(ctx, r.roomTarget(), name)
+				r.name = name
+			}
+		}
+		h.stats.track(trackedRoomOf(r))
+		if !booster && h.cfg.boosterPersistent {
+			h.checkEmptied(ctx, r)
+		}
+		if limit := h.keepAliveLimit(r); !booster && r.emptied && r.deleteTimer != nil && r.deleteAt.After(limit) {
+			// The time the perks added to the grace period is taken back.
+			h.scheduleRoomDeletion(id, r, limit)
+		}
+	}
+}
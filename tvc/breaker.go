@@ -0,0 +1,110 @@
+package tvc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/diamondburned/arikawa/v3/utils/httputil"
+	"github.com/diamondburned/arikawa/v3/utils/httputil/httpdriver"
+)
+
+// breaker tracks consecutive failed Discord API attempts. Once threshold
+// attempts fail in a row it opens for cooldown, after which requests are let
+// through again to probe whether Discord has recovered.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+	// onChange, if set, is called in its own goroutine whenever the breaker
+	// opens or closes.
+	onChange func(open bool)
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time // zero while closed
+}
+
+func newBreaker(env *environment) *breaker {
+	return &breaker{
+		threshold: env.int("BREAKER_THRESHOLD", 10),
+		cooldown:  env.duration("BREAKER_COOLDOWN", 30*time.Second),
+	}
+}
+
+// allow reports whether a request should be attempted.
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.openedAt.IsZero() || time.Since(b.openedAt) >= b.cooldown
+}
+
+// open reports whether the breaker is tripped, i.e. the bot is degraded.
+func (b *breaker) open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !b.openedAt.IsZero()
+}
+
+// observe feeds the breaker from every REST response, retries included.
+// Hooking the HTTP client rather than individual calls keeps lookups served
+// from the cache from masking an outage.
+func (b *breaker) observe(s *state.State) {
+	s.Client.Client.OnResponse = append(s.Client.Client.OnResponse,
+		func(_ httpdriver.Request, resp httpdriver.Response) error {
+			switch {
+			case resp == nil || resp.GetStatus() >= 500:
+				b.failure()
+			case resp.GetStatus() != httputil.StatusTooManyRequests:
+				b.success()
+			}
+			return nil
+		},
+	)
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	if !b.openedAt.IsZero() {
+		b.openedAt = time.Time{}
+		b.changed(false)
+	}
+}
+
+func (b *breaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.threshold > 0 && b.failures >= b.threshold {
+		// Reopening after a failed trial restarts the cooldown.
+		wasOpen := !b.openedAt.IsZero()
+		b.openedAt = time.Now()
+		if !wasOpen {
+			b.changed(true)
+		}
+	}
+}
+
+func (b *breaker) changed(open bool) {
+	if b.onChange != nil {
+		go b.onChange(open)
+	}
+}
+
+// isOutage reports whether err looks like Discord being unavailable rather
+// than a problem with the request itself.
+func isOutage(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, errKilled) || errors.Is(err, errObserving) {
+		return false
+	}
+	var httpErr *httputil.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Status >= 500
+	}
+	return true
+}
@@ -0,0 +1,177 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// guildLimit is a number set for every guild, and overridden for some.
+type guildLimit struct {
+	limit  int
+	guilds map[discord.GuildID]int
+}
+
+// budget is a limit on how often something may happen in a guild.
+type budget struct {
+	window time.Duration
+	guildLimit
+}
+
+func envBudget(env *environment, key string, window time.Duration) *budget {
+	return &budget{window: window, guildLimit: envGuildLimit(env, key)}
+}
+
+// envGuildLimit reads a limit from $<key>, and its overrides from
+// $<key>_<guild ID>.
+func envGuildLimit(env *environment, key string) guildLimit {
+	b := guildLimit{
+		limit:  env.int(key, 0),
+		guilds: make(map[discord.GuildID]int),
+	}
+	prefix := key + "_"
+	for _, kv := range env.environ() {
+		name, value, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		guildID, err := discord.ParseSnowflake(strings.TrimPrefix(name, prefix))
+		if err != nil {
+			env.configError("invalid guild ID in $%s: %v", name, err)
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			env.configError("invalid $%s: %q is not a count", name, value)
+			continue
+		}
+		b.guilds[discord.GuildID(guildID)] = n
+	}
+	return b
+}
+
+// limitFor returns the limit of guildID, or zero if it has none.
+func (b guildLimit) limitFor(guildID discord.GuildID) int {
+	if n, ok := b.guilds[guildID]; ok {
+		return n
+	}
+	return b.limit
+}
+
+// spend takes one from guildID's share of b. If the share is used up, it
+// takes nothing and returns how long until it isn't.
+func (h *handler) spend(b *budget, guildID discord.GuildID) time.Duration {
+	limit := b.limitFor(guildID)
+	if limit <= 0 {
+		return 0
+	}
+	spent := h.budgetSpent[b]
+	if spent == nil {
+		spent = make(map[discord.GuildID][]time.Time)
+		h.budgetSpent[b] = spent
+	}
+
+	now := time.Now()
+	recent := spent[guildID][:0]
+	for _, at := range spent[guildID] {
+		if now.Sub(at) < b.window {
+			recent = append(recent, at)
+		}
+	}
+	if len(recent) >= limit {
+		spent[guildID] = recent
+		return recent[len(recent)-limit].Add(b.window).Sub(now)
+	}
+	spent[guildID] = append(recent, now)
+	return 0
+}
+
+// delayRoom creates the requested room once the guild's creation budget
+// allows it, provided the user is still waiting in the hub. A later request
+// of the same user replaces it.
+func (h *handler) delayRoom(ctx context.Context, req roomRequest, wait time.Duration) {
+	slog.InfoContext(ctx, "Room creation budget is used up, delaying room", "user_id", req.userID, "wait", wait)
+	if t, ok := h.delayedRooms[req.userID]; ok {
+		t.Stop()
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(wait, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		if h.delayedRooms[req.userID] != timer {
+			return
+		}
+		delete(h.delayedRooms, req.userID)
+		if !req.stayPut && h.userVoiceStates[req.userID].ChannelID != req.hubChannel.ID {
+			return
+		}
+		h.requestRoom(context.WithoutCancel(ctx), req)
+	})
+	h.delayedRooms[req.userID] = timer
+}
+
+// slotWait is a room request waiting for a creation slot of its guild.
+type slotWait struct {
+	ctx context.Context
+	req roomRequest
+}
+
+// takeSlot takes one of the guild's creation slots, or reports that all of
+// them are taken.
+func (h *handler) takeSlot(guildID discord.GuildID) bool {
+	if limit := h.cfg.creationSlots.limitFor(guildID); limit > 0 && h.creating[guildID] >= limit {
+		return false
+	}
+	h.creating[guildID]++
+	return true
+}
+
+// releaseSlot gives back a creation slot of the guild, handing it to the
+// next request waiting for one.
+func (h *handler) releaseSlot(guildID discord.GuildID) {
+	if h.creating[guildID]--; h.creating[guildID] <= 0 {
+		delete(h.creating, guildID)
+	}
+	if len(h.slotWaits[guildID]) == 0 {
+		return
+	}
+	// The caller may still be answering an interaction, so the next room is
+	// made on its own.
+	go func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.startWaiting(guildID)
+	}()
+}
+
+// waitForSlot queues the request until one of its guild's creation slots
+// frees up. A later request of the same user replaces it.
+func (h *handler) waitForSlot(ctx context.Context, req roomRequest) {
+	guildID := req.hubChannel.GuildID
+	slog.InfoContext(ctx, "Too many rooms in the making, queueing room", "user_id", req.userID, "in_flight", h.creating[guildID])
+	waits := slices.DeleteFunc(h.slotWaits[guildID], func(w slotWait) bool { return w.req.userID == req.userID })
+	h.slotWaits[guildID] = append(waits, slotWait{ctx: context.WithoutCancel(ctx), req: req})
+}
+
+// startWaiting requests the room of the first member waiting for a creation
+// slot of the guild who is still in the hub.
+func (h *handler) startWaiting(guildID discord.GuildID) {
+	for len(h.slotWaits[guildID]) > 0 {
+		w := h.slotWaits[guildID][0]
+		if h.slotWaits[guildID] = h.slotWaits[guildID][1:]; len(h.slotWaits[guildID]) == 0 {
+			delete(h.slotWaits, guildID)
+		}
+		if !w.req.stayPut && h.userVoiceStates[w.req.userID].ChannelID != w.req.hubChannel.ID {
+			continue
+		}
+		h.requestRoom(w.ctx, w.req)
+		return
+	}
+}
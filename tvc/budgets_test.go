@@ -0,0 +1,110 @@
+package tvc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+func TestSpendSlidingWindow(t *testing.T) {
+	h := &handler{budgetSpent: make(map[*budget]map[discord.GuildID][]time.Time)}
+	b := &budget{window: time.Minute, guildLimit: guildLimit{limit: 2}}
+
+	for i := range 2 {
+		if wait := h.spend(b, 1); wait != 0 {
+			t.Fatalf("spend %d waits %v, want 0 within the limit", i+1, wait)
+		}
+	}
+	wait := h.spend(b, 1)
+	if wait <= 0 || wait > time.Minute {
+		t.Errorf("spend past the limit waits %v, want up to a minute", wait)
+	}
+	if n := len(h.budgetSpent[b][1]); n != 2 {
+		t.Errorf("%d spends recorded, want 2: a refused spend takes nothing", n)
+	}
+	if wait := h.spend(b, 2); wait != 0 {
+		t.Errorf("another guild waits %v, want 0", wait)
+	}
+
+	// Once the oldest spend leaves the window, there is room again.
+	h.budgetSpent[b][1][0] = time.Now().Add(-time.Minute)
+	if wait := h.spend(b, 1); wait != 0 {
+		t.Errorf("spend after the window waits %v, want 0", wait)
+	}
+}
+
+func TestSpendUnlimited(t *testing.T) {
+	h := &handler{budgetSpent: make(map[*budget]map[discord.GuildID][]time.Time)}
+	b := &budget{window: time.Minute, guildLimit: guildLimit{guilds: map[discord.GuildID]int{2: 1}}}
+
+	for range 10 {
+		if wait := h.spend(b, 1); wait != 0 {
+			t.Fatalf("unlimited guild waits %v", wait)
+		}
+	}
+	h.spend(b, 2)
+	if wait := h.spend(b, 2); wait <= 0 {
+		t.Error("guild with an override of 1 may spend twice")
+	}
+}
+
+func TestEnvGuildLimit(t *testing.T) {
+	env := newEnvironment(map[string]string{"TEST_LIMIT": "3", "TEST_LIMIT_42": "0", "TEST_LIMIT_43": "7"})
+	l := envGuildLimit(env, "TEST_LIMIT")
+	for guildID, want := range map[discord.GuildID]int{1: 3, 42: 0, 43: 7} {
+		if got := l.limitFor(guildID); got != want {
+			t.Errorf("limit of guild %d is %d, want %d", guildID, got, want)
+		}
+	}
+}
+
+func TestCreationSlots(t *testing.T) {
+	b := newTestBot(t, "ROOM_CREATIONS_IN_FLIGHT=1")
+	b.send(testGuild(nil, nil))
+	h := b.h
+
+	// A creation that takes long holds the guild's only slot.
+	h.mu.Lock()
+	if !h.takeSlot(testGuildID) {
+		t.Fatal("the first slot is taken")
+	}
+	h.mu.Unlock()
+
+	// Members 5 and 6 wait for it, and 5 gives up.
+	b.send(join(5, testHubID), join(6, testHubID), join(5, 0))
+	h.mu.Lock()
+	if n := len(h.slotWaits[testGuildID]); n != 2 || len(h.rooms) != 0 {
+		t.Fatalf("%d requests wait and %d rooms exist, want 2 and none", n, len(h.rooms))
+	}
+	ctx := context.Background()
+	h.waitForSlot(ctx, h.slotWaits[testGuildID][1].req)
+	if n := len(h.slotWaits[testGuildID]); n != 2 {
+		t.Fatalf("%d requests wait after member 6 asked again, want 2", n)
+	}
+	h.releaseSlot(testGuildID)
+	h.mu.Unlock()
+
+	// The slot goes to member 6 on its own, and is given back once the room
+	// is made.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		h.mu.Lock()
+		inFlight := h.creating[testGuildID]
+		h.mu.Unlock()
+		if b.roomOf(6).IsValid() && inFlight == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("member 6 has room %d and %d creations are in flight, want a room and none", b.roomOf(6), inFlight)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.rooms) != 1 || len(h.slotWaits) != 0 {
+		t.Errorf("%d rooms exist and %d guilds wait, want member 6's room only", len(h.rooms), len(h.slotWaits))
+	}
+}
@@ -0,0 +1,184 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/utils/json/option"
+)
+
+// Custom IDs of the bulk teardown confirmation buttons.
+const (
+	teardownConfirmID = "teardown-confirm"
+	teardownCancelID  = "teardown-cancel"
+)
+
+// teardownProgressEvery is how many deletions happen between progress
+// updates, to stay clear of the rate limit on editing the response.
+const teardownProgressEvery = 5
+
+// bulkTeardown is a set of rooms an admin asked to delete.
+type bulkTeardown struct {
+	guildID   discord.GuildID
+	emptyOnly bool
+	rooms     []discord.ChannelID
+}
+
+// cmdTeardown handles /voiceadmin teardown. It lists the matching rooms and
+// asks for confirmation before deleting anything.
+func (h *handler) cmdTeardown(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	var olderThan time.Duration
+	if v := data.Options.Find("older_than").String(); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return ephemeralData(fmt.Sprintf("%q isn't a duration; try something like 90m or 2h.", v))
+		}
+		olderThan = d
+	}
+	emptyOnly, _ := data.Options.Find("empty_only").BoolValue()
+	hubName := data.Options.Find("hub").String()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	t := &bulkTeardown{guildID: data.Event.GuildID, emptyOnly: emptyOnly}
+	for id, r := range h.rooms {
+		switch {
+		case r.guildID != t.guildID:
+		case hubName != "" && r.hub.label() != hubName:
+		case time.Since(r.createdAt) < olderThan:
+		case emptyOnly && h.roomOccupants(r) > 0:
+		default:
+			t.rooms = append(t.rooms, id)
+		}
+	}
+	if len(t.rooms) == 0 {
+		return ephemeralData("No rooms match.")
+	}
+	sort.Slice(t.rooms, func(i, j int) bool { return t.rooms[i] < t.rooms[j] })
+	h.teardowns[data.Event.SenderID()] = t
+
+	content := fmt.Sprintf("Delete %s?", plural(len(t.rooms), "room"))
+	for _, id := range t.rooms {
+		line := "\n- " + id.Mention()
+		if rec := h.stats.creation(id); rec != nil && len(rec.Notes) > 0 {
+			line += " 📝 " + rec.Notes[len(rec.Notes)-1].Text
+		}
+		if len(content)+len(line) > 1900 {
+			content += "\n- …"
+			break
+		}
+		content += line
+	}
+
+	return &api.InteractionResponseData{
+		Content: option.NewNullableString(content),
+		Flags:   discord.EphemeralMessage,
+		Components: &discord.ContainerComponents{
+			&discord.ActionRowComponent{
+				&discord.ButtonComponent{
+					Style:    discord.DangerButtonStyle(),
+					CustomID: teardownConfirmID,
+					Label:    "Delete",
+				},
+				&discord.ButtonComponent{
+					Style:    discord.SecondaryButtonStyle(),
+					CustomID: teardownCancelID,
+					Label:    "Cancel",
+				},
+			},
+		},
+	}
+}
+
+// onTeardownConfirm starts the admin's pending teardown and reports progress
+// by editing the confirmation message.
+func (h *handler) onTeardownConfirm(ctx context.Context, data cmdroute.ComponentData) *api.InteractionResponse {
+	h.mu.Lock()
+	t := h.teardowns[data.Event.SenderID()]
+	delete(h.teardowns, data.Event.SenderID())
+	h.mu.Unlock()
+
+	if t == nil {
+		return teardownUpdate("This teardown has already been handled.")
+	}
+
+	go h.runTeardown(t, data.Event.AppID, data.Event.Token)
+	return teardownUpdate(fmt.Sprintf("Deleting %s…", plural(len(t.rooms), "room")))
+}
+
+// onTeardownCancel drops the admin's pending teardown.
+func (h *handler) onTeardownCancel(ctx context.Context, data cmdroute.ComponentData) *api.InteractionResponse {
+	h.mu.Lock()
+	delete(h.teardowns, data.Event.SenderID())
+	h.mu.Unlock()
+
+	return teardownUpdate("Teardown cancelled.")
+}
+
+// runTeardown deletes the rooms of t one at a time, so voice state updates
+// can be handled in between.
+func (h *handler) runTeardown(t *bulkTeardown, appID discord.AppID, token string) {
+	ctx := withGuild(context.Background(), t.guildID)
+
+	deleted := 0
+	for i, id := range t.rooms {
+		if h.teardownOne(ctx, t, id) {
+			deleted++
+		}
+		if done := i + 1; done < len(t.rooms) && done%teardownProgressEvery == 0 {
+			h.editTeardownProgress(appID, token, fmt.Sprintf("Deleting rooms… %d/%d", done, len(t.rooms)))
+		}
+	}
+
+	msg := fmt.Sprintf("Deleted %s.", plural(deleted, "room"))
+	if skipped := len(t.rooms) - deleted; skipped > 0 {
+		msg += fmt.Sprintf(" Skipped %d that were already gone or in use again.", skipped)
+	}
+	h.editTeardownProgress(appID, token, msg)
+}
+
+// teardownOne deletes a single room of t if it still qualifies.
+func (h *handler) teardownOne(ctx context.Context, t *bulkTeardown, id discord.ChannelID) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	r, ok := h.rooms[id]
+	if !ok || (t.emptyOnly && h.roomOccupants(r) > 0) {
+		return false
+	}
+
+	ch, err := h.fetchChannel(ctx, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get room to tear down", "err", err)
+		return false
+	}
+	h.deleteRoom(ctx, ch)
+	return true
+}
+
+func (h *handler) editTeardownProgress(appID discord.AppID, token, content string) {
+	_, err := h.s.EditInteractionResponse(appID, token, api.EditInteractionResponseData{
+		Content: option.NewNullableString(content),
+	})
+	if err != nil {
+		slog.Error("Failed to update teardown progress", "err", err)
+	}
+}
+
+// teardownUpdate replaces the confirmation message and removes its buttons.
+func teardownUpdate(content string) *api.InteractionResponse {
+	return &api.InteractionResponse{
+		Type: api.UpdateMessage,
+		Data: &api.InteractionResponseData{
+			Content:    option.NewNullableString(content),
+			Components: &discord.ContainerComponents{},
+		},
+	}
+}
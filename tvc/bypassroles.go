@@ -0,0 +1,52 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+// bypassAccess is what bypass roles are granted in every room. Move Members
+// lets them past the user limit.
+const bypassAccess = discord.PermissionViewChannel | discord.PermissionConnect | discord.PermissionMoveMembers
+
+// applyBypassRoles gives every bypass role of the guild an overwrite on a
+// newly created room or team category, on top of what its hub's template
+// gives the role. ch is updated to match.
+func (h *handler) applyBypassRoles(ctx context.Context, ch *discord.Channel) {
+	for _, roleID := range h.cfg.bypassRoles {
+		id := h.mirrored(ch.GuildID, discord.Snowflake(roleID))
+		if _, err := h.s.Role(ch.GuildID, discord.RoleID(id)); err != nil {
+			continue
+		}
+		o := discord.Overwrite{ID: id, Type: discord.OverwriteRole}
+		i := slices.IndexFunc(ch.Overwrites, func(existing discord.Overwrite) bool { return existing.ID == id })
+		if i >= 0 {
+			o = ch.Overwrites[i]
+		}
+		o.Allow |= bypassAccess
+		o.Deny &^= bypassAccess
+
+		err := h.call(ctx, "EditChannelPermission", func(s *state.State) error {
+			return s.EditChannelPermission(ch.ID, id, api.EditChannelPermissionData{
+				Type:           discord.OverwriteRole,
+				Allow:          o.Allow,
+				Deny:           o.Deny,
+				AuditLogReason: "bypass role",
+			})
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to apply bypass role", "err", err)
+			continue
+		}
+		if i >= 0 {
+			ch.Overwrites[i] = o
+		} else {
+			ch.Overwrites = append(ch.Overwrites, o)
+		}
+	}
+}
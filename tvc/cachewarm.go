@@ -0,0 +1,65 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+// warmCache makes sure everything room creation looks up for a guild with
+// hubs is in the state cache, so the first join after startup or after the
+// bot joins a guild doesn't wait on REST. GuildCreate normally carries the
+// guild's channels, but a guild that arrived unavailable or a cabinet that
+// dropped them would otherwise be refetched lookup by lookup.
+func (h *handler) warmCache(evt *gateway.GuildCreateEvent) {
+	var hubs []*hub
+	for i := range evt.Channels {
+		if hub := h.hubFor(&evt.Channels[i]); hub != nil {
+			hubs = append(hubs, hub)
+		}
+	}
+	if len(hubs) == 0 {
+		return
+	}
+
+	ctx := withGuild(context.Background(), evt.ID)
+	if _, err := h.s.Cabinet.Channels(evt.ID); err != nil {
+		if _, err := h.fetchChannels(ctx, evt.ID); err != nil {
+			slog.ErrorContext(ctx, "Failed to prefetch channels", "err", err)
+		}
+	}
+
+	// Configured categories are looked up by ID and may have been created
+	// after the guild's channels were sent.
+	fetched := make(map[discord.ChannelID]bool)
+	for _, hub := range hubs {
+		for _, id := range hub.categories {
+			id := discord.ChannelID(h.mirrored(evt.ID, discord.Snowflake(id)))
+			if fetched[id] {
+				continue
+			}
+			fetched[id] = true
+			if _, err := h.fetchChannel(ctx, id); err != nil {
+				slog.ErrorContext(ctx, "Failed to prefetch category", "err", err)
+			}
+		}
+	}
+
+	// Permission checks on every room need the bot's own member.
+	me, err := h.s.Me()
+	if err != nil {
+		return
+	}
+	if _, err := h.s.Cabinet.Member(evt.ID, me.ID); err != nil {
+		err := h.call(ctx, "Member", func(s *state.State) error {
+			_, err := s.Member(evt.ID, me.ID)
+			return err
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to prefetch own member", "err", err)
+		}
+	}
+}
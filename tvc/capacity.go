@@ -0,0 +1,44 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// checkCapacity warns the log channel once the category a room was just
+// created in crosses the alert threshold. Each category is reported once
+// until it drops back below the threshold.
+func (h *handler) checkCapacity(ctx context.Context, room *discord.Channel) {
+	categoryID := room.ParentID
+	if h.cfg.capacityAlertPercent <= 0 || !categoryID.IsValid() {
+		return
+	}
+	channels, err := h.fetchChannels(ctx, room.GuildID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to count category channels", "err", err)
+		return
+	}
+	// The room may not have reached the cache yet.
+	n := 1
+	for _, ch := range channels {
+		if ch.ParentID == categoryID && ch.ID != room.ID {
+			n++
+		}
+	}
+
+	if n*100 < maxCategoryChannels*h.cfg.capacityAlertPercent {
+		delete(h.capacityAlerted, categoryID)
+		return
+	}
+	if h.capacityAlerted[categoryID] {
+		return
+	}
+	h.capacityAlerted[categoryID] = true
+	slog.Warn("Category is nearly full", "guild_id", room.GuildID, "category_id", categoryID, "channels", n, "max", maxCategoryChannels)
+	h.postLog(ctx, room.GuildID, fmt.Sprintf(
+		"⚠️ %s holds %d of the %d channels a category can have. Add another category for rooms before it fills up.",
+		categoryID.Mention(), n, maxCategoryChannels))
+}
@@ -0,0 +1,30 @@
+package tvc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+func TestCheckCapacity(t *testing.T) {
+	// Two of the fifty channels a category holds is 4%.
+	category := discord.Channel{ID: 300, GuildID: testGuildID, Type: discord.GuildCategory, Name: "rooms"}
+	room := discord.Channel{ID: 301, GuildID: testGuildID, Type: discord.GuildVoice, ParentID: 300, Name: "room"}
+	other := discord.Channel{ID: 302, GuildID: testGuildID, Type: discord.GuildVoice, ParentID: 300, Name: "other"}
+	b := newTestBot(t, "CATEGORY_CAPACITY_ALERT_PERCENT=4")
+	b.send(testGuild([]discord.Channel{category, room}, nil))
+
+	h := b.h
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ctx := withGuild(context.Background(), testGuildID)
+	h.checkCapacity(ctx, &room)
+	if h.capacityAlerted[300] {
+		t.Fatal("category below the threshold was reported")
+	}
+	h.checkCapacity(ctx, &other)
+	if !h.capacityAlerted[300] {
+		t.Error("category at the threshold wasn't reported")
+	}
+}
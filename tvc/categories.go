@@ -0,0 +1,48 @@
+package tvc
+
+import (
+	"context"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// maxCategoryChannels is how many channels Discord allows in a category.
+const maxCategoryChannels = 50
+
+// roomCategory picks the category a new voice room of the request's hub goes
+// in. Hubs with categories configured spread their rooms over the ones in
+// the request's guild in turn, skipping full ones; otherwise, or when all are
+// full, rooms go next to the hub.
+func (h *handler) roomCategory(ctx context.Context, req roomRequest) discord.ChannelID {
+	if req.category.IsValid() {
+		return req.category
+	}
+	var candidates []discord.ChannelID
+	for _, id := range req.hub.categories {
+		id := discord.ChannelID(h.mirrored(req.hubChannel.GuildID, discord.Snowflake(id)))
+		if ch, err := h.fetchChannel(ctx, id); err == nil && ch.GuildID == req.hubChannel.GuildID {
+			candidates = append(candidates, id)
+		}
+	}
+	if len(candidates) == 0 {
+		return req.hubChannel.ParentID
+	}
+
+	channels, err := h.fetchChannels(ctx, req.hubChannel.GuildID)
+	if err != nil {
+		return req.hubChannel.ParentID
+	}
+	children := make(map[discord.ChannelID]int)
+	for _, ch := range channels {
+		children[ch.ParentID]++
+	}
+
+	for range candidates {
+		id := candidates[req.hub.nextCategory%len(candidates)]
+		req.hub.nextCategory++
+		if children[id] < maxCategoryChannels {
+			return id
+		}
+	}
+	return req.hubChannel.ParentID
+}
@@ -0,0 +1,52 @@
+package tvc
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	// maxChannelName is the longest channel name Discord accepts, in
+	// characters.
+	maxChannelName = 100
+	// fallbackChannelName is used for names that have nothing left once
+	// cleaned up.
+	fallbackChannelName = "Room"
+)
+
+// channelName cleans up a generated or user-supplied name before it is given
+// to Discord: control and invisible formatting characters are dropped, runs
+// of whitespace become one space, and the result is cut to maxChannelName
+// characters. Zero-width joiners are kept, since emoji sequences need them.
+func channelName(name string) string {
+	var b strings.Builder
+	space := false
+	n := 0
+	for _, c := range name {
+		switch {
+		case unicode.IsSpace(c):
+			space = b.Len() > 0
+			continue
+		case c == '\u200d':
+		case unicode.IsControl(c) || unicode.Is(unicode.Cf, c) || c == unicode.ReplacementChar:
+			continue
+		}
+		if space {
+			if n+1 >= maxChannelName {
+				break
+			}
+			b.WriteByte(' ')
+			n++
+			space = false
+		}
+		if n >= maxChannelName {
+			break
+		}
+		b.WriteRune(c)
+		n++
+	}
+	if b.Len() == 0 {
+		return fallbackChannelName
+	}
+	return b.String()
+}
@@ -0,0 +1,50 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+// expireMessage schedules the deletion of a transient message the bot posted
+// in the room's chat. Messages in plain rooms' built-in chat are kept, since
+// that chat goes away with the room anyway.
+func (h *handler) expireMessage(r *room, msgID discord.MessageID) {
+	if h.cfg.transientMessageTTL <= 0 || !r.textChannel.IsValid() {
+		return
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(h.cfg.transientMessageTTL, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		if h.rooms[r.channelID] != r || r.expiring[msgID] != timer {
+			return
+		}
+		delete(r.expiring, msgID)
+		if r.warningMessage == msgID {
+			r.warningMessage = 0
+		}
+
+		ctx := withGuild(context.Background(), r.guildID)
+		err := h.call(ctx, "DeleteMessage", func(s *state.State) error {
+			return s.DeleteMessage(r.textChannel, msgID, "transient message expired")
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to delete expired message", "err", err)
+		}
+	})
+	r.expiring[msgID] = timer
+}
+
+// forgetExpiry cancels a scheduled deletion, for messages removed otherwise.
+func (h *handler) forgetExpiry(r *room, msgID discord.MessageID) {
+	if t, ok := r.expiring[msgID]; ok {
+		t.Stop()
+		delete(r.expiring, msgID)
+	}
+}
@@ -0,0 +1,183 @@
+package tvc
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/utils/httputil"
+)
+
+// commandFields are the fields of a registered command that are compared
+// with its declaration. The rest, like its ID and version, are Discord's.
+var commandFields = []string{
+	"type", "name", "name_localizations", "description", "description_localizations",
+	"options", "default_member_permissions", "dm_permission", "nsfw",
+	"integration_types", "contexts",
+}
+
+// commandDefaults are the values Discord fills in for fields a declaration
+// leaves out; they compare equal to being left out.
+var commandDefaults = map[string]any{
+	"type":              float64(discord.ChatInputCommand),
+	"dm_permission":     true,
+	"integration_types": []any{float64(installGuild)},
+}
+
+// registeredCommand is a command as Discord has it registered.
+type registeredCommand struct {
+	ID   discord.CommandID
+	Name string
+	Type float64
+	// fields are its compared fields, normalized; see normalizeCommand.
+	fields map[string]any
+}
+
+// syncCommands makes the commands registered globally, or in the guild if
+// guildID is valid, match cmds: new ones are created, changed ones updated
+// and ones no longer declared deleted. Nothing is sent for commands that are
+// already up to date, so restarts don't touch them.
+func (h *handler) syncCommands(guildID discord.GuildID, cmds []api.CreateCommandData) error {
+	app, err := h.s.CurrentApplication()
+	if err != nil {
+		return fmt.Errorf("cannot get current app ID: %w", err)
+	}
+	endpoint := api.EndpointApplications + app.ID.String() + "/commands"
+	if guildID.IsValid() {
+		endpoint = api.EndpointApplications + app.ID.String() + "/guilds/" + guildID.String() + "/commands"
+	}
+
+	var raw []map[string]any
+	if err := h.s.RequestJSON(&raw, "GET", endpoint+"?with_localizations=true"); err != nil {
+		return fmt.Errorf("cannot list registered commands: %w", err)
+	}
+	registered := make(map[string]registeredCommand)
+	for _, fields := range raw {
+		id, _ := fields["id"].(string)
+		sf, err := discord.ParseSnowflake(id)
+		if err != nil {
+			return fmt.Errorf("registered command with invalid ID %q", id)
+		}
+		rc := registeredCommand{ID: discord.CommandID(sf), fields: normalizeCommand(fields)}
+		rc.Name, _ = fields["name"].(string)
+		rc.Type, _ = rc.fields["type"].(float64)
+		registered[commandKey(rc.Name, rc.Type)] = rc
+	}
+
+	created, updated, deleted := 0, 0, 0
+	for _, cmd := range cmds {
+		body, err := commandBody(cmd)
+		if err != nil {
+			return err
+		}
+		if guildID.IsValid() {
+			// Guild commands are only ever used in their guild.
+			delete(body, "integration_types")
+			delete(body, "contexts")
+		}
+		desired, err := roundTrip(body)
+		if err != nil {
+			return err
+		}
+		fields := normalizeCommand(desired)
+		typ, _ := fields["type"].(float64)
+		if typ == 0 {
+			typ = float64(discord.ChatInputCommand)
+		}
+		key := commandKey(cmd.Name, typ)
+		rc, ok := registered[key]
+		delete(registered, key)
+		switch {
+		case !ok:
+			err = h.s.FastRequest("POST", endpoint, httputil.WithJSONBody(body))
+			created++
+		case !reflect.DeepEqual(rc.fields, fields):
+			err = h.s.FastRequest("PATCH", endpoint+"/"+rc.ID.String(), httputil.WithJSONBody(body))
+			updated++
+		}
+		if err != nil {
+			return fmt.Errorf("cannot register command %s: %w", cmd.Name, err)
+		}
+	}
+	for _, rc := range registered {
+		if err := h.s.FastRequest("DELETE", endpoint+"/"+rc.ID.String()); err != nil {
+			return fmt.Errorf("cannot delete command %s: %w", rc.Name, err)
+		}
+		deleted++
+	}
+
+	if created+updated+deleted > 0 {
+		slog.Info("Synced commands", "guild_id", guildID, "created", created, "updated", updated, "deleted", deleted)
+	}
+	return nil
+}
+
+func commandKey(name string, typ float64) string {
+	return fmt.Sprintf("%v/%s", typ, name)
+}
+
+// roundTrip turns v into what it decodes to from JSON, so declarations
+// compare with what Discord sends back.
+func roundTrip(v any) (map[string]any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]any
+	return out, json.Unmarshal(b, &out)
+}
+
+// normalizeCommand keeps the compared fields of a decoded command, leaving out
+// the ones that are empty or at Discord's default.
+func normalizeCommand(fields map[string]any) map[string]any {
+	out := make(map[string]any)
+	for _, name := range commandFields {
+		v := compact(fields[name])
+		if v == nil || reflect.DeepEqual(v, commandDefaults[name]) {
+			continue
+		}
+		out[name] = v
+	}
+	return out
+}
+
+// compact drops nulls, false, zero-length values and empty maps from a
+// decoded JSON value, which Discord and arikawa send inconsistently.
+func compact(v any) any {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case bool:
+		if !v {
+			return nil
+		}
+	case string:
+		if v == "" {
+			return nil
+		}
+	case []any:
+		if len(v) == 0 {
+			return nil
+		}
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = compact(e)
+		}
+		return out
+	case map[string]any:
+		out := make(map[string]any)
+		for k, e := range v {
+			if e = compact(e); e != nil {
+				out[k] = e
+			}
+		}
+		if len(out) == 0 {
+			return nil
+		}
+		return out
+	}
+	return v
+}
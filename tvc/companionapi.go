@@ -0,0 +1,146 @@
+package tvc
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// companionEvent tells companion bots about a room going away, so music
+// bots can stop playback before the channel disappears under them.
+type companionEvent struct {
+	Type      string            `json:"type"` // "deleting" or "deleted"
+	GuildID   discord.GuildID   `json:"guild_id"`
+	ChannelID discord.ChannelID `json:"channel_id"`
+	At        time.Time         `json:"at"`
+}
+
+// companionFeed fans companion events out to the bots subscribed to
+// GET /companions/events.
+type companionFeed struct {
+	mu   sync.Mutex
+	subs map[chan companionEvent]discord.GuildID
+}
+
+func newCompanionFeed() *companionFeed {
+	return &companionFeed{subs: make(map[chan companionEvent]discord.GuildID)}
+}
+
+// publish sends ev to every subscriber of its guild or of all guilds,
+// dropping it for subscribers that can't keep up.
+func (f *companionFeed) publish(ev companionEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for sub, guildID := range f.subs {
+		if guildID.IsValid() && guildID != ev.GuildID {
+			continue
+		}
+		select {
+		case sub <- ev:
+		default:
+		}
+	}
+}
+
+// serveEvents streams companion events as server-sent events, for the guild
+// in ?guild_id= or all guilds.
+func (f *companionFeed) serveEvents(w http.ResponseWriter, req *http.Request) {
+	var guildID discord.GuildID
+	if s := req.URL.Query().Get("guild_id"); s != "" {
+		sf, err := discord.ParseSnowflake(s)
+		if err != nil {
+			http.Error(w, "bad guild_id", http.StatusBadRequest)
+			return
+		}
+		guildID = discord.GuildID(sf)
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	sub := make(chan companionEvent, 16)
+	f.mu.Lock()
+	f.subs[sub] = guildID
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		delete(f.subs, sub)
+		f.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case ev := <-sub:
+			b, _ := json.Marshal(ev)
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, b); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// companionChannelJSON is the body of GET /companions/channels/{id}.
+type companionChannelJSON struct {
+	Temporary bool              `json:"temporary"`
+	GuildID   discord.GuildID   `json:"guild_id,omitempty"`
+	RoomID    discord.ChannelID `json:"room_id,omitempty"`
+	OwnerID   discord.UserID    `json:"owner_id,omitempty"`
+	Hub       string            `json:"hub,omitempty"`
+	CreatedAt *time.Time        `json:"created_at,omitempty"`
+}
+
+// serveCompanionChannel answers whether a channel is temporary and, if it
+// belongs to a room, which room and who owns it. A room's text channel or
+// chat resolves to the room.
+func (h *handler) serveCompanionChannel(w http.ResponseWriter, req *http.Request) {
+	if !h.authorized(w, req) {
+		return
+	}
+	sf, err := discord.ParseSnowflake(req.PathValue("id"))
+	if err != nil {
+		http.Error(w, "bad channel id", http.StatusBadRequest)
+		return
+	}
+	channelID := discord.ChannelID(sf)
+
+	h.mu.Lock()
+	var body companionChannelJSON
+	r, ok := h.rooms[channelID]
+	if !ok {
+		channelID, r = h.roomByChat(channelID)
+	}
+	switch {
+	case r != nil:
+		body = companionChannelJSON{
+			Temporary: true,
+			GuildID:   r.guildID,
+			RoomID:    channelID,
+			OwnerID:   r.owner,
+			Hub:       r.hub.label(),
+			CreatedAt: &r.createdAt,
+		}
+	case h.created[discord.ChannelID(sf)]:
+		body.Temporary = true
+	}
+	h.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		slog.Error("Failed to write companion channel", "err", err)
+	}
+}
@@ -0,0 +1,31 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+// companionAccess is what companion bots are granted in every room.
+const companionAccess = discord.PermissionViewChannel | discord.PermissionConnect | discord.PermissionSpeak
+
+// inviteCompanions gives every companion bot a member overwrite on a newly
+// created room or team category, so they can be summoned even into locked
+// rooms.
+func (h *handler) inviteCompanions(ctx context.Context, ch *discord.Channel) {
+	for botID := range h.cfg.companionBots {
+		err := h.call(ctx, "EditChannelPermission", func(s *state.State) error {
+			return s.EditChannelPermission(ch.ID, discord.Snowflake(botID), api.EditChannelPermissionData{
+				Type:           discord.OverwriteMember,
+				Allow:          companionAccess,
+				AuditLogReason: "companion bot",
+			})
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to invite companion bot", "err", err)
+		}
+	}
+}
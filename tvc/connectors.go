@@ -0,0 +1,321 @@
+package tvc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+// connector provisions a counterpart of a team room on another chat
+// service, so a team can keep talking there, and removes it again.
+type connector interface {
+	// provision creates the counterpart of the room and returns its ID and,
+	// if the service has one, a link to it.
+	provision(ctx context.Context, ev hookEvent) (id, link string, err error)
+	// teardown removes the counterpart with the given ID.
+	teardown(ctx context.Context, id string) error
+}
+
+var (
+	// connectors are the services team rooms are mirrored to, by name, made
+	// as hubs name them.
+	connectors   = make(map[string]connector)
+	connectorsMu sync.Mutex
+)
+
+// loadConnector returns the connector with the given name, set up from the
+// environment on first use. "slack" uses $SLACK_TOKEN, "matrix"
+// $MATRIX_HOMESERVER and $MATRIX_TOKEN; any other name, e.g. "mumble", runs
+// $<NAME>_CREATE_COMMAND and $<NAME>_DELETE_COMMAND the way hooks run
+// commands. The create command prints the counterpart's ID and, optionally, a
+// link on the next line; the delete command gets the ID in $TVC_EXTERNAL_ID.
+func loadConnector(env *environment, name string) (connector, error) {
+	connectorsMu.Lock()
+	defer connectorsMu.Unlock()
+
+	if c, ok := connectors[name]; ok {
+		return c, nil
+	}
+	var c connector
+	switch name {
+	case "slack":
+		token := env.string("SLACK_TOKEN", "")
+		if token == "" {
+			return nil, errors.New("the slack connector needs $SLACK_TOKEN")
+		}
+		c = slackConnector{token: token}
+	case "matrix":
+		homeserver, token := env.string("MATRIX_HOMESERVER", ""), env.string("MATRIX_TOKEN", "")
+		if homeserver == "" || token == "" {
+			return nil, errors.New("the matrix connector needs $MATRIX_HOMESERVER and $MATRIX_TOKEN")
+		}
+		c = matrixConnector{homeserver: strings.TrimSuffix(homeserver, "/"), token: token}
+	default:
+		key := strings.ToUpper(name)
+		cc := commandConnector{
+			create: strings.Fields(env.string(key+"_CREATE_COMMAND", "")),
+			delete: strings.Fields(env.string(key+"_DELETE_COMMAND", "")),
+		}
+		if len(cc.create) == 0 || len(cc.delete) == 0 {
+			return nil, fmt.Errorf("the %s connector needs $%s_CREATE_COMMAND and $%s_DELETE_COMMAND", name, key, key)
+		}
+		c = cc
+	}
+	connectors[name] = c
+	return c, nil
+}
+
+// parseConnectors reads the connectors the hub with key mirrors its team
+// rooms to from $<key>_CONNECTORS, falling back to $CONNECTORS, e.g.
+// "slack,mumble".
+func parseConnectors(env *environment, key string) ([]string, error) {
+	names := env.list(key + "_CONNECTORS")
+	if len(names) == 0 {
+		names = env.list("CONNECTORS")
+	}
+	for i, name := range names {
+		names[i] = strings.ToLower(name)
+		if _, err := loadConnector(env, names[i]); err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
+
+// provisionExternal creates the counterparts of a new team room in the
+// background, and posts where they are in its text chat. h.mu must be held.
+func (h *handler) provisionExternal(r *room) {
+	if !r.category.IsValid() || len(r.hub.connectors) == 0 {
+		return
+	}
+	ev := hookEvent{
+		Event:     hookCreate,
+		GuildID:   r.guildID,
+		ChannelID: r.channelID,
+		OwnerID:   r.owner,
+		Hub:       r.hub.label(),
+		Name:      r.name,
+		Number:    r.number,
+		At:        time.Now(),
+	}
+	category, names := r.category, r.hub.connectors
+	go func() {
+		ctx, cancel := context.WithTimeout(withGuild(context.Background(), ev.GuildID), h.cfg.hookTimeout)
+		defer cancel()
+
+		var links []string
+		for _, name := range names {
+			c, err := loadConnector(h.env, name)
+			if err != nil {
+				continue
+			}
+			id, link, err := c.provision(ctx, ev)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to provision external room", "connector", name, "channel_id", ev.ChannelID, "err", err)
+				continue
+			}
+			h.stats.setExternal(category, name, id)
+			if link != "" {
+				links = append(links, fmt.Sprintf("%s: %s", name, link))
+			}
+		}
+
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		var r *room
+		for _, other := range h.rooms {
+			if other.category == category {
+				r = other
+			}
+		}
+		if r == nil {
+			// The room went while its counterparts were being made.
+			h.teardownExternal(ev.GuildID, category)
+			return
+		}
+		if len(links) == 0 || r.hub.silent {
+			return
+		}
+		err := h.call(ctx, "SendMessage", func(s *state.State) error {
+			_, err := s.SendMessageComplex(r.chatChannel(), api.SendMessageData{
+				Content: "This team also has a room on " + strings.Join(links, ", "),
+			})
+			return err
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to post external rooms", "err", err)
+		}
+	}()
+}
+
+// teardownExternal removes the counterparts of the team room with the given
+// category in the background.
+func (h *handler) teardownExternal(guildID discord.GuildID, category discord.ChannelID) {
+	external := h.stats.takeExternal(category)
+	if len(external) == 0 {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(withGuild(context.Background(), guildID), h.cfg.hookTimeout)
+		defer cancel()
+
+		for name, id := range external {
+			c, err := loadConnector(h.env, name)
+			if err != nil {
+				slog.WarnContext(ctx, "Leaving external room of removed connector", "connector", name, "external_id", id, "err", err)
+				continue
+			}
+			if err := c.teardown(ctx, id); err != nil {
+				slog.ErrorContext(ctx, "Failed to tear down external room", "connector", name, "external_id", id, "err", err)
+			}
+		}
+	}()
+}
+
+// postJSON posts body to endpoint with a bearer token and decodes the answer into
+// out.
+func postJSON(ctx context.Context, endpoint, token string, body, out any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := hookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s returned %s", req.URL.Host, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// slackConnector mirrors team rooms to Slack channels, archived when the
+// room goes. The token needs the channels:manage scope.
+type slackConnector struct {
+	token string
+}
+
+// slackUnsafe matches what Slack channel names can't hold.
+var slackUnsafe = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+func (c slackConnector) provision(ctx context.Context, ev hookEvent) (string, string, error) {
+	name := strings.Trim(slackUnsafe.ReplaceAllString(strings.ToLower(ev.Name), "-"), "-")
+	if name == "" {
+		name = "team"
+	}
+	// Names must be unique, also among archived channels.
+	name = fmt.Sprintf("%.60s-%s", name, ev.ChannelID)
+	var resp struct {
+		OK      bool   `json:"ok"`
+		Error   string `json:"error"`
+		Channel struct {
+			ID string `json:"id"`
+		} `json:"channel"`
+	}
+	if err := postJSON(ctx, "https://slack.com/api/conversations.create", c.token, map[string]any{"name": name}, &resp); err != nil {
+		return "", "", err
+	}
+	if !resp.OK {
+		return "", "", fmt.Errorf("slack: %s", resp.Error)
+	}
+	return resp.Channel.ID, "#" + name, nil
+}
+
+func (c slackConnector) teardown(ctx context.Context, id string) error {
+	var resp struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := postJSON(ctx, "https://slack.com/api/conversations.archive", c.token, map[string]any{"channel": id}, &resp); err != nil {
+		return err
+	}
+	if !resp.OK && resp.Error != "already_archived" {
+		return fmt.Errorf("slack: %s", resp.Error)
+	}
+	return nil
+}
+
+// matrixConnector mirrors team rooms to Matrix rooms. Matrix rooms can't be
+// deleted, so the bot's account leaves it on teardown.
+type matrixConnector struct {
+	homeserver string
+	token      string
+}
+
+func (c matrixConnector) provision(ctx context.Context, ev hookEvent) (string, string, error) {
+	var resp struct {
+		RoomID string `json:"room_id"`
+	}
+	body := map[string]any{"name": ev.Name, "preset": "private_chat"}
+	if err := postJSON(ctx, c.homeserver+"/_matrix/client/v3/createRoom", c.token, body, &resp); err != nil {
+		return "", "", err
+	}
+	return resp.RoomID, "https://matrix.to/#/" + resp.RoomID, nil
+}
+
+func (c matrixConnector) teardown(ctx context.Context, id string) error {
+	var resp struct{}
+	return postJSON(ctx, c.homeserver+"/_matrix/client/v3/rooms/"+url.PathEscape(id)+"/leave", c.token, map[string]any{}, &resp)
+}
+
+// commandConnector mirrors team rooms with commands, e.g. scripts managing
+// Mumble channels.
+type commandConnector struct {
+	create, delete []string
+}
+
+func (c commandConnector) provision(ctx context.Context, ev hookEvent) (string, string, error) {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return "", "", err
+	}
+	cmd := exec.CommandContext(ctx, c.create[0], c.create[1:]...)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(),
+		"TVC_GUILD_ID="+ev.GuildID.String(),
+		"TVC_CHANNEL_ID="+ev.ChannelID.String(),
+		"TVC_OWNER_ID="+ev.OwnerID.String(),
+		"TVC_HUB="+ev.Hub,
+		"TVC_NAME="+ev.Name,
+	)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", "", err
+	}
+	id, link, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	if id = strings.TrimSpace(id); id == "" {
+		return "", "", fmt.Errorf("%s printed no ID", c.create[0])
+	}
+	return id, strings.TrimSpace(link), nil
+}
+
+func (c commandConnector) teardown(ctx context.Context, id string) error {
+	cmd := exec.CommandContext(ctx, c.delete[0], c.delete[1:]...)
+	cmd.Env = append(os.Environ(), "TVC_EXTERNAL_ID="+id)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
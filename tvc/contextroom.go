@@ -0,0 +1,101 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/diamondburned/arikawa/v3/utils/json/option"
+)
+
+// contextRoomCommand is the name of the message context menu command that
+// starts a room for a discussion.
+const contextRoomCommand = "Create voice room"
+
+// cmdContextRoom creates a voice room named after the message's thread, or
+// the message itself, and links it in the channel. Owners in voice are moved
+// in; the others use the link.
+func (h *handler) cmdContextRoom(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	guildID := data.Event.GuildID
+	userID := data.Event.SenderID()
+	hub, hubChannel := h.voiceHubOf(guildID)
+	if hub == nil {
+		return ephemeralData("This server has no voice hub to create rooms for.")
+	}
+
+	vs, ok := h.userVoiceStates[userID]
+	stayPut := !ok || !vs.ChannelID.IsValid() || vs.GuildID != guildID
+	if stayPut {
+		vs = discord.VoiceState{GuildID: guildID, UserID: userID}
+	}
+	vs.Member = data.Event.Member
+
+	ctx = withGuild(ctx, guildID)
+	if !h.mayCreateRoom(ctx, hub, guildID, &gateway.VoiceStateUpdateEvent{VoiceState: vs}, vs.ChannelID) {
+		return ephemeralData("You can't get a new room right now.")
+	}
+	if h.admitCreation(guildID).held() {
+		return ephemeralData("Too many rooms are being created right now. Try again in a moment.")
+	}
+	defer h.releaseSlot(guildID)
+
+	req := roomRequest{
+		hub:        hub,
+		hubChannel: hubChannel,
+		userID:     userID,
+		username:   data.Event.Sender().Username,
+		name:       h.contextRoomName(data),
+		stayPut:    stayPut,
+	}
+	if err := h.createRoom(ctx, req); err != nil {
+		slog.ErrorContext(ctx, "Failed to create room for a message", "err", err)
+		return ephemeralData("Sorry, I couldn't create the room. Try again later.")
+	}
+	r := h.newestRoomOf(userID)
+	if r == nil {
+		return ephemeralData("Sorry, I couldn't create the room. Try again later.")
+	}
+	return &api.InteractionResponseData{
+		Content:         option.NewNullableString("🔊 Voice room for this discussion: " + r.channelID.Mention()),
+		AllowedMentions: &api.AllowedMentions{},
+	}
+}
+
+// contextRoomName names a room after the thread the command was used in, or
+// else the first line of the message. It returns "" to fall back to the
+// hub's naming.
+func (h *handler) contextRoomName(data cmdroute.CommandData) string {
+	if ch, err := h.s.Channel(data.Event.ChannelID); err == nil {
+		switch ch.Type {
+		case discord.GuildPublicThread, discord.GuildPrivateThread, discord.GuildAnnouncementThread:
+			return ch.Name
+		}
+	}
+	msg, ok := data.Data.Resolved.Messages[data.Data.TargetMessageID()]
+	if !ok {
+		return ""
+	}
+	topic, _, _ := strings.Cut(strings.TrimSpace(msg.Content), "\n")
+	if runes := []rune(topic); len(runes) > 100 {
+		topic = string(runes[:99]) + "…"
+	}
+	return topic
+}
+
+// newestRoomOf returns the room userID created last.
+func (h *handler) newestRoomOf(userID discord.UserID) *room {
+	var newest *room
+	for _, r := range h.rooms {
+		if r.owner == userID && (newest == nil || r.createdAt.After(newest.createdAt)) {
+			newest = r
+		}
+	}
+	return newest
+}
@@ -0,0 +1,154 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/diamondburned/arikawa/v3/utils/json/option"
+)
+
+// stageModerator is what makes a member a moderator of a stage channel, so a
+// room's owner can invite people to speak once it is converted.
+const stageModerator = discord.PermissionManageChannels | discord.PermissionMuteMembers | discord.PermissionMoveMembers
+
+// maxStageUsers is the most a stage channel's user limit may be.
+const maxStageUsers = 10000
+
+// convertChoices are the channel types /voice convert can switch a room to.
+func convertChoices() []discord.StringChoice {
+	return []discord.StringChoice{
+		{Name: "stage", Value: "stage"},
+		{Name: "voice", Value: "voice"},
+	}
+}
+
+// cmdConvert handles /voice convert, which recreates the caller's room as a
+// stage or voice channel with the same name, limit and overwrites, moves
+// everyone over and deletes the old channel. The room continues as the same
+// session, e.g. for when a hangout turns into a talk.
+func (h *handler) cmdConvert(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	r := h.ownedRoomOf(data.Event.SenderID())
+	if r == nil {
+		return ephemeralData("You need to be in a room you own to do that.")
+	}
+	if h.isFrozen(r.channelID) {
+		return ephemeralData("Your room is frozen by a moderator, so it can't be converted.")
+	}
+	ctx = withGuild(ctx, r.guildID)
+	old, err := h.fetchChannel(ctx, r.channelID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get room to convert", "err", err)
+		return ephemeralData("I couldn't find your room, try again.")
+	}
+
+	kind, typ := "stage", discord.GuildStageVoice
+	if data.Options.Find("type").String() == "voice" {
+		kind, typ = "voice", discord.GuildVoice
+	}
+	if old.Type == typ {
+		return ephemeralData(fmt.Sprintf("Your room already is a %s channel.", kind))
+	}
+
+	createData := api.CreateChannelData{
+		Name:           old.Name,
+		Type:           typ,
+		CategoryID:     old.ParentID,
+		Position:       option.NewInt(old.Position),
+		RTCRegionID:    old.RTCRegionID,
+		VoiceUserLimit: old.VoiceUserLimit,
+		Overwrites:     convertOverwrites(old.Overwrites, r.owner, typ),
+		AuditLogReason: api.AuditLogReason("converting a room to a " + kind + " channel"),
+	}
+	if typ == discord.GuildVoice {
+		// Stage channels have a fixed bitrate and a far higher limit.
+		createData.VoiceBitrate = r.hub.bitrate
+		createData.VoiceUserLimit = min(createData.VoiceUserLimit, 99)
+	} else {
+		createData.VoiceUserLimit = min(createData.VoiceUserLimit, maxStageUsers)
+	}
+	var ch *discord.Channel
+	err = h.call(ctx, "CreateChannel", func(s *state.State) (err error) {
+		ch, err = s.CreateChannel(r.guildID, createData)
+		return err
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create converted room", "type", kind, "err", err)
+		if typ == discord.GuildStageVoice {
+			return ephemeralData("I couldn't create the stage channel. Stage channels need a Community server.")
+		}
+		return ephemeralData("I couldn't create the voice channel, try again.")
+	}
+	h.created[ch.ID] = true
+	slog.InfoContext(ctx, "Room converted", "channel_id", ch.ID, "old_channel_id", old.ID, "type", kind)
+
+	var members []discord.UserID
+	for userID := range h.channelMembers[old.ID] {
+		members = append(members, userID)
+	}
+	h.moveRoom(old.ID, ch.ID, r)
+	h.postRoomInfo(ctx, ch.ID, r)
+
+	// The members are moved before the old channel is deleted, which would
+	// disconnect them instead.
+	go func() {
+		ctx := withGuild(context.Background(), r.guildID)
+		for _, userID := range members {
+			err := h.moveMember(ctx, memberMove{
+				guildID:   r.guildID,
+				userID:    userID,
+				channelID: ch.ID,
+				reason:    "room converted to a " + kind + " channel",
+			})
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to move member into converted room", "user_id", userID, "err", err)
+			}
+		}
+
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if err := h.deleteChannel(ctx, old.ID, "room converted"); err != nil {
+			slog.ErrorContext(ctx, "Failed to delete room channel after converting it", "channel_id", old.ID, "err", err)
+		}
+	}()
+
+	if typ == discord.GuildStageVoice {
+		return ephemeralData("Your room is now a stage channel: " + ch.Mention() + ". Everyone is being moved over; you're a stage moderator and can invite people to speak.")
+	}
+	return ephemeralData("Your room is now a voice channel again: " + ch.Mention() + ". Everyone is being moved over.")
+}
+
+// convertOverwrites returns the overwrites of a room converted to a channel
+// of type typ: the owner is made a stage moderator on a stage channel and
+// loses that again on a voice channel.
+func convertOverwrites(overwrites []discord.Overwrite, owner discord.UserID, typ discord.ChannelType) []discord.Overwrite {
+	converted := make([]discord.Overwrite, 0, len(overwrites)+1)
+	found := false
+	for _, o := range overwrites {
+		if o.Type == discord.OverwriteMember && o.ID == discord.Snowflake(owner) {
+			found = true
+			if typ == discord.GuildStageVoice {
+				o.Allow |= stageModerator
+				o.Deny &^= stageModerator
+			} else {
+				o.Allow &^= stageModerator
+			}
+		}
+		converted = append(converted, o)
+	}
+	if !found && typ == discord.GuildStageVoice {
+		converted = append(converted, discord.Overwrite{
+			ID:    discord.Snowflake(owner),
+			Type:  discord.OverwriteMember,
+			Allow: stageModerator,
+		})
+	}
+	return converted
+}
@@ -0,0 +1,315 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+// roomRequest describes a user waiting in a hub for their room.
+type roomRequest struct {
+	hub        *hub
+	hubChannel *discord.Channel
+	userID     discord.UserID
+	username   string
+	// preset is what the user picked from the hub's presets, if anything.
+	preset *preset
+	// category overrides where the room goes, for launchers.
+	category discord.ChannelID
+	// name overrides the room's name, for rooms started from a message.
+	name string
+	// stayPut creates the room without moving the user into it, for users
+	// who aren't connected to voice. The room is deleted if nobody claims
+	// it in time.
+	stayPut bool
+	// number is the room's {number}, assigned when it is created.
+	number int
+	// joinedAt is when the user joined the hub, or picked their preset if
+	// the hub offered some. Zero for requests that didn't come from a hub.
+	joinedAt time.Time
+	// booster is set if the user boosts the guild, for booster perks.
+	booster bool
+}
+
+// auditReason is the audit log reason for creating what, e.g. "room", for
+// the request, naming who asked for it and from which hub so server audit
+// logs show who is behind the bot's channels. Discord wants the reason URL
+// encoded, and names and hub labels often aren't ASCII.
+func (req roomRequest) auditReason(what string) api.AuditLogReason {
+	who := req.userID.String()
+	if req.username != "" {
+		who = fmt.Sprintf("@%s (%s)", req.username, req.userID)
+	}
+	return api.AuditLogReason(url.PathEscape(fmt.Sprintf("%s for %s from hub %s", what, who, req.hub.label())))
+}
+
+// requestRoom creates the requested room, or queues it if Discord appears to
+// be unavailable, the guild's creation budget is used up or too many of its
+// rooms are in the making.
+func (h *handler) requestRoom(ctx context.Context, req roomRequest) {
+	guildID := req.hubChannel.GuildID
+	switch hold := h.admitCreation(guildID); {
+	case hold.noSlot:
+		h.waitForSlot(ctx, req)
+		return
+	case hold.wait > 0:
+		h.delayRoom(ctx, req, hold.wait)
+		return
+	case hold.outage:
+		h.enqueueRoom(ctx, req)
+		return
+	}
+	defer h.releaseSlot(guildID)
+
+	if err := h.createRoom(ctx, req); err != nil {
+		if isOutage(err) {
+			h.enqueueRoom(ctx, req)
+			return
+		}
+		slog.ErrorContext(ctx, "Failed to create room", "err", err)
+		h.strandMember(ctx, req)
+	}
+}
+
+// creationHold is why a room can't be created right away. The zero value
+// means it can.
+type creationHold struct {
+	// noSlot is set if too many of the guild's rooms are in the making.
+	noSlot bool
+	// wait is how long the guild's creation budget is used up for.
+	wait time.Duration
+	// outage is set while Discord appears to be unavailable.
+	outage bool
+}
+
+// held reports whether the room has to wait.
+func (hold creationHold) held() bool {
+	return hold != creationHold{}
+}
+
+// admitCreation takes a creation slot of the guild and spends from its
+// creation budget for a room, which every room created needs. If it returns
+// a hold, nothing was taken; otherwise the slot must be released once the
+// room is made.
+func (h *handler) admitCreation(guildID discord.GuildID) creationHold {
+	if !h.takeSlot(guildID) {
+		return creationHold{noSlot: true}
+	}
+	if wait := h.spend(h.cfg.creationBudget, guildID); wait > 0 {
+		h.releaseSlot(guildID)
+		return creationHold{wait: wait}
+	}
+	if !h.breaker.allow() {
+		h.releaseSlot(guildID)
+		return creationHold{outage: true}
+	}
+	return creationHold{}
+}
+
+// createRoom spawns the room for the request's hub and moves the user into it.
+func (h *handler) createRoom(ctx context.Context, req roomRequest) error {
+	req.number = h.stats.nextRoomNumber(req.hubChannel.GuildID, req.hub.label())
+	req.booster = h.isBooster(ctx, req.hubChannel.GuildID, req.userID)
+
+	var name string
+	if req.name != "" {
+		name = req.name
+	} else if req.preset != nil && req.preset.RoomName != "" {
+		name = expandTemplate(req.preset.RoomName, templateData{Username: req.username, Number: req.number})
+	} else {
+		name = h.roomName(ctx, req)
+	}
+	name = h.avoidReservedName(req, name)
+	h.shadowRoom(ctx, req, name)
+	if req.booster {
+		name = h.boosterName(name)
+	}
+
+	switch req.hub.kind {
+	case voiceRoom:
+		return h.createVoiceRoom(ctx, req, name)
+	case teamRoom:
+		return h.createTeamRoom(ctx, req, name)
+	default:
+		return fmt.Errorf("hub %q has no room type", req.hub.label())
+	}
+}
+
+// createVoiceRoom creates a single voice channel next to the hub.
+func (h *handler) createVoiceRoom(ctx context.Context, req roomRequest, name string) error {
+	ctx, endIO := releaseForIO(ctx)
+	defer endIO()
+
+	category := h.roomCategory(ctx, req)
+	name = channelName(h.decorateName(req.hubChannel.GuildID, name))
+	var tempChannel *discord.Channel
+	err := h.call(ctx, "CreateChannel", func(s *state.State) (err error) {
+		tempChannel, err = s.CreateChannel(req.hubChannel.GuildID, api.CreateChannelData{
+			Name:           name,
+			Type:           discord.GuildVoice,
+			CategoryID:     category,
+			RTCRegionID:    req.hub.region,
+			VoiceUserLimit: h.userLimit(req),
+			VoiceBitrate:   req.hub.bitrate,
+			AuditLogReason: req.auditReason("room"),
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to clone channel: %w", err)
+	}
+	createdAt := time.Now()
+	h.created[tempChannel.ID] = true
+	h.checkCapacity(ctx, tempChannel)
+	h.applyTemplate(ctx, tempChannel, req)
+	h.denyExternalApps(ctx, tempChannel)
+	h.inviteCompanions(ctx, tempChannel)
+	h.applyPreset(ctx, tempChannel, req)
+	h.applyVisibility(ctx, tempChannel, req)
+	h.applyPrivacy(ctx, tempChannel, req)
+	h.applyRoomBans(ctx, tempChannel, req)
+	h.applyBypassRoles(ctx, tempChannel)
+	if !req.stayPut {
+		err = h.moveMember(ctx, memberMove{
+			guildID:   req.hubChannel.GuildID,
+			userID:    req.userID,
+			channelID: tempChannel.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to move member: %w", err)
+		}
+		h.latency.record(req, createdAt)
+	}
+	endIO()
+	h.temporaryChannels = append(h.temporaryChannels, tempChannel.ID)
+	r := &room{
+		hub:         req.hub,
+		guildID:     req.hubChannel.GuildID,
+		owner:       req.userID,
+		number:      req.number,
+		name:        tempChannel.Name,
+		scaledLimit: h.scaledLimit(req),
+		booster:     req.booster,
+	}
+	h.addRoom(ctx, tempChannel.ID, r)
+	if req.stayPut {
+		r.emptySince = time.Now()
+		h.scheduleRoomDeletion(tempChannel.ID, r, r.emptySince.Add(h.cfg.unclaimedRoomTimeout))
+	}
+	return nil
+}
+
+// createTeamRoom creates a category holding a text and a voice channel.
+func (h *handler) createTeamRoom(ctx context.Context, req roomRequest, name string) error {
+	ctx, endIO := releaseForIO(ctx)
+	defer endIO()
+
+	name = channelName(h.decorateName(req.hubChannel.GuildID, name))
+	var temporaryCategory *discord.Channel
+	err := h.call(ctx, "CreateChannel", func(s *state.State) (err error) {
+		temporaryCategory, err = s.CreateChannel(req.hubChannel.GuildID, api.CreateChannelData{
+			Name:           name,
+			Type:           discord.GuildCategory,
+			AuditLogReason: req.auditReason("team room"),
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create category: %w", err)
+	}
+	h.created[temporaryCategory.ID] = true
+	h.ensureSelfAccess(ctx, temporaryCategory.GuildID, temporaryCategory.ID)
+	h.applyTemplate(ctx, temporaryCategory, req)
+	h.denyExternalApps(ctx, temporaryCategory)
+	h.inviteCompanions(ctx, temporaryCategory)
+	h.applyPreset(ctx, temporaryCategory, req)
+	h.applyVisibility(ctx, temporaryCategory, req)
+	h.applyPrivacy(ctx, temporaryCategory, req)
+	h.applyRoomBans(ctx, temporaryCategory, req)
+	h.applyBypassRoles(ctx, temporaryCategory)
+
+	var textChannelID discord.ChannelID
+	var forumPost bool
+	if forumID := h.teamForumFor(temporaryCategory.GuildID); forumID.IsValid() && h.features.enabled(temporaryCategory.GuildID, featureTextPairing) {
+		if textChannelID, err = h.createForumPost(ctx, forumID, temporaryCategory.Name, req.userID, req.auditReason("team room post")); err != nil {
+			return fmt.Errorf("failed to create forum post: %w", err)
+		}
+		forumPost = true
+	} else if h.features.enabled(temporaryCategory.GuildID, featureTextPairing) {
+		var textChannel *discord.Channel
+		err = h.call(ctx, "CreateChannel", func(s *state.State) (err error) {
+			textChannel, err = s.CreateChannel(temporaryCategory.GuildID, api.CreateChannelData{
+				Name:           "text",
+				Type:           discord.GuildText,
+				CategoryID:     temporaryCategory.ID,
+				AuditLogReason: req.auditReason("team room chat"),
+			})
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create text channel: %w", err)
+		}
+		h.created[textChannel.ID] = true
+		textChannelID = textChannel.ID
+		h.setAutoModExemption(ctx, temporaryCategory.GuildID, textChannelID, true)
+	}
+
+	var tempChannel *discord.Channel
+	err = h.call(ctx, "CreateChannel", func(s *state.State) (err error) {
+		tempChannel, err = s.CreateChannel(temporaryCategory.GuildID, api.CreateChannelData{
+			Name:           "voice",
+			Type:           discord.GuildVoice,
+			CategoryID:     temporaryCategory.ID,
+			RTCRegionID:    req.hub.region,
+			VoiceUserLimit: h.userLimit(req),
+			VoiceBitrate:   req.hub.bitrate,
+			AuditLogReason: req.auditReason("team room voice channel"),
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create voice channel: %w", err)
+	}
+	createdAt := time.Now()
+	h.created[tempChannel.ID] = true
+
+	var afkChannelID discord.ChannelID
+	if h.features.enabled(temporaryCategory.GuildID, featureTeamAFK) {
+		if afkChannelID, err = h.createAFKChannel(ctx, temporaryCategory, req.hub.region, req.auditReason("team room AFK channel")); err != nil {
+			return err
+		}
+	}
+
+	err = h.moveMember(ctx, memberMove{
+		guildID:   temporaryCategory.GuildID,
+		userID:    req.userID,
+		channelID: tempChannel.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to move member: %w", err)
+	}
+	h.latency.record(req, createdAt)
+
+	endIO()
+	h.temporaryCategories = append(h.temporaryCategories, tempChannel.ID)
+	h.addRoom(ctx, tempChannel.ID, &room{
+		hub:         req.hub,
+		guildID:     req.hubChannel.GuildID,
+		owner:       req.userID,
+		number:      req.number,
+		name:        temporaryCategory.Name,
+		category:    temporaryCategory.ID,
+		textChannel: textChannelID,
+		forumPost:   forumPost,
+		afkChannel:  afkChannelID,
+		scaledLimit: h.scaledLimit(req),
+		booster:     req.booster,
+	})
+	return nil
+}
@@ -0,0 +1,114 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+)
+
+// registerDebug adds the pprof endpoints to mux if they are enabled.
+func (h *handler) registerDebug(mux *http.ServeMux) {
+	if !h.cfg.debugEndpoints {
+		return
+	}
+	mux.HandleFunc("GET /debug/pprof/", h.adminOnly("profile the bot", pprof.Index))
+	mux.HandleFunc("GET /debug/pprof/cmdline", h.adminOnly("profile the bot", pprof.Cmdline))
+	mux.HandleFunc("GET /debug/pprof/profile", h.adminOnly("profile the bot", pprof.Profile))
+	mux.HandleFunc("GET /debug/pprof/symbol", h.adminOnly("profile the bot", pprof.Symbol))
+	mux.HandleFunc("GET /debug/pprof/trace", h.adminOnly("profile the bot", pprof.Trace))
+}
+
+// runtimeSizes returns how many entries the handler's registries and the
+// state's caches hold, by name. They should go up and down with the rooms;
+// one that only grows is a leak.
+func (h *handler) runtimeSizes() map[string]int {
+	h.mu.Lock()
+	sizes := map[string]int{
+		"rooms":            len(h.rooms),
+		"created":          len(h.created),
+		"voice_states":     len(h.userVoiceStates),
+		"channel_members":  len(h.channelMembers),
+		"delete_retries":   len(h.deleteRetries),
+		"empty_categories": len(h.emptyCategories),
+		"pending_rooms":    len(h.pendingRooms),
+		"delayed_rooms":    len(h.delayedRooms),
+		"slot_waits":       len(h.slotWaits),
+		"hub_waits":        len(h.hubWaits),
+		"mod_alerts":       len(h.modAlerts),
+		"password_waits":   len(h.passwordWaits),
+		"rules_waits":      len(h.rulesWaits),
+		"hub_idle_timers":  len(h.hubIdleTimers),
+		"preset_offers":    len(h.presetOffers),
+		"emptied_rooms":    len(h.emptiedRooms),
+		"summons":          len(h.summons),
+		"throttle_alerted": len(h.throttleAlerted),
+		"renames_pending":  len(h.renames.pending),
+	}
+	h.mu.Unlock()
+	sizes["deletion_timers"] = h.deletions.pending()
+	sizes["member_cache"] = h.members.len()
+
+	guilds, _ := h.s.Cabinet.Guilds()
+	sizes["cached_guilds"] = len(guilds)
+	for _, g := range guilds {
+		channels, _ := h.s.Cabinet.Channels(g.ID)
+		sizes["cached_channels"] += len(channels)
+		voiceStates, _ := h.s.Cabinet.VoiceStates(g.ID)
+		sizes["cached_voice_states"] += len(voiceStates)
+	}
+	return sizes
+}
+
+// reportRuntime logs the goroutine count, heap and runtimeSizes every
+// interval until ctx is done.
+func (h *handler) reportRuntime(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		var mem runtime.MemStats
+		runtime.ReadMemStats(&mem)
+		attrs := []any{"goroutines", runtime.NumGoroutine(), "heap_bytes", mem.HeapAlloc}
+		sizes := h.runtimeSizes()
+		for _, name := range sortedKeys(sizes) {
+			attrs = append(attrs, name, sizes[name])
+		}
+		slog.Info("Runtime report", attrs...)
+	}
+}
+
+// writeRuntimeMetrics writes the goroutine count, heap and runtimeSizes in
+// the Prometheus text format.
+func (h *handler) writeRuntimeMetrics(b *strings.Builder) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	fmt.Fprintf(b, "# HELP tvc_goroutines Goroutines that exist right now.\n# TYPE tvc_goroutines gauge\ntvc_goroutines %d\n", runtime.NumGoroutine())
+	fmt.Fprintf(b, "# HELP tvc_heap_bytes Bytes of allocated heap objects.\n# TYPE tvc_heap_bytes gauge\ntvc_heap_bytes %d\n", mem.HeapAlloc)
+
+	b.WriteString("# HELP tvc_registry_entries Entries in the bot's registries and caches.\n# TYPE tvc_registry_entries gauge\n")
+	sizes := h.runtimeSizes()
+	for _, name := range sortedKeys(sizes) {
+		fmt.Fprintf(b, "tvc_registry_entries{registry=%q} %d\n", name, sizes[name])
+	}
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
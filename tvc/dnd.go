@@ -0,0 +1,28 @@
+package tvc
+
+import (
+	"context"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+)
+
+// cmdDND handles /voice dnd, which toggles do not disturb on the invoker's
+// room. Unlike locking, people can still join; the room just isn't
+// advertised.
+func (h *handler) cmdDND(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	r := h.ownedRoomOf(data.Event.SenderID())
+	if r == nil {
+		return ephemeralData("You need to be in a room you own to do that.")
+	}
+
+	r.dnd = !r.dnd
+	h.refreshBoard(r.guildID)
+	if r.dnd {
+		return ephemeralData("Do not disturb is on. Your room is hidden from the rooms board.")
+	}
+	return ephemeralData("Do not disturb is off. Your room shows up on the rooms board again.")
+}
@@ -0,0 +1,39 @@
+package tvc
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// emojiPrefix decorates generated room names with an emoji so bot-made
+// channels stand out in the sidebar. Restyling every new room only takes a
+// change to $ROOM_EMOJI.
+type emojiPrefix struct {
+	emojis []string
+	// daily picks one emoji per UTC day instead of rotating per room.
+	daily bool
+	next  atomic.Uint64
+}
+
+func newEmojiPrefix(env *environment) *emojiPrefix {
+	return &emojiPrefix{
+		emojis: env.list("ROOM_EMOJI"),
+		daily:  env.string("ROOM_EMOJI_MODE", "rotate") == "daily",
+	}
+}
+
+// decorate returns name prefixed with the current emoji, or name unchanged
+// when no emoji are configured.
+func (p *emojiPrefix) decorate(name string, now time.Time) string {
+	if len(p.emojis) == 0 {
+		return name
+	}
+
+	var i uint64
+	if p.daily {
+		i = uint64(now.UTC().Unix() / int64(24*time.Hour/time.Second))
+	} else {
+		i = p.next.Add(1) - 1
+	}
+	return p.emojis[i%uint64(len(p.emojis))] + " " + name
+}
@@ -0,0 +1,279 @@
+package tvc
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// environment holds the variables a manager reads its settings from, and
+// records every variable read and what it resolved to, for PrintConfig.
+type environment struct {
+	vars map[string]string
+
+	mu     sync.Mutex
+	values map[string]setting
+	// problems collects the malformed settings found while reading. Rather
+	// than stopping at the first one, they are kept for New to report all
+	// at once.
+	problems []string
+	// checked is set once New has looked at problems. Settings read after
+	// that, e.g. when the hubs are reloaded, are logged instead.
+	checked bool
+}
+
+func newEnvironment(vars map[string]string) *environment {
+	return &environment{vars: vars, values: make(map[string]setting)}
+}
+
+// setting is what a variable resolved to and whether that was its default.
+type setting struct {
+	value     string
+	isDefault bool
+}
+
+// configError notes a malformed setting. The setting falls back to what it
+// would be if unset, or skips the malformed part.
+func (env *environment) configError(format string, args ...any) {
+	env.mu.Lock()
+	defer env.mu.Unlock()
+	problem := fmt.Sprintf(format, args...)
+	if env.checked {
+		slog.Error("Invalid setting, using its default", "problem", problem)
+		return
+	}
+	env.problems = append(env.problems, problem)
+}
+
+// check returns the malformed settings noted so far as one error, or nil if
+// there are none.
+func (env *environment) check() error {
+	env.mu.Lock()
+	defer env.mu.Unlock()
+	env.checked = true
+	if len(env.problems) == 0 {
+		return nil
+	}
+	return errors.New("invalid configuration: " + strings.Join(env.problems, "; "))
+}
+
+func (env *environment) note(key, value string, isDefault bool) {
+	env.mu.Lock()
+	defer env.mu.Unlock()
+	env.values[key] = setting{value: value, isDefault: isDefault}
+}
+
+// PrintConfig writes the variables the manager read so far, one KEY=value
+// line each with defaults marked, to w. Values of variables that look secret
+// are hidden.
+func (m *Manager) PrintConfig(w io.Writer) {
+	env := m.h.env
+	env.mu.Lock()
+	defer env.mu.Unlock()
+
+	keys := make([]string, 0, len(env.values))
+	for key := range env.values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		s := env.values[key]
+		value := s.value
+		if value != "" && isSecret(key) {
+			value = "<hidden>"
+		}
+		if s.isDefault {
+			fmt.Fprintf(w, "%s=%s # default\n", key, value)
+		} else {
+			fmt.Fprintf(w, "%s=%s\n", key, value)
+		}
+	}
+}
+
+// isSecret reports whether the variable key likely holds a credential.
+func isSecret(key string) bool {
+	for _, word := range []string{"TOKEN", "SECRET", "PASSWORD", "KEY"} {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup returns the value of the variable key and whether it is set.
+func (env *environment) lookup(key string) (string, bool) {
+	v, ok := env.vars[key]
+	return v, ok
+}
+
+// getenv returns the value of the variable key, or "" if it is unset.
+func (env *environment) getenv(key string) string {
+	return env.vars[key]
+}
+
+// environ returns the variables as sorted KEY=value strings.
+func (env *environment) environ() []string {
+	kvs := make([]string, 0, len(env.vars))
+	for key, value := range env.vars {
+		kvs = append(kvs, key+"="+value)
+	}
+	slices.Sort(kvs)
+	return kvs
+}
+
+// string reads a string from the variables, falling back to def when
+// the variable is unset.
+func (env *environment) string(key, def string) string {
+	v, ok := env.lookup(key)
+	if !ok {
+		v = def
+	}
+	env.note(key, v, !ok)
+	return v
+}
+
+// list reads a comma-separated list from the variables. Empty items are
+// dropped.
+func (env *environment) list(key string) []string {
+	v := env.getenv(key)
+	env.note(key, v, v == "")
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// channelIDs reads a comma-separated set of channel IDs from the
+// variables.
+func (env *environment) channelIDs(key string) map[discord.ChannelID]bool {
+	ids := make(map[discord.ChannelID]bool)
+	for _, item := range env.list(key) {
+		id, err := discord.ParseSnowflake(item)
+		if err != nil {
+			env.configError("invalid $%s: %v", key, err)
+			continue
+		}
+		ids[discord.ChannelID(id)] = true
+	}
+	return ids
+}
+
+// userIDs reads a comma-separated set of user IDs from the variables.
+func (env *environment) userIDs(key string) map[discord.UserID]bool {
+	ids := make(map[discord.UserID]bool)
+	for _, item := range env.list(key) {
+		id, err := discord.ParseSnowflake(item)
+		if err != nil {
+			env.configError("invalid $%s: %v", key, err)
+			continue
+		}
+		ids[discord.UserID(id)] = true
+	}
+	return ids
+}
+
+// channelIDList reads a comma-separated list of channel IDs from the
+// variables, keeping their order.
+func (env *environment) channelIDList(key string) []discord.ChannelID {
+	var ids []discord.ChannelID
+	for _, item := range env.list(key) {
+		id, err := discord.ParseSnowflake(item)
+		if err != nil {
+			env.configError("invalid $%s: %v", key, err)
+			continue
+		}
+		ids = append(ids, discord.ChannelID(id))
+	}
+	return ids
+}
+
+// roleIDs reads a comma-separated list of role IDs from the variables.
+func (env *environment) roleIDs(key string) []discord.RoleID {
+	var ids []discord.RoleID
+	for _, item := range env.list(key) {
+		id, err := discord.ParseSnowflake(item)
+		if err != nil {
+			env.configError("invalid $%s: %v", key, err)
+			continue
+		}
+		ids = append(ids, discord.RoleID(id))
+	}
+	return ids
+}
+
+// guildIDs reads a comma-separated list of guild IDs from the variables.
+func (env *environment) guildIDs(key string) []discord.GuildID {
+	var ids []discord.GuildID
+	for _, item := range env.list(key) {
+		id, err := discord.ParseSnowflake(item)
+		if err != nil {
+			env.configError("invalid $%s: %v", key, err)
+			continue
+		}
+		ids = append(ids, discord.GuildID(id))
+	}
+	return ids
+}
+
+// int reads an integer from the variables, falling back to def when the
+// variable is unset. Malformed values are reported by New so typos don't go
+// unnoticed.
+func (env *environment) int(key string, def int) int {
+	v := env.getenv(key)
+	if v == "" {
+		env.note(key, strconv.Itoa(def), true)
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		env.configError("invalid $%s: %v", key, err)
+		return def
+	}
+	env.note(key, v, false)
+	return n
+}
+
+// bool reads a boolean from the variables, falling back to def when the
+// variable is unset.
+func (env *environment) bool(key string, def bool) bool {
+	v := env.getenv(key)
+	if v == "" {
+		env.note(key, strconv.FormatBool(def), true)
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		env.configError("invalid $%s: %v", key, err)
+		return def
+	}
+	env.note(key, v, false)
+	return b
+}
+
+// duration reads a time.Duration such as "30s" from the variables,
+// falling back to def when the variable is unset.
+func (env *environment) duration(key string, def time.Duration) time.Duration {
+	v := env.getenv(key)
+	if v == "" {
+		env.note(key, def.String(), true)
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		env.configError("invalid $%s: %v", key, err)
+		return def
+	}
+	env.note(key, v, false)
+	return d
+}
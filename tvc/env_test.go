@@ -0,0 +1,50 @@
+package tvc
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSettingsPerManager(t *testing.T) {
+	quick := newTestBot(t, "ROOM_GRACE_PERIOD=30s")
+	slow := newTestBot(t, "ROOM_GRACE_PERIOD=1h")
+	if quick.h.cfg.roomGracePeriod != 30*time.Second || slow.h.cfg.roomGracePeriod != time.Hour {
+		t.Errorf("grace periods are %v and %v, want 30s and 1h", quick.h.cfg.roomGracePeriod, slow.h.cfg.roomGracePeriod)
+	}
+}
+
+func TestNewReportsInvalidSettings(t *testing.T) {
+	_, err := newManager(Config{SkipCommands: true, Settings: map[string]string{
+		"ROOM_GRACE_PERIOD":        "soon",
+		"ROOM_CREATIONS_IN_FLIGHT": "many",
+	}}, true)
+	if err == nil {
+		t.Fatal("New accepted malformed settings")
+	}
+	for _, key := range []string{"ROOM_GRACE_PERIOD", "ROOM_CREATIONS_IN_FLIGHT"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("error %q doesn't mention $%s", err, key)
+		}
+	}
+}
+
+func TestPrintConfig(t *testing.T) {
+	m, err := newManager(Config{SkipCommands: true, Settings: map[string]string{
+		"API_TOKEN":         "secret",
+		"ROOM_GRACE_PERIOD": "30s",
+	}}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	m.PrintConfig(&out)
+	for _, line := range []string{"API_TOKEN=<hidden>\n", "ROOM_GRACE_PERIOD=30s\n", "ROOM_CREATIONS_IN_FLIGHT=0 # default\n"} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("config has no line %q", strings.TrimSpace(line))
+		}
+	}
+	if strings.Contains(out.String(), "secret") {
+		t.Error("config shows $API_TOKEN")
+	}
+}
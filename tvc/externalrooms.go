@@ -0,0 +1,160 @@
+package tvc
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+// externalRoomRequest is the body of POST /hubs/{key}/rooms.
+type externalRoomRequest struct {
+	GuildID discord.GuildID `json:"guild_id"`
+	OwnerID discord.UserID  `json:"owner_id"`
+	// Preset is the label of one of the hub's presets. Empty gets a default
+	// room.
+	Preset string `json:"preset"`
+}
+
+// serveExternalRoom creates a room in a hub as if its owner had joined it,
+// for matchmaking services and other external systems. The owner goes
+// through the same checks as someone joining the hub, is moved in if they
+// are in voice in the guild, and the room lives and dies like any other.
+// It takes a creation slot and budget like any room, and is refused instead
+// of queued when it has to wait for them. Unclaimed rooms are deleted after
+// $UNCLAIMED_ROOM_TIMEOUT.
+func (h *handler) serveExternalRoom(w http.ResponseWriter, req *http.Request) {
+	if h.cfg.apiToken == "" {
+		http.Error(w, "set $API_TOKEN to create rooms over HTTP", http.StatusForbidden)
+		return
+	}
+	if !h.authorized(w, req) {
+		return
+	}
+	var body externalRoomRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !body.GuildID.IsValid() || !body.OwnerID.IsValid() {
+		http.Error(w, "guild_id and owner_id are required", http.StatusBadRequest)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	hub := h.hubs[req.PathValue("key")]
+	if hub == nil || !hub.serves(body.GuildID) {
+		http.Error(w, "unknown hub", http.StatusNotFound)
+		return
+	}
+	hubChannel := h.hubChannelOf(body.GuildID, hub)
+	if hubChannel == nil {
+		http.Error(w, "the hub has no channel in that guild", http.StatusNotFound)
+		return
+	}
+	var picked *preset
+	if body.Preset != "" {
+		for i := range hub.presets {
+			if hub.presets[i].Label == body.Preset {
+				picked = &hub.presets[i]
+			}
+		}
+		if picked == nil {
+			http.Error(w, "unknown preset", http.StatusBadRequest)
+			return
+		}
+	}
+
+	ctx := withGuild(req.Context(), body.GuildID)
+	var member *discord.Member
+	err := h.call(ctx, "Member", func(s *state.State) (err error) {
+		member, err = s.Member(body.GuildID, body.OwnerID)
+		return err
+	})
+	if err != nil {
+		http.Error(w, "owner isn't a member of that guild", http.StatusBadRequest)
+		return
+	}
+
+	vs, ok := h.userVoiceStates[body.OwnerID]
+	stayPut := !ok || !vs.ChannelID.IsValid() || vs.GuildID != body.GuildID
+	if stayPut {
+		vs = discord.VoiceState{GuildID: body.GuildID, UserID: body.OwnerID}
+	}
+	vs.Member = member
+	if !h.mayCreateRoom(ctx, hub, body.GuildID, &gateway.VoiceStateUpdateEvent{VoiceState: vs}, vs.ChannelID) {
+		http.Error(w, "the owner can't get a room right now", http.StatusConflict)
+		return
+	}
+	switch hold := h.admitCreation(body.GuildID); {
+	case hold.noSlot:
+		http.Error(w, "too many rooms are being created in that guild, try again shortly", http.StatusTooManyRequests)
+		return
+	case hold.wait > 0:
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(hold.wait.Seconds()))))
+		http.Error(w, "the guild's room creation budget is used up", http.StatusTooManyRequests)
+		return
+	case hold.outage:
+		http.Error(w, "Discord appears to be unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	defer h.releaseSlot(body.GuildID)
+
+	// Creation outlives the request, like a room for someone joining the hub.
+	ctx = withGuild(context.WithoutCancel(req.Context()), body.GuildID)
+	rr := roomRequest{
+		hub:        hub,
+		hubChannel: hubChannel,
+		userID:     body.OwnerID,
+		username:   member.User.Username,
+		preset:     picked,
+		stayPut:    stayPut,
+	}
+	if err := h.createRoom(ctx, rr); err != nil {
+		slog.ErrorContext(ctx, "Failed to create room over HTTP", "err", err)
+		http.Error(w, "couldn't create the room", http.StatusBadGateway)
+		return
+	}
+	r := h.newestRoomOf(body.OwnerID)
+	if r == nil {
+		http.Error(w, "couldn't create the room", http.StatusBadGateway)
+		return
+	}
+	slog.Info("Room created over HTTP", "guild_id", r.guildID, "channel_id", r.channelID, "user_id", r.owner, "hub", hub.label())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(roomJSON{
+		GuildID:   r.guildID,
+		ChannelID: r.channelID,
+		OwnerID:   r.owner,
+		Name:      r.name,
+		LobbyCode: r.lobbyCode,
+		Occupants: h.roomOccupants(r),
+	})
+	if err != nil {
+		slog.Error("Failed to write room", "err", err)
+	}
+}
+
+// hubChannelOf returns hub's channel in guildID, or nil if it has none.
+func (h *handler) hubChannelOf(guildID discord.GuildID, hub *hub) *discord.Channel {
+	channels, err := h.s.Channels(guildID)
+	if err != nil {
+		return nil
+	}
+	for i := range channels {
+		if h.lookupHub(&channels[i]) == hub {
+			return &channels[i]
+		}
+	}
+	return nil
+}
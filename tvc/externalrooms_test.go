@@ -0,0 +1,54 @@
+package tvc
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// postRoom asks for a room of the bark hub for ownerID over HTTP, returning
+// the answer.
+func postRoom(b *testBot, ownerID discord.UserID) *httptest.ResponseRecorder {
+	body := fmt.Sprintf(`{"guild_id":"%s","owner_id":"%s"}`, testGuildID, ownerID)
+	req := httptest.NewRequest("POST", "/hubs/BARK/rooms", strings.NewReader(body))
+	req.SetPathValue("key", "BARK")
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	b.h.serveExternalRoom(rec, req)
+	return rec
+}
+
+func TestExternalRoomTakesCreationSlotAndBudget(t *testing.T) {
+	b := newTestBot(t, "API_TOKEN=secret", "ROOM_CREATIONS_IN_FLIGHT=1", "ROOM_CREATIONS_PER_MINUTE=1")
+	b.send(testGuild(nil, nil))
+	h := b.h
+
+	// Another room of the guild is in the making.
+	h.mu.Lock()
+	h.takeSlot(testGuildID)
+	h.mu.Unlock()
+	if rec := postRoom(b, testOwnerID); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("creating with no free slot answers %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+	h.mu.Lock()
+	h.releaseSlot(testGuildID)
+	h.mu.Unlock()
+
+	if rec := postRoom(b, testOwnerID); rec.Code != http.StatusCreated {
+		t.Fatalf("creating answers %d: %s", rec.Code, rec.Body)
+	}
+	rec := postRoom(b, testMemberID)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("creating past the budget answers %d with Retry-After %q, want %d and a wait", rec.Code, rec.Header().Get("Retry-After"), http.StatusTooManyRequests)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.rooms) != 1 || h.creating[testGuildID] != 0 {
+		t.Errorf("%d rooms exist and %d creations are in flight, want one room and none", len(h.rooms), h.creating[testGuildID])
+	}
+}
@@ -0,0 +1,133 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+// failoverWindow is how long before a deletion someone must have left a room
+// to count as displaced by it. Discord disconnects everyone in a deleted
+// channel, and those updates can arrive before the deletion itself.
+const failoverWindow = 5 * time.Second
+
+// onChannelDelete handles rooms deleted by someone other than the bot. With
+// featureFailover on, an occupied room is recreated with the same settings;
+// otherwise it is cleaned up like an emptied room.
+func (h *handler) onChannelDelete(evt *gateway.ChannelDeleteEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	r, ok := h.rooms[evt.ID]
+	if !ok {
+		return
+	}
+	ctx := withGuild(context.Background(), r.guildID)
+	delete(h.created, evt.ID)
+
+	displaced := h.displacedBy(evt.ID, r)
+	if len(displaced) > 0 && h.features.enabled(r.guildID, featureFailover) {
+		err := h.recreateRoom(ctx, &evt.Channel, r, displaced)
+		if err == nil {
+			return
+		}
+		slog.ErrorContext(ctx, "Failed to recreate deleted room", "err", err)
+	}
+
+	if contains(h.temporaryCategories, evt.ID) {
+		// Take the rest of the team room with it.
+		h.deleteRoom(ctx, &evt.Channel)
+		return
+	}
+	remove(&h.temporaryChannels, evt.ID)
+	h.dropRoom(evt.ID)
+}
+
+// displacedBy lists who was in the deleted room channelID.
+func (h *handler) displacedBy(channelID discord.ChannelID, r *room) []discord.UserID {
+	var users []discord.UserID
+	for userID, vs := range h.userVoiceStates {
+		if vs.ChannelID == channelID {
+			users = append(users, userID)
+		}
+	}
+	for userID, at := range r.recentlyLeft {
+		if time.Since(at) < failoverWindow && h.userVoiceStates[userID].ChannelID != channelID {
+			users = append(users, userID)
+		}
+	}
+	return users
+}
+
+// recreateRoom creates a replacement for the deleted room channel old, moves
+// the room over to it and brings the displaced members back. Members Discord
+// disconnected can't be moved, so they are sent a link instead.
+func (h *handler) recreateRoom(ctx context.Context, old *discord.Channel, r *room, displaced []discord.UserID) error {
+	var ch *discord.Channel
+	err := h.call(ctx, "CreateChannel", func(s *state.State) (err error) {
+		data := api.CreateChannelData{
+			Name:           old.Name,
+			Type:           discord.GuildVoice,
+			VoiceBitrate:   old.VoiceBitrate,
+			VoiceUserLimit: old.VoiceUserLimit,
+			RTCRegionID:    old.RTCRegionID,
+			Overwrites:     old.Overwrites,
+			AuditLogReason: "recreating a room that was deleted while in use",
+		}
+		if _, err := s.Channel(old.ParentID); err == nil {
+			data.CategoryID = old.ParentID
+		}
+		ch, err = s.CreateChannel(r.guildID, data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	h.created[ch.ID] = true
+	slog.Info("Recreated room after it was deleted", "guild_id", r.guildID, "channel_id", ch.ID, "old_channel_id", old.ID)
+
+	h.moveRoom(old.ID, ch.ID, r)
+
+	for _, userID := range displaced {
+		if !h.userVoiceStates[userID].ChannelID.IsValid() {
+			if !r.hub.silent {
+				h.notify(ctx, userID, "Your room was deleted, so I recreated it: "+ch.Mention())
+			}
+			continue
+		}
+		h.queueMove(memberMove{
+			guildID:   r.guildID,
+			userID:    userID,
+			channelID: ch.ID,
+			failure:   "Failed to move member back into recreated room",
+		})
+	}
+	return nil
+}
+
+// moveRoom makes the channel newID stand in for the room's channel oldID,
+// keeping the room one session across the two.
+func (h *handler) moveRoom(oldID, newID discord.ChannelID, r *room) {
+	for _, list := range []*[]discord.ChannelID{&h.temporaryChannels, &h.temporaryCategories} {
+		if contains(*list, oldID) {
+			remove(list, oldID)
+			*list = append(*list, newID)
+		}
+	}
+	delete(h.rooms, oldID)
+	h.rooms[newID] = r
+	r.channelID = newID
+	h.stats.recordSuccession(oldID, newID)
+	h.stats.untrack(oldID)
+	h.stats.track(trackedRoomOf(r))
+	if !r.textChannel.IsValid() {
+		// The room's chat went with the old channel.
+		r.infoMessage, r.warningMessage = 0, 0
+	}
+	h.refreshBoard(r.guildID)
+}
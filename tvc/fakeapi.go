@@ -0,0 +1,353 @@
+package tvc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+// fakeAPI stands in for Discord's REST API during replays. It answers from
+// the state's cache and what the bot created, keeps no permissions or limits,
+// and logs every request. Endpoints it doesn't know succeed without a body.
+type fakeAPI struct {
+	s   *state.State
+	mux *http.ServeMux
+	log io.Writer
+
+	mu       sync.Mutex
+	channels map[discord.ChannelID]discord.Channel
+	deleted  map[discord.ChannelID]bool
+	// recorded are the IDs of the channels created in each guild while the
+	// events were recorded. Created channels get them in order, so later
+	// events about them line up.
+	recorded map[discord.GuildID][]discord.ChannelID
+	lastID   discord.Snowflake
+}
+
+func newFakeAPI(s *state.State, log io.Writer) *fakeAPI {
+	f := &fakeAPI{
+		s:        s,
+		mux:      http.NewServeMux(),
+		log:      log,
+		channels: make(map[discord.ChannelID]discord.Channel),
+		deleted:  make(map[discord.ChannelID]bool),
+		recorded: make(map[discord.GuildID][]discord.ChannelID),
+	}
+	p := api.Path
+	f.mux.HandleFunc("GET "+p+"/users/@me", f.getMe)
+	f.mux.HandleFunc("GET "+p+"/oauth2/applications/@me", f.getApplication)
+	f.mux.HandleFunc("GET "+p+"/applications/{app}/commands", f.emptyList)
+	f.mux.HandleFunc("GET "+p+"/applications/{app}/guilds/{guild}/commands", f.emptyList)
+	f.mux.HandleFunc("POST "+p+"/guilds/{guild}/channels", f.createChannel)
+	f.mux.HandleFunc("GET "+p+"/guilds/{guild}/channels", f.getChannels)
+	f.mux.HandleFunc("GET "+p+"/channels/{channel}", f.getChannel)
+	f.mux.HandleFunc("PATCH "+p+"/channels/{channel}", f.modifyChannel)
+	f.mux.HandleFunc("DELETE "+p+"/channels/{channel}", f.deleteChannel)
+	f.mux.HandleFunc("POST "+p+"/channels/{channel}/messages", f.sendMessage)
+	f.mux.HandleFunc("PATCH "+p+"/channels/{channel}/messages/{message}", f.sendMessage)
+	f.mux.HandleFunc("GET "+p+"/guilds/{guild}/members/{user}", f.getMember)
+	f.mux.HandleFunc("GET "+p+"/guilds/{guild}/voice-states/{user}", f.getVoiceState)
+	f.mux.HandleFunc("POST "+p+"/users/@me/channels", f.createDM)
+	f.mux.HandleFunc("/", f.fallback)
+	return f
+}
+
+// RoundTrip serves a request of the bot's API client.
+func (f *fakeAPI) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(strings.NewReader(string(body)))
+	}
+	// Some endpoints are built with a doubled slash, which the mux would
+	// answer with a redirect.
+	req.URL.Path = path.Clean(req.URL.Path)
+
+	rec := httptest.NewRecorder()
+	f.mux.ServeHTTP(rec, req)
+	if f.log != nil {
+		fmt.Fprintf(f.log, "%s %s %s -> %d\n", req.Method, strings.TrimPrefix(req.URL.Path, api.Path), strings.TrimSpace(string(body)), rec.Code)
+	}
+	resp := rec.Result()
+	resp.Request = req
+	return resp, nil
+}
+
+// expect records that a channel was created in guildID with id when the
+// events were recorded.
+func (f *fakeAPI) expect(guildID discord.GuildID, id discord.ChannelID) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.recorded[guildID] = append(f.recorded[guildID], id)
+}
+
+// newChannelID hands out the next recorded channel ID of the guild, or a
+// fresh one once they run out.
+func (f *fakeAPI) newChannelID(guildID discord.GuildID) discord.ChannelID {
+	if ids := f.recorded[guildID]; len(ids) > 0 {
+		f.recorded[guildID] = ids[1:]
+		return ids[0]
+	}
+	return discord.ChannelID(f.newID())
+}
+
+// newID returns a snowflake of now that wasn't handed out before.
+func (f *fakeAPI) newID() discord.Snowflake {
+	id := discord.NewSnowflake(time.Now())
+	if id <= f.lastID {
+		id = f.lastID + 1
+	}
+	f.lastID = id
+	return id
+}
+
+// channel returns a channel the bot created or changed, or else the cached
+// one.
+func (f *fakeAPI) channel(id discord.ChannelID) (discord.Channel, bool) {
+	if f.deleted[id] {
+		return discord.Channel{}, false
+	}
+	if ch, ok := f.channels[id]; ok {
+		return ch, true
+	}
+	ch, err := f.s.Cabinet.Channel(id)
+	if err != nil {
+		return discord.Channel{}, false
+	}
+	return *ch, true
+}
+
+func (f *fakeAPI) getMe(w http.ResponseWriter, req *http.Request) {
+	me, err := f.s.Cabinet.Me()
+	if err != nil {
+		notFound(w)
+		return
+	}
+	writeJSON(w, me)
+}
+
+func (f *fakeAPI) getApplication(w http.ResponseWriter, req *http.Request) {
+	me, err := f.s.Cabinet.Me()
+	if err != nil {
+		notFound(w)
+		return
+	}
+	writeJSON(w, discord.Application{ID: discord.AppID(me.ID), Name: me.Username})
+}
+
+func (f *fakeAPI) emptyList(w http.ResponseWriter, req *http.Request) {
+	writeJSON(w, []any{})
+}
+
+func (f *fakeAPI) createChannel(w http.ResponseWriter, req *http.Request) {
+	guildID, ok := pathSnowflake(w, req, "guild")
+	if !ok {
+		return
+	}
+	var ch discord.Channel
+	if err := json.NewDecoder(req.Body).Decode(&ch); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ch.ID = f.newChannelID(discord.GuildID(guildID))
+	ch.GuildID = discord.GuildID(guildID)
+	f.channels[ch.ID] = ch
+	delete(f.deleted, ch.ID)
+	writeJSON(w, ch)
+}
+
+func (f *fakeAPI) getChannels(w http.ResponseWriter, req *http.Request) {
+	guildID, ok := pathSnowflake(w, req, "guild")
+	if !ok {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	channels := []discord.Channel{}
+	seen := make(map[discord.ChannelID]bool)
+	cached, _ := f.s.Cabinet.Channels(discord.GuildID(guildID))
+	for _, ch := range cached {
+		if ch, ok := f.channel(ch.ID); ok {
+			channels = append(channels, ch)
+		}
+		seen[ch.ID] = true
+	}
+	for id, ch := range f.channels {
+		if !seen[id] && ch.GuildID == discord.GuildID(guildID) && !f.deleted[id] {
+			channels = append(channels, ch)
+		}
+	}
+	writeJSON(w, channels)
+}
+
+func (f *fakeAPI) getChannel(w http.ResponseWriter, req *http.Request) {
+	id, ok := pathSnowflake(w, req, "channel")
+	if !ok {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ch, ok := f.channel(discord.ChannelID(id))
+	if !ok {
+		notFound(w)
+		return
+	}
+	writeJSON(w, ch)
+}
+
+func (f *fakeAPI) modifyChannel(w http.ResponseWriter, req *http.Request) {
+	id, ok := pathSnowflake(w, req, "channel")
+	if !ok {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ch, ok := f.channel(discord.ChannelID(id))
+	if !ok {
+		notFound(w)
+		return
+	}
+	// The fields of the change have the channel's names, so it is applied
+	// by decoding it over the channel.
+	if err := json.NewDecoder(req.Body).Decode(&ch); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.channels[ch.ID] = ch
+	writeJSON(w, ch)
+}
+
+func (f *fakeAPI) deleteChannel(w http.ResponseWriter, req *http.Request) {
+	id, ok := pathSnowflake(w, req, "channel")
+	if !ok {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ch, ok := f.channel(discord.ChannelID(id))
+	if !ok {
+		notFound(w)
+		return
+	}
+	f.deleted[ch.ID] = true
+	delete(f.channels, ch.ID)
+	writeJSON(w, ch)
+}
+
+func (f *fakeAPI) sendMessage(w http.ResponseWriter, req *http.Request) {
+	channelID, ok := pathSnowflake(w, req, "channel")
+	if !ok {
+		return
+	}
+	var msg discord.Message
+	// Messages with files are multipart; their text isn't needed.
+	json.NewDecoder(req.Body).Decode(&msg)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	msg.ID = discord.MessageID(f.newID())
+	msg.ChannelID = discord.ChannelID(channelID)
+	writeJSON(w, msg)
+}
+
+func (f *fakeAPI) getMember(w http.ResponseWriter, req *http.Request) {
+	guildID, ok := pathSnowflake(w, req, "guild")
+	if !ok {
+		return
+	}
+	userID, ok := pathSnowflake(w, req, "user")
+	if !ok {
+		return
+	}
+	m, err := f.s.Cabinet.Member(discord.GuildID(guildID), discord.UserID(userID))
+	if err != nil {
+		notFound(w)
+		return
+	}
+	writeJSON(w, m)
+}
+
+func (f *fakeAPI) getVoiceState(w http.ResponseWriter, req *http.Request) {
+	guildID, ok := pathSnowflake(w, req, "guild")
+	if !ok {
+		return
+	}
+	userID, ok := pathSnowflake(w, req, "user")
+	if !ok {
+		return
+	}
+	vs, err := f.s.Cabinet.VoiceState(discord.GuildID(guildID), discord.UserID(userID))
+	if err != nil {
+		notFound(w)
+		return
+	}
+	writeJSON(w, vs)
+}
+
+func (f *fakeAPI) createDM(w http.ResponseWriter, req *http.Request) {
+	var body struct {
+		RecipientID discord.UserID `json:"recipient_id"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	writeJSON(w, discord.Channel{
+		ID:           discord.ChannelID(f.newID()),
+		Type:         discord.DirectMessage,
+		DMRecipients: []discord.User{{ID: body.RecipientID}},
+	})
+}
+
+// fallback answers reads it doesn't know with 404 and lets every other
+// request succeed.
+func (f *fakeAPI) fallback(w http.ResponseWriter, req *http.Request) {
+	if req.Method == http.MethodGet {
+		notFound(w)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func pathSnowflake(w http.ResponseWriter, req *http.Request, name string) (discord.Snowflake, bool) {
+	sf, err := discord.ParseSnowflake(req.PathValue(name))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return 0, false
+	}
+	return sf, true
+}
+
+func notFound(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	io.WriteString(w, `{"message": "Unknown (replay)", "code": 0}`)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,129 @@
+package tvc
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/diamondburned/arikawa/v3/utils/httputil/httpdriver"
+	"github.com/diamondburned/arikawa/v3/utils/ws"
+)
+
+// IDs of the guild testGuild builds.
+const (
+	testGuildID  discord.GuildID   = 100
+	testHubID    discord.ChannelID = 200
+	testLogID    discord.ChannelID = 201
+	testAdminID  discord.RoleID    = 101
+	testOwnerID  discord.UserID    = 1
+	testMemberID discord.UserID    = 2 // has no permissions
+	testBotID    discord.UserID    = 900
+)
+
+// testBot is a manager attached to a state whose REST calls the fake API
+// answers, as in a replay.
+type testBot struct {
+	h    *handler
+	s    *state.State
+	fake *fakeAPI
+}
+
+// newTestBot returns a bot with the given KEY=value settings.
+func newTestBot(t *testing.T, settings ...string) *testBot {
+	t.Helper()
+	vars := make(map[string]string)
+	for _, kv := range settings {
+		key, value, _ := strings.Cut(kv, "=")
+		vars[key] = value
+	}
+	m, err := newManager(Config{SkipCommands: true, Settings: vars}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := state.New("Bot test")
+	fake := newFakeAPI(s, nil)
+	s.Client.Client.Client = httpdriver.WrapClient(http.Client{Transport: fake})
+	m.Attach(s)
+
+	b := &testBot{h: m.h, s: s, fake: fake}
+	b.send(&gateway.ReadyEvent{
+		User:      discord.User{ID: testBotID, Username: "tvc", Bot: true},
+		SessionID: "test",
+	})
+	return b
+}
+
+// send hands events to the bot as the gateway would, and waits until they
+// are handled.
+func (b *testBot) send(events ...ws.Event) {
+	for _, ev := range events {
+		b.s.Session.Handler.Call(ev)
+	}
+	b.h.queues.drain()
+}
+
+// testGuild returns the GUILD_CREATE of a guild with a bark hub, a text
+// channel for the bot's logs and the given channels and voice states.
+func testGuild(channels []discord.Channel, voiceStates []discord.VoiceState) *gateway.GuildCreateEvent {
+	evt := &gateway.GuildCreateEvent{
+		Guild: discord.Guild{
+			ID:      testGuildID,
+			Name:    "test",
+			OwnerID: testOwnerID,
+			Roles: []discord.Role{
+				{ID: discord.RoleID(testGuildID), Name: "@everyone"},
+				{ID: testAdminID, Name: "admin", Permissions: discord.PermissionAdministrator},
+			},
+		},
+		Members: []discord.Member{
+			{User: discord.User{ID: testBotID, Username: "tvc", Bot: true}, RoleIDs: []discord.RoleID{testAdminID}},
+			{User: discord.User{ID: testOwnerID, Username: "owner"}},
+			{User: discord.User{ID: testMemberID, Username: "member"}},
+		},
+		VoiceStates: voiceStates,
+	}
+	evt.Channels = append([]discord.Channel{
+		{ID: testHubID, GuildID: testGuildID, Type: discord.GuildVoice, Name: voiceHubName},
+		{ID: testLogID, GuildID: testGuildID, Type: discord.GuildText, Name: "logs"},
+	}, channels...)
+	for i := range evt.VoiceStates {
+		evt.VoiceStates[i].GuildID = testGuildID
+	}
+	return evt
+}
+
+// join returns the voice state update of userID joining channelID, or
+// leaving voice if it is 0.
+func join(userID discord.UserID, channelID discord.ChannelID) *gateway.VoiceStateUpdateEvent {
+	return &gateway.VoiceStateUpdateEvent{VoiceState: discord.VoiceState{
+		GuildID:   testGuildID,
+		ChannelID: channelID,
+		UserID:    userID,
+		SessionID: "s",
+		Member:    &discord.Member{User: discord.User{ID: userID, Username: userID.String()}},
+	}}
+}
+
+// channelExists reports whether the channel is still there as far as the
+// fake API knows.
+func (b *testBot) channelExists(id discord.ChannelID) bool {
+	b.fake.mu.Lock()
+	defer b.fake.mu.Unlock()
+	_, ok := b.fake.channel(id)
+	return ok
+}
+
+// roomOf returns the channel of the room userID owns, or 0.
+func (b *testBot) roomOf(userID discord.UserID) discord.ChannelID {
+	b.h.mu.Lock()
+	defer b.h.mu.Unlock()
+	for id, r := range b.h.rooms {
+		if r.owner == userID {
+			return id
+		}
+	}
+	return 0
+}
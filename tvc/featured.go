@@ -0,0 +1,85 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/diamondburned/arikawa/v3/utils/json/option"
+)
+
+// cmdFeature handles /voiceadmin feature, which moves a room to the top of
+// its category and highlights it on the rooms board, e.g. for community
+// events. Featuring a featured room unfeatures it.
+func (h *handler) cmdFeature(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	id, err := data.Options.Find("channel").SnowflakeValue()
+	if err != nil {
+		return ephemeralData("Pick the room to feature.")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	r, ok := h.rooms[discord.ChannelID(id)]
+	if !ok || r.guildID != data.Event.GuildID {
+		return ephemeralData("That isn't a temporary room.")
+	}
+
+	r.featured = !r.featured
+	h.refreshBoard(r.guildID)
+	if !r.featured {
+		return ephemeralData(r.channelID.Mention() + " is no longer featured.")
+	}
+
+	target := r.channelID
+	if r.category.IsValid() {
+		target = r.category
+	}
+	if err := h.moveToTop(withGuild(ctx, r.guildID), target); err != nil {
+		slog.ErrorContext(ctx, "Failed to move featured room", "err", err)
+		return ephemeralData(r.channelID.Mention() + " is featured on the rooms board, but I couldn't move it to the top.")
+	}
+	return ephemeralData(r.channelID.Mention() + " is featured.")
+}
+
+// moveToTop moves channelID above the channels of its type in its category.
+func (h *handler) moveToTop(ctx context.Context, channelID discord.ChannelID) error {
+	ch, err := h.s.Channel(channelID)
+	if err != nil {
+		return err
+	}
+	channels, err := h.s.Channels(ch.GuildID)
+	if err != nil {
+		return err
+	}
+
+	var siblings []discord.Channel
+	for _, c := range channels {
+		if c.ParentID == ch.ParentID && c.Type == ch.Type && c.ID != ch.ID {
+			siblings = append(siblings, c)
+		}
+	}
+	sort.Slice(siblings, func(i, j int) bool {
+		if siblings[i].Position != siblings[j].Position {
+			return siblings[i].Position < siblings[j].Position
+		}
+		return siblings[i].ID < siblings[j].ID
+	})
+
+	moves := []api.MoveChannelData{{ID: ch.ID, Position: option.NewInt(0)}}
+	for i, c := range siblings {
+		if c.Position != i+1 {
+			moves = append(moves, api.MoveChannelData{ID: c.ID, Position: option.NewInt(i + 1)})
+		}
+	}
+	return h.call(ctx, "MoveChannels", func(s *state.State) error {
+		return s.MoveChannels(ch.GuildID, api.MoveChannelsData{
+			Channels:       moves,
+			AuditLogReason: "featuring a room",
+		})
+	})
+}
@@ -0,0 +1,137 @@
+package tvc
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// feature is a per-guild toggle for an optional part of the bot.
+type feature string
+
+const (
+	featureTeams          feature = "teams"      // the team hub
+	featureTextPairing    feature = "text"       // text channels in team rooms
+	featureGreetings      feature = "greetings"  // hub greetings
+	featureRoomInfo       feature = "roominfo"   // pinned room info embeds
+	featureActivityStatus feature = "activity"   // game voice statuses
+	featureAttendance     feature = "attendance" // join/leave log in room chat
+	featureTeamAFK        feature = "afk"        // AFK channels in team rooms
+	featureNoBots         feature = "nobots"     // no external apps or other bots in rooms
+	featureFailover       feature = "failover"   // recreate occupied rooms deleted by others
+	featureThemes         feature = "themes"     // seasonal room name themes
+	featureStrict         feature = "strict"     // hubs off while permissions are missing
+)
+
+var knownFeatures = []feature{
+	featureTeams,
+	featureTextPairing,
+	featureGreetings,
+	featureRoomInfo,
+	featureActivityStatus,
+	featureAttendance,
+	featureTeamAFK,
+	featureNoBots,
+	featureFailover,
+	featureThemes,
+	featureStrict,
+}
+
+// optInFeatures are left out of the default set because they are noisy or
+// restrictive.
+var optInFeatures = map[feature]bool{
+	featureAttendance: true,
+	featureTeamAFK:    true,
+	featureNoBots:     true,
+	featureFailover:   true,
+	featureStrict:     true,
+}
+
+// featureFlags decides which features are on in each guild, so features can
+// be rolled out to a few guilds before everyone gets them. Guilds follow the
+// defaults for every feature their overrides don't mention.
+type featureFlags struct {
+	mu       sync.RWMutex
+	defaults map[feature]bool
+	guilds   map[discord.GuildID]map[feature]bool
+	staging  guildMirrors // see settings.stagingGuilds
+}
+
+// newFeatureFlags reads the default set from $FEATURES (all but the opt-in
+// features when unset) and per-guild changes from $GUILD_FEATURES_<guild ID>, e.g.
+// GUILD_FEATURES_1234="-teams,+roominfo".
+func newFeatureFlags(env *environment, staging guildMirrors) *featureFlags {
+	f := &featureFlags{
+		defaults: make(map[feature]bool),
+		guilds:   make(map[discord.GuildID]map[feature]bool),
+		staging:  staging,
+	}
+
+	if _, ok := env.lookup("FEATURES"); ok {
+		for _, name := range env.list("FEATURES") {
+			f.defaults[parseFeature(env, "FEATURES", name)] = true
+		}
+	} else {
+		for _, feat := range knownFeatures {
+			f.defaults[feat] = !optInFeatures[feat]
+		}
+	}
+
+	const prefix = "GUILD_FEATURES_"
+	for _, kv := range env.environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		guildID, err := discord.ParseSnowflake(strings.TrimPrefix(key, prefix))
+		if err != nil {
+			env.configError("invalid guild ID in $%s: %v", key, err)
+			continue
+		}
+
+		flags := make(map[feature]bool)
+		for _, item := range env.list(key) {
+			on := !strings.HasPrefix(item, "-")
+			name := strings.TrimLeft(item, "+-")
+			flags[parseFeature(env, key, name)] = on
+		}
+		f.guilds[discord.GuildID(guildID)] = flags
+	}
+
+	return f
+}
+
+func parseFeature(env *environment, key, name string) feature {
+	for _, feat := range knownFeatures {
+		if string(feat) == name {
+			return feat
+		}
+	}
+	env.configError("unknown feature %q in $%s", name, key)
+	return ""
+}
+
+// enabled reports whether feat is on in guildID.
+func (f *featureFlags) enabled(guildID discord.GuildID, feat feature) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	flags, ok := f.guilds[guildID]
+	if !ok {
+		// Staging guilds follow their production guild.
+		flags = f.guilds[f.staging.productionOf(guildID)]
+	}
+	if on, ok := flags[feat]; ok {
+		return on
+	}
+	return f.defaults[feat]
+}
+
+// setDefault turns feat on or off in every guild that doesn't override it.
+func (f *featureFlags) setDefault(feat feature, on bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.defaults[feat] = on
+}
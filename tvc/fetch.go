@@ -0,0 +1,159 @@
+package tvc
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+// fetchFlight is a channel lookup in progress that others wait on.
+type fetchFlight struct {
+	done     chan struct{}
+	landed   sync.Once
+	channel  *discord.Channel
+	channels []discord.Channel
+	err      error
+}
+
+// channelMiss is a lookup of a channel that doesn't exist.
+type channelMiss struct {
+	err error
+	at  time.Time
+}
+
+// channelFetches deduplicates channel lookups that miss the state cache, so
+// a burst of voice events for one hub makes a single REST request rather than
+// one per event.
+type channelFetches struct {
+	mu       sync.Mutex
+	channels map[discord.Snowflake]*fetchFlight
+	guilds   map[discord.Snowflake]*fetchFlight
+	missing  map[discord.ChannelID]channelMiss
+}
+
+func newChannelFetches() *channelFetches {
+	return &channelFetches{
+		channels: make(map[discord.Snowflake]*fetchFlight),
+		guilds:   make(map[discord.Snowflake]*fetchFlight),
+		missing:  make(map[discord.ChannelID]channelMiss),
+	}
+}
+
+// join returns the flight in flights under key, and whether the caller has
+// to run it because there was none. Only lookups whose context holds an I/O
+// lease wait on a flight, since they can do so without h.mu; holding it
+// would stall every guild until the flight lands. Others run a flight of
+// their own.
+func (c *channelFetches) join(ctx context.Context, flights map[discord.Snowflake]*fetchFlight, key discord.Snowflake) (*fetchFlight, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if f, ok := flights[key]; ok && leased(ctx) {
+		return f, false
+	}
+	f := &fetchFlight{done: make(chan struct{})}
+	if _, ok := flights[key]; !ok {
+		flights[key] = f
+	}
+	return f, true
+}
+
+// land publishes the flight's result and lets the lookups waiting on it go.
+// The leader lands it from inside its request, before it takes h.mu back,
+// since those lookups may wait while the leader waits for h.mu.
+func (c *channelFetches) land(flights map[discord.Snowflake]*fetchFlight, key discord.Snowflake, f *fetchFlight, err error) {
+	f.landed.Do(func() {
+		f.err = err
+		c.mu.Lock()
+		if flights[key] == f {
+			delete(flights, key)
+		}
+		c.mu.Unlock()
+		close(f.done)
+	})
+}
+
+// wait waits for f to land, or for ctx to be done, without h.mu. ctx must
+// hold an I/O lease.
+func (h *handler) wait(ctx context.Context, f *fetchFlight) (err error) {
+	h.unlocked(ctx, func() {
+		select {
+		case <-f.done:
+			err = f.err
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	})
+	return err
+}
+
+// fetchChannel looks up a channel in the state cache and otherwise fetches
+// it, sharing the request with concurrent lookups of the same channel.
+func (h *handler) fetchChannel(ctx context.Context, id discord.ChannelID) (*discord.Channel, error) {
+	if ch, err := h.s.Cabinet.Channel(id); err == nil {
+		return ch, nil
+	}
+
+	fetches := h.fetches
+	fetches.mu.Lock()
+	if miss, ok := fetches.missing[id]; ok {
+		if time.Since(miss.at) < h.cfg.channelMissTTL {
+			fetches.mu.Unlock()
+			return nil, miss.err
+		}
+		delete(fetches.missing, id)
+	}
+	fetches.mu.Unlock()
+
+	key := discord.Snowflake(id)
+	f, leader := fetches.join(ctx, fetches.channels, key)
+	if !leader {
+		if err := h.wait(ctx, f); err != nil {
+			return nil, err
+		}
+		// Callers update the channel they get, e.g. its overwrites.
+		ch := *f.channel
+		ch.Overwrites = slices.Clone(ch.Overwrites)
+		return &ch, nil
+	}
+
+	err := h.call(ctx, "Channel", func(s *state.State) (err error) {
+		f.channel, err = s.Channel(id)
+		if isNotFound(err) {
+			fetches.mu.Lock()
+			fetches.missing[id] = channelMiss{err: err, at: time.Now()}
+			fetches.mu.Unlock()
+		}
+		fetches.land(fetches.channels, key, f, err)
+		return err
+	})
+	// The call may be refused before it is made.
+	fetches.land(fetches.channels, key, f, err)
+	return f.channel, err
+}
+
+// fetchChannels lists the guild's channels, sharing the request with
+// concurrent listings of the same guild.
+func (h *handler) fetchChannels(ctx context.Context, guildID discord.GuildID) ([]discord.Channel, error) {
+	fetches := h.fetches
+	key := discord.Snowflake(guildID)
+	f, leader := fetches.join(ctx, fetches.guilds, key)
+	if !leader {
+		if err := h.wait(ctx, f); err != nil {
+			return nil, err
+		}
+		return slices.Clone(f.channels), nil
+	}
+
+	err := h.call(ctx, "Channels", func(s *state.State) (err error) {
+		f.channels, err = s.Channels(guildID)
+		fetches.land(fetches.guilds, key, f, err)
+		return err
+	})
+	fetches.land(fetches.guilds, key, f, err)
+	return f.channels, err
+}
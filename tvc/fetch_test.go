@@ -0,0 +1,134 @@
+package tvc
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/utils/httputil/httpdriver"
+)
+
+// stallingAPI holds up the first request whose path ends in suffix until
+// release is closed, and passes everything to the fake API.
+type stallingAPI struct {
+	fake    *fakeAPI
+	suffix  string
+	once    sync.Once
+	stalled chan struct{}
+	release chan struct{}
+}
+
+func (s *stallingAPI) RoundTrip(req *http.Request) (*http.Response, error) {
+	if strings.HasSuffix(req.URL.Path, s.suffix) {
+		first := false
+		s.once.Do(func() { first = true })
+		if first {
+			close(s.stalled)
+			<-s.release
+		}
+	}
+	return s.fake.RoundTrip(req)
+}
+
+// uncachedGuildID is a guild the state has no channels of, so listing them
+// takes a request.
+const uncachedGuildID discord.GuildID = 102
+
+// stall makes the bot's first request whose path ends in suffix wait until
+// the returned function is called.
+func (b *testBot) stall(suffix string) (stalled <-chan struct{}, release func()) {
+	api := &stallingAPI{
+		fake:    b.fake,
+		suffix:  suffix,
+		stalled: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	b.s.Client.Client.Client = httpdriver.WrapClient(http.Client{Transport: api})
+	return api.stalled, func() { close(api.release) }
+}
+
+// stallChannelListing makes the bot's first listing of the uncached guild's
+// channels wait until the returned function is called.
+func (b *testBot) stallChannelListing() (stalled <-chan struct{}, release func()) {
+	return b.stall("/guilds/" + uncachedGuildID.String() + "/channels")
+}
+
+// finishes fails the test unless fn returns in time.
+func finishes(t *testing.T, what string, fn func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%s never finished", what)
+	}
+}
+
+// listChannels lists the uncached guild's channels with h.mu held, as room
+// creation and deletion do, with an I/O lease if leased is set.
+func listChannels(b *testBot, leased bool, errs chan<- error) {
+	b.h.mu.Lock()
+	defer b.h.mu.Unlock()
+	ctx := withGuild(context.Background(), uncachedGuildID)
+	if leased {
+		var endIO func()
+		ctx, endIO = releaseForIO(ctx)
+		defer endIO()
+	}
+	_, err := b.h.fetchChannels(ctx, uncachedGuildID)
+	errs <- err
+}
+
+func TestFetchChannelsLockedLookupDuringLeasedFlight(t *testing.T) {
+	b := newTestBot(t)
+	stalled, release := b.stallChannelListing()
+
+	// A creation lists the channels without h.mu, and a deletion timer
+	// lists them too while holding it.
+	errs := make(chan error, 2)
+	go listChannels(b, true, errs)
+	<-stalled
+	go listChannels(b, false, errs)
+	release()
+
+	finishes(t, "listing the channels", func() {
+		for range 2 {
+			if err := <-errs; err != nil {
+				t.Error(err)
+			}
+		}
+	})
+}
+
+func TestFetchChannelsLeasedLookupsShareFlight(t *testing.T) {
+	b := newTestBot(t)
+	stalled, release := b.stallChannelListing()
+
+	errs := make(chan error, 2)
+	go listChannels(b, true, errs)
+	<-stalled
+	go listChannels(b, true, errs)
+
+	// The second lookup waits without h.mu, so the handler stays free.
+	finishes(t, "taking h.mu during the flight", func() {
+		time.Sleep(50 * time.Millisecond)
+		b.h.mu.Lock()
+		b.h.mu.Unlock()
+	})
+	release()
+	finishes(t, "listing the channels", func() {
+		for range 2 {
+			if err := <-errs; err != nil {
+				t.Error(err)
+			}
+		}
+	})
+}
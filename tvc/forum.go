@@ -0,0 +1,99 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/diamondburned/arikawa/v3/utils/httputil"
+	"github.com/diamondburned/arikawa/v3/utils/json/option"
+)
+
+// teamForumFor returns the guild's team forum, or 0 if it has none.
+func (h *handler) teamForumFor(guildID discord.GuildID) discord.ChannelID {
+	for id := range h.cfg.teamForums {
+		if ch, err := h.s.Channel(id); err == nil && ch.GuildID == guildID {
+			return id
+		}
+	}
+	return 0
+}
+
+// forumPostData starts a forum thread along with its first message.
+type forumPostData struct {
+	Name    string              `json:"name"`
+	Message api.SendMessageData `json:"message"`
+}
+
+// createForumPost opens the team room's post in forumID, titled after the
+// room. arikawa can't start forum threads yet, so this calls the endpoint
+// directly.
+func (h *handler) createForumPost(ctx context.Context, forumID discord.ChannelID, name string, ownerID discord.UserID, reason api.AuditLogReason) (discord.ChannelID, error) {
+	name = channelName(name)
+	var post discord.Channel
+	err := h.call(ctx, "StartThread", func(s *state.State) error {
+		return s.RequestJSON(
+			&post, "POST", api.EndpointChannels+forumID.String()+"/threads",
+			httputil.WithJSONBody(forumPostData{
+				Name: name,
+				Message: api.SendMessageData{
+					Content:         fmt.Sprintf("Team discussion for %s, started by %s.", name, ownerID.Mention()),
+					AllowedMentions: &api.AllowedMentions{},
+				},
+			}),
+			httputil.WithHeaders(reason.Header()),
+		)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return post.ID, nil
+}
+
+// closeForumPost locks and archives a deleted team room's post.
+func (h *handler) closeForumPost(ctx context.Context, postID discord.ChannelID) {
+	err := h.call(ctx, "ModifyChannel", func(s *state.State) error {
+		return s.ModifyChannel(postID, api.ModifyChannelData{
+			Archived:       option.True,
+			Locked:         option.True,
+			AuditLogReason: "team room deleted",
+		})
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to close team forum post", "err", err)
+	}
+}
+
+// validateTeamForum checks the guild's team forum, if it has one.
+func (h *handler) validateTeamForum(guildID discord.GuildID, botID discord.UserID) []string {
+	forumID := h.teamForumFor(guildID)
+	if !forumID.IsValid() {
+		return nil
+	}
+	ch, err := h.s.Channel(forumID)
+	if err != nil {
+		return []string{fmt.Sprintf("Couldn't look up the team forum: %v.", err)}
+	}
+	if ch.Type != discord.GuildForum {
+		return []string{fmt.Sprintf("The team forum %s isn't a forum channel.", ch.Mention())}
+	}
+
+	perms, err := h.s.Permissions(forumID, botID)
+	if err != nil {
+		return []string{fmt.Sprintf("Couldn't compute permissions in %s: %v.", ch.Mention(), err)}
+	}
+	var problems []string
+	for _, need := range []neededPermission{
+		{discord.PermissionSendMessages, "Send Messages", "open team posts"},
+		{discord.PermissionManageThreads, "Manage Threads", "lock team posts when their room is deleted"},
+	} {
+		if !perms.Has(need.perm) {
+			problems = append(problems, fmt.Sprintf(
+				"The bot lacks **%s** in %s, needed to %s.", need.name, ch.Mention(), need.reason))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,155 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/diamondburned/arikawa/v3/utils/json/option"
+)
+
+// roomSnapshot is what a room looked like when a moderator froze it.
+type roomSnapshot struct {
+	GuildID   discord.GuildID   `json:"guild_id"`
+	ChannelID discord.ChannelID `json:"channel_id"`
+	By        discord.UserID    `json:"by"`
+	At        time.Time         `json:"at"`
+	OwnerID   discord.UserID    `json:"owner_id"`
+	Members   []discord.UserID  `json:"members,omitempty"`
+	Name      string            `json:"name"`
+	UserLimit uint              `json:"user_limit,omitempty"`
+	// Overwrites are the channel's overwrites before it was locked, put back
+	// when it is unfrozen.
+	Overwrites []discord.Overwrite `json:"overwrites,omitempty"`
+}
+
+// isFrozen reports whether a moderator froze the room in channelID. Frozen
+// rooms are locked and never deleted by the bot.
+func (h *handler) isFrozen(channelID discord.ChannelID) bool {
+	return h.stats.frozen(channelID) != nil
+}
+
+// roomOption returns the room picked in the command's channel option, or
+// why there is none.
+func (h *handler) roomOption(data cmdroute.CommandData) (*room, string) {
+	id, err := data.Options.Find("channel").SnowflakeValue()
+	if err != nil {
+		return nil, "Pick the room."
+	}
+	r, ok := h.rooms[discord.ChannelID(id)]
+	if !ok || r.guildID != data.Event.GuildID {
+		return nil, "That isn't a temporary room."
+	}
+	return r, ""
+}
+
+// cmdFreeze handles /voiceadmin freeze, which locks a room, records who is
+// in it and how it is set up, and keeps it from being deleted until it is
+// unfrozen, so moderators can look into an incident.
+func (h *handler) cmdFreeze(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	r, problem := h.roomOption(data)
+	if r == nil {
+		return ephemeralData(problem)
+	}
+	if h.isFrozen(r.channelID) {
+		return ephemeralData(r.channelID.Mention() + " is already frozen.")
+	}
+	ctx = withGuild(ctx, r.guildID)
+	ch, err := h.s.Channel(r.channelID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get room to freeze", "channel_id", r.channelID, "err", err)
+		return ephemeralData("I couldn't find that room, try again.")
+	}
+
+	snap := roomSnapshot{
+		GuildID:    r.guildID,
+		ChannelID:  r.channelID,
+		By:         data.Event.SenderID(),
+		At:         time.Now(),
+		OwnerID:    r.owner,
+		Name:       ch.Name,
+		UserLimit:  ch.VoiceUserLimit,
+		Overwrites: slices.Clone(ch.Overwrites),
+	}
+	for userID := range h.channelMembers[r.channelID] {
+		snap.Members = append(snap.Members, userID)
+	}
+	slices.Sort(snap.Members)
+	h.stats.setFrozen(snap)
+	if r.deleteTimer != nil {
+		r.deleteTimer.Stop()
+		r.deleteTimer = nil
+	}
+	slog.InfoContext(ctx, "Room frozen", "channel_id", r.channelID, "user_id", snap.By, "members", len(snap.Members))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Frozen with %s in it", plural(len(snap.Members), "member"))
+	for _, userID := range snap.Members {
+		fmt.Fprintf(&b, " %s", userID.Mention())
+	}
+	h.stats.addNote(r.channelID, roomNote{By: snap.By, At: snap.At, Text: b.String()})
+
+	locked := true
+	if err := h.denyEveryone(ctx, ch, discord.PermissionConnect, "frozen by a moderator"); err != nil {
+		slog.ErrorContext(ctx, "Failed to lock frozen room", "err", err)
+		locked = false
+	}
+	h.postLog(ctx, r.guildID, fmt.Sprintf("🧊 %s froze %s with %s in it.", snap.By.Mention(), r.channelID.Mention(), plural(len(snap.Members), "member")))
+
+	msg := r.channelID.Mention() + " is frozen: it won't be deleted until you run /voiceadmin unfreeze, and who was in it is noted in /voiceadmin whois."
+	if !locked {
+		msg += " I couldn't lock it, though."
+	}
+	return ephemeralData(msg)
+}
+
+// cmdUnfreeze handles /voiceadmin unfreeze, which puts a frozen room's name,
+// limit and overwrites back as they were when it was frozen and lets it be
+// deleted again.
+func (h *handler) cmdUnfreeze(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	r, problem := h.roomOption(data)
+	if r == nil {
+		return ephemeralData(problem)
+	}
+	snap := h.stats.frozen(r.channelID)
+	if snap == nil {
+		return ephemeralData(r.channelID.Mention() + " isn't frozen.")
+	}
+	ctx = withGuild(ctx, r.guildID)
+	overwrites := slices.Clone(snap.Overwrites)
+	err := h.call(ctx, "ModifyChannel", func(s *state.State) error {
+		return s.ModifyChannel(r.channelID, api.ModifyChannelData{
+			Name:           snap.Name,
+			VoiceUserLimit: option.NewNullableUint(snap.UserLimit),
+			Overwrites:     &overwrites,
+			AuditLogReason: "unfrozen by a moderator",
+		})
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to restore frozen room", "err", err)
+		return ephemeralData("I couldn't restore " + r.channelID.Mention() + ", so it stays frozen. Try again.")
+	}
+
+	userID := data.Event.SenderID()
+	h.stats.dropFrozen(r.channelID)
+	h.stats.addNote(r.channelID, roomNote{By: userID, At: time.Now(), Text: "Unfrozen"})
+	slog.InfoContext(ctx, "Room unfrozen", "channel_id", r.channelID, "user_id", userID)
+	h.postLog(ctx, r.guildID, fmt.Sprintf("%s unfroze %s.", userID.Mention(), r.channelID.Mention()))
+
+	// An empty room goes on its way as if it had just emptied.
+	h.checkEmptied(ctx, r)
+	return ephemeralData(r.channelID.Mention() + " is unfrozen and set up as it was when it was frozen.")
+}
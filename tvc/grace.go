@@ -0,0 +1,171 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/diamondburned/arikawa/v3/utils/json/option"
+)
+
+// keepAliveID is the custom ID of the keep-alive button.
+const keepAliveID = "keepalive"
+
+// roomEmptied deletes a room its last member just left, or starts its grace
+// period and posts a warning with a keep-alive button. Persistent booster
+// rooms and frozen rooms are kept, and other boosters' rooms may get a longer
+// grace period.
+func (h *handler) roomEmptied(ctx context.Context, ch *discord.Channel, r *room) {
+	if r.booster && h.cfg.boosterPersistent || h.isFrozen(r.channelID) {
+		return
+	}
+	grace := h.roomGrace(r)
+	if grace <= 0 {
+		if h.features.enabled(r.guildID, featureFailover) && r.deleteTimer == nil {
+			// The members may have been disconnected by someone deleting
+			// the room; wait for that to show up so it can be recreated.
+			h.scheduleRoomDeletion(ch.ID, r, time.Now().Add(failoverWindow))
+			return
+		}
+		h.teardownRoom(ctx, ch)
+		return
+	}
+	if r.deleteTimer != nil || h.roomOccupants(r) > 0 {
+		return
+	}
+
+	r.emptySince = time.Now()
+	r.emptied = true
+	h.scheduleRoomDeletion(ch.ID, r, r.emptySince.Add(grace))
+	if r.hub.silent {
+		return
+	}
+
+	chat, content := r.chatChannel(), deletionWarning(r.deleteAt)
+	var msg *discord.Message
+	err := h.call(ctx, "SendMessage", func(s *state.State) (err error) {
+		msg, err = s.SendMessageComplex(chat, api.SendMessageData{
+			Content:    content,
+			Components: h.keepAliveComponents(),
+		})
+		return err
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to post deletion warning", "err", err)
+		return
+	}
+	r.warningMessage = msg.ID
+	h.expireMessage(r, r.warningMessage)
+	h.stats.track(trackedRoomOf(r))
+}
+
+// scheduleRoomDeletion (re)arms the room's deletion timer for at.
+func (h *handler) scheduleRoomDeletion(channelID discord.ChannelID, r *room, at time.Time) {
+	if r.deleteTimer != nil {
+		r.deleteTimer.Stop()
+	}
+	r.deleteAt = at
+
+	var timer *wheelTimer
+	timer = h.deletions.afterFunc(time.Until(at), func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		if h.rooms[channelID] != r || r.deleteTimer != timer {
+			return
+		}
+		r.deleteTimer = nil
+
+		ctx, endIO := releaseForIO(withGuild(context.Background(), r.guildID))
+		defer endIO()
+		ch, err := h.fetchChannel(ctx, channelID)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to get room to delete", "err", err)
+			return
+		}
+		h.teardownRoom(ctx, ch)
+	})
+	r.deleteTimer = timer
+	if h.rooms[channelID] == r {
+		h.stats.track(trackedRoomOf(r))
+	}
+}
+
+// cancelRoomDeletion stops a pending deletion because someone joined, and
+// removes the warning.
+func (h *handler) cancelRoomDeletion(ctx context.Context, r *room) {
+	if r.deleteTimer != nil {
+		r.deleteTimer.Stop()
+		r.deleteTimer = nil
+		defer h.stats.track(trackedRoomOf(r))
+	}
+	if r.emptied {
+		r.emptied = false
+		h.noteRejoin(r.guildID, time.Since(r.emptySince))
+	}
+
+	if r.warningMessage.IsValid() {
+		msgID := r.warningMessage
+		r.warningMessage = 0
+		h.forgetExpiry(r, msgID)
+		chat := r.chatChannel()
+		err := h.call(ctx, "DeleteMessage", func(s *state.State) error {
+			return s.DeleteMessage(chat, msgID, "room is in use again")
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to remove deletion warning", "err", err)
+		}
+	}
+}
+
+// onKeepAlive extends the grace period of the room the button was posted in.
+// Only people who have been in the room may press it.
+func (h *handler) onKeepAlive(ctx context.Context, data cmdroute.ComponentData) *api.InteractionResponse {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	channelID, r := h.roomByChat(data.Event.ChannelID)
+	if r == nil || r.deleteTimer == nil {
+		return ephemeral("This room is no longer scheduled for deletion.")
+	}
+	if !r.participants[data.Event.SenderID()] {
+		return ephemeral("Only people who have been in this room can keep it open.")
+	}
+
+	deadline := r.deleteAt.Add(h.cfg.keepAliveExtension)
+	if limit := h.keepAliveLimit(r); deadline.After(limit) {
+		deadline = limit
+	}
+	if !deadline.After(r.deleteAt) {
+		return ephemeral("This room can't be kept open any longer.")
+	}
+	h.scheduleRoomDeletion(channelID, r, deadline)
+
+	return &api.InteractionResponse{
+		Type: api.UpdateMessage,
+		Data: &api.InteractionResponseData{
+			Content: option.NewNullableString(deletionWarning(deadline)),
+		},
+	}
+}
+
+func deletionWarning(at time.Time) string {
+	return fmt.Sprintf("This room is empty and will be deleted <t:%d:R>.", at.Unix())
+}
+
+func (h *handler) keepAliveComponents() discord.ContainerComponents {
+	return discord.ContainerComponents{
+		&discord.ActionRowComponent{
+			&discord.ButtonComponent{
+				Style:    discord.SecondaryButtonStyle(),
+				CustomID: keepAliveID,
+				Label:    fmt.Sprintf("Keep open for another %s", formatDuration(h.cfg.keepAliveExtension)),
+			},
+		},
+	}
+}
@@ -0,0 +1,111 @@
+package tvc
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// guildQueues runs each guild's gateway events in order on a worker of its
+// own, so a busy guild can't hold up events of the others. Events must be
+// dispatched from a synchronous gateway handler for their order to hold.
+type guildQueues struct {
+	mu        sync.Mutex
+	queues    map[discord.GuildID]*guildQueue
+	warnDepth int // see settings.guildQueueWarnDepth
+}
+
+// guildQueue is the events of a guild waiting for its worker, which only
+// runs while there are some.
+type guildQueue struct {
+	pending []func()
+	running bool
+	warned  bool
+}
+
+func newGuildQueues(warnDepth int) *guildQueues {
+	return &guildQueues{queues: make(map[discord.GuildID]*guildQueue), warnDepth: warnDepth}
+}
+
+// dispatch queues fn on the worker of guildID, starting the worker if it
+// isn't running.
+func (q *guildQueues) dispatch(guildID discord.GuildID, fn func()) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	queue, ok := q.queues[guildID]
+	if !ok {
+		queue = &guildQueue{}
+		q.queues[guildID] = queue
+	}
+	queue.pending = append(queue.pending, fn)
+	if len(queue.pending) > q.warnDepth && !queue.warned {
+		queue.warned = true
+		slog.Warn("Guild events are piling up", "guild_id", guildID, "queued", len(queue.pending))
+	}
+	if !queue.running {
+		queue.running = true
+		go q.work(queue)
+	}
+}
+
+// work runs the queue's events until none are left.
+func (q *guildQueues) work(queue *guildQueue) {
+	for {
+		q.mu.Lock()
+		if len(queue.pending) == 0 {
+			queue.running = false
+			queue.warned = false
+			queue.pending = nil
+			q.mu.Unlock()
+			return
+		}
+		fn := queue.pending[0]
+		queue.pending[0] = nil
+		queue.pending = queue.pending[1:]
+		q.mu.Unlock()
+
+		fn()
+	}
+}
+
+// drain waits until every guild's worker has run what was queued so far.
+func (q *guildQueues) drain() {
+	q.mu.Lock()
+	guildIDs := make([]discord.GuildID, 0, len(q.queues))
+	for guildID := range q.queues {
+		guildIDs = append(guildIDs, guildID)
+	}
+	q.mu.Unlock()
+
+	var wg sync.WaitGroup
+	wg.Add(len(guildIDs))
+	for _, guildID := range guildIDs {
+		q.dispatch(guildID, wg.Done)
+	}
+	wg.Wait()
+}
+
+// depths returns how many events each guild has waiting.
+func (q *guildQueues) depths() map[discord.GuildID]int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	depths := make(map[discord.GuildID]int, len(q.queues))
+	for guildID, queue := range q.queues {
+		depths[guildID] = len(queue.pending)
+	}
+	return depths
+}
+
+// serveQueues serves the depth of every guild's queue as JSON, keyed by
+// guild ID.
+func (q *guildQueues) serveQueues(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(q.depths()); err != nil {
+		slog.Error("Failed to write queue depths", "err", err)
+	}
+}
@@ -0,0 +1,112 @@
+package tvc
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+func TestGuildQueueKeepsOrder(t *testing.T) {
+	q := newGuildQueues(256)
+
+	var mu sync.Mutex
+	ran := make(map[discord.GuildID][]int)
+	for i := range 100 {
+		for _, guildID := range []discord.GuildID{1, 2, 3} {
+			q.dispatch(guildID, func() {
+				mu.Lock()
+				defer mu.Unlock()
+				ran[guildID] = append(ran[guildID], i)
+			})
+		}
+	}
+	q.drain()
+
+	for _, guildID := range []discord.GuildID{1, 2, 3} {
+		if !slices.IsSorted(ran[guildID]) || len(ran[guildID]) != 100 {
+			t.Errorf("guild %d ran %v, want 0 to 99 in order", guildID, ran[guildID])
+		}
+	}
+}
+
+func TestGuildQueueDoesNotBlockOtherGuilds(t *testing.T) {
+	q := newGuildQueues(256)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	q.dispatch(1, func() {
+		close(started)
+		<-release
+	})
+	<-started
+	done := make(chan struct{})
+	q.dispatch(2, func() { close(done) })
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("guild 2 waited for guild 1")
+	}
+	if depths := q.depths(); depths[1] != 0 {
+		t.Errorf("guild 1 has %d events waiting, want 0 while its only one runs", depths[1])
+	}
+
+	q.dispatch(1, func() {})
+	if depths := q.depths(); depths[1] != 1 {
+		t.Errorf("guild 1 has %d events waiting, want 1", depths[1])
+	}
+	close(release)
+	q.drain()
+	if depths := q.depths(); depths[1] != 0 {
+		t.Errorf("guild 1 has %d events waiting after drain, want 0", depths[1])
+	}
+}
+
+func TestGuildQueueRunsVoiceEventsInOrder(t *testing.T) {
+	b := newTestBot(t)
+	b.send(testGuild(nil, nil))
+
+	// The member ends up out of voice only if the leave is handled after
+	// the join.
+	b.send(join(5, testHubID), join(5, 0))
+
+	room := b.roomOf(5)
+
+	b.h.mu.Lock()
+	defer b.h.mu.Unlock()
+	if vs := b.h.userVoiceStates[5]; vs.ChannelID.IsValid() {
+		t.Errorf("member who left is still in channel %d", vs.ChannelID)
+	}
+	if !b.h.created[room] {
+		t.Error("joining the hub made no room")
+	}
+}
+
+func TestVoiceEventWaitsOnDiscordWithoutHandlerLock(t *testing.T) {
+	b := newTestBot(t)
+	b.send(testGuild(nil, nil))
+	b.send(join(5, testHubID))
+	room := b.roomOf(5)
+	b.send(join(5, room))
+
+	// Deleting the room once it's left waits on Discord.
+	stalled, release := b.stall("/channels/" + room.String())
+	left := make(chan struct{})
+	go func() {
+		defer close(left)
+		b.send(join(5, 0))
+	}()
+	<-stalled
+	finishes(t, "taking h.mu during the deletion", func() {
+		b.h.mu.Lock()
+		b.h.mu.Unlock()
+	})
+	release()
+	finishes(t, "handling the leave", func() { <-left })
+
+	if b.channelExists(room) {
+		t.Error("room wasn't deleted once it was left")
+	}
+}
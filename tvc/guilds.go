@@ -0,0 +1,21 @@
+package tvc
+
+import "github.com/diamondburned/arikawa/v3/gateway"
+
+// onGuildCreate runs once per guild on startup and whenever the bot joins a
+// new guild.
+func (h *handler) onGuildCreate(evt *gateway.GuildCreateEvent) {
+	h.warmCache(evt)
+	h.requestMembers(evt.ID)
+	h.lintGuild(evt.ID)
+	h.checkStrict(evt.ID)
+	h.repairGuild(evt)
+	h.applyNickname(evt.ID, h.currentNickname(evt))
+	h.postLauncher(evt.ID)
+
+	h.mu.Lock()
+	h.seedVoiceStates(evt)
+	h.restoreRooms(evt)
+	h.onboardGuild(evt)
+	h.mu.Unlock()
+}
@@ -0,0 +1,371 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/diamondburned/arikawa/v3/state"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Names of the built-in hub channels, used without $LOBBIES_FILE.
+const (
+	voiceHubName = "🐕 bark"
+	teamHubName  = "teams"
+)
+
+type handler struct {
+	s                *state.State
+	name             string // see Config.Name
+	cfg              settings
+	env              *environment // what cfg was read from
+	voiceLog         *voiceLog
+	queues           *guildQueues
+	latency          *roomLatency
+	companions       *companionFeed
+	metrics          *metrics
+	hubs             map[string]*hub
+	emoji            *emojiPrefix
+	themes           seasonalThemes
+	renames          *renameLimiter
+	members          *memberCache
+	moves            *moveQueue
+	breaker          *breaker
+	permissionAlerts *permissionAlerts
+	features         *featureFlags
+	fetches          *channelFetches
+	deletions        *timerWheel
+	stats            *statsStore
+	// skipCommands leaves command registration to the embedding bot.
+	skipCommands    bool
+	mu              sync.Mutex
+	userVoiceStates map[discord.UserID]discord.VoiceState
+	// channelMembers is who is connected to each channel, kept in step with
+	// userVoiceStates by setVoiceState.
+	channelMembers map[discord.ChannelID]map[discord.UserID]bool
+	rooms          map[discord.ChannelID]*room
+	// passwordWaits maps users in a waiting room to the room they want in.
+	passwordWaits map[discord.UserID]discord.ChannelID
+	// rulesWaits maps users in a waiting room to the room they want in until
+	// they accept its rules.
+	rulesWaits map[discord.UserID]discord.ChannelID
+	// teardowns holds bulk teardowns waiting for the admin to confirm them.
+	teardowns map[discord.UserID]*bulkTeardown
+	// templateDrafts holds the permission templates admins are editing.
+	templateDrafts map[discord.UserID]*templateDraft
+	// modAlerts holds the open moderation alerts by message.
+	modAlerts map[discord.MessageID]*modAlert
+	// capacityAlerted holds the categories reported as nearly full.
+	capacityAlerted map[discord.ChannelID]bool
+	// throttleAlerted is when each throttled member was last reported.
+	throttleAlerted map[discord.UserID]time.Time
+	// setupMessages maps onboarding messages to their guild.
+	setupMessages map[discord.MessageID]discord.GuildID
+	// observed counts the joins seen in hub-like channels of guilds in
+	// observer mode.
+	observed    map[discord.GuildID]map[discord.ChannelID]int
+	boards      map[discord.GuildID]*board
+	tournaments map[discord.GuildID]*tournament
+	// pendingReload is a lobby reload waiting for an admin to confirm it.
+	pendingReload *lobbyReload
+	// heldCleanups are cleanups safe mode holds for confirmation, by guild.
+	heldCleanups map[discord.GuildID]*heldCleanup
+	// hubIdleTimers clear members out of a hub they sit in for too long.
+	hubIdleTimers map[discord.UserID]*time.Timer
+	// presetOffers holds the preset menus waiting for a pick, by member.
+	presetOffers map[discord.UserID]*presetOffer
+	// emptiedRooms is the last room of each owner deleted for being empty;
+	// see noteEmptiedRoom.
+	emptiedRooms map[discord.UserID]emptiedRoom
+	// summons is when each owner used /voice summon in the last hour.
+	summons map[discord.UserID][]time.Time
+	// identified is set once the first gateway session is up; see
+	// noteIdentified.
+	identified   bool
+	pendingRooms []roomRequest
+	// delayedRooms are the creations waiting for their guild's creation
+	// budget, by user; see delayRoom.
+	delayedRooms map[discord.UserID]*time.Timer
+	// creating counts the rooms of each guild in the making, and slotWaits
+	// are the requests waiting for one of them to finish; see
+	// creationSlots.
+	creating  map[discord.GuildID]int
+	slotWaits map[discord.GuildID][]slotWait
+	// strictMissing holds the permissions missing in guilds whose hubs
+	// strict mode turned off; see checkStrict.
+	strictMissing map[discord.GuildID][]string
+	// hubWaits are the stays in hubs the orphan scans saw, by member.
+	hubWaits map[discord.UserID]hubWait
+	// budgetSpent is when each guild spent from each budget; see spend.
+	budgetSpent map[*budget]map[discord.GuildID][]time.Time
+	// created holds every channel the bot created and hasn't deleted yet.
+	created map[discord.ChannelID]bool
+	// deleteRetries holds the deletions of created channels that failed and
+	// are retried by the janitor.
+	deleteRetries map[discord.ChannelID]*deleteRetry
+	// emptyCategories are created categories the janitor found without
+	// channels, by when it first did.
+	emptyCategories     map[discord.ChannelID]time.Time
+	temporaryChannels   []discord.ChannelID
+	temporaryCategories []discord.ChannelID
+}
+
+// newHandler returns a handler for hubs and stats, reading the rest of its
+// settings. Its state is set once it is attached.
+func newHandler(env *environment, cfg settings, hubs map[string]*hub, stats *statsStore) *handler {
+	h := &handler{
+		cfg:              cfg,
+		env:              env,
+		voiceLog:         newVoiceLog(env),
+		queues:           newGuildQueues(cfg.guildQueueWarnDepth),
+		latency:          newRoomLatency(cfg.slowRoomThreshold),
+		companions:       newCompanionFeed(),
+		metrics:          newMetrics(env),
+		hubs:             hubs,
+		emoji:            newEmojiPrefix(env),
+		themes:           newSeasonalThemes(env),
+		renames:          newRenameLimiter(),
+		members:          newMemberCache(cfg.memberCacheTTL, cfg.memberCacheSize),
+		moves:            newMoveQueue(cfg.moveInterval),
+		breaker:          newBreaker(env),
+		permissionAlerts: newPermissionAlerts(env),
+		features:         newFeatureFlags(env, cfg.stagingGuilds),
+		fetches:          newChannelFetches(),
+		deletions:        newTimerWheel(),
+		modAlerts:        make(map[discord.MessageID]*modAlert),
+		capacityAlerted:  make(map[discord.ChannelID]bool),
+		throttleAlerted:  make(map[discord.UserID]time.Time),
+		setupMessages:    make(map[discord.MessageID]discord.GuildID),
+		observed:         make(map[discord.GuildID]map[discord.ChannelID]int),
+		boards:           make(map[discord.GuildID]*board),
+		tournaments:      make(map[discord.GuildID]*tournament),
+		hubIdleTimers:    make(map[discord.UserID]*time.Timer),
+		presetOffers:     make(map[discord.UserID]*presetOffer),
+		emptiedRooms:     make(map[discord.UserID]emptiedRoom),
+		summons:          make(map[discord.UserID][]time.Time),
+		delayedRooms:     make(map[discord.UserID]*time.Timer),
+		creating:         make(map[discord.GuildID]int),
+		slotWaits:        make(map[discord.GuildID][]slotWait),
+		hubWaits:         make(map[discord.UserID]hubWait),
+		strictMissing:    make(map[discord.GuildID][]string),
+		budgetSpent:      make(map[*budget]map[discord.GuildID][]time.Time),
+		stats:            stats,
+		userVoiceStates:  make(map[discord.UserID]discord.VoiceState),
+		channelMembers:   make(map[discord.ChannelID]map[discord.UserID]bool),
+		rooms:            make(map[discord.ChannelID]*room),
+		passwordWaits:    make(map[discord.UserID]discord.ChannelID),
+		rulesWaits:       make(map[discord.UserID]discord.ChannelID),
+		teardowns:        make(map[discord.UserID]*bulkTeardown),
+		templateDrafts:   make(map[discord.UserID]*templateDraft),
+		created:          make(map[discord.ChannelID]bool),
+		deleteRetries:    make(map[discord.ChannelID]*deleteRetry),
+		emptyCategories:  make(map[discord.ChannelID]time.Time),
+		heldCleanups:     make(map[discord.GuildID]*heldCleanup),
+	}
+	for feat, on := range h.stats.featureDefaults() {
+		h.features.setDefault(feat, on)
+	}
+	h.members.dropped = h.dropStateMembers
+	h.loadDeleteRetries()
+	return h
+}
+
+// onReady is called when the bot is ready
+func (h *handler) onReady(e *gateway.ReadyEvent) {
+	me, _ := h.s.Me()
+	slog.Info("Connected to the gateway", "bot", h.name, "username", me.Username)
+	h.noteIdentified()
+	h.registerCommands()
+	if h.stats.inMaintenance(0) {
+		h.refreshPresence()
+	}
+}
+
+// onVoiceStateUpdate handles voice state updates
+func (h *handler) onVoiceStateUpdate(evt *gateway.VoiceStateUpdateEvent) {
+	ctx, span := tracer.Start(withGuild(context.Background(), evt.GuildID), "voice_state_update", trace.WithAttributes(
+		attribute.String("discord.guild_id", evt.GuildID.String()),
+		attribute.String("discord.user_id", evt.UserID.String()),
+		attribute.String("discord.channel_id", evt.ChannelID.String()),
+	))
+	defer span.End()
+	h.metrics.voiceEvent()
+
+	_, lockSpan := tracer.Start(ctx, "handler.lock_wait")
+	h.mu.Lock()
+	lockSpan.End()
+	defer h.mu.Unlock()
+
+	// Events of the guild wait for this one in its queue, but other guilds'
+	// events and timers may change handler state while it waits on Discord.
+	ctx, endIO := releaseForIO(ctx)
+	defer endIO()
+
+	before := h.setVoiceState(evt.VoiceState)
+	if before.ChannelID != evt.ChannelID {
+		h.releaseAFK(ctx, evt.UserID)
+	}
+
+	h.trackAFK(before, evt.VoiceState)
+	h.watchHub(before, evt)
+
+	if h.voiceLog.sample(h.isHubRelated(before.ChannelID, evt.ChannelID)) {
+		slog.Debug("Voice channel changed", "guild_id", evt.GuildID, "user_id", evt.UserID, "from", before.ChannelID, "to", evt.ChannelID)
+	}
+
+	// The admission checks may wait on Discord, so the room is looked up
+	// again before it is updated.
+	if r, ok := h.rooms[evt.ChannelID]; ok && before.ChannelID != evt.ChannelID && h.allowBot(ctx, r, evt) && h.checkRules(ctx, r, evt.UserID) && h.checkPassword(ctx, r, evt.UserID) && h.rooms[evt.ChannelID] == r {
+		if !r.participants[evt.UserID] {
+			r.participants[evt.UserID] = true
+			h.stats.recordParticipant(evt.ChannelID, evt.UserID)
+		}
+		r.joined[evt.UserID] = time.Now()
+		r.peak = max(r.peak, h.roomOccupants(r))
+		h.cancelRoomDeletion(ctx, r)
+		h.recordAttendance(r, evt.UserID, true)
+		h.publishOverlay(r, overlayEvent{Type: "join", UserID: evt.UserID, Username: username(evt), At: time.Now()})
+		h.refreshActivityStatus(evt.ChannelID, r)
+		h.autoscale(ctx, r)
+		h.tuneBitrate(ctx, r)
+		h.clearMutes(ctx, r, evt)
+		h.greet(ctx, evt.ChannelID, r)
+		if h.rooms[evt.ChannelID] == r {
+			h.watchOwner(ctx, evt.ChannelID, r)
+		}
+	}
+	if r, ok := h.rooms[before.ChannelID]; ok && before.ChannelID != evt.ChannelID {
+		r.recentlyLeft[evt.UserID] = time.Now()
+		delete(r.joined, evt.UserID)
+		h.recordAttendance(r, evt.UserID, false)
+		h.publishOverlay(r, overlayEvent{Type: "leave", UserID: evt.UserID, Username: username(evt), At: time.Now()})
+		h.refreshActivityStatus(before.ChannelID, r)
+		h.refreshBoard(r.guildID)
+		h.autoscale(ctx, r)
+		h.tuneBitrate(ctx, r)
+		h.handOverPrivate(ctx, before.ChannelID, r, evt.UserID)
+		if h.rooms[before.ChannelID] == r {
+			h.watchOwner(ctx, before.ChannelID, r)
+		}
+	}
+	if r, ok := h.rooms[evt.ChannelID]; ok {
+		h.noteActivity(r, evt.UserID)
+	}
+
+	if before.ChannelID.IsValid() && before.ChannelID != evt.ChannelID {
+		h.onVoiceLeave(ctx, before, evt.ChannelID)
+	}
+	if evt.ChannelID.IsValid() && before.ChannelID != evt.ChannelID {
+		h.onVoiceJoin(ctx, evt, before.ChannelID)
+	}
+}
+
+// onVoiceLeave starts cleaning up the room a member left, whether they
+// disconnected or moved to another channel, once nobody is left in it.
+func (h *handler) onVoiceLeave(ctx context.Context, before discord.VoiceState, after discord.ChannelID) {
+	r, ok := h.rooms[h.roomChannelOf(before.ChannelID)]
+	if !ok {
+		return
+	}
+	if after.IsValid() && h.isGuildAFK(r.guildID, after) {
+		h.holdAFK(r, before.UserID)
+	}
+	h.checkEmptied(ctx, r)
+}
+
+// checkEmptied starts cleaning up r if nobody is left in it.
+func (h *handler) checkEmptied(ctx context.Context, r *room) {
+	if h.roomOccupants(r) > 0 {
+		return
+	}
+	beforeChannel, err := h.fetchChannel(ctx, r.channelID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get before channel", "err", err)
+		return
+	}
+	if h.rooms[r.channelID] != r || h.roomOccupants(r) > 0 {
+		// Deleted or joined while the channel was looked up.
+		return
+	}
+	h.roomEmptied(ctx, beforeChannel, r)
+}
+
+// onVoiceJoin creates a room for a member who joined or moved into a hub.
+func (h *handler) onVoiceJoin(ctx context.Context, evt *gateway.VoiceStateUpdateEvent, from discord.ChannelID) {
+	afterChannel, err := h.fetchChannel(ctx, evt.ChannelID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get after channel", "err", err)
+		return
+	}
+
+	if h.isObserving(afterChannel.GuildID) {
+		h.observeJoin(ctx, afterChannel, evt.UserID)
+		return
+	}
+	hub := h.hubFor(afterChannel)
+	if hub == nil || !h.mayCreateRoom(ctx, hub, afterChannel.GuildID, evt, from) {
+		return
+	}
+	req := roomRequest{
+		hub:        hub,
+		hubChannel: afterChannel,
+		userID:     evt.UserID,
+		username:   username(evt),
+		joinedAt:   time.Now(),
+	}
+	if len(hub.presets) > 0 && !hub.silent {
+		h.offerPresets(ctx, req)
+		return
+	}
+	h.requestRoom(ctx, req)
+}
+
+// isHubRelated reports whether any of the given channels is a hub or a
+// temporary channel. It only consults the cache so it is cheap enough to call
+// for every update.
+func (h *handler) isHubRelated(ids ...discord.ChannelID) bool {
+	for _, id := range ids {
+		if !id.IsValid() {
+			continue
+		}
+		if contains(h.temporaryChannels, id) || contains(h.temporaryCategories, id) {
+			return true
+		}
+		if ch, err := h.s.Cabinet.Channel(id); err == nil && h.lookupHub(ch) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// username returns the username of the member in evt, or an empty string if
+// the event didn't carry the member.
+func username(evt *gateway.VoiceStateUpdateEvent) string {
+	if evt.Member == nil {
+		return ""
+	}
+	return evt.Member.User.Username
+}
+
+func contains(slice []discord.ChannelID, elem discord.ChannelID) bool {
+	for _, item := range slice {
+		if item == elem {
+			return true
+		}
+	}
+	return false
+}
+
+func remove(slice *[]discord.ChannelID, elem discord.ChannelID) {
+	for i, item := range *slice {
+		if item == elem {
+			*slice = append((*slice)[:i], (*slice)[i+1:]...)
+			break
+		}
+	}
+}
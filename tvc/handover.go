@@ -0,0 +1,56 @@
+package tvc
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+)
+
+// registryExport is the live registry as served by GET /registry.
+type registryExport struct {
+	ExportedAt time.Time     `json:"exported_at"`
+	Rooms      []trackedRoom `json:"rooms"`
+}
+
+// serveRegistry serves GET /registry, the rooms the bot manages with their
+// channels, owners and pending deletions, for moving the bot to another host
+// with $REGISTRY_IMPORT_FILE.
+func (h *handler) serveRegistry(w http.ResponseWriter, req *http.Request) {
+	h.mu.Lock()
+	export := registryExport{ExportedAt: time.Now(), Rooms: []trackedRoom{}}
+	for _, r := range h.rooms {
+		export.Rooms = append(export.Rooms, trackedRoomOf(r))
+	}
+	h.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(export); err != nil {
+		slog.Error("Failed to write registry", "err", err)
+	}
+}
+
+// importRegistry adds the rooms of the registry exported to path to the
+// tracked rooms, replacing what is tracked for the same channels.
+func (st *statsStore) importRegistry(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var export registryExport
+	if err := json.Unmarshal(b, &export); err != nil {
+		return fmt.Errorf("invalid registry %s: %w", path, err)
+	}
+	for _, tr := range export.Rooms {
+		if !tr.GuildID.IsValid() || !tr.ChannelID.IsValid() {
+			return fmt.Errorf("invalid registry %s: room without guild_id or channel_id", path)
+		}
+	}
+	for _, tr := range export.Rooms {
+		st.track(tr)
+	}
+	slog.Info("Imported room registry", "path", path, "rooms", len(export.Rooms), "exported_at", export.ExportedAt)
+	return nil
+}
@@ -0,0 +1,100 @@
+package tvc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+)
+
+// serveHealth serves /healthz, /rooms, /latency, /queues, /registry and the
+// admin endpoints /killswitch, /cleanup and /reload, and /debug/pprof/ if
+// enabled, on addr until ctx is done. /healthz answers 503 while the bot is
+// degraded so orchestrators and uptime checks can alert on it.
+func (h *handler) serveHealth(ctx context.Context, addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		if h.breaker.open() {
+			http.Error(w, "degraded: Discord API requests are failing", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok\n"))
+	})
+
+	mux.HandleFunc("GET /rooms", h.serveRooms)
+	mux.HandleFunc("GET /rooms/{id}/events", h.serveOverlay)
+	mux.HandleFunc("GET /latency", h.tokenOnly(h.latency.serveLatency))
+	mux.HandleFunc("GET /occupancy", h.serveOccupancy)
+	mux.HandleFunc("GET /companions/channels/{id}", h.serveCompanionChannel)
+	mux.HandleFunc("GET /companions/events", h.tokenOnly(h.companions.serveEvents))
+	mux.HandleFunc("GET /queues", h.tokenOnly(h.queues.serveQueues))
+	mux.HandleFunc("POST /hubs/{key}/rooms", h.serveExternalRoom)
+	mux.HandleFunc("POST /killswitch", h.serveKillSwitch)
+	mux.HandleFunc("GET /registry", h.adminOnly("export the registry", h.serveRegistry))
+	mux.HandleFunc("POST /cleanup", h.adminOnly("clean up rooms", h.serveCleanup))
+	mux.HandleFunc("POST /reload", h.adminOnly("reload the lobbies", h.serveReload))
+	h.registerDebug(mux)
+
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("Health endpoint stopped", "err", err)
+	}
+}
+
+// onBreakerChange reflects degraded mode in the bot's presence.
+func (h *handler) onBreakerChange(open bool) {
+	if open {
+		slog.Warn("Discord API is failing, entering degraded mode")
+	} else {
+		slog.Info("Discord API recovered, leaving degraded mode")
+	}
+	h.refreshPresence()
+}
+
+// refreshPresence shows the global kill switch, degraded mode or maintenance
+// in the bot's presence, the ones affecting every guild first.
+func (h *handler) refreshPresence() {
+	switch {
+	case h.stats.isKilled(0):
+		h.setPresence(discord.DoNotDisturbStatus, "Stopped by an operator")
+	case h.breaker.open():
+		h.setPresence(discord.IdleStatus, "Degraded: rooms are queued")
+	case h.stats.inMaintenance(0):
+		h.setPresence(discord.DoNotDisturbStatus, "Maintenance: new rooms are paused")
+	default:
+		h.setPresence(discord.OnlineStatus, "")
+	}
+}
+
+// setPresence updates the bot's status and custom status text. An empty text
+// clears the custom status.
+func (h *handler) setPresence(status discord.Status, text string) {
+	var activities []discord.Activity
+	if text != "" {
+		activities = []discord.Activity{{
+			Name:  "Custom Status",
+			Type:  discord.CustomActivity,
+			State: text,
+		}}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := h.s.SendGateway(ctx, &gateway.UpdatePresenceCommand{
+		Activities: activities,
+		Status:     status,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to update presence", "err", err)
+	}
+}
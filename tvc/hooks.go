@@ -0,0 +1,158 @@
+package tvc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// Room lifecycle events hooks run on. A room is claimed when it passes to a
+// new owner.
+const (
+	hookCreate = "on_create"
+	hookDelete = "on_delete"
+	hookClaim  = "on_claim"
+)
+
+// hookClient sends hooks and connector requests, which their contexts bound
+// by hookTimeout.
+var hookClient = &http.Client{}
+
+// hookEvent is what a hook is told about the room.
+type hookEvent struct {
+	Event     string            `json:"event"`
+	GuildID   discord.GuildID   `json:"guild_id"`
+	ChannelID discord.ChannelID `json:"channel_id"`
+	OwnerID   discord.UserID    `json:"owner_id"`
+	// PreviousOwnerID is who the room was claimed from.
+	PreviousOwnerID discord.UserID `json:"previous_owner_id,omitempty"`
+	Hub             string         `json:"hub"`
+	Name            string         `json:"name"`
+	Number          int            `json:"number"`
+	At              time.Time      `json:"at"`
+}
+
+// parseHooks reads the hooks of the hub with key from $<key>_HOOK_ON_CREATE
+// and so on, falling back to $HOOK_ON_CREATE and the like. A hook is a URL
+// the payload is POSTed to, or a command run with the payload on stdin and
+// the event's fields in TVC_* variables. Commands are split on spaces and
+// not run through a shell.
+func parseHooks(env *environment, key string) map[string]string {
+	hooks := make(map[string]string)
+	for _, event := range []string{hookCreate, hookDelete, hookClaim} {
+		name := "HOOK_" + strings.ToUpper(event)
+		if target := env.string(key+"_"+name, env.string(name, "")); target != "" {
+			hooks[event] = target
+		}
+	}
+	return hooks
+}
+
+// runHook runs the room's hub's hook for event, if it has one, in the
+// background.
+func (h *handler) runHook(r *room, event string, previousOwner discord.UserID) {
+	target := r.hub.hooks[event]
+	if target == "" {
+		return
+	}
+	ev := hookEvent{
+		Event:           event,
+		GuildID:         r.guildID,
+		ChannelID:       r.channelID,
+		OwnerID:         r.owner,
+		PreviousOwnerID: previousOwner,
+		Hub:             r.hub.label(),
+		Name:            r.name,
+		Number:          r.number,
+		At:              time.Now(),
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(withGuild(context.Background(), ev.GuildID), h.cfg.hookTimeout)
+		defer cancel()
+
+		if err := h.callHook(ctx, target, ev); err != nil {
+			slog.ErrorContext(ctx, "Hook failed", "event", event, "channel_id", ev.ChannelID, "err", err)
+		}
+	}()
+}
+
+// callHook delivers ev to target.
+func (h *handler) callHook(ctx context.Context, target string, ev hookEvent) error {
+	payload, err := h.hookPayloadOf(ev)
+	if err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := hookClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			return fmt.Errorf("hook returned %s", resp.Status)
+		}
+		return nil
+	}
+
+	args := strings.Fields(target)
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(),
+		"TVC_EVENT="+ev.Event,
+		"TVC_GUILD_ID="+ev.GuildID.String(),
+		"TVC_CHANNEL_ID="+ev.ChannelID.String(),
+		"TVC_OWNER_ID="+ev.OwnerID.String(),
+		"TVC_HUB="+ev.Hub,
+		"TVC_NAME="+ev.Name,
+		"TVC_NUMBER="+strconv.Itoa(ev.Number),
+	)
+	if ev.PreviousOwnerID.IsValid() {
+		cmd.Env = append(cmd.Env, "TVC_PREVIOUS_OWNER_ID="+ev.PreviousOwnerID.String())
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// hookPayloadOf renders ev with $HOOK_PAYLOAD, or as JSON without one.
+func (h *handler) hookPayloadOf(ev hookEvent) ([]byte, error) {
+	if h.cfg.hookPayload == "" {
+		return json.Marshal(ev)
+	}
+	escape := func(s string) string {
+		b, _ := json.Marshal(s)
+		return string(b[1 : len(b)-1])
+	}
+	previous := ""
+	if ev.PreviousOwnerID.IsValid() {
+		previous = ev.PreviousOwnerID.String()
+	}
+	return []byte(strings.NewReplacer(
+		"{event}", ev.Event,
+		"{guild_id}", ev.GuildID.String(),
+		"{channel_id}", ev.ChannelID.String(),
+		"{owner_id}", ev.OwnerID.String(),
+		"{previous_owner_id}", previous,
+		"{hub}", escape(ev.Hub),
+		"{name}", escape(ev.Name),
+		"{number}", strconv.Itoa(ev.Number),
+		"{at}", ev.At.Format(time.RFC3339),
+	).Replace(h.cfg.hookPayload)), nil
+}
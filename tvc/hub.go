@@ -0,0 +1,250 @@
+package tvc
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// roomKind is what a hub creates.
+type roomKind string
+
+const (
+	// voiceRoom is a single voice channel next to the hub.
+	voiceRoom roomKind = "voice"
+	// teamRoom is a category holding a text and a voice channel.
+	teamRoom roomKind = "category"
+)
+
+// hub is a channel that spawns a temporary room when a user joins it.
+type hub struct {
+	// key prefixes the hub's variables, e.g. "BARK" for $BARK_GREETING.
+	key string
+	// name is the channel name that triggers creation. Empty if the hub is
+	// only picked by ID.
+	name string
+	// channelIDs trigger creation whatever their name.
+	channelIDs []discord.ChannelID
+	// guilds limits the hub to these guilds. Empty serves every guild.
+	guilds []discord.GuildID
+	// staging lets the hub serve the staging guilds of its guilds; see
+	// settings.stagingGuilds.
+	staging guildMirrors
+	kind    roomKind
+	// userLimit and bitrate are given to the hub's voice rooms. Zero leaves
+	// the limit to limitSteps and the bitrate to Discord.
+	userLimit uint
+	bitrate   uint
+	// naming generates the names of the hub's rooms.
+	naming namingProvider
+	// greeting is posted in the room's text chat when the greetAt-th member
+	// joins. Empty disables it. See expandTemplate for placeholders.
+	greeting string
+	greetAt  int
+	// requiredRoles must all be held to use the hub. Pair them with Discord
+	// linked roles to require a verified external account.
+	requiredRoles []discord.RoleID
+	// visibilityRoles are community roles, e.g. clans. A room whose owner
+	// holds some of them is only visible to members sharing one.
+	visibilityRoles []discord.RoleID
+	// rules must be accepted in a guild before joining someone else's room
+	// of the hub there. Empty asks for nothing.
+	rules string
+	// private rooms are hidden from everyone their owner doesn't let in, and
+	// pass to someone still in them when the owner leaves.
+	private bool
+	// region pins the voice region of the hub's rooms, e.g. "rotterdam".
+	// Empty leaves it to Discord.
+	region string
+	// silent skips every message the bot would post or DM for the hub's
+	// rooms, leaving just creation, moves and deletion.
+	silent bool
+	// presets are offered in a select menu when someone joins the hub.
+	presets []preset
+	// limitSteps are the user limits rooms grow through as they fill. Empty
+	// leaves rooms unlimited.
+	limitSteps []uint
+	// bitrateSteps are the bitrates rooms are tuned through as they fill.
+	// Empty keeps bitrate.
+	bitrateSteps []bitrateStep
+	// categories are where the hub's voice rooms are spread, in turn, to stay
+	// under Discord's per-category channel cap. Empty puts rooms next to the
+	// hub. nextCategory is guarded by handler.mu.
+	categories   []discord.ChannelID
+	nextCategory int
+	// shadow is a configuration being tried out. Rooms are still created
+	// with the live one; see shadowRoom.
+	shadow *hub
+	// hooks are run on room lifecycle events; see parseHooks.
+	hooks map[string]string
+	// connectors name the services team rooms are mirrored to; see
+	// parseConnectors.
+	connectors []string
+	// permissions are the role overwrites every room of the hub gets; see
+	// applyTemplate.
+	permissions []discord.Overwrite
+	// lobby is what the hub was built from, for diffing reloads against.
+	lobby lobbyConfig
+}
+
+func newHub(env *environment, l lobbyConfig) (*hub, error) {
+	key := l.Key
+	h := &hub{
+		lobby:      l,
+		key:        key,
+		name:       env.string(key+"_NAME", l.Name),
+		channelIDs: l.ChannelIDs,
+		guilds:     l.GuildIDs,
+		kind:       roomKind(env.string(key+"_TYPE", string(l.Type))),
+		userLimit:  uint(env.int(key+"_USER_LIMIT", int(l.UserLimit))),
+		bitrate:    uint(env.int(key+"_BITRATE", int(l.Bitrate))),
+		naming:     newNamingProvider(env, key, l.NameTemplate),
+		greeting:   env.string(key+"_GREETING", ""),
+		greetAt:    env.int(key+"_GREETING_AT", 2),
+
+		requiredRoles:   env.roleIDs(key + "_REQUIRED_ROLES"),
+		visibilityRoles: env.roleIDs(key + "_VISIBILITY_ROLES"),
+		rules:           env.string(key+"_RULES", ""),
+		private:         env.bool(key+"_PRIVATE", l.Private),
+		region:          env.string(key+"_RTC_REGION", ""),
+		silent:          env.bool(key+"_SILENT", false),
+		presets:         parsePresets(env, key+"_PRESETS"),
+		limitSteps:      parseLimitSteps(env, key+"_LIMIT_STEPS"),
+		bitrateSteps:    parseBitrateSteps(env, key+"_BITRATE_STEPS"),
+		categories:      env.channelIDList(key + "_CATEGORY_IDS"),
+		hooks:           parseHooks(env, key),
+	}
+	var err error
+	if h.permissions, err = templateOverwrites(l.Permissions); err != nil {
+		return nil, fmt.Errorf("hub %s has an invalid permission template: %w", key, err)
+	}
+	if h.connectors, err = parseConnectors(env, key); err != nil {
+		return nil, fmt.Errorf("hub %s: %w", key, err)
+	}
+	if ids := env.channelIDList(key + "_CHANNEL_IDS"); len(ids) > 0 {
+		h.channelIDs = ids
+	}
+	if ids := env.guildIDs(key + "_GUILD_IDS"); len(ids) > 0 {
+		h.guilds = ids
+	}
+	switch {
+	case h.kind != voiceRoom && h.kind != teamRoom:
+		return nil, fmt.Errorf("hub %s has invalid type %q, want %q or %q", key, h.kind, voiceRoom, teamRoom)
+	case h.name == "" && len(h.channelIDs) == 0:
+		return nil, fmt.Errorf("hub %s needs a channel name or channel IDs", key)
+	case h.userLimit > 99:
+		return nil, fmt.Errorf("hub %s has user limit %d, at most 99 is allowed", key, h.userLimit)
+	case h.bitrate != 0 && (h.bitrate < 8000 || h.bitrate > 384000):
+		return nil, fmt.Errorf("hub %s has bitrate %d, want 8000 to 384000", key, h.bitrate)
+	}
+	if !strings.HasSuffix(key, "_SHADOW") {
+		shadow, err := newShadowHub(env, l)
+		if err != nil {
+			return nil, err
+		}
+		h.shadow = shadow
+	}
+	return h, nil
+}
+
+// label names the hub in logs, records and messages.
+func (hub *hub) label() string {
+	if hub.name != "" {
+		return hub.name
+	}
+	return hub.key
+}
+
+// serves reports whether the hub is used in guildID.
+func (hub *hub) serves(guildID discord.GuildID) bool {
+	return len(hub.guilds) == 0 || slices.Contains(hub.guilds, guildID) || slices.Contains(hub.guilds, hub.staging.productionOf(guildID))
+}
+
+// lookupHub returns the hub ch is, whether or not its feature is enabled.
+// Hubs picked by channel ID win over hubs matched by name, and hubs limited
+// to the guild over ones serving every guild.
+func (h *handler) lookupHub(ch *discord.Channel) *hub {
+	var best *hub
+	bestScore := 0
+	for _, hub := range h.hubs {
+		if !hub.serves(ch.GuildID) {
+			continue
+		}
+		var score int
+		switch {
+		case slices.ContainsFunc(hub.channelIDs, func(id discord.ChannelID) bool {
+			return discord.ChannelID(h.mirrored(ch.GuildID, discord.Snowflake(id))) == ch.ID
+		}):
+			score = 3
+		case hub.name != "" && hub.name == ch.Name:
+			score = 1
+		default:
+			continue
+		}
+		if len(hub.guilds) > 0 {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = hub, score
+		}
+	}
+	return best
+}
+
+// hubOfKind returns a hub creating kind in guildID, preferring hubs limited
+// to the guild, then the lowest key. It is nil if there is none.
+func (h *handler) hubOfKind(guildID discord.GuildID, kind roomKind) *hub {
+	var best *hub
+	for _, hub := range h.hubs {
+		if hub.kind != kind || !hub.serves(guildID) {
+			continue
+		}
+		switch {
+		case best == nil,
+			len(hub.guilds) > 0 && len(best.guilds) == 0,
+			(len(hub.guilds) > 0) == (len(best.guilds) > 0) && hub.key < best.key:
+			best = hub
+		}
+	}
+	return best
+}
+
+// hubFor returns the hub ch is, or nil if it isn't a hub or the hub's feature
+// is disabled in the guild.
+func (h *handler) hubFor(ch *discord.Channel) *hub {
+	hub := h.lookupHub(ch)
+	if hub == nil {
+		return nil
+	}
+	if hub.kind == teamRoom && !h.features.enabled(ch.GuildID, featureTeams) {
+		return nil
+	}
+	if !h.hubEnabled(ch.GuildID, hub) {
+		return nil
+	}
+	return hub
+}
+
+// templateData holds the values substituted into user-facing templates.
+type templateData struct {
+	Username string // username of the member a room is for
+	Host     string // mention of the room owner
+	Channel  string // mention of the room's voice channel
+	Count    int    // members currently in the room
+	Number   int    // the room's number within its hub and guild
+}
+
+// expandTemplate replaces {username}, {host}, {channel}, {count} and {number}
+// in tmpl.
+func expandTemplate(tmpl string, data templateData) string {
+	return strings.NewReplacer(
+		"{username}", data.Username,
+		"{host}", data.Host,
+		"{channel}", data.Channel,
+		"{count}", strconv.Itoa(data.Count),
+		"{number}", strconv.Itoa(data.Number),
+	).Replace(tmpl)
+}
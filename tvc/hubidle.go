@@ -0,0 +1,73 @@
+package tvc
+
+import (
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+)
+
+// watchHub starts the idle timer of a member who just joined a hub. Members
+// waiting for a preset pick or a queued room are given more time.
+func (h *handler) watchHub(before discord.VoiceState, evt *gateway.VoiceStateUpdateEvent) {
+	if h.cfg.hubIdleTimeout <= 0 || !evt.ChannelID.IsValid() || before.ChannelID == evt.ChannelID {
+		return
+	}
+	ch, err := h.s.Cabinet.Channel(evt.ChannelID)
+	if err != nil || h.hubFor(ch) == nil {
+		return
+	}
+
+	userID, hubID, guildID := evt.UserID, evt.ChannelID, evt.GuildID
+	if old, ok := h.hubIdleTimers[userID]; ok {
+		old.Stop()
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(h.cfg.hubIdleTimeout, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		if h.hubIdleTimers[userID] != timer {
+			return
+		}
+		if h.userVoiceStates[userID].ChannelID != hubID {
+			delete(h.hubIdleTimers, userID)
+			return
+		}
+		if _, ok := h.presetOffers[userID]; ok || h.roomPending(userID) {
+			timer.Reset(h.cfg.hubIdleTimeout)
+			return
+		}
+		delete(h.hubIdleTimers, userID)
+
+		h.queueMove(memberMove{
+			guildID:   guildID,
+			userID:    userID,
+			channelID: h.hubIdleLobbyFor(guildID),
+			reason:    "sat in the hub without a room",
+			failure:   "Failed to clear idle member out of the hub",
+		})
+	})
+	h.hubIdleTimers[userID] = timer
+}
+
+// hubIdleLobbyFor returns the guild's idle lobby, or discord.NullChannelID to
+// disconnect idle members.
+func (h *handler) hubIdleLobbyFor(guildID discord.GuildID) discord.ChannelID {
+	for id := range h.cfg.hubIdleLobbies {
+		if ch, err := h.s.Channel(id); err == nil && ch.GuildID == guildID {
+			return id
+		}
+	}
+	return discord.NullChannelID
+}
+
+// roomPending reports whether userID's room is queued.
+func (h *handler) roomPending(userID discord.UserID) bool {
+	for _, req := range h.pendingRooms {
+		if req.userID == userID {
+			return true
+		}
+	}
+	return false
+}
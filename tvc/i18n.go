@@ -0,0 +1,114 @@
+package tvc
+
+import (
+	"embed"
+	"encoding/json"
+	"log"
+	"path"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// localeFiles holds a catalog per Discord locale, e.g. locales/de.json. Keys
+// are dotted command paths such as "voice.password.secret.description"; the
+// English defaults live in the command definitions.
+//
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// catalogs maps each locale to its translations.
+var catalogs = loadCatalogs()
+
+func loadCatalogs() map[discord.Language]map[string]string {
+	files, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		log.Fatalln("cannot read locales:", err)
+	}
+
+	catalogs := make(map[discord.Language]map[string]string)
+	for _, f := range files {
+		b, err := localeFiles.ReadFile(path.Join("locales", f.Name()))
+		if err != nil {
+			log.Fatalln("cannot read locale:", err)
+		}
+		var catalog map[string]string
+		if err := json.Unmarshal(b, &catalog); err != nil {
+			log.Fatalf("invalid locale %s: %v", f.Name(), err)
+		}
+		catalogs[discord.Language(strings.TrimSuffix(f.Name(), ".json"))] = catalog
+	}
+	return catalogs
+}
+
+// localize collects the translations of key from every catalog.
+func localize(key string) discord.StringLocales {
+	var locales discord.StringLocales
+	for lang, catalog := range catalogs {
+		if s, ok := catalog[key]; ok {
+			if locales == nil {
+				locales = make(discord.StringLocales)
+			}
+			locales[lang] = s
+		}
+	}
+	return locales
+}
+
+// localizeCommands fills in the name and description localizations of the
+// commands and all their options from the catalogs.
+func localizeCommands(commands []api.CreateCommandData) []api.CreateCommandData {
+	for i := range commands {
+		cmd := &commands[i]
+		cmd.NameLocalizations = localize(cmd.Name + ".name")
+		cmd.DescriptionLocalizations = localize(cmd.Name + ".description")
+		localizeOptions(cmd.Name, cmd.Options)
+	}
+	return commands
+}
+
+func localizeOptions(prefix string, options discord.CommandOptions) {
+	for _, opt := range options {
+		switch opt := opt.(type) {
+		case *discord.SubcommandGroupOption:
+			key := prefix + "." + opt.OptionName
+			opt.OptionNameLocalizations = localize(key + ".name")
+			opt.DescriptionLocalizations = localize(key + ".description")
+			for _, sub := range opt.Subcommands {
+				localizeOptions(key, discord.CommandOptions{sub})
+			}
+		case *discord.SubcommandOption:
+			key := prefix + "." + opt.OptionName
+			opt.OptionNameLocalizations = localize(key + ".name")
+			opt.DescriptionLocalizations = localize(key + ".description")
+			for _, value := range opt.Options {
+				localizeValue(key, value)
+			}
+		case discord.CommandOptionValue:
+			localizeValue(prefix, opt)
+		}
+	}
+}
+
+// localizeValue localizes an option that takes a value.
+func localizeValue(prefix string, opt discord.CommandOptionValue) {
+	var name, desc *discord.StringLocales
+	switch opt := opt.(type) {
+	case *discord.StringOption:
+		name, desc = &opt.OptionNameLocalizations, &opt.DescriptionLocalizations
+	case *discord.BooleanOption:
+		name, desc = &opt.OptionNameLocalizations, &opt.DescriptionLocalizations
+	case *discord.IntegerOption:
+		name, desc = &opt.OptionNameLocalizations, &opt.DescriptionLocalizations
+	case *discord.ChannelOption:
+		name, desc = &opt.OptionNameLocalizations, &opt.DescriptionLocalizations
+	case *discord.UserOption:
+		name, desc = &opt.OptionNameLocalizations, &opt.DescriptionLocalizations
+	default:
+		return
+	}
+	key := prefix + "." + opt.Name()
+	*name = localize(key + ".name")
+	*desc = localize(key + ".description")
+}
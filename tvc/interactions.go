@@ -0,0 +1,791 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/utils/json/option"
+)
+
+// commands returns the application commands registered on startup.
+func (h *handler) commands() []api.CreateCommandData {
+	return []api.CreateCommandData{
+		{
+			Name:           "voice",
+			Description:    "Manage your temporary voice channel",
+			NoDMPermission: true,
+			Options: discord.CommandOptions{
+				&discord.SubcommandOption{
+					OptionName:  "password",
+					Description: "Require a password to join your room, or remove it",
+					Options: []discord.CommandOptionValue{
+						&discord.StringOption{
+							OptionName:  "secret",
+							Description: "The password; leave empty to remove it",
+							MaxLength:   option.NewInt(100),
+						},
+					},
+				},
+				&discord.SubcommandOption{
+					OptionName:  "link",
+					Description: "Link your room to a game lobby, or remove the link",
+					Options: []discord.CommandOptionValue{
+						&discord.StringOption{
+							OptionName:  "lobby_code",
+							Description: "The lobby code; leave empty to remove it",
+							MaxLength:   option.NewInt(maxLobbyCode),
+						},
+					},
+				},
+				&discord.SubcommandOption{
+					OptionName:  "ban",
+					Description: "Keep someone out of your room and your future rooms",
+					Options: []discord.CommandOptionValue{
+						&discord.UserOption{
+							OptionName:  "user",
+							Description: "Who to ban",
+							Required:    true,
+						},
+					},
+				},
+				&discord.SubcommandOption{
+					OptionName:  "unban",
+					Description: "Let someone you banned join your rooms again",
+					Options: []discord.CommandOptionValue{
+						&discord.UserOption{
+							OptionName:  "user",
+							Description: "Who to unban",
+							Required:    true,
+						},
+					},
+				},
+				&discord.SubcommandOption{
+					OptionName:  "votekick",
+					Description: "Start a vote among the people in your room on disconnecting someone",
+					Options: []discord.CommandOptionValue{
+						&discord.UserOption{
+							OptionName:  "user",
+							Description: "Who to vote out",
+							Required:    true,
+						},
+					},
+				},
+				&discord.SubcommandGroupOption{
+					OptionName:  "vote",
+					Description: "Let the people in your room vote on a change",
+					Subcommands: []*discord.SubcommandOption{
+						{
+							OptionName:  "rename",
+							Description: "Vote on a new name for the room",
+							Options: []discord.CommandOptionValue{
+								&discord.StringOption{
+									OptionName:  "name",
+									Description: "The proposed name",
+									Required:    true,
+									MaxLength:   option.NewInt(100),
+								},
+							},
+						},
+						{
+							OptionName:  "limit",
+							Description: "Vote on the room's user limit",
+							Options: []discord.CommandOptionValue{
+								&discord.IntegerOption{
+									OptionName:  "users",
+									Description: "The proposed limit; 0 removes it",
+									Required:    true,
+									Min:         option.NewInt(0),
+									Max:         option.NewInt(99),
+								},
+							},
+						},
+					},
+				},
+				&discord.SubcommandOption{
+					OptionName:  "overlay",
+					Description: "Get a private link that streams who joins and leaves your room",
+				},
+				&discord.SubcommandOption{
+					OptionName:  "dnd",
+					Description: "Toggle do not disturb, which hides your room from the rooms board",
+				},
+				&discord.SubcommandOption{
+					OptionName:  "convert",
+					Description: "Recreate your room as a stage or voice channel, moving everyone over",
+					Options: []discord.CommandOptionValue{
+						&discord.StringOption{
+							OptionName:  "type",
+							Description: "The kind of channel to turn your room into",
+							Required:    true,
+							Choices:     convertChoices(),
+						},
+					},
+				},
+				&discord.SubcommandOption{
+					OptionName:  "reserve",
+					Description: "Reserve a room name for later; the room is created for you then",
+					Options: []discord.CommandOptionValue{
+						&discord.StringOption{
+							OptionName:  "name",
+							Description: "The name of the room",
+							Required:    true,
+							MaxLength:   option.NewInt(100),
+						},
+						&discord.StringOption{
+							OptionName:  "time",
+							Description: "When, as a delay like 90m or a UTC time like 2024-05-01 18:30",
+							Required:    true,
+						},
+					},
+				},
+				&discord.SubcommandOption{
+					OptionName:  "unreserve",
+					Description: "Cancel one of your room reservations",
+					Options: []discord.CommandOptionValue{
+						&discord.StringOption{
+							OptionName:  "name",
+							Description: "The name you reserved",
+							Required:    true,
+						},
+					},
+				},
+				&discord.SubcommandOption{
+					OptionName:  "link-card",
+					Description: "Post a card with a link and QR code to your room, to show at events",
+					Options: []discord.CommandOptionValue{
+						&discord.BooleanOption{
+							OptionName:  "qr",
+							Description: "Whether to include a QR code of the link; on by default",
+						},
+					},
+				},
+				&discord.SubcommandOption{
+					OptionName:  "summon",
+					Description: "Ping the people you let into your room, or a role, to come join",
+					Options: []discord.CommandOptionValue{
+						&discord.RoleOption{
+							OptionName:  "role",
+							Description: "A role to ping instead of the people you let in",
+						},
+					},
+				},
+				&discord.SubcommandOption{
+					OptionName:  "watch",
+					Description: "Start Watch Together or another activity in your room",
+					Options: []discord.CommandOptionValue{
+						&discord.StringOption{
+							OptionName:  "activity",
+							Description: "The activity to start; Watch Together by default",
+							Choices:     watchChoices(),
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:           "room",
+			Description:    "Change the temporary voice channel you own",
+			NoDMPermission: true,
+			Options: discord.CommandOptions{
+				&discord.SubcommandOption{
+					OptionName:  "rename",
+					Description: "Rename your room",
+					Options: []discord.CommandOptionValue{
+						&discord.StringOption{
+							OptionName:  "name",
+							Description: "The new name",
+							Required:    true,
+							MaxLength:   option.NewInt(100),
+						},
+					},
+				},
+				&discord.SubcommandOption{
+					OptionName:  "limit",
+					Description: "Set how many people can join your room",
+					Options: []discord.CommandOptionValue{
+						&discord.IntegerOption{
+							OptionName:  "users",
+							Description: "The user limit; 0 removes it",
+							Required:    true,
+							Min:         option.NewInt(0),
+							Max:         option.NewInt(99),
+						},
+					},
+				},
+				&discord.SubcommandOption{
+					OptionName:  "lock",
+					Description: "Stop new people from joining your room",
+				},
+				&discord.SubcommandOption{
+					OptionName:  "unlock",
+					Description: "Let everyone join your room again",
+				},
+				&discord.SubcommandOption{
+					OptionName:  "kick",
+					Description: "Disconnect someone from your room",
+					Options: []discord.CommandOptionValue{
+						&discord.UserOption{
+							OptionName:  "user",
+							Description: "Who to kick",
+							Required:    true,
+						},
+					},
+				},
+				&discord.SubcommandOption{
+					OptionName:  "invite",
+					Description: "Let someone into your room, even if it's locked or private",
+					Options: []discord.CommandOptionValue{
+						&discord.UserOption{
+							OptionName:  "user",
+							Description: "Who to invite",
+							Required:    true,
+						},
+					},
+				},
+				&discord.SubcommandOption{
+					OptionName:  "transfer",
+					Description: "Hand your room to someone in it",
+					Options: []discord.CommandOptionValue{
+						&discord.UserOption{
+							OptionName:  "user",
+							Description: "The new owner",
+							Required:    true,
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:                     "tournament",
+			Description:              "Keep score between team rooms",
+			DefaultMemberPermissions: discord.NewPermissions(discord.PermissionManageEvents),
+			NoDMPermission:           true,
+			Options: discord.CommandOptions{
+				&discord.SubcommandOption{
+					OptionName:  "start",
+					Description: "Open a read-only scoreboard channel",
+					Options: []discord.CommandOptionValue{
+						&discord.StringOption{
+							OptionName:  "name",
+							Description: "The tournament's name",
+							MaxLength:   option.NewInt(100),
+						},
+					},
+				},
+				&discord.SubcommandOption{
+					OptionName:  "score",
+					Description: "Set a team's score on the scoreboard",
+					Options: []discord.CommandOptionValue{
+						&discord.ChannelOption{
+							OptionName:   "team",
+							Description:  "The team room's voice channel",
+							Required:     true,
+							ChannelTypes: []discord.ChannelType{discord.GuildVoice},
+						},
+						&discord.IntegerOption{
+							OptionName:  "points",
+							Description: "The team's score",
+							Required:    true,
+						},
+					},
+				},
+				&discord.SubcommandOption{
+					OptionName:  "end",
+					Description: "End the tournament and delete the scoreboard",
+				},
+			},
+		},
+		{
+			// operator is only available in DMs; see commandContexts.
+			Name:        "operator",
+			Description: "Manage the bot across all servers",
+			Options: discord.CommandOptions{
+				&discord.SubcommandOption{
+					OptionName:  "stats",
+					Description: "Show bot-wide stats",
+				},
+				&discord.SubcommandOption{
+					OptionName:  "guilds",
+					Description: "List the servers the bot is in",
+				},
+				&discord.SubcommandOption{
+					OptionName:  "leave",
+					Description: "Make the bot leave a server",
+					Options: []discord.CommandOptionValue{
+						&discord.StringOption{
+							OptionName:  "guild_id",
+							Description: "The server's ID",
+							Required:    true,
+						},
+					},
+				},
+				&discord.SubcommandOption{
+					OptionName:  "feature",
+					Description: "Turn a feature on or off for every server that doesn't override it",
+					Options: []discord.CommandOptionValue{
+						&discord.StringOption{
+							OptionName:  "name",
+							Description: "The feature",
+							Required:    true,
+							Choices:     featureChoices(),
+						},
+						&discord.BooleanOption{
+							OptionName:  "enabled",
+							Description: "Whether the feature is on",
+							Required:    true,
+						},
+					},
+				},
+				&discord.SubcommandOption{
+					OptionName:  "kill",
+					Description: "Stop creating, deleting and moving anything, everywhere or in one server",
+					Options: []discord.CommandOptionValue{
+						&discord.BooleanOption{
+							OptionName:  "enabled",
+							Description: "Whether the kill switch is on",
+							Required:    true,
+						},
+						&discord.StringOption{
+							OptionName:  "guild_id",
+							Description: "The server's ID; leave empty for every server",
+						},
+					},
+				},
+			},
+		},
+		{
+			// preferences is user-installable, so it works in DMs and in servers
+			// without the bot too. See commandContexts.
+			Name:        "preferences",
+			Description: "Manage your settings and see your stats",
+			Options: discord.CommandOptions{
+				&discord.SubcommandOption{
+					OptionName:  "privacy",
+					Description: "Choose whether your voice activity counts towards room stats",
+					Options: []discord.CommandOptionValue{
+						&discord.BooleanOption{
+							OptionName:  "share_activity",
+							Description: "Whether to count your activity",
+							Required:    true,
+						},
+					},
+				},
+				&discord.SubcommandOption{
+					OptionName:  "summary",
+					Description: "Choose whether to get a DM summing up your rooms once they're deleted",
+					Options: []discord.CommandOptionValue{
+						&discord.BooleanOption{
+							OptionName:  "enabled",
+							Description: "Whether to send summaries",
+							Required:    true,
+						},
+					},
+				},
+				&discord.SubcommandOption{
+					OptionName:  "stats",
+					Description: "Show how much you have used rooms",
+				},
+			},
+		},
+		{
+			Name:                     "voiceadmin",
+			Description:              "Manage temporary voice channels",
+			DefaultMemberPermissions: discord.NewPermissions(discord.PermissionManageGuild),
+			NoDMPermission:           true,
+			Options: discord.CommandOptions{
+				&discord.SubcommandOption{
+					OptionName:  "validate",
+					Description: "Check hubs, permissions and templates for problems",
+				},
+				&discord.SubcommandOption{
+					OptionName:  "whois",
+					Description: "Show who created a room and who was in it, even after it's deleted",
+					Options: []discord.CommandOptionValue{
+						&discord.StringOption{
+							OptionName:  "channel",
+							Description: "The room's mention or ID",
+							Required:    true,
+						},
+					},
+				},
+				&discord.SubcommandOption{
+					OptionName:  "note",
+					Description: "Leave a note on a room that only moderators see",
+					Options: []discord.CommandOptionValue{
+						&discord.StringOption{
+							OptionName:  "channel",
+							Description: "The room's mention or ID",
+							Required:    true,
+						},
+						&discord.StringOption{
+							OptionName:  "text",
+							Description: "The note",
+							Required:    true,
+							MaxLength:   option.NewInt(500),
+						},
+					},
+				},
+				&discord.SubcommandOption{
+					OptionName:  "maintenance",
+					Description: "Pause room creation while you deploy or debug",
+					Options: []discord.CommandOptionValue{
+						&discord.BooleanOption{
+							OptionName:  "enabled",
+							Description: "Whether to pause room creation",
+							Required:    true,
+						},
+					},
+				},
+				&discord.SubcommandOption{
+					OptionName:  "import",
+					Description: "Adopt rooms made by another temporary channel bot",
+					Options: []discord.CommandOptionValue{
+						&discord.StringOption{
+							OptionName:  "pattern",
+							Description: "Regular expression matching the names of their channels and categories",
+						},
+						&discord.StringOption{
+							OptionName:  "channels",
+							Description: "Mentions or IDs of the channels and categories to adopt",
+						},
+					},
+				},
+				&discord.SubcommandOption{
+					OptionName:  "audit",
+					Description: "Show who may see and join a room, and how it differs from its hub's template",
+					Options: []discord.CommandOptionValue{
+						&discord.ChannelOption{
+							OptionName:   "channel",
+							Description:  "The room",
+							Required:     true,
+							ChannelTypes: []discord.ChannelType{discord.GuildVoice},
+						},
+					},
+				},
+				&discord.SubcommandOption{
+					OptionName:  "freeze",
+					Description: "Lock a room and keep it from being deleted while you look into an incident",
+					Options: []discord.CommandOptionValue{
+						&discord.ChannelOption{
+							OptionName:   "channel",
+							Description:  "The room",
+							Required:     true,
+							ChannelTypes: []discord.ChannelType{discord.GuildVoice},
+						},
+					},
+				},
+				&discord.SubcommandOption{
+					OptionName:  "unfreeze",
+					Description: "Restore a frozen room as it was and let it be deleted again",
+					Options: []discord.CommandOptionValue{
+						&discord.ChannelOption{
+							OptionName:   "channel",
+							Description:  "The room",
+							Required:     true,
+							ChannelTypes: []discord.ChannelType{discord.GuildVoice},
+						},
+					},
+				},
+				&discord.SubcommandOption{
+					OptionName:  "feature",
+					Description: "Move a room to the top and highlight it on the rooms board, or stop featuring it",
+					Options: []discord.CommandOptionValue{
+						&discord.ChannelOption{
+							OptionName:   "channel",
+							Description:  "The room",
+							Required:     true,
+							ChannelTypes: []discord.ChannelType{discord.GuildVoice},
+						},
+					},
+				},
+				&discord.SubcommandOption{
+					OptionName:  "rename-all",
+					Description: "Rename every room with a new name template",
+					Options: []discord.CommandOptionValue{
+						&discord.StringOption{
+							OptionName:  "template",
+							Description: "The new template, e.g. {username}'s room",
+							Required:    true,
+						},
+					},
+				},
+				&discord.SubcommandGroupOption{
+					OptionName:  "template",
+					Description: "Manage the permissions hubs give their rooms",
+					Subcommands: []*discord.SubcommandOption{
+						{
+							OptionName:  "edit",
+							Description: "Edit the role overwrites a hub's rooms get",
+							Options: []discord.CommandOptionValue{
+								&discord.StringOption{
+									OptionName:  "hub",
+									Description: "The hub's key or name",
+									Required:    true,
+								},
+							},
+						},
+					},
+				},
+				&discord.SubcommandGroupOption{
+					OptionName:  "staging",
+					Description: "Mirror the production server's hubs in this staging server",
+					Subcommands: []*discord.SubcommandOption{
+						{
+							OptionName:  "sync",
+							Description: "Copy the production server's hub channels and template roles here",
+						},
+					},
+				},
+				&discord.SubcommandGroupOption{
+					OptionName:  "profile",
+					Description: "Switch between event profiles",
+					Subcommands: []*discord.SubcommandOption{
+						{
+							OptionName:  "apply",
+							Description: "Switch the server to a profile",
+							Options: []discord.CommandOptionValue{
+								&discord.StringOption{
+									OptionName:  "name",
+									Description: "The profile",
+									Required:    true,
+									Choices:     h.profileChoices(),
+								},
+								&discord.StringOption{
+									OptionName:  "after",
+									Description: "Wait this long first, e.g. 2h",
+								},
+							},
+						},
+					},
+				},
+				&discord.SubcommandOption{
+					OptionName:  "teardown",
+					Description: "Delete temporary rooms in bulk",
+					Options: []discord.CommandOptionValue{
+						&discord.StringOption{
+							OptionName:  "older_than",
+							Description: "Only rooms created longer ago than this, e.g. 2h",
+						},
+						&discord.BooleanOption{
+							OptionName:  "empty_only",
+							Description: "Only rooms nobody is connected to",
+						},
+						&discord.StringOption{
+							OptionName:  "hub",
+							Description: "Only rooms created from the hub with this name",
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:           contextRoomCommand,
+			Type:           discord.MessageCommand,
+			NoDMPermission: true,
+		},
+		{
+			Name:           inviteCommand,
+			Type:           discord.UserCommand,
+			NoDMPermission: true,
+		},
+	}
+}
+
+// modalHandler handles the submission of a modal.
+type modalHandler func(ctx context.Context, ev *discord.InteractionEvent, data *discord.ModalInteraction) *api.InteractionResponse
+
+// interactionRouter adds modal submissions, which cmdroute doesn't route, on
+// top of a cmdroute.Router.
+type interactionRouter struct {
+	*cmdroute.Router
+	modals map[discord.ComponentID]modalHandler
+}
+
+// HandleInteraction implements webhook.InteractionHandler.
+func (r *interactionRouter) HandleInteraction(ev *discord.InteractionEvent) *api.InteractionResponse {
+	if data, ok := ev.Data.(*discord.ModalInteraction); ok {
+		if fn, ok := r.modals[data.CustomID]; ok {
+			return fn(context.Background(), ev, data)
+		}
+		return nil
+	}
+	return r.Router.HandleInteraction(ev)
+}
+
+// newRouter builds the router for the bot's interactions.
+func (h *handler) newRouter() *interactionRouter {
+	r := cmdroute.NewRouter()
+	r.Sub("voice", func(r *cmdroute.Router) {
+		r.AddFunc("password", h.cmdPassword)
+		r.AddFunc("dnd", h.cmdDND)
+		r.AddFunc("convert", h.cmdConvert)
+		r.AddFunc("summon", h.cmdSummon)
+		r.AddFunc("watch", h.cmdWatch)
+		r.AddFunc("link-card", h.cmdLinkCard)
+		r.AddFunc("reserve", h.cmdReserve)
+		r.AddFunc("unreserve", h.cmdUnreserve)
+		r.AddFunc("link", h.cmdLink)
+		r.AddFunc("overlay", h.cmdOverlay)
+		r.AddFunc("ban", h.cmdBan)
+		r.AddFunc("unban", h.cmdUnban)
+		r.AddFunc("votekick", h.cmdVoteKick)
+		r.Sub("vote", func(r *cmdroute.Router) {
+			r.AddFunc("rename", h.cmdVoteRename)
+			r.AddFunc("limit", h.cmdVoteLimit)
+		})
+	})
+	r.Sub("room", func(r *cmdroute.Router) {
+		r.AddFunc("rename", h.cmdRoomRename)
+		r.AddFunc("limit", h.cmdRoomLimit)
+		r.AddFunc("lock", h.cmdRoomLock)
+		r.AddFunc("unlock", h.cmdRoomUnlock)
+		r.AddFunc("kick", h.cmdRoomKick)
+		r.AddFunc("transfer", h.cmdRoomTransfer)
+		r.AddFunc("invite", h.cmdRoomInvite)
+	})
+	r.Sub("tournament", func(r *cmdroute.Router) {
+		r.AddFunc("start", h.cmdTournamentStart)
+		r.AddFunc("score", h.cmdTournamentScore)
+		r.AddFunc("end", h.cmdTournamentEnd)
+	})
+	r.Sub("operator", func(r *cmdroute.Router) {
+		r.AddFunc("stats", h.operatorOnly(h.cmdOperatorStats))
+		r.AddFunc("guilds", h.operatorOnly(h.cmdOperatorGuilds))
+		r.AddFunc("leave", h.operatorOnly(h.cmdOperatorLeave))
+		r.AddFunc("feature", h.operatorOnly(h.cmdOperatorFeature))
+		r.AddFunc("kill", h.operatorOnly(h.cmdOperatorKill))
+	})
+	r.Sub("preferences", func(r *cmdroute.Router) {
+		r.AddFunc("privacy", h.cmdPrivacy)
+		r.AddFunc("summary", h.cmdSummary)
+		r.AddFunc("stats", h.cmdStats)
+	})
+	r.AddFunc(contextRoomCommand, h.cmdContextRoom)
+	r.AddFunc(inviteCommand, h.cmdInvite)
+	r.Sub("voiceadmin", func(r *cmdroute.Router) {
+		r.AddFunc("validate", h.cmdValidate)
+		r.AddFunc("teardown", h.cmdTeardown)
+		r.AddFunc("whois", h.cmdWhois)
+		r.AddFunc("note", h.cmdNote)
+		r.AddFunc("rename-all", h.cmdRenameAll)
+		r.AddFunc("feature", h.cmdFeature)
+		r.AddFunc("audit", h.cmdAudit)
+		r.AddFunc("freeze", h.cmdFreeze)
+		r.AddFunc("unfreeze", h.cmdUnfreeze)
+		r.AddFunc("import", h.cmdImport)
+		r.AddFunc("maintenance", h.cmdMaintenance)
+		r.Sub("template", func(r *cmdroute.Router) {
+			r.AddFunc("edit", h.cmdTemplateEdit)
+		})
+		r.Sub("staging", func(r *cmdroute.Router) {
+			r.AddFunc("sync", h.cmdStagingSync)
+		})
+		r.Sub("profile", func(r *cmdroute.Router) {
+			r.AddFunc("apply", h.cmdProfileApply)
+		})
+	})
+	r.AddComponentFunc(keepAliveID, h.onKeepAlive)
+	for i := range h.cfg.launchers {
+		r.AddComponentFunc(launcherID(i), h.onLaunch(i))
+	}
+	r.AddComponentFunc(passwordButtonID, h.onPasswordButton)
+	r.AddComponentFunc(rulesAcceptID, h.onRulesAccept)
+	r.AddComponentFunc(teardownConfirmID, h.onTeardownConfirm)
+	r.AddComponentFunc(teardownCancelID, h.onTeardownCancel)
+	r.AddComponentFunc(modBanID, h.onModBan)
+	r.AddComponentFunc(modDeleteID, h.onModDelete)
+	r.AddComponentFunc(modDismissID, h.onModDismiss)
+	r.AddComponentFunc(setupButtonID, h.onSetup)
+	r.AddComponentFunc(setupAdoptID, h.onSetupAdopt)
+	r.AddComponentFunc(presetSelectID, h.onPresetSelect)
+	r.AddComponentFunc(voteKickYesID, h.onKickVote(true))
+	r.AddComponentFunc(voteKickNoID, h.onKickVote(false))
+	r.AddComponentFunc(settingsVoteYesID, h.onSettingsVote(true))
+	r.AddComponentFunc(settingsVoteNoID, h.onSettingsVote(false))
+	r.AddComponentFunc(privateInviteID, h.onPrivateInvite)
+	r.AddComponentFunc(reloadConfirmID, h.onReloadConfirm)
+	r.AddComponentFunc(reloadCancelID, h.onReloadCancel)
+	r.AddComponentFunc(cleanupConfirmID, h.onCleanupConfirm)
+	r.AddComponentFunc(cleanupCancelID, h.onCleanupCancel)
+	r.AddComponentFunc(templateRoleID, h.onTemplateRole)
+	r.AddComponentFunc(templateSaveID, h.onTemplateSave)
+	r.AddComponentFunc(templateDiscardID, h.onTemplateDiscard)
+	for i := range maxClaimCandidates {
+		r.AddComponentFunc(claimVoteID(i), h.onClaimVote(i))
+	}
+
+	return &interactionRouter{
+		Router: r,
+		modals: map[discord.ComponentID]modalHandler{
+			passwordModalID: h.onPasswordModal,
+			templateModalID: h.onTemplateModal,
+		},
+	}
+}
+
+// Commands returns the localized application commands the manager handles,
+// for bots that register commands themselves; see Config.SkipCommands. The
+// install contexts the bot sets itself aren't part of api.CreateCommandData.
+func (m *Manager) Commands() []api.CreateCommandData {
+	return localizeCommands(m.h.commands())
+}
+
+// registerCommands brings the application's global commands, or the ones of
+// the guilds in $COMMAND_GUILD_IDS, up to date with commands.
+func (h *handler) registerCommands() {
+	if h.skipCommands {
+		return
+	}
+	cmds := localizeCommands(h.commands())
+	if len(h.cfg.commandGuilds) == 0 {
+		if err := h.syncCommands(discord.NullGuildID, cmds); err != nil {
+			slog.Error("Failed to register commands", "err", err)
+		}
+		return
+	}
+	for _, guildID := range h.cfg.commandGuilds {
+		if err := h.syncCommands(guildID, cmds); err != nil {
+			slog.Error("Failed to register commands", "guild_id", guildID, "err", err)
+		}
+	}
+}
+
+// ephemeral builds a reply only the interacting user can see.
+func ephemeral(content string) *api.InteractionResponse {
+	return &api.InteractionResponse{
+		Type: api.MessageInteractionWithSource,
+		Data: ephemeralData(content),
+	}
+}
+
+// ephemeralData is the data of an ephemeral reply, for command handlers.
+func ephemeralData(content string) *api.InteractionResponseData {
+	return &api.InteractionResponseData{
+		Content: option.NewNullableString(content),
+		Flags:   discord.EphemeralMessage,
+	}
+}
+
+// formatDuration renders d for humans, e.g. "30 minutes" or "2 hours".
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= time.Hour && d%time.Hour == 0:
+		return plural(int(d/time.Hour), "hour")
+	case d >= time.Minute && d%time.Minute == 0:
+		return plural(int(d/time.Minute), "minute")
+	default:
+		return d.String()
+	}
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
@@ -0,0 +1,82 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/diamondburned/arikawa/v3/utils/json/option"
+)
+
+// inviteCommand is the name of the user context menu command that lets
+// someone into the invoker's room.
+const inviteCommand = "Invite to my room"
+
+// cmdInvite handles the invite context menu command.
+func (h *handler) cmdInvite(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	return h.invite(ctx, data.Event.GuildID, data.Event.SenderID(), data.Data.TargetUserID())
+}
+
+// cmdRoomInvite handles /room invite.
+func (h *handler) cmdRoomInvite(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	target, err := data.Options.Find("user").SnowflakeValue()
+	if err != nil {
+		return ephemeralData("Pick who to invite.")
+	}
+	return h.invite(ctx, data.Event.GuildID, data.Event.SenderID(), discord.UserID(target))
+}
+
+// invite grants userID access to ownerID's room, even if it's locked or
+// private, and pings them with a button to join.
+func (h *handler) invite(ctx context.Context, guildID discord.GuildID, ownerID, userID discord.UserID) *api.InteractionResponseData {
+	if userID == ownerID {
+		return ephemeralData("You're already welcome in your own room.")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	r := h.ownedRoomOf(ownerID)
+	if r == nil {
+		if r = h.newestRoomOf(ownerID); r == nil || r.guildID != guildID {
+			return ephemeralData("You don't have a room to invite them to.")
+		}
+	}
+	if slices.Contains(h.stats.roomBansOf(guildID, ownerID), userID) {
+		return ephemeralData(userID.Mention() + " is banned from your rooms; use /voice unban first.")
+	}
+
+	if err := h.letIn(withGuild(ctx, guildID), r, userID); err != nil {
+		slog.ErrorContext(ctx, "Failed to invite member to room", "err", err)
+		return ephemeralData("Sorry, I couldn't let them in. Try again later.")
+	}
+
+	return &api.InteractionResponseData{
+		Content: option.NewNullableString(fmt.Sprintf("%s, %s invited you to %s.", userID.Mention(), ownerID.Mention(), r.channelID.Mention())),
+		Components: &discord.ContainerComponents{
+			&discord.ActionRowComponent{
+				&discord.ButtonComponent{
+					Style: discord.LinkButtonStyle(discord.URL(fmt.Sprintf("https://discord.com/channels/%s/%s", guildID, r.channelID))),
+					Label: "Join",
+				},
+			},
+		},
+		AllowedMentions: &api.AllowedMentions{Users: []discord.UserID{userID}},
+	}
+}
+
+// letIn gives userID a member overwrite to see and connect to the room.
+func (h *handler) letIn(ctx context.Context, r *room, userID discord.UserID) error {
+	return h.call(ctx, "EditChannelPermission", func(s *state.State) error {
+		return s.EditChannelPermission(r.channelID, discord.Snowflake(userID), api.EditChannelPermissionData{
+			Type:           discord.OverwriteMember,
+			Allow:          discord.PermissionViewChannel | discord.PermissionConnect,
+			AuditLogReason: "invited by the room owner",
+		})
+	})
+}
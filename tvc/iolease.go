@@ -0,0 +1,38 @@
+package tvc
+
+import "context"
+
+// ioLease lets the Discord calls made with a context give up h.mu while they
+// wait, so a slow or rate limited request in one guild doesn't stall every
+// other guild. Only code that holds h.mu may hold a lease, and handler state
+// may change across any of its calls: rooms it keeps using after one must be
+// looked up again, and fn must not change handler state.
+type ioLease struct {
+	active bool
+}
+
+type ioLeaseKey struct{}
+
+// releaseForIO returns a context whose Discord calls and move waits release
+// h.mu while they run. The lease ends when end is called, after which calls
+// with the context keep h.mu again.
+func releaseForIO(ctx context.Context) (_ context.Context, end func()) {
+	lease := &ioLease{active: true}
+	return context.WithValue(ctx, ioLeaseKey{}, lease), func() { lease.active = false }
+}
+
+// leased reports whether ctx holds an active lease.
+func leased(ctx context.Context) bool {
+	lease, _ := ctx.Value(ioLeaseKey{}).(*ioLease)
+	return lease != nil && lease.active
+}
+
+// unlocked runs fn without h.mu if ctx holds an active lease, and with it
+// otherwise. h.mu must be held.
+func (h *handler) unlocked(ctx context.Context, fn func()) {
+	if leased(ctx) {
+		h.mu.Unlock()
+		defer h.mu.Lock()
+	}
+	fn()
+}
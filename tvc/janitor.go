@@ -0,0 +1,336 @@
+package tvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/diamondburned/arikawa/v3/utils/httputil"
+)
+
+// maxDeleteAttempts is how many failed attempts at deleting a channel are
+// made before it is abandoned: reported to the log channel and listed as
+// abandoned. Attempts carry on after that, maxDeleteBackoff apart.
+const (
+	maxDeleteAttempts = 8
+	maxDeleteBackoff  = time.Hour
+)
+
+// deleteRetry is a channel deletion that failed and is tried again by the
+// janitor until the channel is confirmed gone. It is persisted, so retries
+// carry on after a restart.
+type deleteRetry struct {
+	GuildID  discord.GuildID    `json:"guild_id"`
+	Reason   api.AuditLogReason `json:"reason,omitempty"`
+	Attempts int                `json:"attempts"`
+	Next     time.Time          `json:"next"`
+	// Reported is set once the failures were posted to the log channel.
+	Reported bool `json:"reported,omitempty"`
+	// Abandoned is when the deletion was given up on as failing for good.
+	Abandoned time.Time `json:"abandoned,omitempty"`
+	// LastError is why the last attempt failed.
+	LastError string `json:"last_error,omitempty"`
+}
+
+// runJanitor sweeps rooms every interval until ctx is done.
+func (h *handler) runJanitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.mu.Lock()
+			h.retryDeletes(context.Background())
+			h.sweepEmptyRooms()
+			h.sweepEmptyCategories()
+			h.mu.Unlock()
+		}
+	}
+}
+
+// loadDeleteRetries picks up the deletions that were still being retried
+// before a restart.
+func (h *handler) loadDeleteRetries() {
+	for channelID, retry := range h.stats.pendingDeletes() {
+		if retry.Reported && retry.Abandoned.IsZero() {
+			// Recorded before deletions were abandoned.
+			retry.Abandoned = time.Now()
+		}
+		h.deleteRetries[channelID] = &retry
+		h.created[channelID] = true
+	}
+}
+
+// retryDelete queues another attempt at deleting channelID after err,
+// backing off exponentially between attempts. Requests the kill switch or
+// observer mode blocked don't count as attempts. Deletions that keep failing
+// are abandoned, and reported to the guild's log channel once.
+func (h *handler) retryDelete(ctx context.Context, guildID discord.GuildID, channelID discord.ChannelID, reason api.AuditLogReason, err error) {
+	retry, ok := h.deleteRetries[channelID]
+	if !ok {
+		retry = &deleteRetry{GuildID: guildID, Reason: reason}
+		h.deleteRetries[channelID] = retry
+	}
+	if !errors.Is(err, errKilled) && !errors.Is(err, errObserving) {
+		retry.Attempts++
+	}
+	backoff := maxDeleteBackoff
+	if retry.Attempts <= maxDeleteAttempts {
+		backoff = min(30*time.Second<<max(retry.Attempts-1, 0), maxDeleteBackoff)
+	}
+	retry.Next = time.Now().Add(backoff)
+	retry.LastError = err.Error()
+
+	if retry.Attempts >= maxDeleteAttempts && retry.Abandoned.IsZero() {
+		retry.Abandoned = time.Now()
+	}
+	if !retry.Abandoned.IsZero() && !retry.Reported {
+		retry.Reported = true
+		slog.ErrorContext(ctx, "Channel deletion abandoned", "channel_id", channelID, "attempts", retry.Attempts, "err", err)
+		h.postLog(ctx, guildID, fmt.Sprintf("⚠️ I couldn't delete %s after %s: %v\nI'll keep trying every %s. Check my permissions on it, or delete it yourself.",
+			channelID.Mention(), plural(retry.Attempts, "attempt"), err, formatDuration(maxDeleteBackoff)))
+	}
+	h.stats.setPendingDelete(channelID, *retry)
+}
+
+// forgetDeleteRetry drops the queued deletion of channelID, if any.
+func (h *handler) forgetDeleteRetry(channelID discord.ChannelID) {
+	if _, ok := h.deleteRetries[channelID]; ok {
+		delete(h.deleteRetries, channelID)
+		h.stats.dropPendingDelete(channelID)
+	}
+}
+
+// retryDeletes tries the queued deletions that are due again. ctx may hold
+// an I/O lease.
+func (h *handler) retryDeletes(ctx context.Context) {
+	due := make(map[discord.ChannelID]*deleteRetry)
+	for channelID, retry := range h.deleteRetries {
+		if !time.Now().Before(retry.Next) {
+			due[channelID] = retry
+		}
+	}
+	for channelID, retry := range due {
+		if h.deleteRetries[channelID] != retry || !h.breaker.allow() {
+			continue
+		}
+		if !h.created[channelID] {
+			// Deleted some other way since.
+			h.forgetDeleteRetry(channelID)
+			continue
+		}
+		attempt := retry.Attempts + 1
+		if err := h.deleteChannel(withGuild(ctx, retry.GuildID), channelID, retry.Reason); err != nil {
+			slog.Warn("Retry of deleting channel failed", "guild_id", retry.GuildID, "channel_id", channelID, "attempt", attempt, "err", err)
+			continue
+		}
+		slog.Info("Deleted channel on retry", "guild_id", retry.GuildID, "channel_id", channelID, "attempt", attempt)
+		if !retry.Abandoned.IsZero() {
+			h.postLog(withGuild(ctx, retry.GuildID), retry.GuildID, fmt.Sprintf("✅ I finally deleted channel `%s`, after %s.",
+				channelID, plural(attempt, "attempt")))
+		}
+	}
+}
+
+// abandonedChannels returns the channels in guildID, or every guild if it is
+// null, whose deletion was abandoned.
+func (h *handler) abandonedChannels(guildID discord.GuildID) map[discord.ChannelID]deleteRetry {
+	abandoned := make(map[discord.ChannelID]deleteRetry)
+	for channelID, retry := range h.deleteRetries {
+		if !retry.Abandoned.IsZero() && (!guildID.IsValid() || retry.GuildID == guildID) {
+			abandoned[channelID] = *retry
+		}
+	}
+	return abandoned
+}
+
+// verifyDeleted looks channelID up again, bypassing the cache, and returns
+// an error if Discord still has it. Lookup failures are given the benefit of
+// the doubt.
+func (h *handler) verifyDeleted(ctx context.Context, channelID discord.ChannelID) error {
+	if !h.cfg.verifyDeletes {
+		return nil
+	}
+	var exists bool
+	err := h.call(ctx, "GetChannel", func(s *state.State) error {
+		_, err := s.Client.Channel(channelID)
+		if isNotFound(err) {
+			return nil
+		}
+		exists = err == nil
+		return err
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to verify channel was deleted", "channel_id", channelID, "err", err)
+		return nil
+	}
+	if exists {
+		return fmt.Errorf("channel %s still exists after being deleted", channelID)
+	}
+	return nil
+}
+
+// isNotFound reports whether err is Discord saying the resource doesn't
+// exist.
+func isNotFound(err error) bool {
+	var httpErr *httputil.HTTPError
+	return errors.As(err, &httpErr) && httpErr.Status == http.StatusNotFound
+}
+
+// sweepEmptyRooms deletes rooms that have sat empty for janitorEmptyAfter
+// without a deletion being scheduled for them.
+func (h *handler) sweepEmptyRooms() {
+	due := make(map[discord.GuildID][]*room)
+	for _, r := range h.rooms {
+		if h.roomOccupants(r) > 0 || r.deleteTimer != nil || h.isFrozen(r.channelID) {
+			r.sweptEmpty = time.Time{}
+			continue
+		}
+		if r.sweptEmpty.IsZero() {
+			r.sweptEmpty = time.Now()
+			continue
+		}
+		if time.Since(r.sweptEmpty) < h.cfg.janitorEmptyAfter || h.heldCleanups[r.guildID] != nil {
+			continue
+		}
+		due[r.guildID] = append(due[r.guildID], r)
+	}
+
+	for guildID, rooms := range due {
+		channels := 0
+		for _, r := range rooms {
+			channels += roomChannels(r)
+		}
+		run := func(ctx context.Context) {
+			for _, r := range rooms {
+				if h.rooms[r.channelID] != r || h.roomOccupants(r) > 0 {
+					continue
+				}
+				slog.Info("Deleting room left empty", "guild_id", r.guildID, "channel_id", r.channelID, "empty_since", r.sweptEmpty)
+				h.checkEmptied(ctx, r)
+			}
+		}
+		// Kept rooms get another full wait before they come up again.
+		keep := func() {
+			for _, r := range rooms {
+				r.sweptEmpty = time.Now()
+			}
+		}
+		h.cleanUp(withGuild(context.Background(), guildID), guildID, "The janitor's sweep of empty rooms", channels, run, keep)
+	}
+}
+
+// sweepEmptyCategories deletes categories the bot created that have had no
+// channels for janitorEmptyAfter, such as those left behind when creating a
+// team room failed halfway.
+func (h *handler) sweepEmptyCategories() {
+	children := make(map[discord.GuildID]map[discord.ChannelID]int)
+	inUse := make(map[discord.ChannelID]bool)
+	for _, r := range h.rooms {
+		inUse[r.category] = true
+	}
+
+	now := time.Now()
+	seen := make(map[discord.ChannelID]bool)
+	due := make(map[discord.GuildID][]discord.ChannelID)
+	for id := range h.created {
+		ch, err := h.s.Cabinet.Channel(id)
+		if err != nil || ch.Type != discord.GuildCategory || inUse[id] || h.deleteRetries[id] != nil || h.isFrozen(id) {
+			continue
+		}
+		counts, ok := children[ch.GuildID]
+		if !ok {
+			counts = make(map[discord.ChannelID]int)
+			channels, _ := h.s.Cabinet.Channels(ch.GuildID)
+			for _, c := range channels {
+				counts[c.ParentID]++
+			}
+			children[ch.GuildID] = counts
+		}
+		if counts[id] > 0 {
+			continue
+		}
+
+		seen[id] = true
+		since, ok := h.emptyCategories[id]
+		if !ok {
+			h.emptyCategories[id] = now
+			continue
+		}
+		if now.Sub(since) < h.cfg.janitorEmptyAfter || h.heldCleanups[ch.GuildID] != nil {
+			continue
+		}
+		due[ch.GuildID] = append(due[ch.GuildID], id)
+	}
+	for id := range h.emptyCategories {
+		if !seen[id] || !h.created[id] {
+			delete(h.emptyCategories, id)
+		}
+	}
+
+	for guildID, ids := range due {
+		run := func(ctx context.Context) {
+			channels, _ := h.s.Cabinet.Channels(guildID)
+			for _, id := range ids {
+				// A held sweep may run long after it was decided on.
+				if !h.created[id] || slices.ContainsFunc(channels, func(c discord.Channel) bool { return c.ParentID == id }) {
+					continue
+				}
+				if err := h.deleteChannel(ctx, id, "empty category left behind"); err != nil {
+					slog.ErrorContext(ctx, "Failed to delete empty category", "channel_id", id, "err", err)
+					continue
+				}
+				slog.InfoContext(ctx, "Deleted empty category left behind", "channel_id", id, "empty_since", h.emptyCategories[id])
+			}
+		}
+		keep := func() {
+			for _, id := range ids {
+				if _, ok := h.emptyCategories[id]; ok {
+					h.emptyCategories[id] = time.Now()
+				}
+			}
+		}
+		h.cleanUp(withGuild(context.Background(), guildID), guildID, "The janitor's sweep of empty categories", len(ids), run, keep)
+	}
+}
+
+// withinGuildCap reports whether the guild may have another room. If not,
+// the member is moved back to where they came from, disconnected if that was
+// nowhere, and told why.
+func (h *handler) withinGuildCap(ctx context.Context, guildID discord.GuildID, userID discord.UserID, from discord.ChannelID) bool {
+	limit := h.guildRoomCap(guildID)
+	if limit <= 0 {
+		return true
+	}
+	n := 0
+	for _, r := range h.rooms {
+		if r.guildID == guildID {
+			n++
+		}
+	}
+	if n < limit {
+		return true
+	}
+
+	if h.userVoiceStates[userID].ChannelID != from {
+		h.queueMove(memberMove{
+			guildID:   guildID,
+			userID:    userID,
+			channelID: from,
+			reason:    "server has the maximum number of rooms",
+			failure:   "Failed to move member back from full server",
+		})
+	}
+	h.notify(ctx, userID, "Sorry, this server already has as many rooms as it allows. Try again once one frees up.")
+	return false
+}
@@ -0,0 +1,103 @@
+package tvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// errKilled is returned instead of making a request the kill switch blocks.
+var errKilled = errors.New("blocked by the kill switch")
+
+// killedOps are the requests the kill switch blocks: everything that creates,
+// deletes or moves channels, and member moves.
+var killedOps = map[string]bool{
+	"CreateChannel": true,
+	"DeleteChannel": true,
+	"MoveChannels":  true,
+	"ModifyMember":  true,
+}
+
+// killed reports whether the kill switch blocks op in guildID. Requests
+// without a guild are only blocked by the global switch.
+func (h *handler) killed(guildID discord.GuildID, op string) bool {
+	return killedOps[op] && h.stats.isKilled(guildID)
+}
+
+// setKillSwitch flips the kill switch of guildID, or the global one if
+// guildID is 0, and logs who did it.
+func (h *handler) setKillSwitch(guildID discord.GuildID, on bool, by string) {
+	h.stats.setKilled(guildID, on)
+	scope := "every guild"
+	if guildID.IsValid() {
+		scope = "guild " + guildID.String()
+	}
+	slog.Warn("Kill switch set", "scope", scope, "on", on, "by", by)
+	go h.refreshPresence()
+}
+
+// cmdOperatorKill handles /operator kill. Without a server ID it stops the
+// bot everywhere.
+func (h *handler) cmdOperatorKill(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	on, err := data.Options.Find("enabled").BoolValue()
+	if err != nil {
+		return ephemeralData("Tell me whether to turn the kill switch on.")
+	}
+	var guildID discord.GuildID
+	if arg := strings.TrimSpace(data.Options.Find("guild_id").String()); arg != "" {
+		id, err := discord.ParseSnowflake(arg)
+		if err != nil {
+			return ephemeralData("That isn't a server ID.")
+		}
+		guildID = discord.GuildID(id)
+	}
+
+	h.setKillSwitch(guildID, on, "operator "+data.Event.SenderID().String())
+
+	scope := "every server"
+	if guildID.IsValid() {
+		scope = "server " + guildID.String()
+	}
+	if on {
+		return ephemeralData(fmt.Sprintf("Kill switch is on for %s. I won't create, delete or move anything there until you turn it off.", scope))
+	}
+	return ephemeralData(fmt.Sprintf("Kill switch is off for %s.", scope))
+}
+
+// serveKillSwitch handles POST /killswitch?enabled=true[&guild_id=...]. It
+// needs $API_TOKEN, since it can stop the bot.
+func (h *handler) serveKillSwitch(w http.ResponseWriter, req *http.Request) {
+	if h.cfg.apiToken == "" {
+		http.Error(w, "set $API_TOKEN to use the kill switch", http.StatusForbidden)
+		return
+	}
+	if !h.authorized(w, req) {
+		return
+	}
+
+	on, err := strconv.ParseBool(req.URL.Query().Get("enabled"))
+	if err != nil {
+		http.Error(w, "enabled must be true or false", http.StatusBadRequest)
+		return
+	}
+	var guildID discord.GuildID
+	if arg := req.URL.Query().Get("guild_id"); arg != "" {
+		id, err := discord.ParseSnowflake(arg)
+		if err != nil {
+			http.Error(w, "invalid guild_id", http.StatusBadRequest)
+			return
+		}
+		guildID = discord.GuildID(id)
+	}
+
+	h.setKillSwitch(guildID, on, "the HTTP API")
+	w.Write([]byte("ok\n"))
+}
@@ -0,0 +1,78 @@
+package tvc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// postKillSwitch flips the kill switch over HTTP with the given query.
+func postKillSwitch(b *testBot, query string) int {
+	req := httptest.NewRequest("POST", "/killswitch?"+query, nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	b.h.serveKillSwitch(rec, req)
+	return rec.Code
+}
+
+func TestKillSwitchNeedsToken(t *testing.T) {
+	b := newTestBot(t)
+	if code := postKillSwitch(b, "enabled=true"); code != http.StatusForbidden {
+		t.Errorf("kill switch without $API_TOKEN answers %d, want %d", code, http.StatusForbidden)
+	}
+	if b.h.stats.isKilled(testGuildID) {
+		t.Error("kill switch was turned on without $API_TOKEN")
+	}
+}
+
+func TestKillSwitchGuild(t *testing.T) {
+	b := newTestBot(t, "API_TOKEN=secret")
+	b.send(testGuild([]discord.Channel{{ID: 300, GuildID: testGuildID, Type: discord.GuildVoice, Name: "room"}}, nil))
+	if code := postKillSwitch(b, "enabled=true&guild_id="+testGuildID.String()); code != http.StatusOK {
+		t.Fatalf("turning the kill switch on answers %d", code)
+	}
+	if b.h.stats.isKilled(101) {
+		t.Error("a guild's kill switch stops another guild")
+	}
+
+	b.send(join(5, testHubID))
+	if b.roomOf(5).IsValid() {
+		t.Error("hub created a room with the kill switch on")
+	}
+
+	h := b.h
+	h.mu.Lock()
+	h.created[300] = true
+	err := h.deleteChannel(withGuild(context.Background(), testGuildID), 300, "test")
+	h.mu.Unlock()
+	if !errors.Is(err, errKilled) || !b.channelExists(300) {
+		t.Errorf("deleting with the kill switch on returns %v", err)
+	}
+
+	if code := postKillSwitch(b, "enabled=false&guild_id="+testGuildID.String()); code != http.StatusOK {
+		t.Fatalf("turning the kill switch off answers %d", code)
+	}
+	b.send(join(5, 0), join(5, testHubID))
+	if !b.roomOf(5).IsValid() {
+		t.Error("hub created no room with the kill switch off")
+	}
+}
+
+func TestKillSwitchGlobal(t *testing.T) {
+	b := newTestBot(t, "API_TOKEN=secret")
+	b.send(testGuild(nil, nil))
+	if code := postKillSwitch(b, "enabled=true"); code != http.StatusOK {
+		t.Fatalf("turning the kill switch on answers %d", code)
+	}
+	if !b.h.stats.isKilled(testGuildID) || !b.h.stats.isKilled(101) {
+		t.Error("global kill switch doesn't stop every guild")
+	}
+	b.send(join(5, testHubID))
+	if b.roomOf(5).IsValid() {
+		t.Error("hub created a room with the global kill switch on")
+	}
+}
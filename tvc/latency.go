@@ -0,0 +1,113 @@
+package tvc
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// roomLatency tracks how long members wait between joining a hub and landing
+// in their room, per guild. Waits are folded into a summary every interval
+// so busy guilds don't flood the log.
+type roomLatency struct {
+	mu      sync.Mutex
+	current map[discord.GuildID]*latencyWindow
+	// last is the most recent complete window, served on /latency.
+	last map[discord.GuildID]*latencyWindow
+	slow time.Duration // see settings.slowRoomThreshold
+}
+
+// latencyWindow sums up the waits of one guild over one interval. Create is
+// the time until the room's channel existed, move until the member was in it.
+type latencyWindow struct {
+	Rooms      int           `json:"rooms"`
+	CreateMean time.Duration `json:"create_mean_ns"`
+	CreateMax  time.Duration `json:"create_max_ns"`
+	MoveMean   time.Duration `json:"move_mean_ns"`
+	MoveMax    time.Duration `json:"move_max_ns"`
+
+	createTotal, moveTotal time.Duration
+}
+
+func newRoomLatency(slow time.Duration) *roomLatency {
+	return &roomLatency{
+		slow:    slow,
+		current: make(map[discord.GuildID]*latencyWindow),
+		last:    make(map[discord.GuildID]*latencyWindow),
+	}
+}
+
+// record adds a room whose channel was created at createdAt and whose owner
+// was moved just now. Requests that didn't come from a hub join carry no join
+// time and are ignored.
+func (l *roomLatency) record(req roomRequest, createdAt time.Time) {
+	if req.joinedAt.IsZero() {
+		return
+	}
+	create, move := createdAt.Sub(req.joinedAt), time.Since(req.joinedAt)
+	guildID := req.hubChannel.GuildID
+	if move >= l.slow {
+		slog.Warn("Slow room", "guild_id", guildID, "user_id", req.userID, "hub", req.hub.label(), "waited", move.Round(time.Millisecond), "created_after", create.Round(time.Millisecond))
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	w, ok := l.current[guildID]
+	if !ok {
+		w = &latencyWindow{}
+		l.current[guildID] = w
+	}
+	w.Rooms++
+	w.createTotal += create
+	w.moveTotal += move
+	w.CreateMax = max(w.CreateMax, create)
+	w.MoveMax = max(w.MoveMax, move)
+}
+
+// summarize logs each guild's waits every interval until ctx is done.
+func (l *roomLatency) summarize(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			l.mu.Lock()
+			windows := l.current
+			l.current = make(map[discord.GuildID]*latencyWindow)
+			for _, w := range windows {
+				w.CreateMean = w.createTotal / time.Duration(w.Rooms)
+				w.MoveMean = w.moveTotal / time.Duration(w.Rooms)
+			}
+			l.last = windows
+			l.mu.Unlock()
+
+			for guildID, w := range windows {
+				slog.Info("Room latency", "guild_id", guildID, "window", interval, "rooms", w.Rooms,
+					"create_mean", w.CreateMean.Round(time.Millisecond), "create_max", w.CreateMax.Round(time.Millisecond),
+					"move_mean", w.MoveMean.Round(time.Millisecond), "move_max", w.MoveMax.Round(time.Millisecond))
+			}
+		}
+	}
+}
+
+// serveLatency serves the last summary window as JSON, keyed by guild ID.
+func (l *roomLatency) serveLatency(w http.ResponseWriter, req *http.Request) {
+
+	l.mu.Lock()
+	windows := l.last
+	l.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(windows); err != nil {
+		slog.Error("Failed to write latency", "err", err)
+	}
+}
@@ -0,0 +1,212 @@
+package tvc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+// launcherTitle identifies the launcher message so it can be found again
+// after a restart.
+const launcherTitle = "Start a room"
+
+// maxLaunchers is how many buttons fit in a message.
+const maxLaunchers = 25
+
+// launcher is a button that creates a room of the voice hub with its preset
+// applied, as an alternative to joining the hub.
+type launcher struct {
+	preset
+	Emoji string `json:"emoji,omitempty"`
+	// CategoryID is where the room goes instead of the hub's categories.
+	CategoryID discord.ChannelID `json:"category_id,omitempty"`
+}
+
+// parseLaunchers reads launchers from a JSON array in $key, e.g.
+// [{"label": "Valorant", "emoji": "🎯", "room_name": "{username}'s Valorant", "limit": 5}].
+func parseLaunchers(env *environment, key string) []launcher {
+	v := env.getenv(key)
+	env.note(key, v, v == "")
+	if v == "" {
+		return nil
+	}
+	var ls []launcher
+	if err := json.Unmarshal([]byte(v), &ls); err != nil {
+		env.configError("invalid $%s: %v", key, err)
+		return nil
+	}
+	if len(ls) > maxLaunchers {
+		env.configError("invalid $%s: at most %d launchers fit in a message", key, maxLaunchers)
+		return nil
+	}
+	for _, l := range ls {
+		if l.Label == "" {
+			env.configError("invalid $%s: every launcher needs a label", key)
+			return nil
+		}
+	}
+	return ls
+}
+
+func launcherID(i int) string {
+	return fmt.Sprintf("launch-%d", i)
+}
+
+// postLauncher posts the guild's launcher message, or updates the one posted
+// before so it matches $LAUNCHERS.
+func (h *handler) postLauncher(guildID discord.GuildID) {
+	if len(h.cfg.launchers) == 0 {
+		return
+	}
+	var channelID discord.ChannelID
+	for id := range h.cfg.launcherChannels {
+		if ch, err := h.s.Channel(id); err == nil && ch.GuildID == guildID {
+			channelID = id
+		}
+	}
+	if !channelID.IsValid() {
+		return
+	}
+
+	var buttons []discord.InteractiveComponent
+	for i, l := range h.cfg.launchers {
+		b := &discord.ButtonComponent{
+			Label:    l.Label,
+			CustomID: discord.ComponentID(launcherID(i)),
+			Style:    discord.SecondaryButtonStyle(),
+		}
+		if l.Emoji != "" {
+			b.Emoji = &discord.ComponentEmoji{Name: l.Emoji}
+		}
+		buttons = append(buttons, b)
+	}
+	var rows discord.ContainerComponents
+	for len(buttons) > 0 {
+		n := min(len(buttons), 5)
+		row := discord.ActionRowComponent(buttons[:n])
+		rows = append(rows, &row)
+		buttons = buttons[n:]
+	}
+	embed := discord.Embed{
+		Title:       launcherTitle,
+		Description: "Join any voice channel, then pick a game to get a room for it.",
+	}
+
+	ctx := withGuild(context.Background(), guildID)
+	if msgID := h.findLauncherMessage(ctx, channelID); msgID.IsValid() {
+		err := h.call(ctx, "EditMessage", func(s *state.State) error {
+			_, err := s.EditMessageComplex(channelID, msgID, api.EditMessageData{
+				Embeds:     &[]discord.Embed{embed},
+				Components: &rows,
+			})
+			return err
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to update launcher", "err", err)
+		}
+		return
+	}
+	err := h.call(ctx, "SendMessage", func(s *state.State) error {
+		_, err := s.SendMessageComplex(channelID, api.SendMessageData{
+			Embeds:     []discord.Embed{embed},
+			Components: rows,
+		})
+		return err
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to post launcher", "err", err)
+	}
+}
+
+// findLauncherMessage looks for a launcher posted before among the channel's
+// recent messages.
+func (h *handler) findLauncherMessage(ctx context.Context, channelID discord.ChannelID) discord.MessageID {
+	me, err := h.s.Me()
+	if err != nil {
+		return 0
+	}
+	var msgs []discord.Message
+	err = h.call(ctx, "Messages", func(s *state.State) (err error) {
+		msgs, err = s.Messages(channelID, 50)
+		return err
+	})
+	if err != nil {
+		return 0
+	}
+	for _, msg := range msgs {
+		if msg.Author.ID == me.ID && len(msg.Embeds) > 0 && msg.Embeds[0].Title == launcherTitle {
+			return msg.ID
+		}
+	}
+	return 0
+}
+
+// onLaunch returns the handler of the i-th launcher button. Bots can only
+// move members who are already connected, so the member has to be in some
+// voice channel of the guild.
+func (h *handler) onLaunch(i int) cmdroute.ComponentHandlerFunc {
+	return func(ctx context.Context, data cmdroute.ComponentData) *api.InteractionResponse {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		guildID := data.Event.GuildID
+		userID := data.Event.SenderID()
+		vs, ok := h.userVoiceStates[userID]
+		if !ok || !vs.ChannelID.IsValid() || vs.GuildID != guildID {
+			return ephemeral("Join any voice channel first so I can move you into your room.")
+		}
+		hub, hubChannel := h.voiceHubOf(guildID)
+		if hub == nil {
+			return ephemeral("This server has no voice hub to create rooms for.")
+		}
+
+		ctx = withGuild(ctx, guildID)
+		vs.Member = data.Event.Member
+		if !h.mayCreateRoom(ctx, hub, guildID, &gateway.VoiceStateUpdateEvent{VoiceState: vs}, vs.ChannelID) {
+			return ephemeral("You can't get a new room right now.")
+		}
+		if h.admitCreation(guildID).held() {
+			return ephemeral("Too many rooms are being created right now. Try again in a moment.")
+		}
+		defer h.releaseSlot(guildID)
+
+		l := h.cfg.launchers[i]
+		req := roomRequest{
+			hub:        hub,
+			hubChannel: hubChannel,
+			userID:     userID,
+			username:   data.Event.Sender().Username,
+			preset:     &l.preset,
+			category:   l.CategoryID,
+		}
+		if err := h.createRoom(ctx, req); err != nil {
+			slog.ErrorContext(ctx, "Failed to create launched room", "err", err)
+			return ephemeral("Sorry, I couldn't create your room. Try again later.")
+		}
+		return ephemeral("Moving you into your " + l.Label + " room.")
+	}
+}
+
+// voiceHubOf finds the guild's voice hub channel.
+func (h *handler) voiceHubOf(guildID discord.GuildID) (*hub, *discord.Channel) {
+	channels, err := h.s.Channels(guildID)
+	if err != nil {
+		return nil, nil
+	}
+	for i := range channels {
+		if channels[i].Type != discord.GuildVoice {
+			continue
+		}
+		if hub := h.hubFor(&channels[i]); hub != nil && hub.kind == voiceRoom {
+			return hub, &channels[i]
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,57 @@
+package tvc
+
+import (
+	"context"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+)
+
+// mayCreateRoom runs every check a member must pass before a hub creates a
+// room for them. Each check deals with the member itself when it fails. from
+// is the channel the member was in before, if any.
+func (h *handler) mayCreateRoom(ctx context.Context, hub *hub, guildID discord.GuildID, evt *gateway.VoiceStateUpdateEvent, from discord.ChannelID) bool {
+	return !h.stats.isKilled(guildID) &&
+		!h.hubsTurnedOff(ctx, hub, guildID, evt.UserID) &&
+		!h.underMaintenance(ctx, hub, guildID, evt.UserID) &&
+		!h.hubBanned(ctx, guildID, evt.UserID) &&
+		h.hasRequiredRoles(ctx, hub, guildID, evt) &&
+		h.withinQuota(ctx, hub, guildID, evt) &&
+		!h.throttled(ctx, guildID, evt.UserID) &&
+		h.allowRoom(ctx, guildID, evt.UserID) &&
+		h.withinGuildCap(ctx, guildID, evt.UserID, from)
+}
+
+// ownedRooms lists the rooms currently owned by userID.
+func (h *handler) ownedRooms(userID discord.UserID) []discord.ChannelID {
+	var owned []discord.ChannelID
+	for id, r := range h.rooms {
+		if r.owner == userID {
+			owned = append(owned, id)
+		}
+	}
+	return owned
+}
+
+// allowRoom reports whether userID may get another room. If not, the user is
+// redirected to one of their existing rooms or disconnected from the hub.
+func (h *handler) allowRoom(ctx context.Context, guildID discord.GuildID, userID discord.UserID) bool {
+	owned := h.ownedRooms(userID)
+	if limit := h.userRoomCap(guildID); limit <= 0 || len(owned) < limit {
+		return true
+	}
+
+	target := discord.NullChannelID
+	if !h.cfg.bounceOverLimit {
+		target = owned[0]
+	}
+
+	h.queueMove(memberMove{
+		guildID:   guildID,
+		userID:    userID,
+		channelID: target,
+		reason:    "user already owns the maximum number of rooms",
+		failure:   "Failed to move member over room limit",
+	})
+	return false
+}
@@ -0,0 +1,72 @@
+package tvc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/utils/sendpart"
+)
+
+// qrScale is how many pixels wide a QR code module is on link cards, which
+// makes them readable off a screen share.
+const qrScale = 10
+
+// cmdLinkCard handles /voice link-card, which posts a card with a link to the
+// caller's room and, unless turned off, a QR code of it, for organizers to
+// show attendees of an event.
+func (h *handler) cmdLinkCard(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	h.mu.Lock()
+	r := h.ownedRoomOf(data.Event.SenderID())
+	if r == nil {
+		h.mu.Unlock()
+		return ephemeralData("You need to be in a room you own to do that.")
+	}
+	guildID, channelID, name := r.guildID, r.channelID, r.name
+	h.mu.Unlock()
+
+	withQR, err := data.Options.Find("qr").BoolValue()
+	if err != nil {
+		withQR = true
+	}
+
+	link := fmt.Sprintf("https://discord.com/channels/%s/%s", guildID, channelID)
+	embed := discord.Embed{
+		Title:       name,
+		URL:         link,
+		Description: "Join " + channelID.Mention() + " with the button below, or open " + link,
+	}
+	resp := &api.InteractionResponseData{
+		Embeds: &[]discord.Embed{embed},
+		Components: &discord.ContainerComponents{
+			&discord.ActionRowComponent{
+				&discord.ButtonComponent{
+					Style: discord.LinkButtonStyle(discord.URL(link)),
+					Label: "Join",
+				},
+			},
+		},
+		AllowedMentions: &api.AllowedMentions{},
+	}
+	if !withQR {
+		return resp
+	}
+
+	qr, err := encodeQR(link)
+	if err == nil {
+		var img []byte
+		if img, err = qr.png(qrScale); err == nil {
+			file := sendpart.File{Name: "room-qr.png", Reader: bytes.NewReader(img)}
+			(*resp.Embeds)[0].Image = &discord.EmbedImage{URL: file.AttachmentURI()}
+			resp.Files = []sendpart.File{file}
+		}
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to render room QR code", "err", err)
+	}
+	return resp
+}
@@ -0,0 +1,77 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+)
+
+// hasRequiredRoles reports whether the member in evt holds every role the hub
+// requires. If not, they are disconnected from the hub and told which roles
+// are missing.
+func (h *handler) hasRequiredRoles(ctx context.Context, hub *hub, guildID discord.GuildID, evt *gateway.VoiceStateUpdateEvent) bool {
+	if len(hub.requiredRoles) == 0 {
+		return true
+	}
+
+	member, err := h.member(ctx, guildID, evt)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get member for role check", "err", err)
+		return false
+	}
+
+	var missing []string
+	for _, roleID := range hub.requiredRoles {
+		if !hasRole(member.RoleIDs, roleID) {
+			missing = append(missing, h.roleName(guildID, roleID))
+		}
+	}
+	if len(missing) == 0 {
+		return true
+	}
+
+	h.queueMove(memberMove{
+		guildID:   guildID,
+		userID:    evt.UserID,
+		channelID: discord.NullChannelID,
+		reason:    "member lacks the roles required by the hub",
+		failure:   "Failed to disconnect member without required roles",
+	})
+
+	if hub.silent {
+		return false
+	}
+	h.notify(ctx, evt.UserID, "You need these roles to create a room there: "+strings.Join(missing, ", ")+
+		". If one is a linked role, connect your account under Linked Roles in the server menu.")
+	return false
+}
+
+// member returns the member of a voice state update, fetching it if the
+// event didn't carry it.
+func (h *handler) member(ctx context.Context, guildID discord.GuildID, evt *gateway.VoiceStateUpdateEvent) (*discord.Member, error) {
+	if evt.Member != nil {
+		return evt.Member, nil
+	}
+	return h.lookupMember(ctx, guildID, evt.UserID)
+}
+
+// roleName returns the name of a role for display, falling back to its ID.
+func (h *handler) roleName(guildID discord.GuildID, roleID discord.RoleID) string {
+	role, err := h.s.Role(guildID, roleID)
+	if err != nil {
+		return roleID.String()
+	}
+	return role.Name
+}
+
+func hasRole(roles []discord.RoleID, roleID discord.RoleID) bool {
+	for _, id := range roles {
+		if id == roleID {
+			return true
+		}
+	}
+	return false
+}
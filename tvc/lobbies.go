@@ -0,0 +1,239 @@
+package tvc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"gopkg.in/yaml.v3"
+)
+
+// lobbyConfig is a hub as written in $LOBBIES_FILE. The file is YAML if its
+// name ends in .yaml or .yml, and JSON otherwise:
+//
+//	{"lobbies": [
+//		{"key": "BARK", "name": "🐕 bark", "type": "voice", "name_template": "{username}'s room"},
+//		{"key": "SQUADS", "channel_ids": ["123"], "guild_ids": ["456"], "type": "category", "user_limit": 5, "bitrate": 96000}
+//	]}
+//
+// or, in YAML:
+//
+//	lobbies:
+//	  - key: BARK
+//	    name: 🐕 bark
+//	    type: voice
+//	  - key: SQUADS
+//	    channel_ids: ["123"]
+//	    type: category
+//	    user_limit: 5
+//
+// Every field can be overridden by the hub's variables, e.g. $SQUADS_BITRATE,
+// and the hub's other variables such as $SQUADS_GREETING keep working.
+type lobbyConfig struct {
+	// Key prefixes the hub's variables and must be unique.
+	Key string `json:"key"`
+	// Name is the channel name that triggers creation.
+	Name string `json:"name,omitempty"`
+	// ChannelIDs trigger creation whatever their name.
+	ChannelIDs []discord.ChannelID `json:"channel_ids,omitempty"`
+	// GuildIDs limits the hub to these guilds. Empty serves every guild.
+	GuildIDs []discord.GuildID `json:"guild_ids,omitempty"`
+	// Type is "voice" for a plain voice channel or "category" for a
+	// category with a text and a voice channel.
+	Type         roomKind `json:"type"`
+	NameTemplate string   `json:"name_template,omitempty"`
+	UserLimit    uint     `json:"user_limit,omitempty"`
+	Bitrate      uint     `json:"bitrate,omitempty"`
+	// Private makes rooms only their owner can see until they let others in.
+	Private bool `json:"private,omitempty"`
+	// Permissions is the hub's permission template, the role overwrites its
+	// rooms get. /voiceadmin template edit writes it.
+	Permissions []permissionRule `json:"permissions,omitempty"`
+}
+
+// defaultLobbies are the hubs used without $LOBBIES_FILE.
+var defaultLobbies = []lobbyConfig{
+	{Key: "BARK", Name: voiceHubName, Type: voiceRoom},
+	{Key: "TEAMS", Name: teamHubName, Type: teamRoom},
+}
+
+// loadHubs builds the hub table from the lobby file at path, or the built-in
+// bark and teams hubs without one, keyed by hub key.
+func loadHubs(env *environment, path string, staging guildMirrors) (map[string]*hub, error) {
+	lobbies := defaultLobbies
+	if path != "" {
+		var err error
+		if lobbies, err = readLobbies(path); err != nil {
+			return nil, fmt.Errorf("cannot read lobbies: %w", err)
+		}
+	}
+	return buildHubs(env, staging, lobbies)
+}
+
+// buildHubs builds the hubs of lobbies, keyed by hub key. They serve the
+// staging guilds of their guilds too.
+func buildHubs(env *environment, staging guildMirrors, lobbies []lobbyConfig) (map[string]*hub, error) {
+	hubs := make(map[string]*hub, len(lobbies))
+	for _, l := range lobbies {
+		if l.Key == "" {
+			return nil, errors.New("every lobby needs a key")
+		}
+		if _, ok := hubs[l.Key]; ok {
+			return nil, fmt.Errorf("lobby key %q is used twice", l.Key)
+		}
+		if l.Type == "" {
+			l.Type = voiceRoom
+		}
+		hub, err := newHub(env, l)
+		if err != nil {
+			return nil, err
+		}
+		hub.staging = staging
+		if hub.shadow != nil {
+			hub.shadow.staging = staging
+		}
+		hubs[l.Key] = hub
+	}
+	return hubs, nil
+}
+
+// readLobbies reads the lobbies of a lobby file.
+func readLobbies(path string) ([]lobbyConfig, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if isYAML(path) {
+		if b, err = yamlToJSON(b); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", path, err)
+		}
+	}
+	var file struct {
+		Lobbies []lobbyConfig `json:"lobbies"`
+	}
+	if err := json.Unmarshal(b, &file); err != nil {
+		return nil, err
+	}
+	if len(file.Lobbies) == 0 {
+		return nil, fmt.Errorf("%s defines no lobbies", path)
+	}
+	return file.Lobbies, nil
+}
+
+// writeLobbies replaces the lobbies in the lobby file at path, keeping
+// anything else in it.
+func writeLobbies(path string, lobbies []lobbyConfig) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if isYAML(path) {
+		if b, err = replaceYAMLLobbies(b, lobbies); err != nil {
+			return err
+		}
+		return replaceFile(path, b)
+	}
+	var file map[string]json.RawMessage
+	if err := json.Unmarshal(b, &file); err != nil {
+		return err
+	}
+	if file["lobbies"], err = json.Marshal(lobbies); err != nil {
+		return err
+	}
+	if b, err = json.MarshalIndent(file, "", "\t"); err != nil {
+		return err
+	}
+	return replaceFile(path, append(b, '\n'))
+}
+
+// replaceFile writes b next to path and swaps it in, so a crash can't leave
+// the file half written.
+func replaceFile(path string, b []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// isYAML reports whether the lobby file at path is YAML, by its extension.
+func isYAML(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// yamlToJSON converts a YAML document to JSON, so lobby files in either
+// format are decoded the same way.
+func yamlToJSON(b []byte) ([]byte, error) {
+	var v any
+	if err := yaml.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// replaceYAMLLobbies replaces the lobbies in the YAML document b, keeping
+// its other keys and comments.
+func replaceYAMLLobbies(b []byte, lobbies []lobbyConfig) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("the lobby file isn't a mapping")
+	}
+
+	// JSON is YAML too, so the lobbies are encoded as JSON, which keeps the
+	// field names, order and ID formats, and then restyled as block YAML.
+	j, err := json.Marshal(lobbies)
+	if err != nil {
+		return nil, err
+	}
+	var encoded yaml.Node
+	if err := yaml.Unmarshal(j, &encoded); err != nil {
+		return nil, err
+	}
+	value := encoded.Content[0]
+	unflow(value)
+
+	root := doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "lobbies" {
+			root.Content[i+1] = value
+			return encodeYAML(&doc)
+		}
+	}
+	root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "lobbies"}, value)
+	return encodeYAML(&doc)
+}
+
+// unflow clears the styles JSON gave n and its children, so they are written
+// as plain block YAML. Strings that would read as something else stay
+// quoted.
+func unflow(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		unflow(c)
+	}
+}
+
+func encodeYAML(doc *yaml.Node) ([]byte, error) {
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,142 @@
+package tvc
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+// maxLobbyCode keeps lobby codes short enough for the voice status.
+const maxLobbyCode = 40
+
+// voiceStatus combines the room's lobby code with the activity summary into
+// the status shown under the voice channel.
+func (r *room) voiceStatus(activity string) string {
+	switch {
+	case r.lobbyCode == "":
+		return activity
+	case activity == "":
+		return "Lobby " + r.lobbyCode
+	default:
+		return "Lobby " + r.lobbyCode + " · " + activity
+	}
+}
+
+// cmdLink handles /voice link, which attaches a game lobby code to the
+// invoker's room.
+func (h *handler) cmdLink(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	r := h.ownedRoomOf(data.Event.SenderID())
+	if r == nil {
+		return ephemeralData("You need to be in a room you own to do that.")
+	}
+	r.lobbyCode = strings.TrimSpace(data.Options.Find("lobby_code").String())
+
+	ctx = withGuild(ctx, r.guildID)
+	status := r.voiceStatus(h.activityStatus(r.guildID, r.channelID))
+	err := h.call(ctx, "SetVoiceStatus", func(s *state.State) error {
+		return setVoiceStatus(s, r.channelID, status)
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to set room status", "err", err)
+	} else {
+		r.status = status
+	}
+	h.refreshRoomInfo(r.channelID, r)
+
+	if r.lobbyCode == "" {
+		return ephemeralData("Your room is no longer linked to a lobby.")
+	}
+	return ephemeralData("Your room is linked to lobby `" + r.lobbyCode + "`.")
+}
+
+// roomJSON is a room as served by /rooms.
+type roomJSON struct {
+	GuildID   discord.GuildID   `json:"guild_id"`
+	ChannelID discord.ChannelID `json:"channel_id"`
+	OwnerID   discord.UserID    `json:"owner_id"`
+	Name      string            `json:"name"`
+	LobbyCode string            `json:"lobby_code,omitempty"`
+	Occupants int               `json:"occupants"`
+	// Abandoned is set on channels the bot failed to delete and keeps
+	// trying to, with the last error.
+	Abandoned bool   `json:"abandoned,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// serveRooms lists the live rooms as JSON so external tools can map them to
+// game lobbies. ?guild_id= and ?lobby_code= filter the list. Channels whose
+// deletion was abandoned are listed too, so they aren't lost track of.
+func (h *handler) serveRooms(w http.ResponseWriter, req *http.Request) {
+	if !h.authorized(w, req) {
+		return
+	}
+	guildFilter := req.URL.Query().Get("guild_id")
+	lobbyFilter := req.URL.Query().Get("lobby_code")
+
+	h.mu.Lock()
+	rooms := []roomJSON{}
+	for id, r := range h.rooms {
+		if (guildFilter != "" && r.guildID.String() != guildFilter) || (lobbyFilter != "" && r.lobbyCode != lobbyFilter) {
+			continue
+		}
+		rooms = append(rooms, roomJSON{
+			GuildID:   r.guildID,
+			ChannelID: id,
+			OwnerID:   r.owner,
+			Name:      r.name,
+			LobbyCode: r.lobbyCode,
+			Occupants: h.roomOccupants(r),
+		})
+	}
+	for id, retry := range h.abandonedChannels(discord.NullGuildID) {
+		if (guildFilter != "" && retry.GuildID.String() != guildFilter) || lobbyFilter != "" {
+			continue
+		}
+		abandoned := roomJSON{GuildID: retry.GuildID, ChannelID: id, Abandoned: true, Error: retry.LastError}
+		if ch, err := h.s.Cabinet.Channel(id); err == nil {
+			abandoned.Name = ch.Name
+		}
+		rooms = append(rooms, abandoned)
+	}
+	h.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(rooms); err != nil {
+		slog.Error("Failed to write rooms", "err", err)
+	}
+}
+
+// authorized checks the request's bearer token against $API_TOKEN, answering
+// 401 if it doesn't match. Without a token every request is authorized.
+func (h *handler) authorized(w http.ResponseWriter, req *http.Request) bool {
+	if h.cfg.apiToken == "" {
+		return true
+	}
+	token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.cfg.apiToken)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// tokenOnly wraps an HTTP handler of a part of the bot that doesn't know
+// $API_TOKEN so it is checked first.
+func (h *handler) tokenOnly(serve http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if h.authorized(w, req) {
+			serve(w, req)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+// logChannelFor returns the guild's log channel, or 0 if it has none.
+func (h *handler) logChannelFor(guildID discord.GuildID) discord.ChannelID {
+	for id := range h.cfg.logChannels {
+		if ch, err := h.s.Cabinet.Channel(id); err == nil && ch.GuildID == guildID {
+			return id
+		}
+	}
+	return 0
+}
+
+// postLog posts content to the guild's log channel, if it has one.
+func (h *handler) postLog(ctx context.Context, guildID discord.GuildID, content string) {
+	channelID := h.logChannelFor(guildID)
+	if !channelID.IsValid() {
+		return
+	}
+	err := h.call(ctx, "SendMessage", func(s *state.State) error {
+		_, err := s.SendMessageComplex(channelID, api.SendMessageData{
+			Content:         content,
+			AllowedMentions: &api.AllowedMentions{},
+		})
+		return err
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to post to log channel", "err", err)
+	}
+}
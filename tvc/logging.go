@@ -0,0 +1,32 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LogHandler wraps next so records logged with a context carrying a guild,
+// such as the ones passed to Discord calls, get a guild_id attribute. Install
+// it with slog.SetDefault to get the field on the manager's logs.
+func LogHandler(next slog.Handler) slog.Handler {
+	return guildLogHandler{next}
+}
+
+type guildLogHandler struct {
+	slog.Handler
+}
+
+func (h guildLogHandler) Handle(ctx context.Context, r slog.Record) error {
+	if guildID := guildFrom(ctx); guildID.IsValid() {
+		r.AddAttrs(slog.String("guild_id", guildID.String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h guildLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return guildLogHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h guildLogHandler) WithGroup(name string) slog.Handler {
+	return guildLogHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,39 @@
+package tvc
+
+import (
+	"context"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// cmdMaintenance handles /voiceadmin maintenance, which pauses room creation
+// in the guild while operators deploy or debug. Existing rooms keep working
+// and are still cleaned up. It is saved in the stats store, so it lasts
+// across the restarts of a deploy.
+func (h *handler) cmdMaintenance(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	on, err := data.Options.Find("enabled").BoolValue()
+	if err != nil {
+		return ephemeralData("Tell me whether to turn maintenance on.")
+	}
+	h.stats.setMaintenance(data.Event.GuildID, on)
+	go h.refreshPresence()
+
+	if on {
+		return ephemeralData("Maintenance is on. Nobody gets new rooms until you turn it off; existing rooms are cleaned up as usual.")
+	}
+	return ephemeralData("Maintenance is off. Rooms are created again.")
+}
+
+// underMaintenance reports whether room creation is paused in guildID,
+// letting userID know if so.
+func (h *handler) underMaintenance(ctx context.Context, hub *hub, guildID discord.GuildID, userID discord.UserID) bool {
+	if !h.stats.inMaintenance(guildID) {
+		return false
+	}
+	if !hub.silent {
+		h.notify(ctx, userID, "Rooms are paused for maintenance right now. Try again in a few minutes.")
+	}
+	return true
+}
@@ -0,0 +1,254 @@
+// Package tvc creates temporary voice channels: members join a hub channel
+// and get a room of their own, which is deleted once everyone has left.
+//
+// The bot in the repository root is a thin wrapper around it; other bots can
+// embed it by attaching a Manager to their state:
+//
+//	m, err := tvc.New(tvc.Config{
+//		Storage:  tvc.FileStorage("stats.json"),
+//		Settings: map[string]string{"ROOM_GRACE_PERIOD": "30s"},
+//	})
+//	if err != nil {
+//		log.Fatalln(err)
+//	}
+//	m.Attach(s)
+//	go m.Run(ctx)
+//
+// Everything else is read from Config.Settings, by the variable names
+// documented next to each setting, and checked by New. The bot in the
+// repository root reads them from its environment.
+package tvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+// Config holds what the embedding program decides about a Manager.
+type Config struct {
+	// Name tells the manager apart from the others in the same process, in
+	// its logs and as the bot label of its metrics. Empty means it is the
+	// only one.
+	Name string
+	// Storage persists room history, bans and settings. Nil keeps them in
+	// memory only.
+	Storage Storage
+	// HealthAddr is where /healthz, /rooms and the other HTTP endpoints are
+	// served, e.g. ":8080". Empty serves nothing.
+	HealthAddr string
+	// MetricsAddr is where Prometheus metrics are served at /metrics, e.g.
+	// ":9090". Empty serves none.
+	MetricsAddr string
+	// SkipCommands leaves the application's commands alone, for bots that
+	// register their own. The manager's commands are listed by
+	// Manager.Commands.
+	SkipCommands bool
+	// Settings holds the variables the manager reads the rest of its
+	// settings from, e.g. "ROOM_GRACE_PERIOD": "30s". Unset variables take
+	// their defaults.
+	Settings map[string]string
+}
+
+// Manager runs temporary voice channels on a state.
+type Manager struct {
+	cfg Config
+	h   *handler
+	// recording is where gateway events are recorded to, if anywhere.
+	recording *os.File
+	// replaying is set for managers Replay runs, which don't record.
+	replaying bool
+}
+
+// New checks the configuration, loads the hubs and the stored stats, and
+// returns a manager ready to be attached. Malformed settings are reported
+// here, all at once.
+func New(cfg Config) (*Manager, error) {
+	return newManager(cfg, false)
+}
+
+func newManager(cfg Config, replaying bool) (*Manager, error) {
+	env := newEnvironment(cfg.Settings)
+	set := readSettings(env)
+	hubs, err := loadHubs(env, set.lobbiesFile, set.stagingGuilds)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := newStatsStore(cfg.Storage)
+	if err != nil {
+		return nil, err
+	}
+	stats.saveDelay = set.statsSaveDelay
+	if set.registryImportFile != "" {
+		if err := stats.importRegistry(set.registryImportFile); err != nil {
+			return nil, fmt.Errorf("cannot import $REGISTRY_IMPORT_FILE: %w", err)
+		}
+	}
+
+	// The handler reads settings of its own, so the configuration is
+	// checked once it is built.
+	h := newHandler(env, set, hubs, stats)
+	if err := env.check(); err != nil {
+		return nil, err
+	}
+	if problems := h.validateTemplates(); len(problems) > 0 {
+		return nil, errors.New("invalid templates: " + strings.Join(problems, " "))
+	}
+	h.name = cfg.Name
+	h.metrics.bot = cfg.Name
+	h.skipCommands = cfg.SkipCommands
+
+	m := &Manager{cfg: cfg, h: h, replaying: replaying}
+	if set.recordEventsPath != "" && !replaying {
+		if m.recording, err = openRecording(set.recordEventsPath); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Attach registers the manager's gateway and interaction handlers and the
+// intents they need on s. It must be called once, before s is opened.
+func (m *Manager) Attach(s *state.State) {
+	traceRateLimits(s)
+	if m.recording != nil {
+		recordEvents(s, m.recording)
+	}
+
+	s.AddIntents(gateway.IntentGuilds | gateway.IntentGuildVoiceStates)
+	cfg := &m.h.cfg
+	if cfg.activityStatusEnabled || cfg.partySizeSync {
+		s.AddIntents(gateway.IntentGuildPresences)
+	}
+	if m.h.boosterPerks() || len(cfg.memberChunkGuilds) > 0 {
+		s.AddIntents(gateway.IntentGuildMembers)
+	}
+	if cfg.autoModRoomAction != "off" || len(cfg.modChannels) > 0 {
+		s.AddIntents(intentAutoModExecution)
+	}
+
+	h := m.h
+	h.s = s
+	h.breaker.onChange = h.onBreakerChange
+	h.breaker.observe(s)
+
+	// Guild events are handed to their guild's queue synchronously, so they
+	// keep the order the gateway sent them in; see guildQueues. Replays feed
+	// events back to back, so the other handlers run synchronously there too.
+	on := s.AddHandler
+	if m.replaying {
+		on = s.AddSyncHandler
+	}
+	queued := s.AddSyncHandler
+	on(h.onReady)
+	on(func(*gateway.ResumedEvent) { h.onGatewayGap("resume") })
+	queued(func(e *gateway.VoiceStateUpdateEvent) {
+		h.queues.dispatch(e.GuildID, func() { h.onVoiceStateUpdate(e) })
+	})
+	queued(func(e *gateway.ChannelUpdateEvent) {
+		h.queues.dispatch(e.GuildID, func() {
+			h.onChannelUpdate(e)
+			h.checkStrict(e.GuildID)
+		})
+	})
+	queued(func(e *gateway.ChannelDeleteEvent) {
+		h.queues.dispatch(e.GuildID, func() { h.onChannelDelete(e) })
+	})
+	queued(func(e *gateway.GuildCreateEvent) {
+		h.queues.dispatch(e.ID, func() { h.onGuildCreate(e) })
+	})
+	queued(func(e *gateway.GuildMemberUpdateEvent) {
+		h.members.forget(e.GuildID, e.User.ID)
+		h.queues.dispatch(e.GuildID, func() {
+			h.onGuildMemberUpdate(e)
+			if me, err := s.Me(); err == nil && me.ID == e.User.ID {
+				h.checkStrict(e.GuildID)
+			}
+		})
+	})
+	queued(func(e *gateway.GuildUpdateEvent) {
+		h.queues.dispatch(e.ID, func() { h.checkStrict(e.ID) })
+	})
+	queued(func(e *gateway.GuildRoleCreateEvent) {
+		h.queues.dispatch(e.GuildID, func() { h.checkStrict(e.GuildID) })
+	})
+	queued(func(e *gateway.GuildRoleUpdateEvent) {
+		h.queues.dispatch(e.GuildID, func() { h.checkStrict(e.GuildID) })
+	})
+	queued(func(e *gateway.GuildRoleDeleteEvent) {
+		h.queues.dispatch(e.GuildID, func() { h.checkStrict(e.GuildID) })
+	})
+	on(func(e *gateway.GuildMemberRemoveEvent) { h.members.forget(e.GuildID, e.User.ID) })
+	on(h.onMembersChunk)
+	queued(func(e *gateway.PresenceUpdateEvent) {
+		h.queues.dispatch(e.GuildID, func() { h.onPresenceUpdate(e) })
+	})
+	queued(func(e *autoModActionEvent) {
+		h.queues.dispatch(e.GuildID, func() { h.onAutoModAction(e) })
+	})
+	s.AddInteractionHandler(h.newRouter())
+}
+
+// Run runs the manager's background work, such as retrying queued rooms and
+// serving HTTP, until ctx is done. Attach must have been called.
+func (m *Manager) Run(ctx context.Context) {
+	h := m.h
+
+	if interval := h.cfg.voiceLogSummaryInterval; interval > 0 {
+		go h.voiceLog.summarize(ctx, interval)
+	}
+	if interval := h.cfg.latencySummaryInterval; interval > 0 {
+		go h.latency.summarize(ctx, interval)
+	}
+
+	if interval := h.cfg.runtimeReportInterval; interval > 0 {
+		go h.reportRuntime(ctx, interval)
+	}
+
+	if interval := h.cfg.occupancySnapshotInterval; interval > 0 {
+		go h.snapshotOccupancy(ctx, interval)
+	}
+
+	if m.cfg.HealthAddr != "" {
+		go h.serveHealth(ctx, m.cfg.HealthAddr)
+	}
+	if m.cfg.MetricsAddr != "" {
+		go h.serveMetrics(ctx, m.cfg.MetricsAddr)
+	}
+
+	// Quotas count rooms from the stats store, so a retention shorter than a
+	// week also shortens quota memory.
+	if retention := h.cfg.statsRetention; retention > 0 {
+		go h.stats.cleanup(ctx, retention, h.cfg.statsCleanupInterval)
+	}
+
+	if len(h.cfg.profiles) > 0 {
+		go h.runProfileSchedules(ctx)
+	}
+	go h.runReservations(ctx)
+	go h.runMoves(ctx)
+	go h.drainPendingRooms(ctx, h.cfg.pendingRoomRetryInterval)
+	if h.cfg.hubOrphanTimeout > 0 {
+		go h.rescueOrphans(ctx, h.cfg.hubOrphanScanInterval)
+	}
+	if interval := h.cfg.janitorInterval; interval > 0 {
+		go h.runJanitor(ctx, interval)
+	}
+	if h.cfg.lobbiesFile != "" {
+		go h.watchReloads(ctx)
+	}
+
+	// Rooms renamed within the rename window are skipped, so the default
+	// interval is a little longer than it.
+	if h.cfg.nameSuffix != "" {
+		go h.refreshSuffixes(ctx, h.cfg.nameSuffixInterval)
+	}
+
+	<-ctx.Done()
+	h.stats.flush()
+}
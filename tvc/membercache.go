@@ -0,0 +1,167 @@
+package tvc
+
+import (
+	"container/list"
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/diamondburned/arikawa/v3/utils/json/option"
+)
+
+type memberKey struct {
+	guildID discord.GuildID
+	userID  discord.UserID
+}
+
+type cachedMember struct {
+	key    memberKey
+	member discord.Member
+	at     time.Time
+}
+
+// memberCache is a read-through cache of guild members with a TTL and a size
+// cap, filled by lookups and member chunks. Entries are kept oldest first.
+type memberCache struct {
+	mu      sync.Mutex
+	entries map[memberKey]*list.Element
+	order   *list.List
+	// dropped is called outside mu with the members that left the cache, so
+	// the state's cache doesn't outgrow it.
+	dropped func([]memberKey)
+	ttl     time.Duration // see settings.memberCacheTTL
+	size    int           // see settings.memberCacheSize
+}
+
+func newMemberCache(ttl time.Duration, size int) *memberCache {
+	return &memberCache{
+		ttl:     ttl,
+		size:    size,
+		entries: make(map[memberKey]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// get returns the cached member if it is fresh.
+func (c *memberCache) get(guildID discord.GuildID, userID discord.UserID) (discord.Member, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[memberKey{guildID, userID}]
+	if !ok || time.Since(el.Value.(*cachedMember).at) >= c.ttl {
+		return discord.Member{}, false
+	}
+	return el.Value.(*cachedMember).member, true
+}
+
+// put caches members of guildID, dropping expired ones and then the oldest
+// beyond the cache's size.
+func (c *memberCache) put(guildID discord.GuildID, members ...discord.Member) {
+	if c.size <= 0 {
+		return
+	}
+	c.mu.Lock()
+	now := time.Now()
+	for _, m := range members {
+		key := memberKey{guildID, m.User.ID}
+		if el, ok := c.entries[key]; ok {
+			c.order.Remove(el)
+		}
+		c.entries[key] = c.order.PushBack(&cachedMember{key: key, member: m, at: now})
+	}
+	var dropped []memberKey
+	for el := c.order.Front(); el != nil; el = c.order.Front() {
+		e := el.Value.(*cachedMember)
+		if len(c.entries) <= c.size && now.Sub(e.at) < c.ttl {
+			break
+		}
+		c.order.Remove(el)
+		delete(c.entries, e.key)
+		dropped = append(dropped, e.key)
+	}
+	c.mu.Unlock()
+
+	if len(dropped) > 0 && c.dropped != nil {
+		c.dropped(dropped)
+	}
+}
+
+// forget drops a member that changed or left.
+func (c *memberCache) forget(guildID discord.GuildID, userID discord.UserID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := memberKey{guildID, userID}
+	if el, ok := c.entries[key]; ok {
+		c.order.Remove(el)
+		delete(c.entries, key)
+	}
+}
+
+func (c *memberCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// lookupMember looks up a guild member through the member cache, falling
+// back to the state's cache and REST.
+func (h *handler) lookupMember(ctx context.Context, guildID discord.GuildID, userID discord.UserID) (*discord.Member, error) {
+	if m, ok := h.members.get(guildID, userID); ok {
+		return &m, nil
+	}
+	var member *discord.Member
+	err := h.call(ctx, "Member", func(s *state.State) (err error) {
+		member, err = s.Member(guildID, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	h.members.put(guildID, *member)
+	return member, nil
+}
+
+// dropStateMembers removes members evicted from the member cache from the
+// state's cache, except the bot's own, which permission checks rely on.
+func (h *handler) dropStateMembers(keys []memberKey) {
+	me, err := h.s.Me()
+	if err != nil {
+		return
+	}
+	for _, key := range keys {
+		if key.userID == me.ID {
+			continue
+		}
+		h.s.Cabinet.MemberRemove(key.guildID, key.userID)
+	}
+}
+
+// requestMembers asks the gateway for all members of the guild if it is in
+// $MEMBER_CHUNK_GUILD_IDS. They arrive as chunks; see onMembersChunk.
+func (h *handler) requestMembers(guildID discord.GuildID) {
+	if !slices.Contains(h.cfg.memberChunkGuilds, guildID) {
+		return
+	}
+	ctx := withGuild(context.Background(), guildID)
+	err := h.s.SendGateway(ctx, &gateway.RequestGuildMembersCommand{
+		GuildIDs: []discord.GuildID{guildID},
+		Query:    option.NewString(""),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to request guild members", "err", err)
+	}
+}
+
+// onMembersChunk caches a chunk of requested members.
+func (h *handler) onMembersChunk(evt *gateway.GuildMembersChunkEvent) {
+	h.members.put(evt.GuildID, evt.Members...)
+	if evt.ChunkIndex == evt.ChunkCount-1 {
+		slog.Info("Cached guild members", "guild_id", evt.GuildID, "chunks", evt.ChunkCount, "cached", h.members.len())
+	}
+}
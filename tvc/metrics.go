@@ -0,0 +1,214 @@
+package tvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// otherGuilds is the guild_id label of guilds that don't get one of their
+// own.
+const otherGuilds = "other"
+
+// metrics counts what operators alert on, served in the Prometheus text
+// format at /metrics. Gauges are read from the handler when scraped.
+// Per-guild series are labelled by guild ID for the guilds in
+// $METRICS_GUILDS, or without one, for the first $METRICS_GUILD_LIMIT guilds
+// seen; the rest are summed up under "other" to keep the label count
+// bounded. Every series gets a bot label if the manager is named.
+type metrics struct {
+	mu           sync.Mutex
+	bot          string
+	allowed      map[discord.GuildID]bool
+	limit        int
+	labelled     map[discord.GuildID]bool
+	created      map[string]uint64
+	deleted      map[string]uint64
+	deleteFailed map[string]uint64
+	apiErrors    map[string]uint64
+	gatewayGaps  map[string]uint64
+	voiceEvents  uint64
+}
+
+func newMetrics(env *environment) *metrics {
+	m := &metrics{
+		allowed:      make(map[discord.GuildID]bool),
+		limit:        env.int("METRICS_GUILD_LIMIT", 100),
+		labelled:     make(map[discord.GuildID]bool),
+		created:      make(map[string]uint64),
+		deleted:      make(map[string]uint64),
+		deleteFailed: make(map[string]uint64),
+		apiErrors:    make(map[string]uint64),
+		gatewayGaps:  make(map[string]uint64),
+	}
+	for _, guildID := range env.guildIDs("METRICS_GUILDS") {
+		m.allowed[guildID] = true
+	}
+	return m
+}
+
+// label returns the guild_id label of guildID's series. m.mu must be held.
+func (m *metrics) label(guildID discord.GuildID) string {
+	switch {
+	case m.labelled[guildID]:
+	case len(m.allowed) > 0:
+		if !m.allowed[guildID] {
+			return otherGuilds
+		}
+	case m.limit > 0 && len(m.labelled) >= m.limit:
+		return otherGuilds
+	}
+	m.labelled[guildID] = true
+	return guildID.String()
+}
+
+func (m *metrics) roomCreated(guildID discord.GuildID) {
+	m.mu.Lock()
+	m.created[m.label(guildID)]++
+	m.mu.Unlock()
+}
+
+func (m *metrics) roomDeleted(guildID discord.GuildID) {
+	m.mu.Lock()
+	m.deleted[m.label(guildID)]++
+	m.mu.Unlock()
+}
+
+func (m *metrics) deleteFailure(guildID discord.GuildID) {
+	m.mu.Lock()
+	m.deleteFailed[m.label(guildID)]++
+	m.mu.Unlock()
+}
+
+func (m *metrics) apiError(op string) {
+	m.mu.Lock()
+	m.apiErrors[op]++
+	m.mu.Unlock()
+}
+
+func (m *metrics) gatewayGap(kind string) {
+	m.mu.Lock()
+	m.gatewayGaps[kind]++
+	m.mu.Unlock()
+}
+
+func (m *metrics) voiceEvent() {
+	m.mu.Lock()
+	m.voiceEvents++
+	m.mu.Unlock()
+}
+
+// serveMetrics serves /metrics on addr until ctx is done.
+func (h *handler) serveMetrics(ctx context.Context, addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metrics", h.writeMetrics)
+
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("Metrics endpoint stopped", "err", err)
+	}
+}
+
+func (h *handler) writeMetrics(w http.ResponseWriter, req *http.Request) {
+	h.mu.Lock()
+	rooms := make(map[discord.GuildID]uint64)
+	for _, r := range h.rooms {
+		rooms[r.guildID]++
+	}
+	retrying := len(h.deleteRetries)
+	abandoned := len(h.abandonedChannels(discord.NullGuildID))
+	h.mu.Unlock()
+
+	depths := h.queues.depths()
+
+	// The runtime gauges lock the handler, so they are read before m.mu.
+	var b strings.Builder
+	h.writeRuntimeMetrics(&b)
+
+	m := h.metrics
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	active := make(map[string]uint64)
+	for guildID, n := range rooms {
+		active[m.label(guildID)] += n
+	}
+	queued := make(map[string]uint64)
+	for guildID, n := range depths {
+		queued[m.label(guildID)] += uint64(n)
+	}
+
+	writeGuildMetric(&b, "tvc_rooms_created_total", "counter", "Temporary rooms created.", m.created)
+	writeGuildMetric(&b, "tvc_rooms_deleted_total", "counter", "Temporary rooms deleted.", m.deleted)
+	writeGuildMetric(&b, "tvc_channel_delete_failures_total", "counter", "Failed attempts at deleting a temporary channel.", m.deleteFailed)
+	writeGuildMetric(&b, "tvc_active_rooms", "gauge", "Temporary rooms that exist right now.", active)
+	writeGuildMetric(&b, "tvc_guild_queue_depth", "gauge", "Gateway events waiting for their guild's worker.", queued)
+
+	fmt.Fprintf(&b, "# HELP tvc_delete_retries_pending Failed deletions waiting to be retried.\n# TYPE tvc_delete_retries_pending gauge\ntvc_delete_retries_pending %d\n", retrying)
+	fmt.Fprintf(&b, "# HELP tvc_abandoned_channels Channels whose deletion kept failing, still retried.\n# TYPE tvc_abandoned_channels gauge\ntvc_abandoned_channels %d\n", abandoned)
+	fmt.Fprintf(&b, "# HELP tvc_voice_state_events_total Voice state updates processed.\n# TYPE tvc_voice_state_events_total counter\ntvc_voice_state_events_total %d\n", m.voiceEvents)
+
+	b.WriteString("# HELP tvc_api_errors_total Failed Discord API calls by operation.\n# TYPE tvc_api_errors_total counter\n")
+	ops := make([]string, 0, len(m.apiErrors))
+	for op := range m.apiErrors {
+		ops = append(ops, op)
+	}
+	slices.Sort(ops)
+	for _, op := range ops {
+		fmt.Fprintf(&b, "tvc_api_errors_total{op=%q} %d\n", op, m.apiErrors[op])
+	}
+
+	b.WriteString("# HELP tvc_gateway_gaps_total Gateway reconnects that may have missed events, by whether the session was resumed.\n# TYPE tvc_gateway_gaps_total counter\n")
+	for _, kind := range []string{"resume", "reidentify"} {
+		fmt.Fprintf(&b, "tvc_gateway_gaps_total{kind=%q} %d\n", kind, m.gatewayGaps[kind])
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write([]byte(withBotLabel(b.String(), m.bot)))
+}
+
+// withBotLabel adds bot="<bot>" to every series in text, unless bot is empty.
+func withBotLabel(text, bot string) string {
+	if bot == "" {
+		return text
+	}
+	label := fmt.Sprintf("bot=%q", bot)
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if name, labels, ok := strings.Cut(line, "{"); ok {
+			lines[i] = name + "{" + label + "," + labels
+		} else if name, value, ok := strings.Cut(line, " "); ok {
+			lines[i] = name + "{" + label + "} " + value
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// writeGuildMetric writes a metric labelled by guild.
+func writeGuildMetric(b *strings.Builder, name, kind, help string, values map[string]uint64) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	labels := make([]string, 0, len(values))
+	for label := range values {
+		labels = append(labels, label)
+	}
+	slices.Sort(labels)
+	for _, label := range labels {
+		fmt.Fprintf(b, "%s{guild_id=%q} %d\n", name, label, values[label])
+	}
+}
@@ -0,0 +1,174 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// cmdImport handles /voiceadmin import, which adopts rooms made by another
+// temporary channel bot so switching doesn't strand them. Voice channels
+// become rooms of the voice hub and categories become team rooms. Channels
+// are picked by a name pattern, an explicit list, or both.
+func (h *handler) cmdImport(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	var pattern *regexp.Regexp
+	if p := data.Options.Find("pattern").String(); p != "" {
+		var err error
+		if pattern, err = regexp.Compile(p); err != nil {
+			return ephemeralData(fmt.Sprintf("That pattern doesn't work: %v.", err))
+		}
+	}
+	listed := make(map[discord.ChannelID]bool)
+	for _, arg := range strings.FieldsFunc(data.Options.Find("channels").String(), func(r rune) bool {
+		return r == ',' || r == ' '
+	}) {
+		id, err := parseChannelMention(arg)
+		if err != nil {
+			return ephemeralData(fmt.Sprintf("%q isn't a channel mention or ID.", arg))
+		}
+		listed[id] = true
+	}
+	if pattern == nil && len(listed) == 0 {
+		return ephemeralData("Give a name pattern or a list of channels to import.")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	guildID := data.Event.GuildID
+	ctx = withGuild(ctx, guildID)
+	channels, err := h.fetchChannels(ctx, guildID)
+	if err != nil {
+		return ephemeralData("I couldn't fetch the server's channels, try again.")
+	}
+
+	var adopted []string
+	for i := range channels {
+		ch := &channels[i]
+		if !listed[ch.ID] && (pattern == nil || !pattern.MatchString(ch.Name)) {
+			continue
+		}
+		if h.lookupHub(ch) != nil || h.created[ch.ID] {
+			continue
+		}
+
+		var r *room
+		switch ch.Type {
+		case discord.GuildVoice:
+			r = h.adoptVoiceRoom(ch)
+		case discord.GuildCategory:
+			r = h.adoptTeamRoom(ch, channels)
+		}
+		if r == nil {
+			continue
+		}
+		h.addRoom(ctx, r.channelID, r)
+		adopted = append(adopted, r.channelID.Mention())
+		if h.roomOccupants(r) == 0 {
+			voice, err := h.s.Channel(r.channelID)
+			if err == nil {
+				h.roomEmptied(ctx, voice, r)
+			}
+		}
+	}
+
+	if len(adopted) == 0 {
+		return ephemeralData("No channels matched that weren't managed already.")
+	}
+	msg := fmt.Sprintf("Imported %s: %s", plural(len(adopted), "room"), strings.Join(adopted, " "))
+	if len(msg) > 1900 {
+		msg = fmt.Sprintf("Imported %s.", plural(len(adopted), "room"))
+	}
+	return ephemeralData(msg)
+}
+
+// adoptVoiceRoom registers a voice channel made by another bot as a room of
+// the voice hub.
+func (h *handler) adoptVoiceRoom(ch *discord.Channel) *room {
+	hub := h.hubOfKind(ch.GuildID, voiceRoom)
+	if hub == nil {
+		return nil
+	}
+	h.created[ch.ID] = true
+	h.temporaryChannels = append(h.temporaryChannels, ch.ID)
+	return &room{
+		hub:       hub,
+		guildID:   ch.GuildID,
+		channelID: ch.ID,
+		owner:     h.inferOwner(ch, ch.ID),
+		name:      ch.Name,
+	}
+}
+
+// adoptTeamRoom registers a category made by another bot as a team room. The
+// category needs a voice channel; its first text channel, if any, becomes
+// the room's chat.
+func (h *handler) adoptTeamRoom(category *discord.Channel, channels []discord.Channel) *room {
+	hub := h.hubOfKind(category.GuildID, teamRoom)
+	if hub == nil {
+		return nil
+	}
+	var voice, text discord.ChannelID
+	for _, ch := range channels {
+		switch {
+		case ch.ParentID != category.ID:
+		case ch.Type == discord.GuildVoice && !voice.IsValid():
+			voice = ch.ID
+		case ch.Type == discord.GuildText && !text.IsValid():
+			text = ch.ID
+		}
+	}
+	if !voice.IsValid() || h.created[voice] {
+		return nil
+	}
+
+	for _, ch := range channels {
+		if ch.ParentID == category.ID {
+			h.created[ch.ID] = true
+		}
+	}
+	h.created[category.ID] = true
+	h.temporaryCategories = append(h.temporaryCategories, voice)
+	return &room{
+		hub:         hub,
+		guildID:     category.GuildID,
+		channelID:   voice,
+		owner:       h.inferOwner(category, voice),
+		name:        category.Name,
+		category:    category.ID,
+		textChannel: text,
+	}
+}
+
+// inferOwner guesses who owns a room made by another bot. Such bots give the
+// owner a member overwrite, usually with Manage Channels, on the channel or
+// category; failing that, whoever is in the voice channel is picked.
+func (h *handler) inferOwner(ch *discord.Channel, voice discord.ChannelID) discord.UserID {
+	me, _ := h.s.Me()
+	var fallback discord.UserID
+	for _, o := range ch.Overwrites {
+		if o.Type != discord.OverwriteMember || (me != nil && discord.UserID(o.ID) == me.ID) || h.cfg.companionBots[discord.UserID(o.ID)] {
+			continue
+		}
+		if o.Allow.Has(discord.PermissionManageChannels) {
+			return discord.UserID(o.ID)
+		}
+		if !fallback.IsValid() && o.Allow.Has(discord.PermissionConnect) {
+			fallback = discord.UserID(o.ID)
+		}
+	}
+	if fallback.IsValid() {
+		return fallback
+	}
+	for userID, vs := range h.userVoiceStates {
+		if vs.ChannelID == voice {
+			return userID
+		}
+	}
+	return 0
+}
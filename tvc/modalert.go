@@ -0,0 +1,218 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/diamondburned/arikawa/v3/utils/json/option"
+)
+
+// Custom IDs of the moderation alert buttons.
+const (
+	modBanID     = "mod-ban"
+	modDeleteID  = "mod-delete"
+	modDismissID = "mod-dismiss"
+)
+
+// modAlert is an alert waiting for a moderator to act on it.
+type modAlert struct {
+	guildID   discord.GuildID
+	userID    discord.UserID
+	channelID discord.ChannelID // the offending room, if any
+	reason    string
+}
+
+// modChannelFor returns the guild's moderation channel, or 0 if it has none.
+func (h *handler) modChannelFor(guildID discord.GuildID) discord.ChannelID {
+	for id := range h.cfg.modChannels {
+		if ch, err := h.s.Channel(id); err == nil && ch.GuildID == guildID {
+			return id
+		}
+	}
+	return 0
+}
+
+// postModAlert posts reason to the guild's moderation channel with buttons to
+// ban userID from hubs, delete the room channelID, or dismiss the alert.
+func (h *handler) postModAlert(ctx context.Context, guildID discord.GuildID, userID discord.UserID, channelID discord.ChannelID, reason string) {
+	modChannel := h.modChannelFor(guildID)
+	if !modChannel.IsValid() {
+		return
+	}
+
+	buttons := discord.ActionRowComponent{
+		&discord.ButtonComponent{
+			Style:    discord.DangerButtonStyle(),
+			CustomID: modBanID,
+			Label:    "Ban from hubs",
+		},
+	}
+	if channelID.IsValid() {
+		buttons = append(buttons, &discord.ButtonComponent{
+			Style:    discord.DangerButtonStyle(),
+			CustomID: modDeleteID,
+			Label:    "Delete channel",
+		})
+	}
+	buttons = append(buttons, &discord.ButtonComponent{
+		Style:    discord.SecondaryButtonStyle(),
+		CustomID: modDismissID,
+		Label:    "Dismiss",
+	})
+
+	err := h.call(ctx, "SendMessage", func(s *state.State) error {
+		msg, err := s.SendMessageComplex(modChannel, api.SendMessageData{
+			Content:         reason,
+			Components:      discord.ContainerComponents{&buttons},
+			AllowedMentions: &api.AllowedMentions{},
+		})
+		if err != nil {
+			return err
+		}
+		h.modAlerts[msg.ID] = &modAlert{guildID, userID, channelID, reason}
+		return nil
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to post moderation alert", "err", err)
+	}
+}
+
+// checkRoomName alerts moderators if the room's name contains a banned word.
+func (h *handler) checkRoomName(ctx context.Context, channelID discord.ChannelID, r *room) {
+	word := h.bannedWordIn(r.name)
+	if word == "" {
+		return
+	}
+	h.postModAlert(ctx, r.guildID, r.owner, channelID,
+		"Room "+channelID.Mention()+" of "+r.owner.Mention()+" is named `"+strings.ReplaceAll(r.name, "`", "")+
+			"`, which contains the banned word ||"+word+"||.")
+}
+
+// onRoomRenamed rechecks a room's name when its channel, or its category in
+// team mode, is renamed.
+func (h *handler) onRoomRenamed(ch *discord.Channel) {
+	for id, r := range h.rooms {
+		if (id != ch.ID && r.category != ch.ID) || r.name == ch.Name {
+			continue
+		}
+		if id == ch.ID && r.category.IsValid() {
+			// Team rooms are named by their category.
+			continue
+		}
+		r.name = ch.Name
+		h.stats.recordRename(id, ch.Name, time.Now())
+		h.checkRoomName(withGuild(context.Background(), r.guildID), id, r)
+	}
+}
+
+func (h *handler) bannedWordIn(name string) string {
+	name = strings.ToLower(name)
+	for _, word := range h.cfg.bannedNameWords {
+		if strings.Contains(name, strings.ToLower(word)) {
+			return word
+		}
+	}
+	return ""
+}
+
+// hubBanned reports whether userID is banned from the guild's hubs, and
+// disconnects them from the hub if so.
+func (h *handler) hubBanned(ctx context.Context, guildID discord.GuildID, userID discord.UserID) bool {
+	if !h.stats.hubBanned(guildID, userID) {
+		return false
+	}
+	h.queueMove(memberMove{
+		guildID:   guildID,
+		userID:    userID,
+		channelID: discord.NullChannelID,
+		reason:    "member is banned from hubs",
+		failure:   "Failed to disconnect member banned from hubs",
+	})
+	return true
+}
+
+// takeModAlert removes and returns the alert a button belongs to if the
+// presser may act on it. Otherwise the response to send instead is returned.
+func (h *handler) takeModAlert(data cmdroute.ComponentData) (*modAlert, *api.InteractionResponse) {
+	perms, err := h.s.Permissions(data.Event.ChannelID, data.Event.SenderID())
+	if err != nil || !perms.Has(discord.PermissionModerateMembers) {
+		return nil, ephemeral("You need the Timeout Members permission to act on alerts.")
+	}
+	alert, ok := h.modAlerts[data.Event.Message.ID]
+	if !ok {
+		return nil, resolveModAlert("This alert has expired.")
+	}
+	delete(h.modAlerts, data.Event.Message.ID)
+	return alert, nil
+}
+
+// onModBan bans the alert's member from creating rooms in the guild.
+func (h *handler) onModBan(ctx context.Context, data cmdroute.ComponentData) *api.InteractionResponse {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	alert, resp := h.takeModAlert(data)
+	if alert == nil {
+		return resp
+	}
+	h.stats.banFromHubs(hubBan{
+		GuildID:  alert.guildID,
+		UserID:   alert.userID,
+		BannedBy: data.Event.SenderID(),
+		BannedAt: time.Now(),
+	})
+	return resolveModAlert(alert.reason + "\n**Banned from hubs** by " + data.Event.SenderID().Mention() + ".")
+}
+
+// onModDelete deletes the alert's room.
+func (h *handler) onModDelete(ctx context.Context, data cmdroute.ComponentData) *api.InteractionResponse {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	alert, resp := h.takeModAlert(data)
+	if alert == nil {
+		return resp
+	}
+	if _, ok := h.rooms[alert.channelID]; !ok {
+		return resolveModAlert(alert.reason + "\nThe channel was already gone.")
+	}
+
+	ctx = withGuild(ctx, alert.guildID)
+	ch, err := h.fetchChannel(ctx, alert.channelID)
+	if err != nil {
+		h.modAlerts[data.Event.Message.ID] = alert
+		return ephemeral("Couldn't find the channel, try again.")
+	}
+	h.deleteRoom(ctx, ch)
+	return resolveModAlert(alert.reason + "\n**Channel deleted** by " + data.Event.SenderID().Mention() + ".")
+}
+
+// onModDismiss closes the alert without acting on it.
+func (h *handler) onModDismiss(ctx context.Context, data cmdroute.ComponentData) *api.InteractionResponse {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	alert, resp := h.takeModAlert(data)
+	if alert == nil {
+		return resp
+	}
+	return resolveModAlert(alert.reason + "\nDismissed by " + data.Event.SenderID().Mention() + ".")
+}
+
+// resolveModAlert replaces an alert with content and removes its buttons.
+func resolveModAlert(content string) *api.InteractionResponse {
+	return &api.InteractionResponse{
+		Type: api.UpdateMessage,
+		Data: &api.InteractionResponseData{
+			Content:         option.NewNullableString(content),
+			Components:      &discord.ContainerComponents{},
+			AllowedMentions: &api.AllowedMentions{},
+		},
+	}
+}
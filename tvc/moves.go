@@ -0,0 +1,141 @@
+package tvc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/diamondburned/arikawa/v3/utils/httputil"
+)
+
+// memberMove moves a member to another voice channel, or disconnects them if
+// channelID is discord.NullChannelID.
+type memberMove struct {
+	guildID   discord.GuildID
+	userID    discord.UserID
+	channelID discord.ChannelID
+	reason    string
+	// failure is logged with the error if the move fails for good, e.g.
+	// "Failed to move member to AFK channel".
+	failure string
+}
+
+// moveQueue paces every member move the bot makes. Moves nobody waits for are
+// queued and made in order by run; the others wait their turn in moveMember.
+type moveQueue struct {
+	mu      sync.Mutex
+	next    time.Time
+	pending []memberMove
+	wake    chan struct{}
+	// interval is the least time between two moves; see
+	// settings.moveInterval.
+	interval time.Duration
+}
+
+func newMoveQueue(interval time.Duration) *moveQueue {
+	return &moveQueue{wake: make(chan struct{}, 1), interval: interval}
+}
+
+// queueMove queues mv and returns immediately. Failures are only logged.
+func (h *handler) queueMove(mv memberMove) {
+	q := h.moves
+	q.mu.Lock()
+	q.pending = append(q.pending, mv)
+	q.mu.Unlock()
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// runMoves makes the queued moves until ctx is done.
+func (h *handler) runMoves(ctx context.Context) {
+	q := h.moves
+	for {
+		q.mu.Lock()
+		if len(q.pending) == 0 {
+			q.mu.Unlock()
+			select {
+			case <-ctx.Done():
+				return
+			case <-q.wake:
+				continue
+			}
+		}
+		mv := q.pending[0]
+		q.pending = q.pending[1:]
+		q.mu.Unlock()
+
+		if err := h.moveMember(withGuild(ctx, mv.guildID), mv); err != nil {
+			slog.Error(mv.failure, "guild_id", mv.guildID, "user_id", mv.userID, "channel_id", mv.channelID, "err", err)
+		}
+	}
+}
+
+// moveMember makes mv once its turn comes, retrying while Discord is rate
+// limiting or failing. Its waits release h.mu like h.call does.
+func (h *handler) moveMember(ctx context.Context, mv memberMove) error {
+	backoff := h.cfg.moveInterval
+	for attempt := 0; ; attempt++ {
+		var err error
+		h.unlocked(ctx, func() { err = h.moves.wait(ctx) })
+		if err != nil {
+			return err
+		}
+		err = h.call(ctx, "ModifyMember", func(s *state.State) error {
+			return s.ModifyMember(mv.guildID, mv.userID, api.ModifyMemberData{
+				VoiceChannel:   mv.channelID,
+				AuditLogReason: api.AuditLogReason(mv.reason),
+			})
+		})
+		if err == nil || attempt >= h.cfg.moveRetries || !retryableMove(err) {
+			return err
+		}
+
+		backoff = max(2*backoff, time.Second)
+		h.unlocked(ctx, func() {
+			select {
+			case <-ctx.Done():
+			case <-time.After(backoff):
+			}
+		})
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// wait blocks until the next move may be made.
+func (q *moveQueue) wait(ctx context.Context) error {
+	q.mu.Lock()
+	now := time.Now()
+	at := q.next
+	if at.Before(now) {
+		at = now
+	}
+	q.next = at.Add(q.interval)
+	q.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(time.Until(at)):
+		return nil
+	}
+}
+
+// retryableMove reports whether a failed move is worth another try.
+func retryableMove(err error) bool {
+	var httpErr *httputil.HTTPError
+	if errors.As(err, &httpErr) && httpErr.Status == http.StatusTooManyRequests {
+		return true
+	}
+	return isOutage(err)
+}
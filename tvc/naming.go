@@ -0,0 +1,150 @@
+package tvc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// defaultNameTemplate is used when a hub has no naming configuration, and as
+// the fallback when a provider fails.
+const defaultNameTemplate = "{username}'s room"
+
+// namingProvider generates the name of a new room.
+type namingProvider interface {
+	roomName(ctx context.Context, req namingRequest) (string, error)
+}
+
+// namingRequest holds what providers may base a name on.
+type namingRequest struct {
+	GuildID  discord.GuildID `json:"guild_id"`
+	Hub      string          `json:"hub"`
+	UserID   discord.UserID  `json:"user_id"`
+	Username string          `json:"username"`
+	// Number counts the hub's rooms in the guild, for names like
+	// "Room #17". It is persisted with the stats.
+	Number int `json:"number"`
+}
+
+// newNamingProvider builds the provider selected by $<key>_NAMING, which is
+// one of "template" (the default), "words" or "http". tmpl is the template
+// used unless $<key>_NAME_TEMPLATE is set; empty means defaultNameTemplate.
+func newNamingProvider(env *environment, key, tmpl string) namingProvider {
+	if tmpl == "" {
+		tmpl = defaultNameTemplate
+	}
+	tmpl = env.string(key+"_NAME_TEMPLATE", tmpl)
+
+	switch mode := env.string(key+"_NAMING", "template"); mode {
+	case "template":
+		return templateNamer(tmpl)
+	case "words":
+		words := env.list(key + "_NAME_WORDS")
+		if len(words) == 0 {
+			env.configError("$%s_NAMING is words but $%s_NAME_WORDS is empty", key, key)
+			return templateNamer(tmpl)
+		}
+		return wordPoolNamer(words)
+	case "http":
+		url := env.string(key+"_NAME_URL", "")
+		if url == "" {
+			env.configError("$%s_NAMING is http but $%s_NAME_URL is empty", key, key)
+			return templateNamer(tmpl)
+		}
+		return &httpNamer{
+			url:    url,
+			client: &http.Client{Timeout: env.duration(key+"_NAME_TIMEOUT", 2*time.Second)},
+		}
+	default:
+		env.configError("invalid $%s_NAMING %q", key, mode)
+		return templateNamer(tmpl)
+	}
+}
+
+// templateNamer expands a fixed template such as "{username}'s room".
+type templateNamer string
+
+func (t templateNamer) roomName(_ context.Context, req namingRequest) (string, error) {
+	return expandTemplate(string(t), templateData{Username: req.Username, Number: req.Number}), nil
+}
+
+// wordPoolNamer picks a random entry from a list of names. Entries are
+// templates themselves, so "{username}'s den" works too.
+type wordPoolNamer []string
+
+func (w wordPoolNamer) roomName(_ context.Context, req namingRequest) (string, error) {
+	word := w[rand.IntN(len(w))]
+	return expandTemplate(word, templateData{Username: req.Username, Number: req.Number}), nil
+}
+
+// httpNamer asks an external service, such as an LLM-backed generator, for a
+// name. The request is POSTed as JSON and the service answers with
+// {"name": "..."}.
+type httpNamer struct {
+	url    string
+	client *http.Client
+}
+
+func (n *httpNamer) roomName(ctx context.Context, req namingRequest) (string, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(httpReq)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("naming service returned %s", resp.Status)
+	}
+
+	var out struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("failed to decode naming service response: %w", err)
+	}
+	if out.Name = strings.TrimSpace(out.Name); out.Name == "" {
+		return "", fmt.Errorf("naming service returned an empty name")
+	}
+	return out.Name, nil
+}
+
+// roomName generates the name of the room requested by req, falling back to
+// the default template if the hub's provider fails.
+func (h *handler) roomName(ctx context.Context, req roomRequest) string {
+	nreq := namingRequest{
+		GuildID:  req.hubChannel.GuildID,
+		Hub:      req.hub.label(),
+		UserID:   req.userID,
+		Username: req.username,
+		Number:   req.number,
+	}
+
+	if p := h.profileOf(nreq.GuildID); p != nil && p.NameTemplate != "" {
+		return expandTemplate(p.NameTemplate, templateData{Username: req.username, Number: req.number})
+	}
+	name, err := req.hub.naming.roomName(ctx, nreq)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to generate room name, using the default", "err", err)
+		name, _ = templateNamer(defaultNameTemplate).roomName(ctx, nreq)
+	}
+	return name
+}
@@ -0,0 +1,77 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+func parseGuildNicknames(env *environment) map[discord.GuildID]string {
+	const prefix = "GUILD_NICKNAME_"
+	nicks := make(map[discord.GuildID]string)
+	for _, kv := range env.environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		guildID, err := discord.ParseSnowflake(strings.TrimPrefix(key, prefix))
+		if err != nil {
+			env.configError("invalid guild ID in $%s: %v", key, err)
+			continue
+		}
+		nicks[discord.GuildID(guildID)] = value
+	}
+	return nicks
+}
+
+// nicknameFor returns the nickname the bot should have in guildID.
+func (h *handler) nicknameFor(guildID discord.GuildID) string {
+	if nick, ok := h.cfg.guildNicknames[guildID]; ok {
+		return nick
+	}
+	return h.cfg.botNickname
+}
+
+// applyNickname sets the bot's nickname in the guild if it differs from the
+// configured one.
+func (h *handler) applyNickname(guildID discord.GuildID, current string) {
+	want := h.nicknameFor(guildID)
+	if want == "" || want == current {
+		return
+	}
+
+	ctx := withGuild(context.Background(), guildID)
+	err := h.call(ctx, "ModifyCurrentMember", func(s *state.State) error {
+		return s.ModifyCurrentMember(guildID, want)
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to set nickname", "err", err)
+	}
+}
+
+// currentNickname returns the bot's nickname in the guild of evt.
+func (h *handler) currentNickname(evt *gateway.GuildCreateEvent) string {
+	me, err := h.s.Me()
+	if err != nil {
+		return ""
+	}
+	if member, err := h.s.Member(evt.ID, me.ID); err == nil {
+		return member.Nick
+	}
+	return ""
+}
+
+// onGuildMemberUpdate puts the bot's nickname back when someone changes it,
+// and updates booster perks when a room owner starts or stops boosting.
+// Discord sends updates of the bot's own member without the members intent.
+func (h *handler) onGuildMemberUpdate(evt *gateway.GuildMemberUpdateEvent) {
+	if me, err := h.s.Me(); err == nil && me.ID == evt.User.ID {
+		h.applyNickname(evt.GuildID, evt.Nick)
+		return
+	}
+	h.updateBoostPerks(evt)
+}
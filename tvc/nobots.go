@@ -0,0 +1,84 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+// permissionUseExternalApps lets members use user-installed apps. arikawa
+// doesn't know it yet.
+const permissionUseExternalApps discord.Permissions = 1 << 50
+
+// denyExternalApps denies Use External Apps to @everyone in a newly created
+// room or team category when the guild has featureNoBots on.
+func (h *handler) denyExternalApps(ctx context.Context, ch *discord.Channel) {
+	if !h.features.enabled(ch.GuildID, featureNoBots) {
+		return
+	}
+	if err := h.denyEveryone(ctx, ch, permissionUseExternalApps, "external apps are not allowed in rooms"); err != nil {
+		slog.ErrorContext(ctx, "Failed to deny external apps", "err", err)
+	}
+}
+
+// denyEveryone denies perms to @everyone in ch. The deny is merged into any
+// @everyone overwrite the channel inherited, and ch is updated to match so
+// later calls see it.
+func (h *handler) denyEveryone(ctx context.Context, ch *discord.Channel, perms discord.Permissions, reason api.AuditLogReason) error {
+	everyone := discord.Snowflake(ch.GuildID)
+	o := discord.Overwrite{ID: everyone, Type: discord.OverwriteRole}
+	i := -1
+	for j, existing := range ch.Overwrites {
+		if existing.ID == everyone {
+			o, i = existing, j
+		}
+	}
+	o.Allow &^= perms
+	o.Deny |= perms
+
+	err := h.call(ctx, "EditChannelPermission", func(s *state.State) error {
+		return s.EditChannelPermission(ch.ID, everyone, api.EditChannelPermissionData{
+			Type:           discord.OverwriteRole,
+			Allow:          o.Allow,
+			Deny:           o.Deny,
+			AuditLogReason: reason,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	if i >= 0 {
+		ch.Overwrites[i] = o
+	} else {
+		ch.Overwrites = append(ch.Overwrites, o)
+	}
+	return nil
+}
+
+// allowBot reports whether the member joining room r may stay. When the guild
+// has featureNoBots on, bots other than this one and its companions are
+// disconnected.
+func (h *handler) allowBot(ctx context.Context, r *room, evt *gateway.VoiceStateUpdateEvent) bool {
+	if evt.Member == nil || !evt.Member.User.Bot || !h.features.enabled(r.guildID, featureNoBots) {
+		return true
+	}
+	if h.cfg.companionBots[evt.UserID] {
+		return true
+	}
+	if me, err := h.s.Me(); err == nil && me.ID == evt.UserID {
+		return true
+	}
+
+	h.queueMove(memberMove{
+		guildID:   r.guildID,
+		userID:    evt.UserID,
+		channelID: discord.NullChannelID,
+		reason:    "bots are not allowed in rooms",
+		failure:   "Failed to disconnect bot from room",
+	})
+	return false
+}
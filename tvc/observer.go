@@ -0,0 +1,199 @@
+package tvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+// errObserving is returned instead of making a request observer mode blocks.
+var errObserving = errors.New("blocked by observer mode")
+
+// setupAdoptID is the custom ID of the setup wizard's menu of channels that
+// look like hubs.
+const setupAdoptID = "setup-adopt"
+
+// hubLikeNames are what channel names of other bots' hubs tend to contain,
+// compared after lowercasing and turning dashes and underscores into spaces.
+var hubLikeNames = []string{"join to create", "join 2 create", "jtc", "create room", "create a room", "create channel", "new room", "➕"}
+
+// isHubLike reports whether a voice channel's name looks like a hub's.
+func isHubLike(name string) bool {
+	name = strings.NewReplacer("-", " ", "_", " ").Replace(strings.ToLower(name))
+	for _, like := range hubLikeNames {
+		if strings.Contains(name, like) {
+			return true
+		}
+	}
+	return false
+}
+
+// configured reports whether the guild has a hub channel, is named by a
+// hub's config or is a staging guild.
+func (h *handler) configured(evt *gateway.GuildCreateEvent) bool {
+	if _, ok := h.cfg.stagingGuilds[evt.ID]; ok {
+		return true
+	}
+	for _, hub := range h.hubs {
+		if slices.Contains(hub.guilds, evt.ID) {
+			return true
+		}
+	}
+	for i := range evt.Channels {
+		if h.lookupHub(&evt.Channels[i]) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// hubLikeChannels lists the guild's voice channels that look like hubs of
+// another bot.
+func (h *handler) hubLikeChannels(guildID discord.GuildID) []discord.Channel {
+	channels, err := h.s.Channels(guildID)
+	if err != nil {
+		return nil
+	}
+	var found []discord.Channel
+	for i, ch := range channels {
+		if ch.Type == discord.GuildVoice && h.lookupHub(&channels[i]) == nil && isHubLike(ch.Name) {
+			found = append(found, ch)
+		}
+	}
+	return found
+}
+
+// observeJoin logs a join in an observed guild if the channel is a hub or
+// looks like one, and counts it for the setup wizard. Nothing else happens.
+func (h *handler) observeJoin(ctx context.Context, ch *discord.Channel, userID discord.UserID) {
+	if h.lookupHub(ch) == nil && !isHubLike(ch.Name) {
+		return
+	}
+	seen := h.observed[ch.GuildID]
+	if seen == nil {
+		seen = make(map[discord.ChannelID]int)
+		h.observed[ch.GuildID] = seen
+	}
+	seen[ch.ID]++
+	slog.InfoContext(ctx, "Observed hub-like activity", "channel_id", ch.ID, "channel", ch.Name, "user_id", userID, "joins", seen[ch.ID])
+}
+
+// observeSummary describes what the bot saw in an observed guild and the
+// channels it thinks are hubs, for the setup wizard.
+func (h *handler) observeSummary(guildID discord.GuildID) string {
+	var b strings.Builder
+	if like := h.hubLikeChannels(guildID); len(like) > 0 {
+		b.WriteString("These channels look like hubs of another bot:")
+		for _, ch := range like {
+			b.WriteString("\n- " + ch.Mention())
+			if n := h.observed[guildID][ch.ID]; n > 0 {
+				fmt.Fprintf(&b, " (%s seen)", plural(n, "join"))
+			}
+		}
+		b.WriteString("\nPick one to turn it into my hub, or press Set up to create my own hubs.")
+	}
+	return b.String()
+}
+
+// setupComponents are the setup wizard's controls: its button and, if the
+// guild has channels that look like hubs, a menu to adopt one.
+func (h *handler) setupComponents(guildID discord.GuildID) discord.ContainerComponents {
+	components := discord.ContainerComponents{
+		&discord.ActionRowComponent{
+			&discord.ButtonComponent{
+				Style:    discord.PrimaryButtonStyle(),
+				CustomID: setupButtonID,
+				Label:    "Set up",
+			},
+		},
+	}
+	like := h.hubLikeChannels(guildID)
+	if len(like) == 0 || !h.isObserving(guildID) {
+		return components
+	}
+	var options []discord.SelectOption
+	for _, ch := range like[:min(len(like), 25)] {
+		options = append(options, discord.SelectOption{Label: ch.Name, Value: ch.ID.String()})
+	}
+	return append(components, &discord.ActionRowComponent{
+		&discord.StringSelectComponent{
+			CustomID:    setupAdoptID,
+			Placeholder: "Use one of these as my hub",
+			Options:     options,
+		},
+	})
+}
+
+// isObserving reports whether guildID is in observer mode.
+func (h *handler) isObserving(guildID discord.GuildID) bool {
+	return guildID.IsValid() && h.stats.isObserving(guildID)
+}
+
+// stopObserving takes the guild out of observer mode.
+func (h *handler) stopObserving(ctx context.Context, guildID discord.GuildID, userID discord.UserID) {
+	if !h.isObserving(guildID) {
+		return
+	}
+	h.stats.setObserving(guildID, false)
+	delete(h.observed, guildID)
+	slog.InfoContext(ctx, "Left observer mode", "user_id", userID)
+}
+
+// onSetupAdopt renames the picked channel to the voice hub's name, so it
+// becomes a hub, and then runs the rest of the setup wizard.
+func (h *handler) onSetupAdopt(ctx context.Context, data cmdroute.ComponentData) *api.InteractionResponse {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	guildID, ok := h.setupGuild(data)
+	if !ok {
+		return ephemeral("This setup message has expired. Run /voiceadmin validate to check your setup.")
+	}
+	if !h.canManageGuild(guildID, data.Event.SenderID()) {
+		return ephemeral("You need the Manage Server permission to set me up.")
+	}
+	sel, ok := data.ComponentInteraction.(*discord.StringSelectInteraction)
+	if !ok || len(sel.Values) == 0 {
+		return nil
+	}
+	id, err := discord.ParseSnowflake(sel.Values[0])
+	if err != nil {
+		return nil
+	}
+	if ch, err := h.s.Channel(discord.ChannelID(id)); err != nil || ch.GuildID != guildID {
+		return ephemeral("That channel is gone.")
+	}
+	hub := h.hubOfKind(guildID, voiceRoom)
+	if hub == nil || hub.name == "" {
+		return ephemeral("None of my hubs goes by a channel name, so I can't adopt a channel. Add its ID to a hub's channel_ids instead.")
+	}
+
+	ctx = withGuild(ctx, guildID)
+	err = h.call(ctx, "ModifyChannel", func(s *state.State) error {
+		return s.ModifyChannel(discord.ChannelID(id), api.ModifyChannelData{
+			Name:           hub.name,
+			AuditLogReason: "setup wizard",
+		})
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to adopt hub", "err", err)
+		return ephemeral("I couldn't rename that channel: " + err.Error())
+	}
+	// Let createHubs see the new name before the gateway confirms it.
+	if ch, err := h.s.Channel(discord.ChannelID(id)); err == nil {
+		renamed := *ch
+		renamed.Name = hub.name
+		h.s.ChannelSet(&renamed, true)
+	}
+	h.stopObserving(ctx, guildID, data.Event.SenderID())
+	return h.finishSetup(ctx, guildID, "Turned "+discord.ChannelID(id).Mention()+" into a hub.\n")
+}
@@ -0,0 +1,122 @@
+package tvc
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// occupancySnapshot is how busy a guild's rooms were at one point in time.
+type occupancySnapshot struct {
+	GuildID discord.GuildID `json:"guild_id"`
+	At      time.Time       `json:"at"`
+	Rooms   int             `json:"rooms"`
+	Members int             `json:"members"`
+}
+
+// snapshotOccupancy records the occupancy of every guild with live rooms
+// every interval until ctx is done. Guilds without rooms aren't recorded, but
+// every interval is, so they count as empty in the heatmap.
+func (h *handler) snapshotOccupancy(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			h.mu.Lock()
+			byGuild := make(map[discord.GuildID]*occupancySnapshot)
+			for _, r := range h.rooms {
+				snap, ok := byGuild[r.guildID]
+				if !ok {
+					snap = &occupancySnapshot{GuildID: r.guildID, At: now.UTC()}
+					byGuild[r.guildID] = snap
+				}
+				snap.Rooms++
+				snap.Members += h.roomOccupants(r)
+			}
+			h.mu.Unlock()
+
+			snaps := make([]occupancySnapshot, 0, len(byGuild))
+			for _, snap := range byGuild {
+				snaps = append(snaps, *snap)
+			}
+			h.stats.recordOccupancy(now.UTC(), snaps, h.cfg.occupancyRetention)
+		}
+	}
+}
+
+// occupancyJSON is the body of GET /occupancy. Heatmap holds the average
+// members in rooms by weekday (Sunday first) and hour.
+type occupancyJSON struct {
+	Snapshots int            `json:"snapshots"`
+	Heatmap   [7][24]float64 `json:"heatmap"`
+	Peak      [7][24]int     `json:"peak"`
+}
+
+// serveOccupancy answers GET /occupancy with a heatmap of the busiest hours,
+// for the guild in ?guild_id= or all guilds, in the time zone in ?tz= or UTC.
+func (h *handler) serveOccupancy(w http.ResponseWriter, req *http.Request) {
+	if !h.authorized(w, req) {
+		return
+	}
+	var guildID discord.GuildID
+	if s := req.URL.Query().Get("guild_id"); s != "" {
+		sf, err := discord.ParseSnowflake(s)
+		if err != nil {
+			http.Error(w, "bad guild_id", http.StatusBadRequest)
+			return
+		}
+		guildID = discord.GuildID(sf)
+	}
+	loc := time.UTC
+	if tz := req.URL.Query().Get("tz"); tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			http.Error(w, "bad tz", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// A snapshot is taken for every guild at once, so snapshots sharing a
+	// time are summed first when all guilds are asked for. Times without a
+	// snapshot of the guild count as empty; snapshots from before ticks were
+	// recorded count as ticks of their own.
+	ticks, snaps := h.stats.occupancy(guildID)
+	totals := make(map[time.Time]int, len(ticks))
+	for _, at := range ticks {
+		totals[at] = 0
+	}
+	for _, snap := range snaps {
+		totals[snap.At] += snap.Members
+	}
+
+	var body occupancyJSON
+	var counts [7][24]int
+	for at, members := range totals {
+		at = at.In(loc)
+		day, hour := at.Weekday(), at.Hour()
+		body.Heatmap[day][hour] += float64(members)
+		body.Peak[day][hour] = max(body.Peak[day][hour], members)
+		counts[day][hour]++
+	}
+	for day := range counts {
+		for hour, n := range counts[day] {
+			if n > 0 {
+				body.Heatmap[day][hour] /= float64(n)
+			}
+		}
+	}
+	body.Snapshots = len(totals)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		slog.Error("Failed to write occupancy", "err", err)
+	}
+}
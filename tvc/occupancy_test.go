@@ -0,0 +1,98 @@
+package tvc
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+func TestOccupancyAverageCountsEmptyTicks(t *testing.T) {
+	st, _ := newStatsStore(nil)
+	h := &handler{stats: st}
+
+	// A Wednesday morning: both guilds are busy at 10:00, guild 1 still is
+	// at 10:15, and neither has a room at 10:30 or 10:45.
+	at := time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)
+	tick := func(minutes int) time.Time { return at.Add(time.Duration(minutes) * time.Minute) }
+	retention := 28 * 24 * time.Hour
+	st.recordOccupancy(tick(0), []occupancySnapshot{
+		{GuildID: 1, At: tick(0), Rooms: 2, Members: 4},
+		{GuildID: 2, At: tick(0), Rooms: 1, Members: 2},
+	}, retention)
+	st.recordOccupancy(tick(15), []occupancySnapshot{{GuildID: 1, At: tick(15), Rooms: 1, Members: 2}}, retention)
+	st.recordOccupancy(tick(30), nil, retention)
+	st.recordOccupancy(tick(45), nil, retention)
+
+	for _, test := range []struct {
+		query   string
+		average float64
+		peak    int
+	}{
+		{"", 2, 6},
+		{"?guild_id=1", 1.5, 4},
+		{"?guild_id=2", 0.5, 2},
+		{"?guild_id=3", 0, 0},
+	} {
+		rec := httptest.NewRecorder()
+		h.serveOccupancy(rec, httptest.NewRequest("GET", "/occupancy"+test.query, nil))
+		var body occupancyJSON
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatalf("%q: %v", test.query, err)
+		}
+		if body.Snapshots != 4 {
+			t.Errorf("%q: %d snapshots, want 4", test.query, body.Snapshots)
+		}
+		if got := body.Heatmap[time.Wednesday][10]; got != test.average {
+			t.Errorf("%q: average %v, want %v", test.query, got, test.average)
+		}
+		if got := body.Peak[time.Wednesday][10]; got != test.peak {
+			t.Errorf("%q: peak %d, want %d", test.query, got, test.peak)
+		}
+	}
+}
+
+func TestOccupancyTimeZone(t *testing.T) {
+	st, _ := newStatsStore(nil)
+	h := &handler{stats: st}
+	at := time.Date(2026, 10, 14, 23, 0, 0, 0, time.UTC)
+	st.recordOccupancy(at, []occupancySnapshot{{GuildID: 1, At: at, Rooms: 1, Members: 3}}, 0)
+
+	rec := httptest.NewRecorder()
+	h.serveOccupancy(rec, httptest.NewRequest("GET", "/occupancy?tz=Asia/Tokyo", nil))
+	var body occupancyJSON
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if got := body.Heatmap[time.Thursday][8]; got != 3 {
+		t.Errorf("Thursday 8:00 in Tokyo averages %v, want 3", got)
+	}
+}
+
+func TestOccupancyRetention(t *testing.T) {
+	st, _ := newStatsStore(nil)
+	now := time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)
+	old := now.Add(-30 * 24 * time.Hour)
+	retention := 28 * 24 * time.Hour
+
+	st.recordOccupancy(old, []occupancySnapshot{{GuildID: 1, At: old, Members: 1}}, retention)
+	st.recordOccupancy(now, []occupancySnapshot{{GuildID: 1, At: now, Members: 2}}, retention)
+
+	ticks, snaps := st.occupancy(discord.NullGuildID)
+	if len(ticks) != 1 || !ticks[0].Equal(now) {
+		t.Errorf("ticks are %v, want only %v", ticks, now)
+	}
+	if len(snaps) != 1 || snaps[0].Members != 2 {
+		t.Errorf("snapshots are %v, want only the one of %v", snaps, now)
+	}
+
+	// Without a retention, everything is kept.
+	st.recordOccupancy(now.Add(time.Hour), nil, 0)
+	st.recordOccupancy(old, nil, 0)
+	if ticks, _ := st.occupancy(discord.NullGuildID); len(ticks) != 3 {
+		t.Errorf("%d ticks kept, want 3", len(ticks))
+	}
+}
@@ -0,0 +1,219 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+// onboardingWindow is how recently the bot must have joined a guild for it to
+// count as a new install. Guilds joined while the bot was down for longer
+// than this are not greeted.
+const onboardingWindow = time.Hour
+
+// setupButtonID is the custom ID of the button that runs the setup wizard.
+const setupButtonID = "setup"
+
+// setupCategoryName is the category the setup wizard creates hubs in.
+const setupCategoryName = "Temporary rooms"
+
+// onboardGuild sends a setup message to a guild the bot was just added to,
+// in its system channel or, failing that, to the owner by DM.
+func (h *handler) onboardGuild(evt *gateway.GuildCreateEvent) {
+	if !evt.Joined.IsValid() || time.Since(evt.Joined.Time()) > onboardingWindow || !h.stats.markOnboarded(evt.ID) {
+		return
+	}
+
+	ctx := withGuild(context.Background(), evt.ID)
+	content := "Thanks for adding me! Press the button to create the hub channels members join to get their own " +
+		"room, and check that I have the permissions I need. You can run it again any time."
+	if h.cfg.observeNewGuilds && !h.configured(evt) {
+		h.stats.setObserving(evt.ID, true)
+		slog.InfoContext(ctx, "Observing new guild until it is set up")
+		content = "Thanks for adding me! For now I'm only watching: I won't create, move or delete anything until " +
+			"you set me up. Press the button to create the hub channels members join to get their own room, and " +
+			"check that I have the permissions I need."
+		if summary := h.observeSummary(evt.ID); summary != "" {
+			content += "\n\n" + summary
+		}
+	}
+	data := api.SendMessageData{
+		Content:    content,
+		Components: h.setupComponents(evt.ID),
+	}
+
+	send := func(channelID discord.ChannelID) bool {
+		err := h.call(ctx, "SendMessage", func(s *state.State) error {
+			msg, err := s.SendMessageComplex(channelID, data)
+			if err != nil {
+				return err
+			}
+			h.setupMessages[msg.ID] = evt.ID
+			return nil
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to send onboarding message", "err", err)
+		}
+		return err == nil
+	}
+
+	if evt.SystemChannelID.IsValid() && send(evt.SystemChannelID) {
+		return
+	}
+	var dm *discord.Channel
+	err := h.call(ctx, "CreatePrivateChannel", func(s *state.State) (err error) {
+		dm, err = s.CreatePrivateChannel(evt.OwnerID)
+		return err
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to open DM with guild owner", "err", err)
+		return
+	}
+	send(dm.ID)
+}
+
+// onSetup runs the setup wizard: it creates the hubs the guild is missing and
+// reports what is left to fix, ending observer mode. Only the owner and
+// members with Manage Server may run it.
+func (h *handler) onSetup(ctx context.Context, data cmdroute.ComponentData) *api.InteractionResponse {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	guildID, ok := h.setupGuild(data)
+	if !ok {
+		return ephemeral("This setup message has expired. Run /voiceadmin validate to check your setup.")
+	}
+	if !h.canManageGuild(guildID, data.Event.SenderID()) {
+		return ephemeral("You need the Manage Server permission to set me up.")
+	}
+
+	ctx = withGuild(ctx, guildID)
+	h.stopObserving(ctx, guildID, data.Event.SenderID())
+	return h.finishSetup(ctx, guildID, "")
+}
+
+// setupGuild returns the guild a setup wizard control was used for: the one
+// its message was sent for, or else the guild it was used in, as for the
+// button /voiceadmin validate shows.
+func (h *handler) setupGuild(data cmdroute.ComponentData) (discord.GuildID, bool) {
+	if data.Event.Message != nil {
+		if guildID, ok := h.setupMessages[data.Event.Message.ID]; ok {
+			return guildID, true
+		}
+	}
+	return data.Event.GuildID, data.Event.GuildID.IsValid()
+}
+
+// finishSetup creates the hubs the guild is missing and reports what is left
+// to fix, after what the wizard already did.
+func (h *handler) finishSetup(ctx context.Context, guildID discord.GuildID, done string) *api.InteractionResponse {
+	created, err := h.createHubs(ctx, guildID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create hubs", "err", err)
+		return ephemeral(done + "I couldn't create the hubs: " + err.Error())
+	}
+
+	var b strings.Builder
+	b.WriteString(done)
+	if len(created) > 0 {
+		b.WriteString("Created " + strings.Join(created, ", ") + ".\n")
+	}
+	if problems := h.validateGuild(guildID); len(problems) > 0 {
+		b.WriteString("Left to fix:\n- " + strings.Join(problems, "\n- "))
+	} else {
+		b.WriteString("You're all set. Join a hub to get a room.")
+	}
+	return ephemeral(b.String())
+}
+
+// canManageGuild reports whether userID owns the guild or has Manage Server.
+func (h *handler) canManageGuild(guildID discord.GuildID, userID discord.UserID) bool {
+	guild, err := h.s.Guild(guildID)
+	if err != nil {
+		return false
+	}
+	if guild.OwnerID == userID {
+		return true
+	}
+	member, err := h.s.Member(guildID, userID)
+	if err != nil {
+		return false
+	}
+	var perms discord.Permissions
+	for _, roleID := range append(member.RoleIDs, discord.RoleID(guildID)) {
+		if role, err := h.s.Role(guildID, roleID); err == nil {
+			perms |= role.Permissions
+		}
+	}
+	return perms.Has(discord.PermissionAdministrator) || perms.Has(discord.PermissionManageGuild)
+}
+
+// createHubs creates a voice channel for every enabled hub the guild doesn't
+// have yet, in a category of their own. It returns the mentions of the hubs
+// it created.
+func (h *handler) createHubs(ctx context.Context, guildID discord.GuildID) ([]string, error) {
+	channels, err := h.s.Channels(guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	for _, hub := range h.hubs {
+		if hub.name == "" || !hub.serves(guildID) || slices.Contains(missing, hub.name) {
+			continue
+		}
+		if hub.kind == teamRoom && !h.features.enabled(guildID, featureTeams) {
+			continue
+		}
+		found := false
+		for i, ch := range channels {
+			found = found || (ch.Type == discord.GuildVoice && h.lookupHub(&channels[i]) == hub)
+		}
+		if !found {
+			missing = append(missing, hub.name)
+		}
+	}
+	if len(missing) == 0 {
+		return nil, nil
+	}
+
+	var category *discord.Channel
+	err = h.call(ctx, "CreateChannel", func(s *state.State) (err error) {
+		category, err = s.CreateChannel(guildID, api.CreateChannelData{
+			Name:           setupCategoryName,
+			Type:           discord.GuildCategory,
+			AuditLogReason: "setup wizard",
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var created []string
+	for _, name := range missing {
+		var ch *discord.Channel
+		err := h.call(ctx, "CreateChannel", func(s *state.State) (err error) {
+			ch, err = s.CreateChannel(guildID, api.CreateChannelData{
+				Name:           name,
+				Type:           discord.GuildVoice,
+				CategoryID:     category.ID,
+				AuditLogReason: "setup wizard",
+			})
+			return err
+		})
+		if err != nil {
+			return created, err
+		}
+		created = append(created, ch.Mention())
+	}
+	return created, nil
+}
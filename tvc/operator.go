@@ -0,0 +1,145 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+// operatorOnly wraps a command handler so only operators can use it.
+func (h *handler) operatorOnly(fn cmdroute.CommandHandlerFunc) cmdroute.CommandHandlerFunc {
+	return func(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+		if !h.cfg.operators[data.Event.SenderID()] {
+			return ephemeralData("This command is for the bot's h.cfg.operators.")
+		}
+		return fn(ctx, data)
+	}
+}
+
+// cmdOperatorStats handles /operator stats.
+func (h *handler) cmdOperatorStats(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	guilds, _ := h.s.Guilds()
+
+	h.mu.Lock()
+	rooms, occupants, pending := len(h.rooms), 0, len(h.pendingRooms)
+	for _, r := range h.rooms {
+		occupants += h.roomOccupants(r)
+	}
+	h.mu.Unlock()
+
+	recorded := h.stats.recorded()
+
+	status := "healthy"
+	if h.breaker.open() {
+		status = "degraded"
+	}
+	return ephemeralData(fmt.Sprintf(
+		"%s, %s open with %s in them, %s queued, %s on record. Discord API: %s.",
+		plural(len(guilds), "server"), plural(rooms, "room"), plural(occupants, "member"),
+		plural(pending, "room"), plural(recorded, "room"), status))
+}
+
+// cmdOperatorGuilds handles /operator guilds, which lists the bot's servers.
+func (h *handler) cmdOperatorGuilds(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	guilds, err := h.s.Guilds()
+	if err != nil {
+		return ephemeralData("I couldn't list my servers.")
+	}
+
+	h.mu.Lock()
+	rooms := make(map[discord.GuildID]int)
+	for _, r := range h.rooms {
+		rooms[r.guildID]++
+	}
+	h.mu.Unlock()
+
+	var b strings.Builder
+	for _, g := range guilds {
+		line := fmt.Sprintf("- %s (%s): %s\n", g.Name, g.ID, plural(rooms[g.ID], "room"))
+		if b.Len()+len(line) > 1900 {
+			b.WriteString("- …")
+			break
+		}
+		b.WriteString(line)
+	}
+	if b.Len() == 0 {
+		return ephemeralData("I'm not in any servers.")
+	}
+	return ephemeralData(b.String())
+}
+
+// cmdOperatorLeave handles /operator leave, which makes the bot leave a
+// server.
+func (h *handler) cmdOperatorLeave(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	id, err := discord.ParseSnowflake(strings.TrimSpace(data.Options.Find("guild_id").String()))
+	if err != nil {
+		return ephemeralData("That isn't a server ID.")
+	}
+	guildID := discord.GuildID(id)
+	g, err := h.s.Guild(guildID)
+	if err != nil {
+		return ephemeralData("I'm not in that server.")
+	}
+
+	err = h.call(withGuild(ctx, guildID), "LeaveGuild", func(s *state.State) error {
+		return s.LeaveGuild(guildID)
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to leave guild", "err", err)
+		return ephemeralData("I couldn't leave " + g.Name + ".")
+	}
+	slog.Warn("Left guild at the request of an operator", "guild_id", guildID, "user_id", data.Event.SenderID())
+	return ephemeralData("Left " + g.Name + ".")
+}
+
+// cmdOperatorFeature handles /operator feature, which turns a feature on or
+// off for every server that doesn't override it. The change is saved in the
+// stats store and outlives restarts.
+func (h *handler) cmdOperatorFeature(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	name := data.Options.Find("name").String()
+	var feat feature
+	for _, f := range knownFeatures {
+		if string(f) == name {
+			feat = f
+		}
+	}
+	if feat == "" {
+		return ephemeralData(fmt.Sprintf("There is no feature called %q.", name))
+	}
+	on, err := data.Options.Find("enabled").BoolValue()
+	if err != nil {
+		return ephemeralData("Tell me whether to turn it on.")
+	}
+
+	h.features.setDefault(feat, on)
+	h.stats.setFeatureDefault(feat, on)
+	if feat == featureStrict {
+		guilds, _ := h.s.Cabinet.Guilds()
+		for _, g := range guilds {
+			guildID := g.ID
+			go h.queues.dispatch(guildID, func() { h.checkStrict(guildID) })
+		}
+	}
+	slog.Info("Operator set feature", "user_id", data.Event.SenderID(), "feature", feat, "on", on)
+
+	setting := "off"
+	if on {
+		setting = "on"
+	}
+	return ephemeralData(fmt.Sprintf("%s is now %s by default. Servers overriding it in $GUILD_FEATURES_<id> keep their setting.", feat, setting))
+}
+
+// featureChoices lists the known features for a command option.
+func featureChoices() []discord.StringChoice {
+	choices := make([]discord.StringChoice, len(knownFeatures))
+	for i, f := range knownFeatures {
+		choices[i] = discord.StringChoice{Name: string(f), Value: string(f)}
+	}
+	return choices
+}
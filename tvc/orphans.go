@@ -0,0 +1,100 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+)
+
+// hubWait is a member's stay in a hub, as seen by the orphan scans.
+type hubWait struct {
+	hubID   discord.ChannelID
+	since   time.Time
+	retried bool
+}
+
+// rescueOrphans scans the hubs every interval until ctx is done, creating
+// rooms again for the members stuck in them.
+func (h *handler) rescueOrphans(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.mu.Lock()
+			h.scanHubs(time.Now())
+			h.mu.Unlock()
+		}
+	}
+}
+
+// scanHubs notes who is in each hub and creates the room again of members
+// who have been there for hubOrphanTimeout. That happens once per stay, so
+// members one of the checks turned away aren't turned away again and again.
+// Members picking a preset or waiting for a queued room are left alone.
+func (h *handler) scanHubs(now time.Time) {
+	seen := make(map[discord.UserID]bool)
+	for channelID, members := range h.channelMembers {
+		ch, err := h.s.Cabinet.Channel(channelID)
+		if err != nil || h.hubFor(ch) == nil || h.isObserving(ch.GuildID) {
+			continue
+		}
+		for userID := range members {
+			seen[userID] = true
+			w, ok := h.hubWaits[userID]
+			if !ok || w.hubID != channelID {
+				h.hubWaits[userID] = hubWait{hubID: channelID, since: now}
+				continue
+			}
+			if w.retried || now.Sub(w.since) < h.cfg.hubOrphanTimeout || h.waitingForRoom(userID) {
+				continue
+			}
+			w.retried = true
+			h.hubWaits[userID] = w
+			h.retryOrphan(ch, userID, now.Sub(w.since))
+		}
+	}
+	for userID := range h.hubWaits {
+		if !seen[userID] {
+			delete(h.hubWaits, userID)
+		}
+	}
+}
+
+// waitingForRoom reports whether userID's room is on its way already.
+func (h *handler) waitingForRoom(userID discord.UserID) bool {
+	if _, ok := h.presetOffers[userID]; ok {
+		return true
+	}
+	if _, ok := h.delayedRooms[userID]; ok {
+		return true
+	}
+	for _, waits := range h.slotWaits {
+		for _, w := range waits {
+			if w.req.userID == userID {
+				return true
+			}
+		}
+	}
+	return h.roomPending(userID)
+}
+
+// retryOrphan goes through the hub join of userID again.
+func (h *handler) retryOrphan(hubChannel *discord.Channel, userID discord.UserID, waited time.Duration) {
+	ctx := withGuild(context.Background(), hubChannel.GuildID)
+	slog.InfoContext(ctx, "Member is stuck in the hub, creating their room again", "user_id", userID, "hub_id", hubChannel.ID, "waited", waited)
+
+	vs := h.userVoiceStates[userID]
+	if vs.Member == nil {
+		if m, err := h.lookupMember(ctx, hubChannel.GuildID, userID); err == nil {
+			vs.Member = m
+		}
+	}
+	h.onVoiceJoin(ctx, &gateway.VoiceStateUpdateEvent{VoiceState: vs}, 0)
+}
@@ -0,0 +1,159 @@
+package tvc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// overlayEvent is a membership change streamed to overlays.
+type overlayEvent struct {
+	Type     string         `json:"type"` // "members", "join", "leave" or "closed"
+	UserID   discord.UserID `json:"user_id,omitempty"`
+	Username string         `json:"username,omitempty"`
+	Members  []overlayUser  `json:"members,omitempty"`
+	At       time.Time      `json:"at"`
+}
+
+type overlayUser struct {
+	UserID   discord.UserID `json:"user_id"`
+	Username string         `json:"username"`
+}
+
+// cmdOverlay handles /voice overlay, which gives the owner a private link
+// streaming who joins and leaves their room. Running it again revokes the old
+// link.
+func (h *handler) cmdOverlay(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.cfg.overlayBaseURL == "" {
+		return ephemeralData("Overlays aren't set up on this bot.")
+	}
+	r := h.ownedRoomOf(data.Event.SenderID())
+	if r == nil {
+		return ephemeralData("You need to be in a room you own to do that.")
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		slog.ErrorContext(ctx, "Failed to generate overlay token", "err", err)
+		return ephemeralData("Something went wrong, try again.")
+	}
+	r.overlayToken = hex.EncodeToString(b)
+	h.closeOverlays(r)
+
+	return ephemeralData(fmt.Sprintf(
+		"Add this as a browser source; it streams server-sent events as people join and leave. Keep it private.\n%s/rooms/%s/events?token=%s",
+		h.cfg.overlayBaseURL, r.channelID, r.overlayToken))
+}
+
+// publishOverlay sends ev to every overlay of the room, dropping it for
+// overlays that can't keep up.
+func (h *handler) publishOverlay(r *room, ev overlayEvent) {
+	for sub := range r.overlays {
+		select {
+		case sub <- ev:
+		default:
+		}
+	}
+}
+
+// closeOverlays disconnects every overlay of the room.
+func (h *handler) closeOverlays(r *room) {
+	for sub := range r.overlays {
+		close(sub)
+	}
+	clear(r.overlays)
+}
+
+// overlayMembers lists who is in the room now.
+func (h *handler) overlayMembers(r *room) []overlayUser {
+	var users []overlayUser
+	for userID, vs := range h.userVoiceStates {
+		if vs.ChannelID != r.channelID {
+			continue
+		}
+		u := overlayUser{UserID: userID}
+		if m, err := h.s.Cabinet.Member(r.guildID, userID); err == nil {
+			u.Username = m.User.Username
+		}
+		users = append(users, u)
+	}
+	return users
+}
+
+// serveOverlay streams the membership events of a room as server-sent events.
+func (h *handler) serveOverlay(w http.ResponseWriter, req *http.Request) {
+	id, err := discord.ParseSnowflake(req.PathValue("id"))
+	if err != nil {
+		http.NotFound(w, req)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	h.mu.Lock()
+	r, ok := h.rooms[discord.ChannelID(id)]
+	token := req.URL.Query().Get("token")
+	if !ok || r.overlayToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(r.overlayToken)) != 1 {
+		h.mu.Unlock()
+		http.Error(w, "unknown room or token", http.StatusNotFound)
+		return
+	}
+	sub := make(chan overlayEvent, 16)
+	r.overlays[sub] = struct{}{}
+	snapshot := overlayEvent{Type: "members", Members: h.overlayMembers(r), At: time.Now()}
+	h.mu.Unlock()
+
+	defer func() {
+		h.mu.Lock()
+		if _, ok := r.overlays[sub]; ok {
+			delete(r.overlays, sub)
+			close(sub)
+		}
+		h.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	writeEvent := func(ev overlayEvent) bool {
+		b, _ := json.Marshal(ev)
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, b); err != nil {
+			return false
+		}
+		flusher.Flush()
+		return true
+	}
+
+	if !writeEvent(snapshot) {
+		return
+	}
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case ev, ok := <-sub:
+			if !ok {
+				writeEvent(overlayEvent{Type: "closed", At: time.Now()})
+				return
+			}
+			if !writeEvent(ev) {
+				return
+			}
+		}
+	}
+}
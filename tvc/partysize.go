@@ -0,0 +1,67 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/diamondburned/arikawa/v3/utils/json/option"
+)
+
+// refreshPartySize schedules a sync of the user limit of every room owned by
+// userID with their party size.
+func (h *handler) refreshPartySize(userID discord.UserID) {
+	if !h.cfg.partySizeSync {
+		return
+	}
+	for _, channelID := range h.ownedRooms(userID) {
+		r := h.rooms[channelID]
+		if r.partyTimer != nil {
+			r.partyTimer.Stop()
+		}
+		r.partyTimer = time.AfterFunc(h.cfg.partySizeDebounce, func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+
+			r.partyTimer = nil
+			if h.rooms[channelID] != r {
+				return
+			}
+			h.syncPartySize(withGuild(context.Background(), r.guildID), channelID, r)
+		})
+	}
+}
+
+// syncPartySize applies the owner's current party size to the room. Rooms are
+// left alone while the owner isn't in a party.
+func (h *handler) syncPartySize(ctx context.Context, channelID discord.ChannelID, r *room) {
+	p, err := h.s.Presence(r.guildID, r.owner)
+	if err != nil {
+		return
+	}
+	var limit uint
+	for _, a := range p.Activities {
+		if a.Party != nil && a.Party.Size[1] > 0 {
+			limit = uint(a.Party.Size[1])
+			break
+		}
+	}
+	if limit == 0 || limit == r.partyLimit {
+		return
+	}
+
+	err = h.call(ctx, "ModifyChannel", func(s *state.State) error {
+		return s.ModifyChannel(channelID, api.ModifyChannelData{
+			VoiceUserLimit: option.NewNullableUint(limit),
+			AuditLogReason: "following the owner's party size",
+		})
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to sync room limit with party size", "err", err)
+		return
+	}
+	r.partyLimit = limit
+}
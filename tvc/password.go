@@ -0,0 +1,170 @@
+package tvc
+
+import (
+	"context"
+	"crypto/subtle"
+	"log/slog"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/diamondburned/arikawa/v3/utils/json/option"
+)
+
+// Custom IDs of the password prompt.
+const (
+	passwordButtonID = "password"
+	passwordModalID  = "password-modal"
+	passwordInputID  = "password-input"
+)
+
+// cmdPassword handles /voice password, which sets or clears the password of
+// the invoker's room. Everyone already in the room stays admitted.
+func (h *handler) cmdPassword(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	r := h.ownedRoomOf(data.Event.SenderID())
+	if r == nil {
+		return ephemeralData("You need to be in a room you own to do that.")
+	}
+
+	secret := data.Options.Find("secret").String()
+	r.password = secret
+	if secret == "" {
+		return ephemeralData("Your room no longer needs a password.")
+	}
+
+	for userID, vs := range h.userVoiceStates {
+		if vs.ChannelID == r.channelID {
+			r.admitted[userID] = true
+		}
+	}
+	return ephemeralData("Your room now needs a password. People joining will be asked for it in " + h.cfg.waitingRoomName + ".")
+}
+
+// checkPassword lets userID stay in the room if it has no password or they
+// already entered it. Otherwise they are moved to the waiting room and asked
+// for the password, and false is returned.
+func (h *handler) checkPassword(ctx context.Context, r *room, userID discord.UserID) bool {
+	if r.password == "" || userID == r.owner || r.admitted[userID] {
+		return true
+	}
+
+	waitingRoom := h.findWaitingRoom(r.guildID)
+	target := discord.NullChannelID
+	if waitingRoom != nil {
+		target = waitingRoom.ID
+	}
+	err := h.moveMember(ctx, memberMove{
+		guildID:   r.guildID,
+		userID:    userID,
+		channelID: target,
+		reason:    "room needs a password",
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to move member to waiting room", "err", err)
+		return false
+	}
+	if waitingRoom == nil {
+		return false
+	}
+
+	h.passwordWaits[userID] = r.channelID
+	err = h.call(ctx, "SendMessage", func(s *state.State) error {
+		_, err := s.SendMessageComplex(waitingRoom.ID, api.SendMessageData{
+			Content: userID.Mention() + ", " + r.channelID.Mention() + " needs a password.",
+			Components: discord.ContainerComponents{
+				&discord.ActionRowComponent{
+					&discord.ButtonComponent{
+						Style:    discord.PrimaryButtonStyle(),
+						CustomID: passwordButtonID,
+						Label:    "Enter password",
+					},
+				},
+			},
+			AllowedMentions: &api.AllowedMentions{Users: []discord.UserID{userID}},
+		})
+		return err
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to prompt for password", "err", err)
+	}
+	return false
+}
+
+// findWaitingRoom looks up the guild's waiting room by name.
+func (h *handler) findWaitingRoom(guildID discord.GuildID) *discord.Channel {
+	channels, err := h.s.Channels(guildID)
+	if err != nil {
+		return nil
+	}
+	for i, ch := range channels {
+		if ch.Name == h.cfg.waitingRoomName && ch.Type == discord.GuildVoice {
+			return &channels[i]
+		}
+	}
+	return nil
+}
+
+// onPasswordButton opens the password modal for the room the user is waiting
+// to get into.
+func (h *handler) onPasswordButton(ctx context.Context, data cmdroute.ComponentData) *api.InteractionResponse {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.passwordWaits[data.Event.SenderID()]; !ok {
+		return ephemeral("You aren't waiting to join a room.")
+	}
+
+	return &api.InteractionResponse{
+		Type: api.ModalResponse,
+		Data: &api.InteractionResponseData{
+			CustomID: option.NewNullableString(passwordModalID),
+			Title:    option.NewNullableString("Room password"),
+			Components: &discord.ContainerComponents{
+				&discord.ActionRowComponent{
+					&discord.TextInputComponent{
+						CustomID: passwordInputID,
+						Style:    discord.TextInputShortStyle,
+						Label:    "Password",
+						Required: true,
+					},
+				},
+			},
+		},
+	}
+}
+
+// onPasswordModal checks the submitted password and moves the user into the
+// room if it matches.
+func (h *handler) onPasswordModal(ctx context.Context, ev *discord.InteractionEvent, data *discord.ModalInteraction) *api.InteractionResponse {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	userID := ev.SenderID()
+	r := h.rooms[h.passwordWaits[userID]]
+	if r == nil || r.password == "" {
+		delete(h.passwordWaits, userID)
+		return ephemeral("That room is gone or no longer needs a password.")
+	}
+
+	var entered string
+	if input, ok := data.Components.Find(passwordInputID).(*discord.TextInputComponent); ok {
+		entered = input.Value
+	}
+	if subtle.ConstantTimeCompare([]byte(entered), []byte(r.password)) != 1 {
+		return ephemeral("That's not the right password.")
+	}
+
+	delete(h.passwordWaits, userID)
+	r.admitted[userID] = true
+
+	ctx = withGuild(ctx, r.guildID)
+	err := h.moveMember(ctx, memberMove{guildID: r.guildID, userID: userID, channelID: r.channelID})
+	if err != nil {
+		return ephemeral("Password accepted, but I couldn't move you. Join " + r.channelID.Mention() + " yourself.")
+	}
+	return ephemeral("Password accepted, welcome in!")
+}
@@ -0,0 +1,90 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+// enqueueRoom queues a creation until Discord recovers and lets the user know
+// their room is on the way. Interactions are the only way to send ephemeral
+// messages, so the notice goes out as a DM.
+func (h *handler) enqueueRoom(ctx context.Context, req roomRequest) {
+	for _, p := range h.pendingRooms {
+		if p.userID == req.userID {
+			return
+		}
+	}
+	if len(h.pendingRooms) >= h.cfg.maxPendingRooms {
+		slog.Warn("Dropping room request because the pending queue is full", "guild_id", req.hubChannel.GuildID, "user_id", req.userID)
+		return
+	}
+
+	h.pendingRooms = append(h.pendingRooms, req)
+	if req.hub.silent {
+		return
+	}
+	h.notify(ctx, req.userID, "Discord is having trouble right now, so your room is queued. "+
+		"Stay in "+req.hubChannel.Mention()+" and you'll be moved as soon as it's ready.")
+}
+
+// drainPendingRooms retries queued creations every interval until ctx is
+// done. Requests from users who have since left the hub are dropped.
+func (h *handler) drainPendingRooms(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.mu.Lock()
+			h.retryPendingRooms(ctx)
+			h.mu.Unlock()
+		}
+	}
+}
+
+func (h *handler) retryPendingRooms(ctx context.Context) {
+	for len(h.pendingRooms) > 0 && h.breaker.allow() {
+		req := h.pendingRooms[0]
+
+		if h.userVoiceStates[req.userID].ChannelID != req.hubChannel.ID {
+			h.pendingRooms = h.pendingRooms[1:]
+			continue
+		}
+
+		if !h.takeSlot(req.hubChannel.GuildID) {
+			return
+		}
+		err := h.createRoom(withGuild(ctx, req.hubChannel.GuildID), req)
+		h.releaseSlot(req.hubChannel.GuildID)
+		if err != nil {
+			if isOutage(err) {
+				return
+			}
+			slog.ErrorContext(ctx, "Failed to create queued room", "err", err)
+			h.strandMember(withGuild(ctx, req.hubChannel.GuildID), req)
+		}
+		h.pendingRooms = h.pendingRooms[1:]
+	}
+}
+
+// notify sends userID a direct message, logging failures.
+func (h *handler) notify(ctx context.Context, userID discord.UserID, content string) {
+	err := h.call(ctx, "SendMessage", func(s *state.State) error {
+		dm, err := s.CreatePrivateChannel(userID)
+		if err != nil {
+			return err
+		}
+		_, err = s.SendMessage(dm.ID, content)
+		return err
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to DM user", "err", err)
+	}
+}
@@ -0,0 +1,98 @@
+package tvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/diamondburned/arikawa/v3/utils/httputil"
+)
+
+// requiredPermission names the permission each guarded API operation needs,
+// for use in alerts.
+var requiredPermission = map[string]string{
+	"CreateChannel": "Manage Channels",
+	"DeleteChannel": "Manage Channels",
+	"ModifyMember":  "Move Members",
+}
+
+type guildKey struct{}
+
+// withGuild tags ctx with the guild an API call is made on behalf of.
+func withGuild(ctx context.Context, guildID discord.GuildID) context.Context {
+	return context.WithValue(ctx, guildKey{}, guildID)
+}
+
+func guildFrom(ctx context.Context) discord.GuildID {
+	id, _ := ctx.Value(guildKey{}).(discord.GuildID)
+	return id
+}
+
+// permissionAlerts counts 403 responses per guild and operation, alerting the
+// guild owner once a threshold is crossed. Each alert is only sent once per
+// process so owners aren't spammed.
+type permissionAlerts struct {
+	threshold int
+
+	mu       sync.Mutex
+	failures map[permissionAlertKey]int
+}
+
+type permissionAlertKey struct {
+	guildID    discord.GuildID
+	permission string
+}
+
+func newPermissionAlerts(env *environment) *permissionAlerts {
+	return &permissionAlerts{
+		threshold: env.int("PERMISSION_ALERT_THRESHOLD", 3),
+		failures:  make(map[permissionAlertKey]int),
+	}
+}
+
+// record counts err if it is a 403 from op and reports whether the alert for
+// it should be sent now.
+func (a *permissionAlerts) record(guildID discord.GuildID, op string, err error) (string, bool) {
+	var httpErr *httputil.HTTPError
+	if a.threshold <= 0 || !guildID.IsValid() || !errors.As(err, &httpErr) || httpErr.Status != http.StatusForbidden {
+		return "", false
+	}
+	perm, ok := requiredPermission[op]
+	if !ok {
+		return "", false
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	key := permissionAlertKey{guildID, perm}
+	a.failures[key]++
+	return perm, a.failures[key] == a.threshold
+}
+
+// alertMissingPermission DMs the guild owner about a permission the bot keeps
+// being denied. It runs asynchronously from the failing call.
+func (h *handler) alertMissingPermission(guildID discord.GuildID, perm string) {
+	ctx := context.Background()
+
+	var guild *discord.Guild
+	err := h.call(ctx, "Guild", func(s *state.State) (err error) {
+		guild, err = s.Guild(guildID)
+		return err
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to look up guild for permission alert", "err", err)
+		return
+	}
+
+	slog.Warn("Guild keeps denying a permission, alerting the owner", "guild_id", guildID, "permission", perm)
+	h.notify(ctx, guild.OwnerID, fmt.Sprintf(
+		"I couldn't manage temporary voice channels in **%s** because I'm missing the **%s** permission. "+
+			"Please grant it to my role, or to the categories with hub channels, so rooms can be created and cleaned up.",
+		guild.Name, perm))
+}
@@ -0,0 +1,84 @@
+package tvc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+)
+
+// Installation and interaction contexts of commands. arikawa doesn't know
+// about user-installed apps yet.
+const (
+	installGuild = 0
+	installUser  = 1
+
+	contextGuild          = 0
+	contextBotDM          = 1
+	contextPrivateChannel = 2
+)
+
+// commandContext is where a command can be installed and used.
+type commandContext struct {
+	integrationTypes []int
+	contexts         []int
+}
+
+// commandContexts overrides where commands are available. Commands not
+// listed are guild commands.
+var commandContexts = map[string]commandContext{
+	// preferences doesn't need a server, so members can install the app on
+	// their account and use it anywhere.
+	"preferences": {
+		integrationTypes: []int{installGuild, installUser},
+		contexts:         []int{contextGuild, contextBotDM, contextPrivateChannel},
+	},
+	// operator is for the bot's owners, in DMs only.
+	"operator": {
+		integrationTypes: []int{installGuild},
+		contexts:         []int{contextBotDM},
+	},
+}
+
+// commandBody encodes cmd for registration, adding the installation and
+// interaction contexts from commandContexts.
+func commandBody(cmd api.CreateCommandData) (map[string]any, error) {
+	b, err := json.Marshal(cmd)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	if cc, ok := commandContexts[cmd.Name]; ok {
+		fields["integration_types"] = cc.integrationTypes
+		fields["contexts"] = cc.contexts
+	}
+	return fields, nil
+}
+
+// cmdStats handles /preferences stats, which shows the user's rooms across
+// every server the bot is in.
+func (h *handler) cmdStats(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	userID := data.Event.SenderID()
+	st := h.stats.userStats(userID)
+	if st.created == 0 && st.joined == 0 {
+		return ephemeralData("You haven't been in any rooms yet.")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "You created %s", plural(st.created, "room"))
+	if st.created > 0 {
+		fmt.Fprintf(&b, ", open for %s in total", strings.TrimSuffix(st.hosted.Round(time.Minute).String(), "0s"))
+	}
+	fmt.Fprintf(&b, ", and joined %s made by others.", plural(st.joined, "room"))
+	if h.stats.private(userID) {
+		b.WriteString("\nYour voice activity doesn't count towards room stats.")
+	}
+	return ephemeralData(b.String())
+}
@@ -0,0 +1,228 @@
+package tvc
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/diamondburned/arikawa/v3/utils/json/option"
+)
+
+// presetSelectID is the custom ID of the preset select menu.
+const presetSelectID = "preset"
+
+// defaultPresetValue is the select value for a room without a preset.
+const defaultPresetValue = "default"
+
+// preset is a kind of room members can pick when they join a hub.
+type preset struct {
+	// Label is shown in the select menu, e.g. "Ranked 5v5".
+	Label       string `json:"label"`
+	Description string `json:"description,omitempty"`
+	// RoomName overrides the hub's naming. See expandTemplate for
+	// placeholders; only {username} is known at creation.
+	RoomName string `json:"room_name,omitempty"`
+	// Limit is the room's user limit. Zero means no limit.
+	Limit uint `json:"limit,omitempty"`
+	// Locked denies Connect to @everyone, so only the owner and people they
+	// let in can join.
+	Locked bool `json:"locked,omitempty"`
+}
+
+// parsePresets reads a hub's presets from a JSON array in $key, e.g.
+// [{"label": "Ranked 5v5", "limit": 5, "locked": true}].
+func parsePresets(env *environment, key string) []preset {
+	v := env.getenv(key)
+	env.note(key, v, v == "")
+	if v == "" {
+		return nil
+	}
+	var presets []preset
+	if err := json.Unmarshal([]byte(v), &presets); err != nil {
+		env.configError("invalid $%s: %v", key, err)
+		return nil
+	}
+	if len(presets) > 24 {
+		env.configError("invalid $%s: at most 24 presets fit in a menu", key)
+		return nil
+	}
+	for _, p := range presets {
+		if p.Label == "" {
+			env.configError("invalid $%s: every preset needs a label", key)
+			return nil
+		}
+	}
+	return presets
+}
+
+// presetOffer is a preset menu waiting for a member's pick.
+type presetOffer struct {
+	req     roomRequest
+	message discord.MessageID
+	timer   *time.Timer
+}
+
+// offerPresets posts the hub's presets for the member to pick from in the
+// hub's chat. If they don't pick one in time, they get a default room.
+func (h *handler) offerPresets(ctx context.Context, req roomRequest) {
+	options := []discord.SelectOption{{
+		Label: "Default",
+		Value: defaultPresetValue,
+	}}
+	for i, p := range req.hub.presets {
+		options = append(options, discord.SelectOption{
+			Label:       p.Label,
+			Value:       strconv.Itoa(i),
+			Description: p.Description,
+		})
+	}
+
+	var msg *discord.Message
+	err := h.call(ctx, "SendMessage", func(s *state.State) (err error) {
+		msg, err = s.SendMessageComplex(req.hubChannel.ID, api.SendMessageData{
+			Content: req.userID.Mention() + ", what kind of room do you want?",
+			Components: discord.ContainerComponents{
+				&discord.ActionRowComponent{
+					&discord.StringSelectComponent{
+						CustomID:    presetSelectID,
+						Placeholder: "Pick a preset",
+						Options:     options,
+					},
+				},
+			},
+			AllowedMentions: &api.AllowedMentions{Users: []discord.UserID{req.userID}},
+		})
+		return err
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to offer presets", "err", err)
+		h.requestRoom(ctx, req)
+		return
+	}
+
+	offer := &presetOffer{req: req, message: msg.ID}
+	offer.timer = time.AfterFunc(h.cfg.presetTimeout, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		if h.presetOffers[req.userID] != offer {
+			return
+		}
+		delete(h.presetOffers, req.userID)
+
+		ctx := withGuild(context.Background(), req.hubChannel.GuildID)
+		h.withdrawPresets(ctx, offer)
+		if h.userVoiceStates[req.userID].ChannelID == req.hubChannel.ID {
+			req.joinedAt = time.Now()
+			h.requestRoom(ctx, req)
+		}
+	})
+	if old, ok := h.presetOffers[req.userID]; ok {
+		old.timer.Stop()
+		h.withdrawPresets(ctx, old)
+	}
+	h.presetOffers[req.userID] = offer
+}
+
+// withdrawPresets deletes an offer's menu.
+func (h *handler) withdrawPresets(ctx context.Context, offer *presetOffer) {
+	err := h.call(ctx, "DeleteMessage", func(s *state.State) error {
+		return s.DeleteMessage(offer.req.hubChannel.ID, offer.message, "preset menu expired")
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to delete preset menu", "err", err)
+	}
+}
+
+// onPresetSelect creates the member's room with the preset they picked.
+func (h *handler) onPresetSelect(ctx context.Context, data cmdroute.ComponentData) *api.InteractionResponse {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	userID := data.Event.SenderID()
+	offer, ok := h.presetOffers[userID]
+	if !ok || offer.message != data.Event.Message.ID {
+		return ephemeral("This menu isn't for you.")
+	}
+	offer.timer.Stop()
+	delete(h.presetOffers, userID)
+
+	req := offer.req
+	req.joinedAt = time.Now()
+	if h.userVoiceStates[userID].ChannelID != req.hubChannel.ID {
+		return presetUpdate("You left the hub, so no room was created.")
+	}
+
+	label := "default"
+	if sel, ok := data.ComponentInteraction.(*discord.StringSelectInteraction); ok && len(sel.Values) > 0 {
+		if i, err := strconv.Atoi(sel.Values[0]); err == nil && i >= 0 && i < len(req.hub.presets) {
+			req.preset = &req.hub.presets[i]
+			label = req.preset.Label
+		}
+	}
+
+	h.requestRoom(withGuild(ctx, req.hubChannel.GuildID), req)
+	return presetUpdate(userID.Mention() + " picked a " + label + " room.")
+}
+
+// presetUpdate replaces the preset menu with content.
+func presetUpdate(content string) *api.InteractionResponse {
+	return &api.InteractionResponse{
+		Type: api.UpdateMessage,
+		Data: &api.InteractionResponseData{
+			Content:         option.NewNullableString(content),
+			Components:      &discord.ContainerComponents{},
+			AllowedMentions: &api.AllowedMentions{},
+		},
+	}
+}
+
+// userLimit is the user limit of the requested room.
+func (h *handler) userLimit(req roomRequest) uint {
+	if req.preset != nil && req.preset.Limit > 0 {
+		return req.preset.Limit
+	}
+	if req.booster && h.cfg.boosterUserLimit > 0 {
+		return h.cfg.boosterUserLimit
+	}
+	if len(req.hub.limitSteps) > 0 {
+		return req.hub.limitSteps[0]
+	}
+	return req.hub.userLimit
+}
+
+// scaledLimit returns the limit the room starts scaling from, or 0 if the
+// preset or a booster perk fixed its limit, or the hub doesn't scale.
+func (h *handler) scaledLimit(req roomRequest) uint {
+	if (req.preset != nil && req.preset.Limit > 0) || (req.booster && h.cfg.boosterUserLimit > 0) {
+		return 0
+	}
+	return h.userLimit(req)
+}
+
+// applyPreset locks the new room, or team category, if its preset asks for
+// it. The owner keeps access.
+func (h *handler) applyPreset(ctx context.Context, ch *discord.Channel, req roomRequest) {
+	if req.preset == nil || !req.preset.Locked {
+		return
+	}
+	err := h.call(ctx, "EditChannelPermission", func(s *state.State) error {
+		return s.EditChannelPermission(ch.ID, discord.Snowflake(req.userID), api.EditChannelPermissionData{
+			Type:           discord.OverwriteMember,
+			Allow:          discord.PermissionViewChannel | discord.PermissionConnect,
+			AuditLogReason: "room owner",
+		})
+	})
+	if err == nil {
+		err = h.denyEveryone(ctx, ch, discord.PermissionConnect, "locked preset")
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to lock preset room", "err", err)
+	}
+}
@@ -0,0 +1,156 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/diamondburned/arikawa/v3/utils/json/option"
+)
+
+// privateInviteID is the custom ID of the menu private room owners pick who
+// to let in from.
+const privateInviteID = "private-invite"
+
+// applyPrivacy hides the new room, or team category, of a private hub from
+// everyone but its owner, the bot and companion bots. The owner lets others
+// in from the menu posted in the room, /room invite or the invite context
+// menu command.
+func (h *handler) applyPrivacy(ctx context.Context, ch *discord.Channel, req roomRequest) {
+	if !req.hub.private {
+		return
+	}
+	allow := func(id discord.Snowflake) error {
+		return h.call(ctx, "EditChannelPermission", func(s *state.State) error {
+			return s.EditChannelPermission(ch.ID, id, api.EditChannelPermissionData{
+				Type:           discord.OverwriteMember,
+				Allow:          discord.PermissionViewChannel | discord.PermissionConnect,
+				AuditLogReason: "private room",
+			})
+		})
+	}
+	err := allow(discord.Snowflake(req.userID))
+	if me, meErr := h.s.Me(); err == nil && meErr == nil {
+		// Keep seeing the room ourselves so it can still be cleaned up.
+		err = allow(discord.Snowflake(me.ID))
+	}
+	if err == nil {
+		err = h.denyEveryone(ctx, ch, discord.PermissionViewChannel|discord.PermissionConnect, "private room")
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to make room private", "err", err)
+	}
+}
+
+// postInviteMenu posts the menu the owner of a private room picks who to let
+// in from.
+func (h *handler) postInviteMenu(ctx context.Context, r *room) {
+	if !r.hub.private || r.hub.silent {
+		return
+	}
+	err := h.call(ctx, "SendMessage", func(s *state.State) error {
+		_, err := s.SendMessageComplex(r.chatChannel(), api.SendMessageData{
+			Content: "This room is private. " + r.owner.Mention() + ", pick who may join.",
+			Components: discord.ContainerComponents{
+				&discord.ActionRowComponent{
+					&discord.UserSelectComponent{
+						CustomID:    privateInviteID,
+						Placeholder: "Let someone in",
+						ValueLimits: [2]int{1, 10},
+					},
+				},
+			},
+			AllowedMentions: &api.AllowedMentions{},
+		})
+		return err
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to post invite menu", "err", err)
+	}
+}
+
+// onPrivateInvite lets the members picked by the room's owner in.
+func (h *handler) onPrivateInvite(ctx context.Context, data cmdroute.ComponentData) *api.InteractionResponse {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	_, r := h.roomByChat(data.Event.ChannelID)
+	if r == nil {
+		return ephemeral("This room is gone.")
+	}
+	if data.Event.SenderID() != r.owner {
+		return ephemeral("Only the room's owner can let people in.")
+	}
+	sel, ok := data.ComponentInteraction.(*discord.UserSelectInteraction)
+	if !ok || len(sel.Values) == 0 {
+		return ephemeral("Pick who to let in.")
+	}
+
+	ctx = withGuild(ctx, r.guildID)
+	var admitted []string
+	for _, userID := range sel.Values {
+		if userID == r.owner {
+			continue
+		}
+		if err := h.letIn(ctx, r, userID); err != nil {
+			slog.ErrorContext(ctx, "Failed to let member into private room", "err", err)
+			continue
+		}
+		admitted = append(admitted, userID.Mention())
+	}
+	if len(admitted) == 0 {
+		return ephemeral("Nobody new was let in.")
+	}
+	return &api.InteractionResponse{
+		Type: api.MessageInteractionWithSource,
+		Data: &api.InteractionResponseData{
+			Content:         option.NewNullableString(r.owner.Mention() + " let in " + strings.Join(admitted, ", ") + "."),
+			AllowedMentions: &api.AllowedMentions{},
+		},
+	}
+}
+
+// handOverPrivate passes a private room to whoever has been in it longest
+// when its owner leaves, so the people still in it keep control of who may
+// join instead of being stuck in a room nobody can open.
+func (h *handler) handOverPrivate(ctx context.Context, channelID discord.ChannelID, r *room, leaver discord.UserID) {
+	if !r.hub.private || leaver != r.owner {
+		return
+	}
+	var heir discord.UserID
+	var since time.Time
+	for userID, vs := range h.userVoiceStates {
+		if vs.ChannelID != channelID {
+			continue
+		}
+		if m, err := h.s.Cabinet.Member(r.guildID, userID); err == nil && m.User.Bot {
+			continue
+		}
+		if at := r.joined[userID]; !heir.IsValid() || at.Before(since) {
+			heir, since = userID, at
+		}
+	}
+	if !heir.IsValid() {
+		return
+	}
+	h.closeClaimVote(ctx, r, "The room was handed over, so this vote is over.")
+	h.transferOwnership(ctx, channelID, r, heir)
+	if r.hub.silent {
+		return
+	}
+	err := h.call(ctx, "SendMessage", func(s *state.State) error {
+		_, err := s.SendMessageComplex(r.chatChannel(), api.SendMessageData{
+			Content:         leaver.Mention() + " left, so " + heir.Mention() + " owns this room now and decides who may join.",
+			AllowedMentions: &api.AllowedMentions{Users: []discord.UserID{heir}},
+		})
+		return err
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to announce new room owner", "err", err)
+	}
+}
@@ -0,0 +1,210 @@
+package tvc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// profileScheduleInterval is how often scheduled profile switches are
+// checked for.
+const profileScheduleInterval = 30 * time.Second
+
+// profile bundles settings that replace the configured ones in a guild while
+// it is active, e.g. for a tournament night. Unset fields keep the
+// configured values, so a profile with just a name goes back to normal.
+type profile struct {
+	Name            string `json:"name"`
+	MaxRoomsPerUser *int   `json:"max_rooms_per_user,omitempty"`
+	MaxGuildRooms   *int   `json:"max_guild_rooms,omitempty"`
+	// GracePeriod replaces $ROOM_GRACE_PERIOD, e.g. "10m".
+	GracePeriod  string `json:"grace_period,omitempty"`
+	NameTemplate string `json:"name_template,omitempty"`
+	// Hubs turns hubs on or off by key. Hubs not listed stay as they are.
+	Hubs map[string]bool `json:"hubs,omitempty"`
+
+	gracePeriod *time.Duration
+}
+
+// scheduledProfile is a profile switch waiting for its time.
+type scheduledProfile struct {
+	GuildID discord.GuildID `json:"guild_id"`
+	Profile string          `json:"profile"`
+	At      time.Time       `json:"at"`
+	By      discord.UserID  `json:"by"`
+}
+
+// parseProfiles reads profiles from a JSON array in $key, e.g.
+// [{"name": "Tournament night", "max_rooms_per_user": 1, "grace_period": "10m", "hubs": {"BARK": false}}, {"name": "Normal"}].
+func parseProfiles(env *environment, key string) []profile {
+	v := env.getenv(key)
+	env.note(key, v, v == "")
+	if v == "" {
+		return nil
+	}
+	var ps []profile
+	if err := json.Unmarshal([]byte(v), &ps); err != nil {
+		env.configError("invalid $%s: %v", key, err)
+		return nil
+	}
+	if len(ps) > 25 {
+		env.configError("invalid $%s: at most 25 profiles can be offered", key)
+		return nil
+	}
+	seen := make(map[string]bool)
+	for i := range ps {
+		p := &ps[i]
+		switch {
+		case p.Name == "":
+			env.configError("invalid $%s: every profile needs a name", key)
+		case seen[p.Name]:
+			env.configError("invalid $%s: profile %q is defined twice", key, p.Name)
+		case p.NameTemplate != "" && checkTemplate(p.NameTemplate) != nil:
+			env.configError("invalid $%s: profile %q: %v", key, p.Name, checkTemplate(p.NameTemplate))
+		}
+		seen[p.Name] = true
+		if p.GracePeriod != "" {
+			d, err := time.ParseDuration(p.GracePeriod)
+			if err != nil || d < 0 {
+				env.configError("invalid $%s: profile %q has invalid grace_period %q", key, p.Name, p.GracePeriod)
+				continue
+			}
+			p.gracePeriod = &d
+		}
+	}
+	return ps
+}
+
+func (h *handler) profileChoices() []discord.StringChoice {
+	choices := make([]discord.StringChoice, len(h.cfg.profiles))
+	for i, p := range h.cfg.profiles {
+		choices[i] = discord.StringChoice{Name: p.Name, Value: p.Name}
+	}
+	return choices
+}
+
+// findProfile returns the profile called name, or nil.
+func (h *handler) findProfile(name string) *profile {
+	for i := range h.cfg.profiles {
+		if h.cfg.profiles[i].Name == name {
+			return &h.cfg.profiles[i]
+		}
+	}
+	return nil
+}
+
+// profileOf returns the guild's active profile, or nil if it has none.
+func (h *handler) profileOf(guildID discord.GuildID) *profile {
+	name := h.stats.activeProfile(guildID)
+	if name == "" {
+		name = h.stats.activeProfile(h.cfg.stagingGuilds.productionOf(guildID))
+	}
+	if name == "" {
+		return nil
+	}
+	return h.findProfile(name)
+}
+
+// userRoomCap is how many rooms a member of the guild may own at once.
+func (h *handler) userRoomCap(guildID discord.GuildID) int {
+	if p := h.profileOf(guildID); p != nil && p.MaxRoomsPerUser != nil {
+		return *p.MaxRoomsPerUser
+	}
+	return h.cfg.maxRoomsPerUser
+}
+
+// guildRoomCap is how many rooms the guild may have at once.
+func (h *handler) guildRoomCap(guildID discord.GuildID) int {
+	if p := h.profileOf(guildID); p != nil && p.MaxGuildRooms != nil {
+		return *p.MaxGuildRooms
+	}
+	return h.cfg.maxGuildRooms
+}
+
+// gracePeriod is how long the guild's empty rooms are kept: its profile's,
+// else the one learned from its rejoins, else $ROOM_GRACE_PERIOD.
+func (h *handler) gracePeriod(guildID discord.GuildID) time.Duration {
+	if p := h.profileOf(guildID); p != nil && p.gracePeriod != nil {
+		return *p.gracePeriod
+	}
+	if grace, ok := h.learnedGrace(guildID); ok {
+		return grace
+	}
+	return h.cfg.roomGracePeriod
+}
+
+// hubEnabled reports whether the guild's active profile leaves hub on.
+func (h *handler) hubEnabled(guildID discord.GuildID, hub *hub) bool {
+	p := h.profileOf(guildID)
+	if p == nil {
+		return true
+	}
+	on, ok := p.Hubs[hub.key]
+	return !ok || on
+}
+
+// applyProfile makes name the guild's active profile. Everything it sets
+// takes effect at once, since settings are looked up through the active
+// profile; rooms that already exist keep their names.
+func (h *handler) applyProfile(ctx context.Context, guildID discord.GuildID, p *profile, by discord.UserID) {
+	h.stats.setActiveProfile(guildID, p.Name)
+	slog.InfoContext(ctx, "Profile applied", "profile", p.Name, "user_id", by)
+	h.postLog(ctx, guildID, fmt.Sprintf("Switched to the **%s** profile, set by %s.", p.Name, by.Mention()))
+}
+
+// runProfileSchedules applies scheduled profile switches when they are due,
+// until ctx is done.
+func (h *handler) runProfileSchedules(ctx context.Context) {
+	ticker := time.NewTicker(profileScheduleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, sp := range h.stats.dueProfiles(time.Now()) {
+				if p := h.findProfile(sp.Profile); p != nil {
+					h.applyProfile(withGuild(ctx, sp.GuildID), sp.GuildID, p, sp.By)
+				}
+			}
+		}
+	}
+}
+
+// cmdProfileApply handles /voiceadmin profile apply, switching now or after
+// a delay.
+func (h *handler) cmdProfileApply(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	p := h.findProfile(data.Options.Find("name").String())
+	if p == nil {
+		return ephemeralData("There's no such profile. Profiles are defined in $PROFILES.")
+	}
+	guildID := data.Event.GuildID
+	userID := data.Event.SenderID()
+	ctx = withGuild(ctx, guildID)
+
+	if v := strings.TrimSpace(data.Options.Find("after").String()); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return ephemeralData(fmt.Sprintf("%q isn't a duration; try something like 90m or 2h.", v))
+		}
+		at := time.Now().Add(d)
+		h.stats.scheduleProfile(scheduledProfile{GuildID: guildID, Profile: p.Name, At: at, By: userID})
+		slog.InfoContext(ctx, "Profile scheduled", "profile", p.Name, "user_id", userID, "at", at)
+		return ephemeralData(fmt.Sprintf("The **%s** profile will be applied <t:%d:R>. Scheduling another switch replaces this one.", p.Name, at.Unix()))
+	}
+
+	previous := h.stats.activeProfile(guildID)
+	h.applyProfile(ctx, guildID, p, userID)
+	if previous != "" && previous != p.Name {
+		return ephemeralData(fmt.Sprintf("Switched from **%s** to **%s**.", previous, p.Name))
+	}
+	return ephemeralData(fmt.Sprintf("Switched to **%s**.", p.Name))
+}
@@ -0,0 +1,62 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+// deleteChannel deletes a channel the bot created. It refuses to touch hubs,
+// explicitly protected channels and anything missing from h.created, so a
+// corrupted registry can't take out channels owned by the server.
+func (h *handler) deleteChannel(ctx context.Context, channelID discord.ChannelID, reason api.AuditLogReason) error {
+	if h.cfg.protectedChannels[channelID] {
+		return fmt.Errorf("refusing to delete protected channel %s", channelID)
+	}
+	if h.isHubChannelID(channelID) {
+		return fmt.Errorf("refusing to delete hub channel %s", channelID)
+	}
+	if !h.created[channelID] {
+		return fmt.Errorf("refusing to delete channel %s not created by the bot", channelID)
+	}
+	// Hubs picked by name are only known by their channel.
+	if ch, err := h.s.Cabinet.Channel(channelID); err == nil {
+		if h.lookupHub(ch) != nil {
+			return fmt.Errorf("refusing to delete hub channel %s", channelID)
+		}
+	}
+
+	err := h.call(ctx, "DeleteChannel", func(s *state.State) error {
+		if err := s.DeleteChannel(channelID, reason); !isNotFound(err) {
+			return err
+		}
+		// Already gone.
+		return nil
+	})
+	if err == nil {
+		err = h.verifyDeleted(ctx, channelID)
+	}
+	if err != nil {
+		h.metrics.deleteFailure(guildFrom(ctx))
+		h.retryDelete(ctx, guildFrom(ctx), channelID, reason, err)
+		return err
+	}
+	delete(h.created, channelID)
+	h.forgetDeleteRetry(channelID)
+	return nil
+}
+
+// isHubChannelID reports whether a hub is configured with channelID, which
+// doesn't take the channel being cached.
+func (h *handler) isHubChannelID(channelID discord.ChannelID) bool {
+	for _, hub := range h.hubs {
+		if slices.Contains(hub.channelIDs, channelID) {
+			return true
+		}
+	}
+	return false
+}
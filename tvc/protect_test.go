@@ -0,0 +1,46 @@
+package tvc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+func TestDeleteChannelProtection(t *testing.T) {
+	b := newTestBot(t, "PROTECTED_CHANNEL_IDS=300")
+	var channels []discord.Channel
+	for _, id := range []discord.ChannelID{300, 301, 302} {
+		channels = append(channels, discord.Channel{ID: id, GuildID: testGuildID, Type: discord.GuildVoice, Name: "room"})
+	}
+	b.send(testGuild(channels, nil))
+
+	h := b.h
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	// A corrupted registry claims the hub and the protected channel, but
+	// not channel 301.
+	for _, id := range []discord.ChannelID{testHubID, 300, 302} {
+		h.created[id] = true
+	}
+
+	ctx := withGuild(context.Background(), testGuildID)
+	for _, id := range []discord.ChannelID{testHubID, 300, 301} {
+		if err := h.deleteChannel(ctx, id, "test"); err == nil {
+			t.Errorf("channel %d was deleted", id)
+		}
+		if !b.channelExists(id) {
+			t.Errorf("channel %d is gone", id)
+		}
+	}
+	if len(h.deleteRetries) != 0 {
+		t.Errorf("%d refused deletions are retried", len(h.deleteRetries))
+	}
+
+	if err := h.deleteChannel(ctx, 302, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if b.channelExists(302) || h.created[302] {
+		t.Error("channel the bot created wasn't deleted")
+	}
+}
@@ -0,0 +1,443 @@
+package tvc
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// qrBlocks describes the error correction blocks of a QR code version at
+// level M: ecLen error correction codewords per block, and groups of blocks
+// with dataLen data codewords each.
+type qrBlocks struct {
+	ecLen  int
+	groups [][2]int // {blocks, dataLen}
+}
+
+// qrVersions are QR code versions 1 to 10 at error correction level M, which
+// hold up to 213 bytes, plenty for a channel link.
+var qrVersions = []qrBlocks{
+	{10, [][2]int{{1, 16}}},
+	{16, [][2]int{{1, 28}}},
+	{26, [][2]int{{1, 44}}},
+	{18, [][2]int{{2, 32}}},
+	{24, [][2]int{{2, 43}}},
+	{16, [][2]int{{4, 27}}},
+	{18, [][2]int{{4, 31}}},
+	{22, [][2]int{{2, 38}, {2, 39}}},
+	{22, [][2]int{{3, 36}, {2, 37}}},
+	{26, [][2]int{{4, 43}, {1, 44}}},
+}
+
+// qrAlignment are the alignment pattern coordinates of versions 1 to 10.
+var qrAlignment = [][]int{
+	nil, {6, 18}, {6, 22}, {6, 26}, {6, 30}, {6, 34},
+	{6, 22, 38}, {6, 24, 42}, {6, 26, 46}, {6, 28, 50},
+}
+
+var errQRTooLong = errors.New("too long for a QR code")
+
+// qrCode is a QR code's modules, true for dark ones.
+type qrCode struct {
+	size     int
+	modules  [][]bool
+	function [][]bool // finder, timing, alignment and format modules
+}
+
+// encodeQR encodes text in byte mode in the smallest version that fits it.
+func encodeQR(text string) (*qrCode, error) {
+	for i, blocks := range qrVersions {
+		version := i + 1
+		countBits := 8
+		if version >= 10 {
+			countBits = 16
+		}
+		capacity := 0
+		for _, g := range blocks.groups {
+			capacity += g[0] * g[1]
+		}
+		if 4+countBits+8*len(text) > 8*capacity {
+			continue
+		}
+
+		var bits qrBits
+		bits.append(0b0100, 4)
+		bits.append(len(text), countBits)
+		for i := 0; i < len(text); i++ {
+			bits.append(int(text[i]), 8)
+		}
+		bits.append(0, min(4, 8*capacity-len(bits)))
+		bits.append(0, (8-len(bits)%8)%8)
+		data := bits.bytes()
+		for pad := byte(0xEC); len(data) < capacity; pad ^= 0xEC ^ 0x11 {
+			data = append(data, pad)
+		}
+
+		q := newQRCode(version)
+		q.placeCodewords(interleaveQR(data, blocks))
+		q.applyBestMask()
+		return q, nil
+	}
+	return nil, errQRTooLong
+}
+
+type qrBits []bool
+
+func (b *qrBits) append(v, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, v>>i&1 == 1)
+	}
+}
+
+func (b qrBits) bytes() []byte {
+	out := make([]byte, len(b)/8)
+	for i, bit := range b {
+		if bit {
+			out[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return out
+}
+
+// interleaveQR splits data into blocks, adds their error correction
+// codewords and interleaves them.
+func interleaveQR(data []byte, blocks qrBlocks) []byte {
+	divisor := rsDivisor(blocks.ecLen)
+	var dataBlocks, ecBlocks [][]byte
+	for _, g := range blocks.groups {
+		for range g[0] {
+			block := data[:g[1]]
+			data = data[g[1]:]
+			dataBlocks = append(dataBlocks, block)
+			ecBlocks = append(ecBlocks, rsRemainder(block, divisor))
+		}
+	}
+
+	var out []byte
+	for i := 0; ; i++ {
+		added := false
+		for _, block := range dataBlocks {
+			if i < len(block) {
+				out = append(out, block[i])
+				added = true
+			}
+		}
+		if !added {
+			break
+		}
+	}
+	for i := range blocks.ecLen {
+		for _, block := range ecBlocks {
+			out = append(out, block[i])
+		}
+	}
+	return out
+}
+
+// gfMul multiplies in GF(256) modulo the QR code polynomial.
+func gfMul(x, y byte) byte {
+	var z byte
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x1D
+		z ^= (y >> i & 1) * x
+	}
+	return z
+}
+
+// rsDivisor returns the Reed-Solomon generator polynomial of the degree,
+// without its leading term.
+func rsDivisor(degree int) []byte {
+	d := make([]byte, degree)
+	d[degree-1] = 1
+	root := byte(1)
+	for range degree {
+		for j := range d {
+			d[j] = gfMul(d[j], root)
+			if j+1 < degree {
+				d[j] ^= d[j+1]
+			}
+		}
+		root = gfMul(root, 2)
+	}
+	return d
+}
+
+// rsRemainder returns the error correction codewords of data.
+func rsRemainder(data, divisor []byte) []byte {
+	rem := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ rem[0]
+		copy(rem, rem[1:])
+		rem[len(rem)-1] = 0
+		for i, d := range divisor {
+			rem[i] ^= gfMul(d, factor)
+		}
+	}
+	return rem
+}
+
+// newQRCode draws the function patterns of a version.
+func newQRCode(version int) *qrCode {
+	size := 17 + 4*version
+	q := &qrCode{size: size, modules: make([][]bool, size), function: make([][]bool, size)}
+	for y := range size {
+		q.modules[y] = make([]bool, size)
+		q.function[y] = make([]bool, size)
+	}
+
+	for i := range size {
+		q.set(6, i, i%2 == 0)
+		q.set(i, 6, i%2 == 0)
+	}
+	for _, c := range [][2]int{{3, 3}, {size - 4, 3}, {3, size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := c[0]+dx, c[1]+dy
+				if x >= 0 && x < size && y >= 0 && y < size {
+					dist := max(abs(dx), abs(dy))
+					q.set(x, y, dist != 2 && dist != 4)
+				}
+			}
+		}
+	}
+	pos := qrAlignment[version-1]
+	for i, x := range pos {
+		for j, y := range pos {
+			if i == 0 && j == 0 || i == 0 && j == len(pos)-1 || i == len(pos)-1 && j == 0 {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					q.set(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+	// Reserve the format modules; applyBestMask fills them in.
+	q.drawFormat(0)
+
+	if version >= 7 {
+		rem := version
+		for range 12 {
+			rem = rem<<1 ^ (rem>>11)*0x1F25
+		}
+		bits := version<<12 | rem
+		for i := range 18 {
+			dark := bits>>i&1 == 1
+			a, b := size-11+i%3, i/3
+			q.set(a, b, dark)
+			q.set(b, a, dark)
+		}
+	}
+	return q
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// set sets a function module.
+func (q *qrCode) set(x, y int, dark bool) {
+	q.modules[y][x] = dark
+	q.function[y][x] = true
+}
+
+// drawFormat draws both copies of the format information for level M and
+// mask.
+func (q *qrCode) drawFormat(mask int) {
+	data := mask // level M is 00
+	rem := data
+	for range 10 {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return bits>>i&1 == 1 }
+
+	for i := range 6 {
+		q.set(8, i, bit(i))
+	}
+	q.set(8, 7, bit(6))
+	q.set(8, 8, bit(7))
+	q.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.set(14-i, 8, bit(i))
+	}
+	for i := range 8 {
+		q.set(q.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.set(8, q.size-15+i, bit(i))
+	}
+	q.set(8, q.size-8, true)
+}
+
+// placeCodewords fills the data modules in the zigzag order.
+func (q *qrCode) placeCodewords(data []byte) {
+	i := 0
+	for right := q.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := range q.size {
+			for j := range 2 {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = q.size - 1 - vert
+				}
+				if !q.function[y][x] && i < len(data)*8 {
+					q.modules[y][x] = data[i/8]>>(7-i%8)&1 == 1
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyMask inverts the data modules selected by mask; applying it again
+// undoes it.
+func (q *qrCode) applyMask(mask int) {
+	for y := range q.size {
+		for x := range q.size {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert && !q.function[y][x] {
+				q.modules[y][x] = !q.modules[y][x]
+			}
+		}
+	}
+}
+
+// applyBestMask applies the mask that scores the lowest penalty.
+func (q *qrCode) applyBestMask() {
+	best, bestPenalty := 0, -1
+	for mask := range 8 {
+		q.applyMask(mask)
+		q.drawFormat(mask)
+		if p := q.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		q.applyMask(mask)
+	}
+	q.applyMask(best)
+	q.drawFormat(best)
+}
+
+// penalty scores how hard the code is to scan, by the four rules of the
+// standard: long runs, 2x2 blocks, finder-like patterns and dark balance.
+func (q *qrCode) penalty() int {
+	p := 0
+	at := func(x, y int, transposed bool) bool {
+		if transposed {
+			return q.modules[x][y]
+		}
+		return q.modules[y][x]
+	}
+	finderLike := []bool{true, false, true, true, true, false, true}
+	for _, transposed := range []bool{false, true} {
+		for y := range q.size {
+			run := 1
+			for x := 1; x <= q.size; x++ {
+				if x < q.size && at(x, y, transposed) == at(x-1, y, transposed) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					p += run - 2
+				}
+				run = 1
+			}
+			for x := 0; x+7 <= q.size; x++ {
+				match := true
+				for i, dark := range finderLike {
+					if at(x+i, y, transposed) != dark {
+						match = false
+						break
+					}
+				}
+				if match && (q.lightRun(x-4, x, y, transposed) || q.lightRun(x+7, x+11, y, transposed)) {
+					p += 40
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := range q.size {
+		for x := range q.size {
+			if q.modules[y][x] {
+				dark++
+			}
+			if x+1 < q.size && y+1 < q.size {
+				c := q.modules[y][x]
+				if q.modules[y][x+1] == c && q.modules[y+1][x] == c && q.modules[y+1][x+1] == c {
+					p += 3
+				}
+			}
+		}
+	}
+	total := q.size * q.size
+	p += 10 * (abs(dark*20-total*10) / total)
+	return p
+}
+
+// lightRun reports whether the modules from x0 up to x1 on line y are light,
+// counting the quiet zone outside the code as light.
+func (q *qrCode) lightRun(x0, x1, y int, transposed bool) bool {
+	for x := x0; x < x1; x++ {
+		if x < 0 || x >= q.size {
+			continue
+		}
+		if transposed && q.modules[x][y] || !transposed && q.modules[y][x] {
+			return false
+		}
+	}
+	return true
+}
+
+// png renders the code with scale pixels per module and the standard quiet
+// zone of four modules.
+func (q *qrCode) png(scale int) ([]byte, error) {
+	const quiet = 4
+	side := (q.size + 2*quiet) * scale
+	img := image.NewPaletted(image.Rect(0, 0, side, side), color.Palette{color.White, color.Black})
+	for y := range q.size {
+		for x := range q.size {
+			if !q.modules[y][x] {
+				continue
+			}
+			for dy := range scale {
+				for dx := range scale {
+					img.SetColorIndex((x+quiet)*scale+dx, (y+quiet)*scale+dy, 1)
+				}
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,103 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+)
+
+// quotaWindow is the period room quotas are counted over.
+const quotaWindow = 7 * 24 * time.Hour
+
+// everyoneQuota is the key of the quota that applies to all members.
+const everyoneQuota discord.RoleID = 0
+
+func parseRoomQuotas(env *environment, key string) map[discord.RoleID]int {
+	quotas := make(map[discord.RoleID]int)
+	for _, item := range env.list(key) {
+		role, n, ok := strings.Cut(item, "=")
+		limit, err := strconv.Atoi(strings.TrimSpace(n))
+		if !ok || err != nil || limit < 0 {
+			env.configError("invalid $%s: %q is not role=count", key, item)
+			continue
+		}
+
+		role = strings.TrimSpace(role)
+		if role == "everyone" {
+			quotas[everyoneQuota] = limit
+			continue
+		}
+		id, err := discord.ParseSnowflake(role)
+		if err != nil {
+			env.configError("invalid $%s: %v", key, err)
+			continue
+		}
+		quotas[discord.RoleID(id)] = limit
+	}
+	return quotas
+}
+
+// quotaFor returns the weekly quota of a member with the given roles, or 0 if
+// they are unlimited.
+func (h *handler) quotaFor(roles []discord.RoleID) int {
+	quota, limited := h.cfg.roomQuotas[everyoneQuota]
+	for _, id := range roles {
+		n, ok := h.cfg.roomQuotas[id]
+		if !ok {
+			continue
+		}
+		if n == 0 {
+			return 0
+		}
+		if !limited || n > quota {
+			quota, limited = n, true
+		}
+	}
+	return quota
+}
+
+// withinQuota reports whether the member in evt may create another room this
+// week. If not, they are disconnected from the hub and told when they can
+// create the next one.
+func (h *handler) withinQuota(ctx context.Context, hub *hub, guildID discord.GuildID, evt *gateway.VoiceStateUpdateEvent) bool {
+	if len(h.cfg.roomQuotas) == 0 {
+		return true
+	}
+
+	member, err := h.member(ctx, guildID, evt)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get member for quota check", "err", err)
+		return true
+	}
+	quota := h.quotaFor(member.RoleIDs)
+	if quota == 0 {
+		return true
+	}
+
+	used, oldest := h.stats.creationsSince(guildID, evt.UserID, time.Now().Add(-quotaWindow))
+	if used < quota {
+		return true
+	}
+
+	h.queueMove(memberMove{
+		guildID:   guildID,
+		userID:    evt.UserID,
+		channelID: discord.NullChannelID,
+		reason:    "member used up their weekly room quota",
+		failure:   "Failed to disconnect member over quota",
+	})
+	if hub.silent {
+		return false
+	}
+
+	h.notify(ctx, evt.UserID, fmt.Sprintf(
+		"You've created %s this week, which is your limit. You can create another <t:%d:R>.",
+		plural(used, "room"), oldest.Add(quotaWindow).Unix()))
+	return false
+}
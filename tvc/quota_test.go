@@ -0,0 +1,64 @@
+package tvc
+
+import (
+	"testing"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+func TestQuotaFor(t *testing.T) {
+	h := &handler{cfg: settings{roomQuotas: map[discord.RoleID]int{everyoneQuota: 2, 10: 5, 11: 3, 12: 0}}}
+	for _, test := range []struct {
+		roles []discord.RoleID
+		quota int
+	}{
+		{nil, 2},
+		{[]discord.RoleID{11}, 3},
+		{[]discord.RoleID{11, 10}, 5}, // the most generous role counts
+		{[]discord.RoleID{10, 12}, 0}, // an unlimited role beats any quota
+		{[]discord.RoleID{13}, 2},
+	} {
+		if got := h.quotaFor(test.roles); got != test.quota {
+			t.Errorf("quota of roles %v is %d, want %d", test.roles, got, test.quota)
+		}
+	}
+
+	// Without a quota for everyone, only members with a role are limited.
+	h.cfg.roomQuotas = map[discord.RoleID]int{11: 3}
+	if got := h.quotaFor([]discord.RoleID{13}); got != 0 {
+		t.Errorf("member without a quota role has quota %d, want 0", got)
+	}
+}
+
+func TestWeeklyQuota(t *testing.T) {
+	b := newTestBot(t, "ROOM_WEEKLY_QUOTAS=everyone=1,"+testAdminID.String()+"=0")
+	b.send(testGuild(nil, nil))
+
+	// Member 5 uses up their quota with a room they leave again.
+	b.send(join(5, testHubID))
+	room := b.roomOf(5)
+	if !room.IsValid() {
+		t.Fatal("member within their quota got no room")
+	}
+	b.send(join(5, room), join(5, 0))
+	if b.roomOf(5).IsValid() {
+		t.Fatal("left room wasn't deleted")
+	}
+
+	b.send(join(5, testHubID))
+	if b.roomOf(5).IsValid() {
+		t.Error("member over their quota got a room")
+	}
+
+	// Admins are unlimited, however many rooms they have created.
+	admin := join(6, testHubID)
+	admin.Member.RoleIDs = []discord.RoleID{testAdminID}
+	for range 2 {
+		b.send(admin)
+		room := b.roomOf(6)
+		if !room.IsValid() {
+			t.Fatal("unlimited member got no room")
+		}
+		b.send(join(6, room), join(6, 0))
+	}
+}
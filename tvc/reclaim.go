@@ -0,0 +1,235 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/diamondburned/arikawa/v3/utils/json/option"
+)
+
+// maxClaimCandidates is how many occupants a claim vote offers, one button
+// each, which is what fits in a single row.
+const maxClaimCandidates = 5
+
+// claimVote is an open vote on who takes over a room from its absent owner.
+type claimVote struct {
+	message    discord.MessageID
+	candidates []discord.UserID
+	// votes maps each voter to the candidate they picked.
+	votes map[discord.UserID]discord.UserID
+}
+
+func claimVoteID(i int) string {
+	return fmt.Sprintf("reclaim-%d", i)
+}
+
+// watchOwner starts counting down to a claim vote while the room is used
+// without its owner, and calls off the countdown or vote once the owner is
+// back or the room is empty.
+func (h *handler) watchOwner(ctx context.Context, channelID discord.ChannelID, r *room) {
+	if h.cfg.ownerReclaimAfter <= 0 || r.hub.silent {
+		return
+	}
+	ownerChannel := h.userVoiceStates[r.owner].ChannelID
+	if ownerChannel == channelID || (r.afkChannel.IsValid() && ownerChannel == r.afkChannel) || h.roomOccupants(r) == 0 {
+		if r.reclaimTimer != nil {
+			r.reclaimTimer.Stop()
+			r.reclaimTimer = nil
+		}
+		h.closeClaimVote(ctx, r, "The owner is back, so this vote is over.")
+		return
+	}
+	if r.reclaimTimer != nil || r.claim != nil {
+		return
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(h.cfg.ownerReclaimAfter, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		if h.rooms[channelID] != r || r.reclaimTimer != timer {
+			return
+		}
+		r.reclaimTimer = nil
+		h.openClaimVote(withGuild(context.Background(), r.guildID), channelID, r)
+	})
+	r.reclaimTimer = timer
+}
+
+// openClaimVote posts a vote among the room's occupants on who becomes its
+// owner.
+func (h *handler) openClaimVote(ctx context.Context, channelID discord.ChannelID, r *room) {
+	var candidates []discord.UserID
+	for userID, vs := range h.userVoiceStates {
+		if vs.ChannelID == channelID && userID != r.owner {
+			candidates = append(candidates, userID)
+		}
+	}
+	if len(candidates) == 0 {
+		return
+	}
+	slices.Sort(candidates)
+	candidates = candidates[:min(len(candidates), maxClaimCandidates)]
+
+	vote := &claimVote{candidates: candidates, votes: make(map[discord.UserID]discord.UserID)}
+	chat := r.chatChannel()
+	err := h.call(ctx, "SendMessage", func(s *state.State) error {
+		msg, err := s.SendMessageComplex(chat, api.SendMessageData{
+			Content:         h.claimVoteText(r, vote),
+			Components:      h.claimVoteComponents(r, vote),
+			AllowedMentions: &api.AllowedMentions{},
+		})
+		if err != nil {
+			return err
+		}
+		vote.message = msg.ID
+		return nil
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to post claim vote", "err", err)
+		return
+	}
+	r.claim = vote
+}
+
+// onClaimVote records a vote for the i-th candidate and hands the room over
+// once a candidate has a majority of the people connected.
+func (h *handler) onClaimVote(i int) cmdroute.ComponentHandlerFunc {
+	return func(ctx context.Context, data cmdroute.ComponentData) *api.InteractionResponse {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		channelID, r := h.roomByChat(data.Event.ChannelID)
+		if r == nil || r.claim == nil || r.claim.message != data.Event.Message.ID || i >= len(r.claim.candidates) {
+			return ephemeral("This vote is over.")
+		}
+		voter := data.Event.SenderID()
+		if h.userVoiceStates[voter].ChannelID != channelID {
+			return ephemeral("Only people in this room can vote.")
+		}
+		vote := r.claim
+		vote.votes[voter] = vote.candidates[i]
+
+		content := h.claimVoteText(r, vote)
+		components := h.claimVoteComponents(r, vote)
+		if winner, ok := h.claimWinner(channelID, vote); ok {
+			h.transferOwnership(ctx, channelID, r, winner)
+			content = fmt.Sprintf("%s is the new owner of this room.", winner.Mention())
+			components = discord.ContainerComponents{}
+			r.claim = nil
+			h.expireMessage(r, vote.message)
+		}
+		return &api.InteractionResponse{
+			Type: api.UpdateMessage,
+			Data: &api.InteractionResponseData{
+				Content:         option.NewNullableString(content),
+				Components:      &components,
+				AllowedMentions: &api.AllowedMentions{},
+			},
+		}
+	}
+}
+
+// claimWinner returns the candidate more than half of the people connected
+// voted for. Votes of people who left don't count.
+func (h *handler) claimWinner(channelID discord.ChannelID, vote *claimVote) (discord.UserID, bool) {
+	present := h.occupants(channelID)
+	tally := make(map[discord.UserID]int)
+	for voter, candidate := range vote.votes {
+		if h.userVoiceStates[voter].ChannelID == channelID {
+			tally[candidate]++
+		}
+	}
+	for candidate, n := range tally {
+		if n*2 > present && h.userVoiceStates[candidate].ChannelID == channelID {
+			return candidate, true
+		}
+	}
+	return 0, false
+}
+
+// transferOwnership makes newOwner the owner of the room, with the access
+// an owner gets on creation.
+func (h *handler) transferOwnership(ctx context.Context, channelID discord.ChannelID, r *room, newOwner discord.UserID) {
+	target := channelID
+	if r.category.IsValid() {
+		target = r.category
+	}
+	err := h.call(ctx, "EditChannelPermission", func(s *state.State) error {
+		return s.EditChannelPermission(target, discord.Snowflake(newOwner), api.EditChannelPermissionData{
+			Type:           discord.OverwriteMember,
+			Allow:          discord.PermissionViewChannel | discord.PermissionConnect,
+			AuditLogReason: "room owner by vote",
+		})
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to grant new owner access", "err", err)
+	}
+	slog.Info("Room passed to a new owner by vote", "guild_id", r.guildID, "channel_id", channelID, "from", r.owner, "to", newOwner)
+	previous := r.owner
+	r.owner = newOwner
+	h.stats.track(trackedRoomOf(r))
+	h.runHook(r, hookClaim, previous)
+}
+
+// closeClaimVote ends the room's open vote, if any, replacing it with reason.
+func (h *handler) closeClaimVote(ctx context.Context, r *room, reason string) {
+	if r.claim == nil {
+		return
+	}
+	msgID := r.claim.message
+	r.claim = nil
+	chat := r.chatChannel()
+	err := h.call(ctx, "EditMessage", func(s *state.State) error {
+		_, err := s.EditMessageComplex(chat, msgID, api.EditMessageData{
+			Content:    option.NewNullableString(reason),
+			Components: &discord.ContainerComponents{},
+		})
+		return err
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to close claim vote", "err", err)
+	}
+	h.expireMessage(r, msgID)
+}
+
+func (h *handler) claimVoteText(r *room, vote *claimVote) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s has been away for a while. Vote for who should own this room now; "+
+		"more than half of the people here need to agree.", r.owner.Mention())
+	for _, candidate := range vote.candidates {
+		n := 0
+		for _, picked := range vote.votes {
+			if picked == candidate {
+				n++
+			}
+		}
+		fmt.Fprintf(&sb, "\n%s: %s", candidate.Mention(), plural(n, "vote"))
+	}
+	return sb.String()
+}
+
+func (h *handler) claimVoteComponents(r *room, vote *claimVote) discord.ContainerComponents {
+	var row discord.ActionRowComponent
+	for i, candidate := range vote.candidates {
+		label := candidate.String()
+		if m, err := h.s.Cabinet.Member(r.guildID, candidate); err == nil {
+			label = m.User.Username
+		}
+		row = append(row, &discord.ButtonComponent{
+			Style:    discord.SecondaryButtonStyle(),
+			CustomID: discord.ComponentID(claimVoteID(i)),
+			Label:    label,
+		})
+	}
+	return discord.ContainerComponents{&row}
+}
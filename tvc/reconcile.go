@@ -0,0 +1,112 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+// onGatewayGap notes that the bot was disconnected from the gateway and may
+// have missed voice state updates, and reconciles the rooms once things have
+// settled. kind is "resume" if the session was resumed and "reidentify" if a
+// new one had to be started.
+func (h *handler) onGatewayGap(kind string) {
+	slog.Warn("Gateway connection was interrupted", "kind", kind)
+	h.metrics.gatewayGap(kind)
+	if h.cfg.reconcileDelay > 0 {
+		time.AfterFunc(h.cfg.reconcileDelay, h.reconcileRooms)
+	}
+}
+
+// noteIdentified reports a gap if a session was identified before; the first
+// READY is just the bot starting.
+func (h *handler) noteIdentified() {
+	h.mu.Lock()
+	gap := h.identified
+	h.identified = true
+	h.mu.Unlock()
+
+	if gap {
+		h.onGatewayGap("reidentify")
+	}
+}
+
+// reconcileRooms reconciles every guild that has rooms, on its guild queue.
+func (h *handler) reconcileRooms() {
+	h.mu.Lock()
+	guilds := make(map[discord.GuildID]bool)
+	for _, r := range h.rooms {
+		guilds[r.guildID] = true
+	}
+	h.mu.Unlock()
+
+	for guildID := range guilds {
+		h.queues.dispatch(guildID, func() { h.reconcileGuild(guildID) })
+	}
+}
+
+// reconcileGuild compares who the handler thinks is in the guild's rooms
+// with the voice states Discord has for everyone who was or could be in them,
+// fixes what drifted, and cleans up rooms that turned out to be empty or
+// keeps ones that turned out not to be.
+func (h *handler) reconcileGuild(guildID discord.GuildID) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ctx := withGuild(context.Background(), guildID)
+	checked := make(map[discord.UserID]bool)
+	var rooms []*room
+	for _, r := range h.rooms {
+		if r.guildID != guildID {
+			continue
+		}
+		rooms = append(rooms, r)
+		for _, channelID := range []discord.ChannelID{r.channelID, r.afkChannel} {
+			for userID := range h.channelMembers[channelID] {
+				checked[userID] = true
+			}
+		}
+		for userID := range r.participants {
+			checked[userID] = true
+		}
+	}
+
+	drifted := 0
+	for userID := range checked {
+		var vs *discord.VoiceState
+		err := h.call(ctx, "GetVoiceState", func(s *state.State) (err error) {
+			vs, err = fetchVoiceState(s, guildID, userID)
+			return err
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to reconcile voice state", "user_id", userID, "err", err)
+			continue
+		}
+		actual := discord.VoiceState{GuildID: guildID, UserID: userID}
+		if vs != nil {
+			actual = *vs
+			actual.GuildID = guildID
+		}
+		if known := h.userVoiceStates[userID]; known.ChannelID != actual.ChannelID {
+			slog.InfoContext(ctx, "Voice state drifted during gateway gap", "user_id", userID, "known", known.ChannelID, "actual", actual.ChannelID)
+			h.setVoiceState(actual)
+			drifted++
+		}
+	}
+
+	for _, r := range rooms {
+		if h.rooms[r.channelID] != r {
+			continue
+		}
+		switch occupied := h.roomOccupants(r) > 0; {
+		case occupied && r.deleteTimer != nil:
+			h.cancelRoomDeletion(ctx, r)
+		case !occupied && r.deleteTimer == nil:
+			h.checkEmptied(ctx, r)
+		}
+	}
+	slog.InfoContext(ctx, "Reconciled rooms after gateway gap", "rooms", len(rooms), "members", len(checked), "drifted", drifted)
+}
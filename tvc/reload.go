@@ -0,0 +1,321 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/diamondburned/arikawa/v3/utils/json/option"
+)
+
+// Custom IDs of the buttons on a reload that needs confirming.
+const (
+	reloadConfirmID = "reload-confirm"
+	reloadCancelID  = "reload-cancel"
+)
+
+// lobbyReload is a reloaded hub table waiting for an admin to confirm it,
+// because it would leave live rooms without their hub.
+type lobbyReload struct {
+	hubs map[string]*hub
+	// messages are the log channel posts asking for confirmation.
+	messages map[discord.MessageID]bool
+}
+
+// watchReloads reloads $LOBBIES_FILE on SIGHUP until ctx is done.
+func (h *handler) watchReloads(ctx context.Context) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+	defer signal.Stop(sig)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sig:
+			h.reloadLobbies()
+		}
+	}
+}
+
+// reloadLobbies rereads the lobby file and posts what changed to the log
+// channels. Changes that would orphan live rooms wait for an admin to confirm
+// them there; anything else is applied right away. A broken file keeps the
+// current hubs and is returned.
+func (h *handler) reloadLobbies() error {
+	lobbies, err := readLobbies(h.cfg.lobbiesFile)
+	if err != nil {
+		slog.Error("Failed to reload lobbies", "err", err)
+		return err
+	}
+	hubs, err := buildHubs(h.env, h.cfg.stagingGuilds, lobbies)
+	if err != nil {
+		slog.Error("Failed to reload lobbies", "err", err)
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	diff := diffHubs(h.hubs, hubs)
+	if len(diff) == 0 {
+		slog.Info("Reloaded lobbies, nothing changed")
+		return nil
+	}
+	orphans := h.orphanedBy(hubs)
+	if len(orphans) == 0 {
+		h.pendingReload = nil
+		h.applyHubs(hubs)
+		slog.Info("Reloaded lobbies", "changes", diffLines(diff, discord.NullGuildID))
+		h.postReload("**Lobby config reloaded**", diff, nil, false)
+		return nil
+	}
+
+	slog.Warn("Reloaded lobbies, waiting for confirmation since rooms would lose their hub", "orphans", len(orphans), "changes", diffLines(diff, discord.NullGuildID))
+	h.pendingReload = &lobbyReload{hubs: hubs, messages: h.postReload("**Lobby config reloaded**", diff, orphans, true)}
+	return nil
+}
+
+// hubChange is how one hub differs between two hub tables. prev is nil for
+// added hubs and next for removed ones.
+type hubChange struct {
+	line       string
+	prev, next *hub
+}
+
+// concerns reports whether the change matters to guildID, because the hub
+// served it before or serves it now.
+func (c hubChange) concerns(guildID discord.GuildID) bool {
+	return c.prev != nil && c.prev.serves(guildID) || c.next != nil && c.next.serves(guildID)
+}
+
+// diffLines returns the lines of the changes that concern guildID, or of all
+// of them for the null guild.
+func diffLines(diff []hubChange, guildID discord.GuildID) []string {
+	var lines []string
+	for _, c := range diff {
+		if !guildID.IsValid() || c.concerns(guildID) {
+			lines = append(lines, c.line)
+		}
+	}
+	return lines
+}
+
+// diffHubs lists how the hubs in next differ from those in prev, one change
+// per added, removed or changed hub, sorted by line.
+func diffHubs(prev, next map[string]*hub) []hubChange {
+	var diff []hubChange
+	for key, hub := range next {
+		old, ok := prev[key]
+		if !ok {
+			diff = append(diff, hubChange{line: fmt.Sprintf("+ added %s (%s)", key, hub.label()), next: hub})
+			continue
+		}
+		var changes []string
+		a, b := old.lobby, hub.lobby
+		if a.Name != b.Name {
+			changes = append(changes, fmt.Sprintf("name %q → %q", a.Name, b.Name))
+		}
+		if a.Type != b.Type {
+			changes = append(changes, fmt.Sprintf("type %s → %s", a.Type, b.Type))
+		}
+		if a.NameTemplate != b.NameTemplate {
+			changes = append(changes, fmt.Sprintf("template %q → %q", a.NameTemplate, b.NameTemplate))
+		}
+		if !slices.Equal(a.ChannelIDs, b.ChannelIDs) {
+			changes = append(changes, "channels")
+		}
+		if !slices.Equal(a.GuildIDs, b.GuildIDs) {
+			changes = append(changes, "servers")
+		}
+		if a.UserLimit != b.UserLimit {
+			changes = append(changes, fmt.Sprintf("user limit %d → %d", a.UserLimit, b.UserLimit))
+		}
+		if a.Bitrate != b.Bitrate {
+			changes = append(changes, fmt.Sprintf("bitrate %d → %d", a.Bitrate, b.Bitrate))
+		}
+		if a.Private != b.Private {
+			changes = append(changes, fmt.Sprintf("private %t → %t", a.Private, b.Private))
+		}
+		if !slices.EqualFunc(a.Permissions, b.Permissions, func(x, y permissionRule) bool {
+			return x.RoleID == y.RoleID && slices.Equal(x.Allow, y.Allow) && slices.Equal(x.Deny, y.Deny)
+		}) {
+			changes = append(changes, "permission template")
+		}
+		if len(changes) > 0 {
+			diff = append(diff, hubChange{line: fmt.Sprintf("~ changed %s: %s", key, strings.Join(changes, ", ")), prev: old, next: hub})
+		}
+	}
+	for key, hub := range prev {
+		if _, ok := next[key]; !ok {
+			diff = append(diff, hubChange{line: fmt.Sprintf("- removed %s (%s)", key, hub.label()), prev: hub})
+		}
+	}
+	slices.SortFunc(diff, func(a, b hubChange) int { return strings.Compare(a.line, b.line) })
+	return diff
+}
+
+// orphanedBy lists the live rooms whose hub is missing from hubs, or now
+// creates a different kind of room.
+func (h *handler) orphanedBy(hubs map[string]*hub) []discord.ChannelID {
+	var orphans []discord.ChannelID
+	for id, r := range h.rooms {
+		if next, ok := hubs[r.hub.key]; !ok || next.kind != r.hub.kind {
+			orphans = append(orphans, id)
+		}
+	}
+	return orphans
+}
+
+// applyHubs switches to hubs, moving the live rooms of hubs that are still
+// there over to their new settings.
+func (h *handler) applyHubs(hubs map[string]*hub) {
+	for _, r := range h.rooms {
+		if next, ok := hubs[r.hub.key]; ok && next.kind == r.hub.kind {
+			r.hub = next
+		}
+	}
+	for key, hub := range hubs {
+		if old, ok := h.hubs[key]; ok && slices.Equal(old.categories, hub.categories) {
+			hub.nextCategory = old.nextCategory
+		}
+	}
+	h.hubs = hubs
+	for guildID := range h.boards {
+		h.refreshBoard(guildID)
+	}
+}
+
+// postReload posts the changes under title to the log channels, each getting
+// only the changes and orphaned rooms of its own guild, with buttons to apply
+// or drop the reload if it needs confirming. Guilds the reload doesn't
+// concern get nothing. It returns the messages posted.
+func (h *handler) postReload(title string, diff []hubChange, orphans []discord.ChannelID, confirm bool) map[discord.MessageID]bool {
+	posted := make(map[discord.MessageID]bool)
+	var components discord.ContainerComponents
+	if confirm {
+		components = discord.ContainerComponents{
+			&discord.ActionRowComponent{
+				&discord.ButtonComponent{
+					Style:    discord.DangerButtonStyle(),
+					CustomID: reloadConfirmID,
+					Label:    "Apply",
+				},
+				&discord.ButtonComponent{
+					Style:    discord.SecondaryButtonStyle(),
+					CustomID: reloadCancelID,
+					Label:    "Keep current config",
+				},
+			},
+		}
+	}
+	for channelID := range h.cfg.logChannels {
+		ch, err := h.s.Channel(channelID)
+		if err != nil {
+			slog.Error("Failed to get log channel", "err", err)
+			continue
+		}
+		lines := diffLines(diff, ch.GuildID)
+		orphaned := 0
+		for _, id := range orphans {
+			if r := h.rooms[id]; r != nil && r.guildID == ch.GuildID {
+				orphaned++
+			}
+		}
+		if len(lines) == 0 && orphaned == 0 {
+			continue
+		}
+
+		summary := title + "\n" + strings.Join(lines, "\n")
+		if orphaned > 0 {
+			summary += fmt.Sprintf("\n\nThis leaves %s here without their hub. They keep their old settings until they empty out, but aren't restored after a restart.", plural(orphaned, "live room"))
+		}
+		if len(summary) > 1900 {
+			summary = strings.ToValidUTF8(summary[:1900], "") + "\n…"
+		}
+		data := api.SendMessageData{
+			Content:         summary,
+			Components:      components,
+			AllowedMentions: &api.AllowedMentions{},
+		}
+		err = h.call(withGuild(context.Background(), ch.GuildID), "SendMessage", func(s *state.State) error {
+			msg, err := s.SendMessageComplex(channelID, data)
+			if err != nil {
+				return err
+			}
+			posted[msg.ID] = true
+			return nil
+		})
+		if err != nil {
+			slog.Error("Failed to post lobby reload", "err", err)
+		}
+	}
+	return posted
+}
+
+// onReloadConfirm applies the reload waiting for confirmation. Since it
+// changes the hubs of every guild, only operators may apply it, or members
+// with Manage Server if the bot has no operators.
+func (h *handler) onReloadConfirm(ctx context.Context, data cmdroute.ComponentData) *api.InteractionResponse {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.pendingReload == nil || data.Event.Message == nil || !h.pendingReload.messages[data.Event.Message.ID] {
+		return reloadUpdate(data, "This reload has already been handled.")
+	}
+	userID := data.Event.SenderID()
+	switch {
+	case h.cfg.operators[userID]:
+	case len(h.cfg.operators) > 0:
+		return ephemeral("Only the bot's operators can apply a lobby reload.")
+	case !h.canManageGuild(data.Event.GuildID, userID):
+		return ephemeral("You need the Manage Server permission to apply a lobby reload.")
+	}
+	h.applyHubs(h.pendingReload.hubs)
+	h.pendingReload = nil
+	slog.Info("Lobby reload confirmed", "user_id", data.Event.SenderID())
+	return reloadUpdate(data, "Applied by "+data.Event.SenderID().Mention()+".")
+}
+
+// onReloadCancel drops the reload waiting for confirmation. Operators and
+// members with Manage Server may drop it.
+func (h *handler) onReloadCancel(ctx context.Context, data cmdroute.ComponentData) *api.InteractionResponse {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.pendingReload == nil || data.Event.Message == nil || !h.pendingReload.messages[data.Event.Message.ID] {
+		return reloadUpdate(data, "This reload has already been handled.")
+	}
+	if userID := data.Event.SenderID(); !h.cfg.operators[userID] && !h.canManageGuild(data.Event.GuildID, userID) {
+		return ephemeral("You need the Manage Server permission to drop a lobby reload.")
+	}
+	h.pendingReload = nil
+	slog.Info("Lobby reload dropped", "user_id", data.Event.SenderID())
+	return reloadUpdate(data, "Dropped by "+data.Event.SenderID().Mention()+", the current config stays.")
+}
+
+// reloadUpdate notes outcome under the reload summary and removes its
+// buttons.
+func reloadUpdate(data cmdroute.ComponentData, outcome string) *api.InteractionResponse {
+	content := outcome
+	if msg := data.Event.Message; msg != nil {
+		content = msg.Content + "\n\n" + outcome
+	}
+	return &api.InteractionResponse{
+		Type: api.UpdateMessage,
+		Data: &api.InteractionResponseData{
+			Content:         option.NewNullableString(content),
+			Components:      &discord.ContainerComponents{},
+			AllowedMentions: &api.AllowedMentions{},
+		},
+	}
+}
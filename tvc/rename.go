@@ -0,0 +1,155 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+// Discord allows renaming a channel twice per ten minutes. Going over that
+// stalls every other request on the channel until the window passes, so
+// renames past the limit wait their turn instead.
+const (
+	renameBurst  = 2
+	renameWindow = 10 * time.Minute
+)
+
+// renameLimiter queues channel renames so no channel is renamed more often
+// than Discord allows, and no guild more often than its rename budget. A
+// queued rename is replaced by later ones for the same channel. It is guarded
+// by handler.mu.
+type renameLimiter struct {
+	recent  map[discord.ChannelID][]time.Time
+	pending map[discord.ChannelID]*queuedRename
+}
+
+type queuedRename struct {
+	name  string
+	timer *time.Timer
+}
+
+func newRenameLimiter() *renameLimiter {
+	return &renameLimiter{
+		recent:  make(map[discord.ChannelID][]time.Time),
+		pending: make(map[discord.ChannelID]*queuedRename),
+	}
+}
+
+// renameChannel renames channelID now if the limit allows it and queues the
+// rename otherwise. It reports whether the rename was queued.
+func (h *handler) renameChannel(ctx context.Context, channelID discord.ChannelID, name string) (queued bool) {
+	name = channelName(name)
+	l := h.renames
+	if q, ok := l.pending[channelID]; ok {
+		q.name = name
+		return true
+	}
+
+	now := time.Now()
+	recent := l.recent[channelID][:0]
+	for _, at := range l.recent[channelID] {
+		if now.Sub(at) < renameWindow {
+			recent = append(recent, at)
+		}
+	}
+	l.recent[channelID] = recent
+
+	var wait time.Duration
+	if len(recent) >= renameBurst {
+		wait = recent[0].Add(renameWindow).Sub(now)
+	} else {
+		wait = h.spend(h.cfg.renameBudget, guildFrom(ctx))
+	}
+	if wait > 0 {
+		q := &queuedRename{name: name}
+		q.timer = time.AfterFunc(wait, func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+
+			if l.pending[channelID] != q {
+				return
+			}
+			delete(l.pending, channelID)
+			if h.created[channelID] {
+				h.renameChannel(context.WithoutCancel(ctx), channelID, q.name)
+			}
+		})
+		l.pending[channelID] = q
+		return true
+	}
+
+	l.recent[channelID] = append(recent, now)
+	err := h.call(ctx, "ModifyChannel", func(s *state.State) error {
+		return s.ModifyChannel(channelID, api.ModifyChannelData{
+			Name:           name,
+			AuditLogReason: "renaming rooms",
+		})
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to rename room", "err", err)
+	}
+	return false
+}
+
+// forgetRenames drops the rename state of a deleted channel.
+func (h *handler) forgetRenames(channelID discord.ChannelID) {
+	if q, ok := h.renames.pending[channelID]; ok {
+		q.timer.Stop()
+		delete(h.renames.pending, channelID)
+	}
+	delete(h.renames.recent, channelID)
+}
+
+// cmdRenameAll handles /voiceadmin rename-all, which renames every room in
+// the guild with a new template.
+func (h *handler) cmdRenameAll(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	tmpl := data.Options.Find("template").String()
+	if err := checkTemplate(tmpl); err != nil {
+		return ephemeralData(fmt.Sprintf("That template doesn't work: %v.", err))
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	guildID := data.Event.GuildID
+	ctx = withGuild(ctx, guildID)
+	renamed, queued := 0, 0
+	for channelID, r := range h.rooms {
+		if r.guildID != guildID {
+			continue
+		}
+
+		m, err := h.lookupMember(ctx, guildID, r.owner)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to look up room owner", "err", err)
+			continue
+		}
+
+		target := channelID
+		if r.category.IsValid() {
+			target = r.category
+		}
+		name := h.decorateName(guildID, expandTemplate(tmpl, templateData{Username: m.User.Username, Number: r.number}))
+		if h.renameChannel(ctx, target, name) {
+			queued++
+		} else {
+			renamed++
+		}
+	}
+
+	if renamed+queued == 0 {
+		return ephemeralData("There are no rooms to rename.")
+	}
+	msg := fmt.Sprintf("Renamed %s.", plural(renamed, "room"))
+	if queued > 0 {
+		msg += fmt.Sprintf(" %s renamed recently will follow within %s because of Discord's rename limit.",
+			plural(queued, "room"), formatDuration(renameWindow))
+	}
+	return ephemeralData(msg)
+}
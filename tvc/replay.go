@@ -0,0 +1,137 @@
+package tvc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/diamondburned/arikawa/v3/utils/httputil/httpdriver"
+	"github.com/diamondburned/arikawa/v3/utils/ws"
+)
+
+// recordedEvent is a line of a recording: a gateway dispatch and when it
+// arrived.
+type recordedEvent struct {
+	At   time.Time       `json:"at"`
+	Type ws.EventType    `json:"t"`
+	Data json.RawMessage `json:"d"`
+}
+
+// openRecording opens path for recordEvents to append to.
+func openRecording(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open $RECORD_EVENTS: %w", err)
+	}
+	return f, nil
+}
+
+// recordEvents appends every gateway dispatch s receives to f.
+func recordEvents(s *state.State, f *os.File) {
+	var mu sync.Mutex
+	enc := json.NewEncoder(f)
+	s.Session.AddSyncHandler(func(ev ws.Event) {
+		if ev.Op() != dispatchOp || ev.EventType() == "" {
+			return
+		}
+		data, err := json.Marshal(ev)
+		if err != nil {
+			slog.Error("Failed to record event", "type", ev.EventType(), "err", err)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if err := enc.Encode(recordedEvent{At: time.Now(), Type: ev.EventType(), Data: data}); err != nil {
+			slog.Error("Failed to record event", "type", ev.EventType(), "err", err)
+		}
+	})
+}
+
+// dispatchOp is the gateway opcode of events.
+const dispatchOp ws.OpCode = 0
+
+// ReplayOptions tune Replay.
+type ReplayOptions struct {
+	// Speed scales the recorded time between events: 1 keeps it and 10
+	// replays ten times as fast. Zero replays without waiting, which skips
+	// whatever the bot does on timers, like grace periods.
+	Speed float64
+	// Requests, if set, gets a line for every request the bot makes.
+	Requests io.Writer
+}
+
+// Replay feeds the gateway events recorded with $RECORD_EVENTS through a
+// manager configured by cfg, as if they arrived again, with a fake REST API
+// in place of Discord's. Nothing reaches Discord, and the bot's own commands
+// aren't registered. It returns once every event was handled.
+func Replay(ctx context.Context, cfg Config, events io.Reader, opts ReplayOptions) error {
+	cfg.SkipCommands = true
+	m, err := newManager(cfg, true)
+	if err != nil {
+		return err
+	}
+	s := state.New("Bot replay")
+	fake := newFakeAPI(s, opts.Requests)
+	s.Client.Client.Client = httpdriver.WrapClient(http.Client{Transport: fake})
+	m.Attach(s)
+
+	// Channels were created with the IDs the recording has them under.
+	var recorded []recordedEvent
+	scanner := bufio.NewScanner(events)
+	scanner.Buffer(nil, 16<<20)
+	for n := 1; scanner.Scan(); n++ {
+		var rec recordedEvent
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+		if rec.Type == "CHANNEL_CREATE" {
+			var ch discord.Channel
+			if err := json.Unmarshal(rec.Data, &ch); err != nil {
+				return fmt.Errorf("line %d: %w", n, err)
+			}
+			fake.expect(ch.GuildID, ch.ID)
+		}
+		recorded = append(recorded, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go m.Run(runCtx)
+
+	h := m.h
+	for i, rec := range recorded {
+		if i > 0 && opts.Speed > 0 {
+			gap := time.Duration(float64(rec.At.Sub(recorded[i-1].At)) / opts.Speed)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(gap):
+			}
+		}
+		newEvent := gateway.OpUnmarshalers.Lookup(dispatchOp, rec.Type)
+		if newEvent == nil {
+			slog.Warn("Skipping unknown event", "type", rec.Type)
+			continue
+		}
+		ev := newEvent()
+		if err := json.Unmarshal(rec.Data, ev); err != nil {
+			return fmt.Errorf("event %d (%s): %w", i+1, rec.Type, err)
+		}
+		s.Session.Handler.Call(ev)
+	}
+	h.queues.drain()
+	return nil
+}
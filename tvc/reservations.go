@@ -0,0 +1,214 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+// reservationTimeLayout is the absolute form /voice reserve takes, in UTC.
+const reservationTimeLayout = "2006-01-02 15:04"
+
+// reservation is a room reserved by name for a time. Until then, no other
+// room in the guild gets the name, and at that time the room is created for
+// the member who reserved it.
+type reservation struct {
+	GuildID discord.GuildID `json:"guild_id"`
+	Hub     string          `json:"hub"` // the hub's key
+	Name    string          `json:"name"`
+	At      time.Time       `json:"at"`
+	OwnerID discord.UserID  `json:"owner_id"`
+}
+
+// parseReservationTime reads when a reservation is for: a delay like 90m,
+// or a UTC time like 2024-05-01 18:30.
+func parseReservationTime(v string, now time.Time) (time.Time, bool) {
+	if d, err := time.ParseDuration(v); err == nil {
+		return now.Add(d), d > 0
+	}
+	at, err := time.Parse(reservationTimeLayout, v)
+	return at, err == nil && at.After(now)
+}
+
+// cmdReserve handles /voice reserve, which reserves a room name for a time.
+func (h *handler) cmdReserve(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	name := strings.TrimSpace(data.Options.Find("name").String())
+	if name == "" || len(name) > 100 {
+		return ephemeralData("Pick a name of up to 100 characters.")
+	}
+	if h.bannedWordIn(name) != "" {
+		return ephemeralData("That name isn't allowed here.")
+	}
+	now := time.Now()
+	v := strings.TrimSpace(data.Options.Find("time").String())
+	at, ok := parseReservationTime(v, now)
+	if !ok {
+		return ephemeralData(fmt.Sprintf("%q isn't a time in the future; try a delay like 90m or a UTC time like %s.", v, now.UTC().Add(time.Hour).Format(reservationTimeLayout)))
+	}
+	if at.Sub(now) > h.cfg.reservationMaxAhead {
+		return ephemeralData(fmt.Sprintf("Rooms can be reserved up to %s ahead.", formatDuration(h.cfg.reservationMaxAhead)))
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	guildID := data.Event.GuildID
+	userID := data.Event.SenderID()
+	hub := h.reservationHub(guildID, userID)
+	if hub == nil {
+		return ephemeralData("There's no hub to create the room from in this server.")
+	}
+	if other := h.stats.reservationFor(guildID, name); other != nil {
+		if other.OwnerID == userID {
+			return ephemeralData(fmt.Sprintf("You already reserved **%s** for <t:%d:f>; use /voice unreserve first to move it.", other.Name, other.At.Unix()))
+		}
+		return ephemeralData(fmt.Sprintf("**%s** is already reserved.", other.Name))
+	}
+	if len(h.stats.reservationsOf(guildID, userID)) >= h.cfg.maxReservations {
+		return ephemeralData(fmt.Sprintf("You can hold %s at a time.", plural(h.cfg.maxReservations, "reservation")))
+	}
+
+	h.stats.reserve(reservation{GuildID: guildID, Hub: hub.key, Name: name, At: at, OwnerID: userID})
+	slog.InfoContext(withGuild(ctx, guildID), "Room reserved", "name", name, "user_id", userID, "at", at, "hub", hub.label())
+	return ephemeralData(fmt.Sprintf("Reserved **%s** for <t:%d:f>. I'll create it then, with you as its owner.", name, at.Unix()))
+}
+
+// cmdUnreserve handles /voice unreserve, which cancels one of the caller's
+// reservations.
+func (h *handler) cmdUnreserve(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	name := strings.TrimSpace(data.Options.Find("name").String())
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	guildID := data.Event.GuildID
+	userID := data.Event.SenderID()
+	if !h.stats.cancelReservation(guildID, userID, name) {
+		var names []string
+		for _, res := range h.stats.reservationsOf(guildID, userID) {
+			names = append(names, fmt.Sprintf("**%s** (<t:%d:R>)", res.Name, res.At.Unix()))
+		}
+		if len(names) == 0 {
+			return ephemeralData("You have no reservations.")
+		}
+		return ephemeralData("You have no reservation by that name. Yours are " + strings.Join(names, ", ") + ".")
+	}
+	slog.InfoContext(withGuild(ctx, guildID), "Room reservation cancelled", "name", name, "user_id", userID)
+	return ephemeralData(fmt.Sprintf("Cancelled your reservation of **%s**.", name))
+}
+
+// reservationHub returns the hub a reserved room is created from: the one of
+// the member's room if they own one, else the first hub of the guild.
+func (h *handler) reservationHub(guildID discord.GuildID, userID discord.UserID) *hub {
+	if r := h.ownedRoomOf(userID); r != nil && r.guildID == guildID && r.hub != nil {
+		return r.hub
+	}
+	keys := make([]string, 0, len(h.hubs))
+	for key := range h.hubs {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		if hub := h.hubs[key]; hub.serves(guildID) && h.hubChannelOf(guildID, hub) != nil {
+			return hub
+		}
+	}
+	return nil
+}
+
+// avoidReservedName returns name, numbered if someone other than the room's
+// owner reserved it.
+func (h *handler) avoidReservedName(req roomRequest, name string) string {
+	if res := h.stats.reservationFor(req.hubChannel.GuildID, name); res != nil && res.OwnerID != req.userID {
+		return fmt.Sprintf("%s #%d", name, req.number)
+	}
+	return name
+}
+
+// runReservations creates reserved rooms when they are due, until ctx is
+// done.
+func (h *handler) runReservations(ctx context.Context) {
+	ticker := time.NewTicker(profileScheduleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, res := range h.stats.dueReservations(time.Now()) {
+				h.queues.dispatch(res.GuildID, func() { h.createReservedRoom(res) })
+			}
+		}
+	}
+}
+
+// createReservedRoom creates the room of a reservation that is due and pings
+// the owner in it. Like rooms created over HTTP for members who aren't
+// connected, it is deleted if nobody joins in time.
+func (h *handler) createReservedRoom(res reservation) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ctx := withGuild(context.Background(), res.GuildID)
+	hub := h.hubs[res.Hub]
+	var hubChannel *discord.Channel
+	if hub != nil {
+		hubChannel = h.hubChannelOf(res.GuildID, hub)
+	}
+	if hubChannel == nil {
+		slog.WarnContext(ctx, "Hub of reserved room is gone", "name", res.Name, "hub", res.Hub)
+		return
+	}
+
+	var member *discord.Member
+	err := h.call(ctx, "Member", func(s *state.State) (err error) {
+		member, err = s.Member(res.GuildID, res.OwnerID)
+		return err
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "Owner of reserved room is gone", "name", res.Name, "user_id", res.OwnerID, "err", err)
+		return
+	}
+	vs := discord.VoiceState{GuildID: res.GuildID, UserID: res.OwnerID, Member: member}
+	if !h.mayCreateRoom(ctx, hub, res.GuildID, &gateway.VoiceStateUpdateEvent{VoiceState: vs}, 0) {
+		slog.InfoContext(ctx, "Reserved room not allowed now", "name", res.Name, "user_id", res.OwnerID)
+		return
+	}
+
+	err = h.createRoom(ctx, roomRequest{
+		hub:        hub,
+		hubChannel: hubChannel,
+		userID:     res.OwnerID,
+		username:   member.User.Username,
+		name:       res.Name,
+		stayPut:    true,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create reserved room", "name", res.Name, "err", err)
+		return
+	}
+	r := h.newestRoomOf(res.OwnerID)
+	if r == nil {
+		return
+	}
+	err = h.call(ctx, "SendMessage", func(s *state.State) error {
+		_, err := s.SendMessageComplex(r.chatChannel(), api.SendMessageData{
+			Content:         fmt.Sprintf("%s, your reserved room %s is ready.", res.OwnerID.Mention(), r.channelID.Mention()),
+			AllowedMentions: &api.AllowedMentions{Users: []discord.UserID{res.OwnerID}},
+		})
+		return err
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to ping owner of reserved room", "err", err)
+	}
+}
@@ -0,0 +1,193 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+)
+
+// trackedRoom is what is persisted about a live room to pick it up again
+// after a restart.
+type trackedRoom struct {
+	GuildID     discord.GuildID   `json:"guild_id"`
+	ChannelID   discord.ChannelID `json:"channel_id"`
+	OwnerID     discord.UserID    `json:"owner_id"`
+	Hub         string            `json:"hub"` // the hub's key
+	Name        string            `json:"name"`
+	Number      int               `json:"number,omitempty"`
+	Category    discord.ChannelID `json:"category,omitempty"`
+	TextChannel discord.ChannelID `json:"text_channel,omitempty"`
+	ForumPost   bool              `json:"forum_post,omitempty"`
+	AFKChannel  discord.ChannelID `json:"afk_channel,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	Booster     bool              `json:"booster,omitempty"`
+	// DeleteAt is when the room is due to be deleted while empty, carried
+	// over so a restart or a move to another host doesn't restart its grace
+	// period. EmptySince is set if the room emptied out, rather than never
+	// being joined.
+	DeleteAt       time.Time         `json:"delete_at"`
+	EmptySince     time.Time         `json:"empty_since"`
+	WarningMessage discord.MessageID `json:"warning_message,omitempty"`
+}
+
+func trackedRoomOf(r *room) trackedRoom {
+	tr := trackedRoom{
+		GuildID:     r.guildID,
+		ChannelID:   r.channelID,
+		OwnerID:     r.owner,
+		Hub:         r.hub.key,
+		Name:        r.name,
+		Number:      r.number,
+		Category:    r.category,
+		TextChannel: r.textChannel,
+		ForumPost:   r.forumPost,
+		AFKChannel:  r.afkChannel,
+		CreatedAt:   r.createdAt,
+		Booster:     r.booster,
+	}
+	if r.deleteTimer != nil {
+		tr.DeleteAt = r.deleteAt
+		tr.WarningMessage = r.warningMessage
+		if r.emptied {
+			tr.EmptySince = r.emptySince
+		}
+	}
+	return tr
+}
+
+// ownChannels lists the channels created along with the room's voice
+// channel. A forum post isn't one; it is closed rather than deleted.
+func (tr trackedRoom) ownChannels() []discord.ChannelID {
+	var ids []discord.ChannelID
+	for _, id := range []discord.ChannelID{tr.Category, tr.TextChannel, tr.AFKChannel} {
+		if id.IsValid() && (id != tr.TextChannel || !tr.ForumPost) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// restoreRooms picks up the rooms the guild had before a restart. Rooms
+// still in use are managed again as if the bot had never left; empty ones
+// are deleted, and rooms whose voice channel is gone are forgotten after
+// their leftovers are cleaned up, unless safe mode holds the cleanup. h.mu
+// must be held.
+func (h *handler) restoreRooms(evt *gateway.GuildCreateEvent) {
+	ctx := withGuild(context.Background(), evt.ID)
+	channels := make(map[discord.ChannelID]*discord.Channel, len(evt.Channels))
+	for i := range evt.Channels {
+		channels[evt.Channels[i].ID] = &evt.Channels[i]
+	}
+	var cleanups []func(ctx context.Context)
+	deletions := 0
+
+	for _, tr := range h.stats.trackedIn(evt.ID) {
+		if _, ok := h.rooms[tr.ChannelID]; ok {
+			continue
+		}
+		voice, ok := channels[tr.ChannelID]
+		if !ok {
+			var leftovers []discord.ChannelID
+			for _, id := range tr.ownChannels() {
+				if _, exists := channels[id]; exists {
+					h.created[id] = true
+					leftovers = append(leftovers, id)
+				}
+			}
+			deletions += len(leftovers)
+			cleanups = append(cleanups, func(ctx context.Context) {
+				for _, id := range leftovers {
+					if err := h.deleteChannel(ctx, id, "cleaning up after restart"); err != nil {
+						slog.ErrorContext(ctx, "Failed to delete leftover channel", "err", err)
+					}
+				}
+				if tr.ForumPost {
+					h.closeForumPost(ctx, tr.TextChannel)
+				}
+				if tr.Category.IsValid() {
+					h.teardownExternal(tr.GuildID, tr.Category)
+				}
+				h.stats.untrack(tr.ChannelID)
+			})
+			continue
+		}
+
+		hub := h.hubs[tr.Hub]
+		if hub == nil {
+			kind := voiceRoom
+			if tr.Category.IsValid() {
+				kind = teamRoom
+			}
+			if hub = h.hubOfKind(evt.ID, kind); hub == nil {
+				slog.Info("Forgetting room of removed hub", "guild_id", tr.GuildID, "channel_id", tr.ChannelID, "hub", tr.Hub)
+				h.stats.untrack(tr.ChannelID)
+				continue
+			}
+		}
+
+		h.created[tr.ChannelID] = true
+		for _, id := range tr.ownChannels() {
+			h.created[id] = true
+		}
+		if tr.Category.IsValid() {
+			h.temporaryCategories = append(h.temporaryCategories, tr.ChannelID)
+		} else {
+			h.temporaryChannels = append(h.temporaryChannels, tr.ChannelID)
+		}
+
+		r := &room{
+			hub:          hub,
+			guildID:      tr.GuildID,
+			owner:        tr.OwnerID,
+			number:       tr.Number,
+			name:         tr.Name,
+			category:     tr.Category,
+			textChannel:  tr.TextChannel,
+			forumPost:    tr.ForumPost,
+			afkChannel:   tr.AFKChannel,
+			createdAt:    tr.CreatedAt,
+			booster:      tr.Booster,
+			participants: make(map[discord.UserID]bool),
+		}
+
+		// seedVoiceStates has already taken the occupants from the guild's
+		// snapshot.
+		for _, vs := range evt.VoiceStates {
+			if vs.ChannelID == tr.ChannelID || (tr.AFKChannel.IsValid() && vs.ChannelID == tr.AFKChannel) {
+				r.participants[vs.UserID] = true
+			}
+		}
+		h.registerRoom(tr.ChannelID, r)
+		r.peak = h.roomOccupants(r)
+
+		if r.peak == 0 && tr.DeleteAt.After(time.Now()) {
+			// Let the room wait out the rest of its grace period.
+			r.emptySince, r.emptied = tr.EmptySince, !tr.EmptySince.IsZero()
+			r.warningMessage = tr.WarningMessage
+			h.scheduleRoomDeletion(tr.ChannelID, r, tr.DeleteAt)
+			slog.Info("Restored empty room", "guild_id", tr.GuildID, "channel_id", tr.ChannelID, "delete_at", tr.DeleteAt)
+			continue
+		}
+		if r.peak == 0 && !(r.booster && h.cfg.boosterPersistent) && !h.isFrozen(tr.ChannelID) {
+			deletions += 1 + len(tr.ownChannels())
+			cleanups = append(cleanups, func(ctx context.Context) {
+				if h.rooms[voice.ID] == r && h.roomOccupants(r) == 0 {
+					h.deleteRoom(ctx, voice)
+				}
+			})
+			continue
+		}
+		slog.Info("Restored room", "guild_id", tr.GuildID, "channel_id", tr.ChannelID, "members", r.peak)
+	}
+
+	// Empty rooms held by safe mode stay registered, so the janitor brings
+	// them up again if they are kept.
+	h.cleanUp(ctx, evt.ID, "Cleaning up after the restart", deletions, func(ctx context.Context) {
+		for _, cleanup := range cleanups {
+			cleanup(ctx)
+		}
+	}, nil)
+}
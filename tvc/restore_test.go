@@ -0,0 +1,108 @@
+package tvc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+)
+
+// Channels of the rooms restoreTestGuild sets up.
+const (
+	busyRoomID     discord.ChannelID = 300 // member 5 is in it
+	emptyRoomID    discord.ChannelID = 301 // empty and past its deletion
+	waitingRoomID  discord.ChannelID = 302 // empty, still in its grace period
+	goneRoomID     discord.ChannelID = 303 // deleted while the bot was away
+	goneRoomTextID discord.ChannelID = 304 // goneRoomID's text channel, left over
+)
+
+// restoreTestGuild tracks four rooms in the bot's stats and returns the
+// GUILD_CREATE the bot gets for them after a restart.
+func restoreTestGuild(b *testBot) *gateway.GuildCreateEvent {
+	created := time.Now().Add(-time.Hour)
+	for i, tr := range []trackedRoom{
+		{ChannelID: busyRoomID},
+		{ChannelID: emptyRoomID, DeleteAt: time.Now().Add(-time.Minute)},
+		{ChannelID: waitingRoomID, DeleteAt: time.Now().Add(time.Hour), EmptySince: created},
+		{ChannelID: goneRoomID, TextChannel: goneRoomTextID},
+	} {
+		tr.GuildID, tr.OwnerID, tr.Hub, tr.CreatedAt = testGuildID, discord.UserID(10+i), "BARK", created
+		b.h.stats.track(tr)
+	}
+
+	var channels []discord.Channel
+	for _, id := range []discord.ChannelID{busyRoomID, emptyRoomID, waitingRoomID} {
+		channels = append(channels, discord.Channel{ID: id, GuildID: testGuildID, Type: discord.GuildVoice, Name: "room"})
+	}
+	channels = append(channels, discord.Channel{ID: goneRoomTextID, GuildID: testGuildID, Type: discord.GuildText, Name: "room"})
+	return testGuild(channels, []discord.VoiceState{{ChannelID: busyRoomID, UserID: 5}})
+}
+
+func TestRestoreRooms(t *testing.T) {
+	b := newTestBot(t)
+	b.send(restoreTestGuild(b))
+
+	h := b.h
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	busy := h.rooms[busyRoomID]
+	if busy == nil || busy.owner != 10 || busy.hub != h.hubs["BARK"] || !busy.participants[5] {
+		t.Errorf("busy room restored as %+v, want it managed with member 5 in it", busy)
+	}
+	if r := h.rooms[waitingRoomID]; r == nil || r.deleteTimer == nil {
+		t.Error("room in its grace period isn't waiting to be deleted")
+	}
+	for _, id := range []discord.ChannelID{emptyRoomID, goneRoomID} {
+		if _, ok := h.rooms[id]; ok {
+			t.Errorf("room %d is still managed", id)
+		}
+	}
+	for _, id := range []discord.ChannelID{emptyRoomID, goneRoomTextID} {
+		if b.channelExists(id) {
+			t.Errorf("channel %d wasn't deleted", id)
+		}
+	}
+	if !b.channelExists(busyRoomID) || !b.channelExists(waitingRoomID) {
+		t.Error("a room still in use was deleted")
+	}
+
+	tracked := make(map[discord.ChannelID]bool)
+	for _, tr := range h.stats.trackedIn(testGuildID) {
+		tracked[tr.ChannelID] = true
+	}
+	if tracked[goneRoomID] || !tracked[busyRoomID] || !tracked[waitingRoomID] {
+		t.Errorf("tracked rooms are %v, want the busy and the waiting one", tracked)
+	}
+}
+
+func TestRestoreRoomsHeldBySafeMode(t *testing.T) {
+	b := newTestBot(t, safeMode(1)...)
+	b.send(restoreTestGuild(b))
+
+	h := b.h
+	h.mu.Lock()
+	c := h.heldCleanups[testGuildID]
+	if c == nil || c.channels != 2 {
+		h.mu.Unlock()
+		t.Fatalf("held cleanup is %+v, want one of the empty room and the leftover text channel", c)
+	}
+	if _, ok := h.rooms[emptyRoomID]; !ok {
+		t.Error("empty room held by safe mode isn't managed")
+	}
+	h.mu.Unlock()
+	if !b.channelExists(emptyRoomID) || !b.channelExists(goneRoomTextID) {
+		t.Fatal("channels were deleted while the cleanup is held")
+	}
+
+	b.h.onCleanupConfirm(context.Background(), pressed(testOwnerID, c.message))
+	deadline := time.Now().Add(5 * time.Second)
+	for b.channelExists(emptyRoomID) || b.channelExists(goneRoomTextID) {
+		if time.Now().After(deadline) {
+			t.Fatal("confirmed cleanup didn't delete the channels")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,294 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+// room is a temporary voice channel created by the bot.
+type room struct {
+	hub       *hub
+	guildID   discord.GuildID
+	channelID discord.ChannelID
+	owner     discord.UserID
+	// number is the room's {number}.
+	number int
+	// name is the room's name as last checked for banned words. It is the
+	// category's name in team mode.
+	name string
+	// autoModFlagged is what AutoMod last caught in the room's name.
+	autoModFlagged string
+	// category is the room's own category in team mode.
+	category discord.ChannelID
+	// textChannel is the paired text channel in team mode. Plain rooms use the
+	// voice channel's built-in text chat instead.
+	textChannel discord.ChannelID
+	// forumPost is set if textChannel is a post in the team forum rather
+	// than a channel in the category.
+	forumPost bool
+	// afkChannel is where idle members of a team room are moved, if enabled.
+	afkChannel discord.ChannelID
+	afkTimers  map[discord.UserID]*time.Timer
+	// afkAway holds members Discord moved to the guild's AFK channel who
+	// still count as in the room until their timer fires.
+	afkAway   map[discord.UserID]*time.Timer
+	createdAt time.Time
+	// lastActive is the last voice activity seen in the room.
+	lastActive time.Time
+	greeted    bool
+	// participants is everyone who has been in the room; joined is when
+	// those in it now joined.
+	participants map[discord.UserID]bool
+	joined       map[discord.UserID]time.Time
+	// recentlyLeft is when participants last left, for failover.
+	recentlyLeft map[discord.UserID]time.Time
+	// scaledLimit is the user limit autoscale last set, or 0 if the room
+	// doesn't scale.
+	scaledLimit uint
+	// tunedBitrate is the bitrate tuneBitrate last saw or set.
+	tunedBitrate uint
+	// peak is the most members the room held at once.
+	peak int
+	// partyLimit is the user limit last set from the owner's party size.
+	partyLimit uint
+	partyTimer *time.Timer
+	// overlayToken grants access to the room's overlay stream; overlays are
+	// the connected streams.
+	overlayToken string
+	overlays     map[chan overlayEvent]struct{}
+	// lobbyCode links the room to an external game lobby.
+	lobbyCode string
+	// featured rooms are listed first on the rooms board.
+	featured bool
+	// dnd hides the room from the rooms board without locking it.
+	dnd bool
+	// booster is set while the owner boosts the guild, for booster perks.
+	booster bool
+	// password, if set, must be entered to join. admitted holds who did.
+	password string
+	admitted map[discord.UserID]bool
+
+	// infoMessage is the pinned room info embed, if any.
+	infoMessage discord.MessageID
+	infoTimer   *time.Timer
+
+	// status is the activity summary shown under the voice channel.
+	status          string
+	statusUpdatedAt time.Time
+	statusTimer     *time.Timer
+
+	// deleteTimer is set while an empty room waits out its grace period.
+	deleteTimer *wheelTimer
+	deleteAt    time.Time
+	emptySince  time.Time
+	// emptied is set while the grace period started by the last member
+	// leaving runs, unlike that of a room nobody has joined yet.
+	emptied bool
+	// sweptEmpty is when the janitor first found the room empty.
+	sweptEmpty     time.Time
+	warningMessage discord.MessageID
+
+	// reclaimTimer is set while others use the room without its owner;
+	// claim is the vote on a new owner it opens.
+	reclaimTimer *time.Timer
+	claim        *claimVote
+
+	// kickVotes are the open vote kicks by message; kickBans lift the
+	// Connect denials of those voted out.
+	kickVotes map[discord.MessageID]*kickVote
+	kickBans  map[discord.UserID]*time.Timer
+	// settingsVotes are the open votes on renaming the room or changing
+	// its limit, by message.
+	settingsVotes map[discord.MessageID]*settingsVote
+
+	// attendance holds join/leave lines not posted yet.
+	attendance      []string
+	attendanceTimer *time.Timer
+
+	// expiring holds the deletion timers of transient messages in the
+	// paired text channel.
+	expiring map[discord.MessageID]*time.Timer
+}
+
+// chatChannel returns the channel messages about the room should be posted in.
+func (r *room) chatChannel() discord.ChannelID {
+	if r.textChannel.IsValid() {
+		return r.textChannel
+	}
+	return r.channelID
+}
+
+// addRoom registers a room the bot just created.
+func (h *handler) addRoom(ctx context.Context, channelID discord.ChannelID, r *room) {
+	r.createdAt = time.Now()
+	r.participants = map[discord.UserID]bool{r.owner: true}
+	r.peak = 1
+	h.noteReturn(r.guildID, r.owner)
+	h.registerRoom(channelID, r)
+	h.metrics.roomCreated(r.guildID)
+	slog.Info("Room created", "guild_id", r.guildID, "channel_id", channelID, "user_id", r.owner, "hub", r.hub.label())
+	h.stats.recordCreation(creationRecord{
+		GuildID:      r.guildID,
+		ChannelID:    channelID,
+		OwnerID:      r.owner,
+		Hub:          r.hub.label(),
+		Name:         r.name,
+		CreatedAt:    r.createdAt,
+		Participants: []discord.UserID{r.owner},
+	})
+	h.postRoomInfo(ctx, channelID, r)
+	h.postInviteMenu(ctx, r)
+	h.checkRoomName(ctx, channelID, r)
+	h.refreshBoard(r.guildID)
+	h.runHook(r, hookCreate, 0)
+	h.provisionExternal(r)
+}
+
+// dropRoom forgets a room whose channel was deleted.
+func (h *handler) dropRoom(channelID discord.ChannelID) {
+	if r, ok := h.rooms[channelID]; ok {
+		h.noteEmptiedRoom(r)
+		for _, t := range []*time.Timer{r.infoTimer, r.statusTimer, r.attendanceTimer, r.partyTimer, r.reclaimTimer} {
+			if t != nil {
+				t.Stop()
+			}
+		}
+		if r.deleteTimer != nil {
+			r.deleteTimer.Stop()
+		}
+		for _, t := range r.afkTimers {
+			t.Stop()
+		}
+		for _, t := range r.afkAway {
+			t.Stop()
+		}
+		for _, t := range r.expiring {
+			t.Stop()
+		}
+		for _, vote := range r.kickVotes {
+			vote.timer.Stop()
+		}
+		for _, t := range r.kickBans {
+			t.Stop()
+		}
+		for _, vote := range r.settingsVotes {
+			vote.timer.Stop()
+		}
+		h.closeOverlays(r)
+		h.forgetRenames(channelID)
+		h.forgetRenames(r.category)
+		h.dropScore(r)
+		h.stats.recordDeletion(channelID, time.Now(), r.peak)
+		h.metrics.roomDeleted(r.guildID)
+		slog.Info("Room deleted", "guild_id", r.guildID, "channel_id", channelID, "peak", r.peak)
+		h.stats.untrack(channelID)
+		h.stats.dropFrozen(channelID)
+		h.refreshBoard(r.guildID)
+		ctx := withGuild(context.Background(), r.guildID)
+		switch {
+		case r.forumPost:
+			h.closeForumPost(ctx, r.textChannel)
+		case r.textChannel.IsValid():
+			h.setAutoModExemption(ctx, r.guildID, r.textChannel, false)
+		}
+		h.sendSummary(ctx, r)
+		h.companions.publish(companionEvent{Type: "deleted", GuildID: r.guildID, ChannelID: channelID, At: time.Now()})
+		h.runHook(r, hookDelete, 0)
+		h.teardownExternal(r.guildID, r.category)
+	}
+	delete(h.rooms, channelID)
+}
+
+// registerRoom starts tracking a room, persisting it so it can be restored
+// after a restart.
+func (h *handler) registerRoom(channelID discord.ChannelID, r *room) {
+	r.channelID = channelID
+	if r.participants == nil {
+		r.participants = make(map[discord.UserID]bool)
+	}
+	r.joined = make(map[discord.UserID]time.Time)
+	r.afkTimers = make(map[discord.UserID]*time.Timer)
+	r.afkAway = make(map[discord.UserID]*time.Timer)
+	r.admitted = make(map[discord.UserID]bool)
+	r.recentlyLeft = make(map[discord.UserID]time.Time)
+	r.overlays = make(map[chan overlayEvent]struct{})
+	r.expiring = make(map[discord.MessageID]*time.Timer)
+	r.kickVotes = make(map[discord.MessageID]*kickVote)
+	r.kickBans = make(map[discord.UserID]*time.Timer)
+	r.settingsVotes = make(map[discord.MessageID]*settingsVote)
+	h.rooms[channelID] = r
+	h.stats.track(trackedRoomOf(r))
+}
+
+// roomByChat finds the room whose text chat is channelID.
+func (h *handler) roomByChat(channelID discord.ChannelID) (discord.ChannelID, *room) {
+	for id, r := range h.rooms {
+		if r.chatChannel() == channelID {
+			return id, r
+		}
+	}
+	return 0, nil
+}
+
+// ownedRoomOf returns the room userID is in if they own it.
+func (h *handler) ownedRoomOf(userID discord.UserID) *room {
+	r, ok := h.rooms[h.userVoiceStates[userID].ChannelID]
+	if !ok || r.owner != userID {
+		return nil
+	}
+	return r
+}
+
+// roomOccupants counts the members connected to the room, including its AFK
+// channel and those still held after an AFK timeout.
+func (h *handler) roomOccupants(r *room) int {
+	n := h.occupants(r.channelID) + len(r.afkAway)
+	if r.afkChannel.IsValid() {
+		n += h.occupants(r.afkChannel)
+	}
+	return n
+}
+
+// occupants counts the members currently connected to the given channel.
+func (h *handler) occupants(channelID discord.ChannelID) int {
+	return len(h.channelMembers[channelID])
+}
+
+// greet posts the hub's greeting once the room reaches the configured number
+// of members. The owner is mentioned without being pinged.
+func (h *handler) greet(ctx context.Context, channelID discord.ChannelID, r *room) {
+	if r.greeted || r.hub.silent || r.hub.greeting == "" || !h.features.enabled(r.guildID, featureGreetings) {
+		return
+	}
+
+	count := h.occupants(channelID)
+	if count < r.hub.greetAt {
+		return
+	}
+	r.greeted = true
+
+	content := expandTemplate(r.hub.greeting, templateData{
+		Host:    r.owner.Mention(),
+		Channel: channelID.Mention(),
+		Count:   count,
+	})
+
+	var msg *discord.Message
+	err := h.call(ctx, "SendMessage", func(s *state.State) (err error) {
+		msg, err = s.SendMessageComplex(r.chatChannel(), api.SendMessageData{
+			Content:         content,
+			AllowedMentions: &api.AllowedMentions{},
+		})
+		return err
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to send greeting", "err", err)
+		return
+	}
+	h.expireMessage(r, msg.ID)
+}
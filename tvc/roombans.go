@@ -0,0 +1,98 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+// cmdBan handles /voice ban, which keeps someone out of the owner's current
+// room and every room they create after it, until /voice unban.
+func (h *handler) cmdBan(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	target, err := data.Options.Find("user").SnowflakeValue()
+	if err != nil {
+		return ephemeralData("Pick who to ban.")
+	}
+	userID := discord.UserID(target)
+	ownerID := data.Event.SenderID()
+	if me, err := h.s.Me(); userID == ownerID || err == nil && userID == me.ID {
+		return ephemeralData("You can't ban them.")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	guildID := data.Event.GuildID
+	h.stats.banFromRooms(roomBan{GuildID: guildID, OwnerID: ownerID, UserID: userID})
+
+	r := h.ownedRoomOf(ownerID)
+	if r == nil {
+		return ephemeralData(userID.Mention() + " is banned from your future rooms.")
+	}
+	ctx = withGuild(ctx, guildID)
+	if err := h.denyRoom(ctx, r.channelID, userID); err != nil {
+		slog.ErrorContext(ctx, "Failed to ban member from room", "err", err)
+		return ephemeralData(userID.Mention() + " is banned from your future rooms, but I couldn't keep them out of this one.")
+	}
+	if h.userVoiceStates[userID].ChannelID == r.channelID {
+		h.queueMove(memberMove{
+			guildID:   guildID,
+			userID:    userID,
+			channelID: discord.NullChannelID,
+			reason:    "banned by the room owner",
+			failure:   "Failed to disconnect banned member",
+		})
+	}
+	return ephemeralData(userID.Mention() + " is banned from this and your future rooms.")
+}
+
+// cmdUnban handles /voice unban.
+func (h *handler) cmdUnban(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	target, err := data.Options.Find("user").SnowflakeValue()
+	if err != nil {
+		return ephemeralData("Pick who to unban.")
+	}
+	userID := discord.UserID(target)
+	ownerID := data.Event.SenderID()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.stats.unbanFromRooms(data.Event.GuildID, ownerID, userID) {
+		return ephemeralData(userID.Mention() + " isn't banned from your rooms.")
+	}
+	if r := h.ownedRoomOf(ownerID); r != nil {
+		err := h.call(withGuild(ctx, r.guildID), "DeleteChannelPermission", func(s *state.State) error {
+			return s.DeleteChannelPermission(r.channelID, discord.Snowflake(userID), "unbanned by the room owner")
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to unban member from room", "err", err)
+		}
+	}
+	return ephemeralData(userID.Mention() + " can join your rooms again.")
+}
+
+// applyRoomBans keeps the members the owner banned out of their new room, or
+// team category.
+func (h *handler) applyRoomBans(ctx context.Context, ch *discord.Channel, req roomRequest) {
+	for _, userID := range h.stats.roomBansOf(ch.GuildID, req.userID) {
+		if err := h.denyRoom(ctx, ch.ID, userID); err != nil {
+			slog.ErrorContext(ctx, "Failed to reapply room ban", "err", err)
+		}
+	}
+}
+
+// denyRoom denies userID connecting to channelID.
+func (h *handler) denyRoom(ctx context.Context, channelID discord.ChannelID, userID discord.UserID) error {
+	return h.call(ctx, "EditChannelPermission", func(s *state.State) error {
+		return s.EditChannelPermission(channelID, discord.Snowflake(userID), api.EditChannelPermissionData{
+			Type:           discord.OverwriteMember,
+			Deny:           discord.PermissionConnect,
+			AuditLogReason: "banned by the room owner",
+		})
+	})
+}
@@ -0,0 +1,215 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/diamondburned/arikawa/v3/utils/json/option"
+)
+
+// roomTarget is the channel carrying a room's name and permissions: its
+// category in team mode, its voice channel otherwise.
+func (r *room) roomTarget() discord.ChannelID {
+	if r.category.IsValid() {
+		return r.category
+	}
+	return r.channelID
+}
+
+// cmdRoomRename handles /room rename.
+func (h *handler) cmdRoomRename(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	name := data.Options.Find("name").String()
+	if h.bannedWordIn(name) != "" {
+		return ephemeralData("That name isn't allowed here.")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	r := h.ownedRoomOf(data.Event.SenderID())
+	if r == nil {
+		return ephemeralData("You need to be in a room you own to do that.")
+	}
+	if h.renameChannel(withGuild(ctx, r.guildID), r.roomTarget(), h.decorateName(r.guildID, name)) {
+		return ephemeralData(fmt.Sprintf(
+			"Your room was renamed recently; it will be renamed within %s because of Discord's rename limit.",
+			formatDuration(renameWindow)))
+	}
+	return ephemeralData("Renamed your room.")
+}
+
+// cmdRoomLimit handles /room limit. Setting a limit stops the room's limit
+// from scaling with its members.
+func (h *handler) cmdRoomLimit(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	users, err := data.Options.Find("users").IntValue()
+	if err != nil || users < 0 || users > 99 {
+		return ephemeralData("Pick a limit from 0 to 99.")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	r := h.ownedRoomOf(data.Event.SenderID())
+	if r == nil {
+		return ephemeralData("You need to be in a room you own to do that.")
+	}
+	if err := h.setRoomLimit(withGuild(ctx, r.guildID), r, uint(users), "limit set by the room owner"); err != nil {
+		slog.ErrorContext(ctx, "Failed to set room limit", "err", err)
+		return ephemeralData("I couldn't change the limit, try again.")
+	}
+	if users == 0 {
+		return ephemeralData("Your room has no user limit now.")
+	}
+	return ephemeralData(fmt.Sprintf("Your room is limited to %s now.", plural(int(users), "member")))
+}
+
+// setRoomLimit sets the room's user limit, 0 meaning none, and stops it from
+// scaling with its members.
+func (h *handler) setRoomLimit(ctx context.Context, r *room, limit uint, reason api.AuditLogReason) error {
+	err := h.call(ctx, "ModifyChannel", func(s *state.State) error {
+		return s.ModifyChannel(r.channelID, api.ModifyChannelData{
+			VoiceUserLimit: option.NewNullableUint(limit),
+			AuditLogReason: reason,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	r.scaledLimit = 0
+	return nil
+}
+
+// cmdRoomLock handles /room lock, which keeps everyone but the owner and
+// those with their own overwrite from connecting.
+func (h *handler) cmdRoomLock(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	r := h.ownedRoomOf(data.Event.SenderID())
+	if r == nil {
+		return ephemeralData("You need to be in a room you own to do that.")
+	}
+	ctx = withGuild(ctx, r.guildID)
+	ch, err := h.s.Channel(r.roomTarget())
+	if err == nil {
+		err = h.call(ctx, "EditChannelPermission", func(s *state.State) error {
+			return s.EditChannelPermission(ch.ID, discord.Snowflake(r.owner), api.EditChannelPermissionData{
+				Type:           discord.OverwriteMember,
+				Allow:          discord.PermissionViewChannel | discord.PermissionConnect,
+				AuditLogReason: "room owner",
+			})
+		})
+	}
+	if err == nil {
+		err = h.denyEveryone(ctx, ch, discord.PermissionConnect, "locked by the room owner")
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to lock room", "err", err)
+		return ephemeralData("I couldn't lock your room, try again.")
+	}
+	return ephemeralData("Your room is locked. People already in it can stay.")
+}
+
+// cmdRoomUnlock handles /room unlock.
+func (h *handler) cmdRoomUnlock(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	r := h.ownedRoomOf(data.Event.SenderID())
+	if r == nil {
+		return ephemeralData("You need to be in a room you own to do that.")
+	}
+	if h.isFrozen(r.channelID) {
+		return ephemeralData("A moderator froze your room, so it stays locked until they unfreeze it.")
+	}
+	ch, err := h.s.Channel(r.roomTarget())
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get room to unlock", "err", err)
+		return ephemeralData("I couldn't unlock your room, try again.")
+	}
+	everyone := discord.Snowflake(ch.GuildID)
+	o := discord.Overwrite{ID: everyone, Type: discord.OverwriteRole}
+	for _, existing := range ch.Overwrites {
+		if existing.ID == everyone {
+			o = existing
+		}
+	}
+	if !o.Deny.Has(discord.PermissionConnect) {
+		return ephemeralData("Your room isn't locked.")
+	}
+	err = h.call(withGuild(ctx, r.guildID), "EditChannelPermission", func(s *state.State) error {
+		return s.EditChannelPermission(ch.ID, everyone, api.EditChannelPermissionData{
+			Type:           discord.OverwriteRole,
+			Allow:          o.Allow,
+			Deny:           o.Deny &^ discord.PermissionConnect,
+			AuditLogReason: "unlocked by the room owner",
+		})
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to unlock room", "err", err)
+		return ephemeralData("I couldn't unlock your room, try again.")
+	}
+	return ephemeralData("Your room is open to everyone again.")
+}
+
+// cmdRoomKick handles /room kick, which disconnects someone from the owner's
+// room. Unlike /voice ban they may come back.
+func (h *handler) cmdRoomKick(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	target, err := data.Options.Find("user").SnowflakeValue()
+	if err != nil {
+		return ephemeralData("Pick who to kick.")
+	}
+	userID := discord.UserID(target)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	r := h.ownedRoomOf(data.Event.SenderID())
+	if r == nil {
+		return ephemeralData("You need to be in a room you own to do that.")
+	}
+	if userID == r.owner || h.userVoiceStates[userID].ChannelID != r.channelID {
+		return ephemeralData(userID.Mention() + " isn't in your room.")
+	}
+	h.queueMove(memberMove{
+		guildID:   r.guildID,
+		userID:    userID,
+		channelID: discord.NullChannelID,
+		reason:    "kicked by the room owner",
+		failure:   "Failed to kick member",
+	})
+	return ephemeralData("Kicked " + userID.Mention() + ".")
+}
+
+// cmdRoomTransfer handles /room transfer, which hands the room to someone in
+// it.
+func (h *handler) cmdRoomTransfer(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	target, err := data.Options.Find("user").SnowflakeValue()
+	if err != nil {
+		return ephemeralData("Pick who to hand your room to.")
+	}
+	userID := discord.UserID(target)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	r := h.ownedRoomOf(data.Event.SenderID())
+	if r == nil {
+		return ephemeralData("You need to be in a room you own to do that.")
+	}
+	if userID == r.owner || h.userVoiceStates[userID].ChannelID != r.channelID {
+		return ephemeralData(userID.Mention() + " needs to be in your room.")
+	}
+	if m, err := h.s.Member(r.guildID, userID); err == nil && m.User.Bot {
+		return ephemeralData("Bots can't own rooms.")
+	}
+	ctx = withGuild(ctx, r.guildID)
+	h.closeClaimVote(ctx, r, "The room was handed over, so this vote is over.")
+	h.transferOwnership(ctx, r.channelID, r, userID)
+	return ephemeralData(userID.Mention() + " owns your room now.")
+}
@@ -0,0 +1,136 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+// postRoomInfo sends and pins the info embed of a freshly created room.
+func (h *handler) postRoomInfo(ctx context.Context, channelID discord.ChannelID, r *room) {
+	if !h.cfg.roomInfoEnabled || r.hub.silent || !h.features.enabled(r.guildID, featureRoomInfo) {
+		return
+	}
+
+	embed, err := h.roomInfoEmbed(ctx, channelID, r)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to build room info", "err", err)
+		return
+	}
+
+	chat := r.chatChannel()
+	err = h.call(ctx, "SendMessage", func(s *state.State) error {
+		msg, err := s.SendEmbeds(chat, embed)
+		if err != nil {
+			return err
+		}
+		r.infoMessage = msg.ID
+		return s.PinMessage(chat, msg.ID, "room info")
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to post room info", "err", err)
+	}
+}
+
+// refreshRoomInfo schedules an edit of the room's info embed. Calls within
+// roomInfoDebounce of each other are coalesced.
+func (h *handler) refreshRoomInfo(channelID discord.ChannelID, r *room) {
+	if !r.infoMessage.IsValid() || r.infoTimer != nil {
+		return
+	}
+
+	r.infoTimer = time.AfterFunc(h.cfg.roomInfoDebounce, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		r.infoTimer = nil
+		if h.rooms[channelID] != r {
+			return
+		}
+
+		ctx := withGuild(context.Background(), r.guildID)
+		embed, err := h.roomInfoEmbed(ctx, channelID, r)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to build room info", "err", err)
+			return
+		}
+		err = h.call(ctx, "EditMessage", func(s *state.State) error {
+			_, err := s.EditEmbeds(r.chatChannel(), r.infoMessage, embed)
+			return err
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to update room info", "err", err)
+		}
+	})
+}
+
+// onChannelUpdate refreshes the info embed when a room's settings change.
+func (h *handler) onChannelUpdate(evt *gateway.ChannelUpdateEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if r, ok := h.rooms[evt.ID]; ok {
+		h.refreshRoomInfo(evt.ID, r)
+		if r.scaledLimit != 0 && evt.VoiceUserLimit != r.scaledLimit {
+			// Someone else set the limit; stop overriding it.
+			r.scaledLimit = 0
+		}
+	}
+	h.onRoomRenamed(&evt.Channel)
+}
+
+// roomInfoEmbed describes the room's current settings. Lock state and invites
+// are read from the channel's permission overwrites so changes made by
+// moderators in the Discord client show up too.
+func (h *handler) roomInfoEmbed(ctx context.Context, channelID discord.ChannelID, r *room) (discord.Embed, error) {
+	ch, err := h.fetchChannel(ctx, channelID)
+	if err != nil {
+		return discord.Embed{}, err
+	}
+
+	limit := "none"
+	if ch.VoiceUserLimit > 0 {
+		limit = fmt.Sprint(ch.VoiceUserLimit)
+	}
+
+	locked := "no"
+	var invited []string
+	for _, o := range ch.Overwrites {
+		switch {
+		case o.Type == discord.OverwriteRole && discord.GuildID(o.ID) == ch.GuildID:
+			if o.Deny.Has(discord.PermissionConnect) {
+				locked = "yes"
+			}
+		case o.Type == discord.OverwriteMember && o.Allow.Has(discord.PermissionConnect):
+			if discord.UserID(o.ID) != r.owner {
+				invited = append(invited, discord.UserID(o.ID).Mention())
+			}
+		}
+	}
+	if len(invited) == 0 {
+		invited = []string{"nobody"}
+	}
+
+	embed := discord.Embed{
+		Title: ch.Name,
+		Fields: []discord.EmbedField{
+			{Name: "Owner", Value: r.owner.Mention(), Inline: true},
+			{Name: "User limit", Value: limit, Inline: true},
+			{Name: "Locked", Value: locked, Inline: true},
+			{Name: "Invited", Value: strings.Join(invited, " ")},
+			// Discord renders relative timestamps client-side, so the age
+			// stays current without editing the message.
+			{Name: "Created", Value: fmt.Sprintf("<t:%d:R>", r.createdAt.Unix())},
+		},
+	}
+	if r.lobbyCode != "" {
+		embed.Fields = append(embed.Fields, discord.EmbedField{Name: "Lobby", Value: "`" + r.lobbyCode + "`"})
+	}
+	return embed, nil
+}
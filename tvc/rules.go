@@ -0,0 +1,96 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+// rulesAcceptID is the custom ID of the button accepting a hub's room rules.
+const rulesAcceptID = "rules-accept"
+
+// checkRules lets userID stay in the room if its hub has no rules or they
+// accepted rules in the guild before. Otherwise they are moved to the waiting
+// room and shown the rules with a button to accept them, and false is
+// returned.
+func (h *handler) checkRules(ctx context.Context, r *room, userID discord.UserID) bool {
+	if r.hub == nil || r.hub.rules == "" || userID == r.owner || h.cfg.companionBots[userID] || h.stats.acceptedRules(r.guildID, userID) {
+		return true
+	}
+
+	waitingRoom := h.findWaitingRoom(r.guildID)
+	target := discord.NullChannelID
+	if waitingRoom != nil {
+		target = waitingRoom.ID
+	}
+	err := h.moveMember(ctx, memberMove{
+		guildID:   r.guildID,
+		userID:    userID,
+		channelID: target,
+		reason:    "member hasn't accepted the room rules",
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to move member to waiting room", "err", err)
+		return false
+	}
+	if waitingRoom == nil {
+		return false
+	}
+
+	h.rulesWaits[userID] = r.channelID
+	err = h.call(ctx, "SendMessage", func(s *state.State) error {
+		_, err := s.SendMessageComplex(waitingRoom.ID, api.SendMessageData{
+			Content: userID.Mention() + ", please accept the rules before joining " + r.channelID.Mention() + ".",
+			Embeds: []discord.Embed{{
+				Title:       "Room rules",
+				Description: r.hub.rules,
+			}},
+			Components: discord.ContainerComponents{
+				&discord.ActionRowComponent{
+					&discord.ButtonComponent{
+						Style:    discord.SuccessButtonStyle(),
+						CustomID: rulesAcceptID,
+						Label:    "I accept",
+					},
+				},
+			},
+			AllowedMentions: &api.AllowedMentions{Users: []discord.UserID{userID}},
+		})
+		return err
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to show room rules", "err", err)
+	}
+	return false
+}
+
+// onRulesAccept records that the member accepted the rules and moves them
+// into the room they were waiting for.
+func (h *handler) onRulesAccept(ctx context.Context, data cmdroute.ComponentData) *api.InteractionResponse {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	userID := data.Event.SenderID()
+	channelID, ok := h.rulesWaits[userID]
+	if !ok {
+		return ephemeral("You aren't waiting to join a room.")
+	}
+	delete(h.rulesWaits, userID)
+	h.stats.acceptRules(data.Event.GuildID, userID, time.Now())
+
+	r := h.rooms[channelID]
+	if r == nil {
+		return ephemeral("Thanks! That room is gone, but you won't be asked again.")
+	}
+	ctx = withGuild(ctx, r.guildID)
+	err := h.moveMember(ctx, memberMove{guildID: r.guildID, userID: userID, channelID: r.channelID})
+	if err != nil {
+		return ephemeral("Thanks! I couldn't move you, so join " + r.channelID.Mention() + " yourself.")
+	}
+	return ephemeral("Thanks, welcome in!")
+}
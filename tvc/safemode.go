@@ -0,0 +1,187 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/diamondburned/arikawa/v3/utils/json/option"
+)
+
+// Custom IDs of the buttons on a cleanup held by safe mode.
+const (
+	cleanupConfirmID = "cleanup-confirm"
+	cleanupCancelID  = "cleanup-cancel"
+)
+
+// heldCleanup is a cleanup waiting for an admin to confirm it.
+type heldCleanup struct {
+	guildID  discord.GuildID
+	what     string
+	channels int
+	// run performs the cleanup with h.mu held.
+	run func(ctx context.Context)
+	// keep is called with h.mu held if the cleanup is dropped. It may be
+	// nil.
+	keep func()
+	// message is the log channel post asking for confirmation, 0 if the
+	// guild has no log channel.
+	message discord.MessageID
+}
+
+// cleanUp runs a cleanup that deletes channels channels of the guild, unless
+// that is more than safe mode allows, in which case it is held until an admin
+// confirms it. A guild without a log channel keeps its channels until the
+// next restart. keep is called if the cleanup is dropped, or replaced by a
+// later one held in the same guild. h.mu must be held.
+func (h *handler) cleanUp(ctx context.Context, guildID discord.GuildID, what string, channels int, run func(ctx context.Context), keep func()) {
+	if channels == 0 {
+		return
+	}
+	if h.cfg.safeModeThreshold <= 0 || channels <= h.cfg.safeModeThreshold {
+		run(ctx)
+		return
+	}
+
+	h.releaseCleanup(ctx, guildID)
+	c := &heldCleanup{guildID: guildID, what: what, channels: channels, run: run, keep: keep}
+	h.heldCleanups[guildID] = c
+	slog.WarnContext(ctx, "Holding cleanup for confirmation", "what", what, "channels", channels, "threshold", h.cfg.safeModeThreshold)
+
+	channelID := h.logChannelFor(guildID)
+	if !channelID.IsValid() {
+		slog.WarnContext(ctx, "No log channel to confirm the cleanup in, keeping the channels")
+		return
+	}
+	var msg *discord.Message
+	err := h.call(ctx, "SendMessage", func(s *state.State) (err error) {
+		msg, err = s.SendMessageComplex(channelID, api.SendMessageData{
+			Content: fmt.Sprintf("⚠️ **Safe mode**: %s would delete %s at once, more than the %d allowed without confirmation. "+
+				"This can mean the bot's view of the server is out of date, so the channels are left alone until you decide.",
+				what, plural(channels, "channel"), h.cfg.safeModeThreshold),
+			AllowedMentions: &api.AllowedMentions{},
+			Components: discord.ContainerComponents{
+				&discord.ActionRowComponent{
+					&discord.ButtonComponent{
+						Style:    discord.DangerButtonStyle(),
+						CustomID: cleanupConfirmID,
+						Label:    "Delete them",
+					},
+					&discord.ButtonComponent{
+						Style:    discord.SecondaryButtonStyle(),
+						CustomID: cleanupCancelID,
+						Label:    "Keep them",
+					},
+				},
+			},
+		})
+		return err
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to post held cleanup", "err", err)
+		return
+	}
+	c.message = msg.ID
+}
+
+// releaseCleanup drops the cleanup held in the guild, if any, keeping its
+// channels, and takes the buttons off its post.
+func (h *handler) releaseCleanup(ctx context.Context, guildID discord.GuildID) {
+	c := h.heldCleanups[guildID]
+	if c == nil {
+		return
+	}
+	delete(h.heldCleanups, guildID)
+	if c.keep != nil {
+		c.keep()
+	}
+	slog.InfoContext(ctx, "Held cleanup replaced by a newer one", "what", c.what, "channels", c.channels)
+	if !c.message.IsValid() {
+		return
+	}
+	channelID := h.logChannelFor(guildID)
+	err := h.call(ctx, "EditMessage", func(s *state.State) error {
+		_, err := s.EditMessageComplex(channelID, c.message, api.EditMessageData{
+			Content:    option.NewNullableString(fmt.Sprintf("Safe mode held %s deleting %s. A newer cleanup replaced it, so these channels were kept.", c.what, plural(c.channels, "channel"))),
+			Components: &discord.ContainerComponents{},
+		})
+		return err
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to update replaced cleanup", "err", err)
+	}
+}
+
+// canConfirmCleanup reports whether the member pressing a held cleanup's
+// button may decide on it: they need Manage Channels where it was posted, or
+// to be able to manage the guild.
+func (h *handler) canConfirmCleanup(data cmdroute.ComponentData) bool {
+	perms, err := h.s.Permissions(data.Event.ChannelID, data.Event.SenderID())
+	if err == nil && perms.Has(discord.PermissionManageChannels) {
+		return true
+	}
+	return h.canManageGuild(data.Event.GuildID, data.Event.SenderID())
+}
+
+// heldCleanupOf returns the held cleanup the button was pressed on, or nil
+// if it has been handled.
+func (h *handler) heldCleanupOf(data cmdroute.ComponentData) *heldCleanup {
+	c := h.heldCleanups[data.Event.GuildID]
+	if c == nil || data.Event.Message == nil || c.message != data.Event.Message.ID {
+		return nil
+	}
+	return c
+}
+
+// onCleanupConfirm runs the held cleanup.
+func (h *handler) onCleanupConfirm(ctx context.Context, data cmdroute.ComponentData) *api.InteractionResponse {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	c := h.heldCleanupOf(data)
+	if c == nil {
+		return reloadUpdate(data, "This cleanup has already been handled.")
+	}
+	if !h.canConfirmCleanup(data) {
+		return ephemeral("You need the Manage Channels or Manage Server permission to decide on cleanups.")
+	}
+	delete(h.heldCleanups, c.guildID)
+	slog.InfoContext(ctx, "Held cleanup confirmed", "user_id", data.Event.SenderID(), "what", c.what, "channels", c.channels)
+
+	// Deleting may take longer than the interaction may go unanswered.
+	go func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		c.run(withGuild(context.Background(), c.guildID))
+	}()
+	return reloadUpdate(data, "Confirmed by "+data.Event.SenderID().Mention()+", deleting.")
+}
+
+// onCleanupCancel drops the held cleanup.
+func (h *handler) onCleanupCancel(ctx context.Context, data cmdroute.ComponentData) *api.InteractionResponse {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	c := h.heldCleanupOf(data)
+	if c == nil {
+		return reloadUpdate(data, "This cleanup has already been handled.")
+	}
+	if !h.canConfirmCleanup(data) {
+		return ephemeral("You need the Manage Channels or Manage Server permission to decide on cleanups.")
+	}
+	delete(h.heldCleanups, c.guildID)
+	if c.keep != nil {
+		c.keep()
+	}
+	slog.InfoContext(ctx, "Held cleanup dropped", "user_id", data.Event.SenderID(), "what", c.what, "channels", c.channels)
+	return reloadUpdate(data, "Kept by "+data.Event.SenderID().Mention()+".")
+}
+
+// roomChannels is how many channels deleting r deletes.
+func roomChannels(r *room) int {
+	return 1 + len(trackedRoomOf(r).ownChannels())
+}
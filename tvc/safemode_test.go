@@ -0,0 +1,218 @@
+package tvc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// safeMode is the settings turning safe mode on with the given threshold
+// and the test guild's log channel.
+func safeMode(threshold int) []string {
+	return []string{"SAFE_MODE_THRESHOLD=" + strconv.Itoa(threshold), "LOG_CHANNEL_IDS=" + testLogID.String()}
+}
+
+// pressed returns the data of userID pressing a button on message in the
+// test guild's log channel.
+func pressed(userID discord.UserID, message discord.MessageID) cmdroute.ComponentData {
+	return cmdroute.ComponentData{Event: &discord.InteractionEvent{
+		GuildID:   testGuildID,
+		ChannelID: testLogID,
+		Message:   &discord.Message{ID: message, Content: "held"},
+		Member:    &discord.Member{User: discord.User{ID: userID}},
+	}}
+}
+
+// heldCleanupFor holds a cleanup of three channels, returning channels that
+// are closed when it runs and when it is kept.
+func heldCleanupFor(t *testing.T, h *handler) (ran, kept chan struct{}) {
+	t.Helper()
+	ran, kept = make(chan struct{}), make(chan struct{})
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.cleanUp(context.Background(), testGuildID, "A test", 3,
+		func(context.Context) { close(ran) },
+		func() { close(kept) })
+	c := h.heldCleanups[testGuildID]
+	if c == nil {
+		t.Fatal("cleanup past the threshold wasn't held")
+	}
+	if !c.message.IsValid() {
+		t.Fatal("held cleanup wasn't posted to the log channel")
+	}
+	return ran, kept
+}
+
+func closed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestCleanUpWithinThresholdRuns(t *testing.T) {
+	b := newTestBot(t, safeMode(3)...)
+	b.send(testGuild(nil, nil))
+
+	ran := false
+	b.h.mu.Lock()
+	defer b.h.mu.Unlock()
+	b.h.cleanUp(context.Background(), testGuildID, "A test", 3, func(context.Context) { ran = true }, nil)
+	if !ran || b.h.heldCleanups[testGuildID] != nil {
+		t.Error("cleanup within the threshold was held")
+	}
+}
+
+func TestHeldCleanupConfirm(t *testing.T) {
+	b := newTestBot(t, safeMode(2)...)
+	b.send(testGuild(nil, nil))
+	ran, kept := heldCleanupFor(t, b.h)
+	message := b.h.heldCleanups[testGuildID].message
+
+	resp := b.h.onCleanupConfirm(context.Background(), pressed(testMemberID, message))
+	if resp.Type != api.MessageInteractionWithSource || b.h.heldCleanups[testGuildID] == nil {
+		t.Fatal("a member without permissions confirmed the cleanup")
+	}
+	if resp := b.h.onCleanupConfirm(context.Background(), pressed(testOwnerID, message+1)); resp.Type != api.UpdateMessage || b.h.heldCleanups[testGuildID] == nil {
+		t.Fatal("a button on another message confirmed the cleanup")
+	}
+
+	resp = b.h.onCleanupConfirm(context.Background(), pressed(testOwnerID, message))
+	if resp.Type != api.UpdateMessage {
+		t.Errorf("confirming answers with %v, want a message update", resp.Type)
+	}
+	select {
+	case <-ran:
+	case <-time.After(5 * time.Second):
+		t.Fatal("confirmed cleanup didn't run")
+	}
+	if closed(kept) || b.h.heldCleanups[testGuildID] != nil {
+		t.Error("confirmed cleanup was kept or is still held")
+	}
+
+	// Pressing again finds nothing to decide on.
+	resp = b.h.onCleanupConfirm(context.Background(), pressed(testOwnerID, message))
+	if !strings.HasSuffix(resp.Data.Content.Val, "already been handled.") {
+		t.Errorf("pressing again answers %q", resp.Data.Content.Val)
+	}
+}
+
+func TestHeldCleanupCancel(t *testing.T) {
+	b := newTestBot(t, safeMode(2)...)
+	b.send(testGuild(nil, nil))
+	ran, kept := heldCleanupFor(t, b.h)
+	message := b.h.heldCleanups[testGuildID].message
+
+	b.h.onCleanupCancel(context.Background(), pressed(testMemberID, message))
+	if closed(kept) {
+		t.Fatal("a member without permissions kept the channels")
+	}
+	b.h.onCleanupCancel(context.Background(), pressed(testOwnerID, message))
+	if !closed(kept) || closed(ran) || b.h.heldCleanups[testGuildID] != nil {
+		t.Error("cancelled cleanup ran or is still held")
+	}
+}
+
+func TestHeldCleanupReplaced(t *testing.T) {
+	b := newTestBot(t, safeMode(2)...)
+	b.send(testGuild(nil, nil))
+	firstRan, firstKept := heldCleanupFor(t, b.h)
+	first := b.h.heldCleanups[testGuildID]
+	_, secondKept := heldCleanupFor(t, b.h)
+
+	if !closed(firstKept) || closed(firstRan) {
+		t.Error("replaced cleanup wasn't kept")
+	}
+	if closed(secondKept) || b.h.heldCleanups[testGuildID] == first {
+		t.Error("newer cleanup isn't the one held")
+	}
+	if resp := b.h.onCleanupConfirm(context.Background(), pressed(testOwnerID, first.message)); resp.Type != api.UpdateMessage || b.h.heldCleanups[testGuildID] == nil {
+		t.Error("the replaced cleanup's button decided on the newer one")
+	}
+}
+
+// waitDeleted fails the test unless the channels are deleted in time, as
+// confirmed cleanups run in the background.
+func waitDeleted(t *testing.T, b *testBot, ids ...discord.ChannelID) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for _, id := range ids {
+		for b.channelExists(id) {
+			if time.Now().After(deadline) {
+				t.Fatalf("channel %d wasn't deleted", id)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
+
+func TestSweepEmptyCategoriesHeldBySafeMode(t *testing.T) {
+	b := newTestBot(t, safeMode(1)...)
+	b.send(testGuild([]discord.Channel{
+		{ID: 300, GuildID: testGuildID, Type: discord.GuildCategory, Name: "left"},
+		{ID: 301, GuildID: testGuildID, Type: discord.GuildCategory, Name: "behind"},
+	}, nil))
+
+	h := b.h
+	h.mu.Lock()
+	for _, id := range []discord.ChannelID{300, 301} {
+		h.created[id] = true
+		h.emptyCategories[id] = time.Now().Add(-2 * h.cfg.janitorEmptyAfter)
+	}
+	h.sweepEmptyCategories()
+	c := h.heldCleanups[testGuildID]
+	h.mu.Unlock()
+	if c == nil || c.channels != 2 {
+		t.Fatalf("held cleanup is %+v, want one of both categories", c)
+	}
+	if !b.channelExists(300) || !b.channelExists(301) {
+		t.Fatal("categories were deleted while the sweep is held")
+	}
+
+	h.onCleanupConfirm(context.Background(), pressed(testOwnerID, c.message))
+	waitDeleted(t, b, 300, 301)
+}
+
+func TestServeCleanupHeldBySafeMode(t *testing.T) {
+	b := newTestBot(t, append(safeMode(1), "ROOM_GRACE_PERIOD=1h")...)
+	b.send(testGuild(nil, nil))
+
+	// Two rooms in their grace period, one more channel than safe mode
+	// lets go at once.
+	var rooms []discord.ChannelID
+	for _, userID := range []discord.UserID{5, 6} {
+		b.send(join(userID, testHubID))
+		room := b.roomOf(userID)
+		b.send(join(userID, room), join(userID, 0))
+		rooms = append(rooms, room)
+	}
+
+	rec := httptest.NewRecorder()
+	b.h.serveCleanup(rec, httptest.NewRequest("POST", "/cleanup", nil))
+	var body struct{ Deleted, Held int }
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Deleted != 0 || body.Held != 2 {
+		t.Errorf("cleanup answered %+v, want both rooms held", body)
+	}
+	b.h.mu.Lock()
+	c := b.h.heldCleanups[testGuildID]
+	b.h.mu.Unlock()
+	if c == nil || !b.channelExists(rooms[0]) || !b.channelExists(rooms[1]) {
+		t.Fatal("cleanup past the threshold wasn't held")
+	}
+
+	b.h.onCleanupConfirm(context.Background(), pressed(testOwnerID, c.message))
+	waitDeleted(t, b, rooms...)
+}
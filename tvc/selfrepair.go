@@ -0,0 +1,104 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+var (
+
+	// selfAccess is what the bot needs on managed categories to keep seeing
+	// and moving users into locked or hidden rooms.
+	selfAccess = discord.PermissionViewChannel | discord.PermissionConnect
+)
+
+// repairGuild repairs the bot's access to the categories of the guild's hubs.
+func (h *handler) repairGuild(evt *gateway.GuildCreateEvent) {
+	if !h.cfg.selfRepairPermissions {
+		return
+	}
+
+	ctx := withGuild(context.Background(), evt.ID)
+	repaired := make(map[discord.ChannelID]bool)
+	for i, ch := range evt.Channels {
+		if h.lookupHub(&evt.Channels[i]) == nil || !ch.ParentID.IsValid() || repaired[ch.ParentID] {
+			continue
+		}
+		repaired[ch.ParentID] = true
+		h.ensureSelfAccess(ctx, evt.ID, ch.ParentID)
+	}
+}
+
+// ensureSelfAccess adds an overwrite granting the bot's role selfAccess on
+// channelID. It does nothing unless the bot has Administrator or Manage Roles
+// there, since Discord would reject the edit otherwise.
+func (h *handler) ensureSelfAccess(ctx context.Context, guildID discord.GuildID, channelID discord.ChannelID) {
+	if !h.cfg.selfRepairPermissions {
+		return
+	}
+
+	me, err := h.s.Me()
+	if err != nil {
+		return
+	}
+	perms, err := h.s.Permissions(channelID, me.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to check own permissions", "err", err)
+		return
+	}
+	if !perms.Has(discord.PermissionAdministrator) && !perms.Has(discord.PermissionManageRoles) {
+		return
+	}
+
+	target, typ := h.ownOverwriteTarget(guildID, me.ID)
+
+	ch, err := h.s.Channel(channelID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get channel for self repair", "err", err)
+		return
+	}
+	allow := selfAccess
+	var deny discord.Permissions
+	for _, o := range ch.Overwrites {
+		if o.ID == target && o.Type == typ {
+			if o.Allow.Has(selfAccess) && o.Deny&selfAccess == 0 {
+				return
+			}
+			allow |= o.Allow
+			deny = o.Deny &^ selfAccess
+		}
+	}
+
+	err = h.call(ctx, "EditChannelPermission", func(s *state.State) error {
+		return s.EditChannelPermission(channelID, target, api.EditChannelPermissionData{
+			Type:           typ,
+			Allow:          allow,
+			Deny:           deny,
+			AuditLogReason: "keep temporary rooms manageable by the bot",
+		})
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to repair own permissions", "err", err)
+		return
+	}
+	slog.Info("Granted own role access to category", "guild_id", guildID, "category_id", channelID)
+}
+
+// ownOverwriteTarget returns the bot's managed role, falling back to a member
+// overwrite for the bot user if the role can't be found.
+func (h *handler) ownOverwriteTarget(guildID discord.GuildID, me discord.UserID) (discord.Snowflake, discord.OverwriteType) {
+	roles, err := h.s.Roles(guildID)
+	if err == nil {
+		for _, role := range roles {
+			if role.Managed && role.Tags.BotID == me {
+				return discord.Snowflake(role.ID), discord.OverwriteRole
+			}
+		}
+	}
+	return discord.Snowflake(me), discord.OverwriteMember
+}
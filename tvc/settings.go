@@ -0,0 +1,533 @@
+package tvc
+
+import (
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// settings holds what a manager reads from Config.Settings.
+type settings struct {
+	// activityStatusEnabled sets each room's voice channel status to the most
+	// common game among its occupants. It needs the privileged presence
+	// intent.
+	activityStatusEnabled bool
+	// activityStatusInterval is the minimum time between two status updates
+	// of the same room. Presence updates are frequent, so keep this generous.
+	activityStatusInterval time.Duration
+
+	// adaptiveGrace learns how long after a room empties people come back to
+	// it in each guild, and sets the guild's grace period to cover most of
+	// those rejoins, within adaptiveGraceMin and adaptiveGraceMax. Guilds
+	// with fewer than adaptiveGraceSamples rejoins seen keep
+	// $ROOM_GRACE_PERIOD.
+	adaptiveGrace        bool
+	adaptiveGraceMin     time.Duration
+	adaptiveGraceMax     time.Duration
+	adaptiveGraceSamples int
+	// adaptiveGracePercentile is the share of rejoins the grace period
+	// covers, in percent.
+	adaptiveGracePercentile int
+
+	// teamAFKTimeout is how long a member of a team room may sit muted and
+	// deafened before being moved to the team's AFK channel.
+	teamAFKTimeout time.Duration
+
+	// afkTimeoutGrace is how long a member Discord moved to the guild's AFK
+	// channel out of a room still counts as in it, so a room isn't torn down
+	// under someone who only dozed off. Zero counts them as gone right away.
+	afkTimeoutGrace time.Duration
+	// guildAFKTimeoutGraces override afkTimeoutGrace per guild, read from
+	// $AFK_TIMEOUT_GRACE_<guild ID>.
+	guildAFKTimeoutGraces map[discord.GuildID]time.Duration
+
+	// attendanceFlushDelay batches joins and leaves into one message, keeping the
+	// log compact and the API calls few.
+	attendanceFlushDelay time.Duration
+
+	// autoModExemptRules are the AutoMod rules that team rooms' text channels
+	// are exempted from, e.g. slow mode or link filters meant for public
+	// channels. The rules themselves are managed in the Discord client.
+	autoModExemptRules []string
+
+	// autoModRoomAction is what happens to a room whose name contains what an
+	// AutoMod rule caught in one of its channels: "rename" gives it back its
+	// default name, "delete" deletes it and "off" leaves it alone. Either way
+	// the mod channel hears about it.
+	autoModRoomAction string
+
+	// boardChannels are the text channels the rooms board is kept in, at
+	// most one per guild.
+	boardChannels map[discord.ChannelID]bool
+	// boardDebounce batches bursts of room changes into a single edit.
+	boardDebounce time.Duration
+
+	// boosterUserLimit is the user limit of rooms owned by server boosters,
+	// unless a preset sets one. Zero gives them the usual limit.
+	boosterUserLimit uint
+	// boosterPersistent keeps boosters' rooms when they empty out, for as
+	// long as their owner keeps boosting.
+	boosterPersistent bool
+	// boosterEmoji prefixes the names of boosters' rooms, e.g. "💎" or a
+	// custom emoji. Empty leaves them alone.
+	boosterEmoji string
+	// boosterGraceExtension is added to the grace period of boosters' rooms
+	// once they empty out, even where rooms are otherwise deleted at once.
+	boosterGraceExtension time.Duration
+	// boosterKeepAliveMax replaces $ROOM_KEEPALIVE_MAX for boosters' rooms
+	// when it is longer.
+	boosterKeepAliveMax time.Duration
+
+	// Budgets cap how much of the bot's work one guild gets within a window, so
+	// operators of very large guilds can trade latency for rate limit safety.
+	// Work past a budget waits until the budget allows it. Each budget is set for
+	// every guild with $<name> and for one guild with $<name>_<guild ID>; zero
+	// leaves it unlimited.
+	//
+	// creationBudget is how many rooms a guild may get per minute.
+	creationBudget *budget
+	// deletionBudget is how many empty rooms a guild may have deleted per
+	// minute.
+	deletionBudget *budget
+	// renameBudget is how many renames a guild may have per rename window,
+	// on top of Discord's limit for each channel.
+	renameBudget *budget
+
+	// creationSlots is how many rooms of a guild may be in the making at once,
+	// set with $ROOM_CREATIONS_IN_FLIGHT and $ROOM_CREATIONS_IN_FLIGHT_<guild ID>.
+	// Requests past it wait for a creation to finish. Zero leaves it unlimited.
+	creationSlots guildLimit
+
+	// bypassRoles, such as moderator roles, may always join rooms, even full,
+	// locked or hidden ones, to moderate them.
+	bypassRoles []discord.RoleID
+
+	// capacityAlertPercent is how full, in percent of Discord's channel limit, a
+	// category may get before admins are told to add another. Zero disables the
+	// alerts.
+	capacityAlertPercent int
+
+	// transientMessageTTL is how long greetings and warnings stay in a team
+	// room's text channel before the bot deletes them. Zero keeps them. A
+	// deletion warning that expires takes its keep-alive button with it.
+	transientMessageTTL time.Duration
+
+	// commandGuilds register the commands in these guilds instead of globally,
+	// for test bots: guild commands change at once, and the global ones are
+	// left alone.
+	commandGuilds []discord.GuildID
+
+	// companionBots are bots, such as music or recording bots, that may always
+	// join rooms. They are exempt from featureNoBots.
+	companionBots map[discord.UserID]bool
+
+	// unclaimedRoomTimeout is how long a room started from a message waits for
+	// its first member when its owner wasn't in voice to be moved in.
+	unclaimedRoomTimeout time.Duration
+
+	// debugEndpoints serves Go's pprof profiles under /debug/pprof/ next to
+	// /healthz, behind $API_TOKEN, for chasing leaks in long-running bots.
+	debugEndpoints bool
+
+	// channelMissTTL is how long a channel Discord said doesn't exist is
+	// answered from memory. Deleted channels never come back, but events naming
+	// them keep arriving for a while after a room is torn down.
+	channelMissTTL time.Duration
+
+	// teamForums are forum channels, at most one per guild, where team rooms get
+	// a post instead of a text channel. Posts outlive the room: they are locked
+	// and archived when it is deleted, so the discussion stays readable.
+	teamForums map[discord.ChannelID]bool
+
+	// roomGracePeriod is how long an empty room is kept before it is
+	// deleted. Zero deletes rooms as soon as the last member leaves.
+	roomGracePeriod time.Duration
+	// keepAliveExtension is how much time the keep-alive button adds.
+	keepAliveExtension time.Duration
+	// keepAliveMax caps the total time the button can add to a grace period.
+	keepAliveMax time.Duration
+
+	// guildQueueWarnDepth is how many events of one guild may wait for its
+	// worker before a warning is logged. It isn't a limit: events are never
+	// dropped, and dispatching never blocks, since that would hold up the gateway
+	// for every guild.
+	guildQueueWarnDepth int
+
+	// registryImportFile is a registry exported from GET /registry on another
+	// host, read on startup to take its rooms over. Rooms are picked up as their
+	// guilds come up, with what is left of their grace periods, the same as after
+	// a restart; unset it again once the move is done.
+	registryImportFile string
+
+	// hookPayload is the template of what hooks are sent, with the fields of
+	// hookEvent as placeholders, e.g. {"server": "{channel_id}"}. Values are
+	// JSON-escaped. Empty sends hookEvent as JSON.
+	hookPayload string
+	// hookTimeout bounds how long a hook may run.
+	hookTimeout time.Duration
+
+	// hubIdleTimeout is how long a member may sit in a hub without getting
+	// a room, e.g. because creation failed or they were turned away, before
+	// they are cleared out of it. Zero leaves them. Keep it above
+	// $PRESET_TIMEOUT so members picking a preset aren't affected.
+	hubIdleTimeout time.Duration
+	// hubIdleLobbies are voice channels, at most one per guild, idle members
+	// are moved to. Without one they are disconnected.
+	hubIdleLobbies map[discord.ChannelID]bool
+
+	// janitorEmptyAfter is how long a room the janitor finds empty, without
+	// a deletion already scheduled, may stay before it is deleted. It catches
+	// rooms whose last leave was missed, e.g. during a reconnect.
+	janitorEmptyAfter time.Duration
+	// maxGuildRooms caps how many rooms a guild may have at once. Zero means
+	// unlimited.
+	maxGuildRooms int
+
+	// verifyDeletes double-checks every deletion by looking the channel up
+	// again, queueing another attempt if it is still there.
+	verifyDeletes bool
+
+	// slowRoomThreshold is how long a member may wait for their room before the
+	// wait gets a log line of its own. Faster rooms only show up in the summary.
+	slowRoomThreshold time.Duration
+
+	// launcherChannels are the text channels holding a launcher message, at
+	// most one per guild.
+	launcherChannels map[discord.ChannelID]bool
+	// launchers are the buttons of the launcher message.
+	launchers []launcher
+
+	// maxRoomsPerUser caps how many rooms a single user may own at once.
+	// Zero means unlimited.
+	maxRoomsPerUser int
+	// bounceOverLimit disconnects users who hit the cap instead of moving
+	// them back into a room they already own.
+	bounceOverLimit bool
+
+	// lobbiesFile is where the hubs are read from. Empty uses defaultLobbies.
+	lobbiesFile string
+
+	// apiToken, if set, must be sent as a bearer token to read /rooms.
+	apiToken string
+
+	// logChannels are text channels, at most one per guild, where the bot tells
+	// admins about things that need their attention but no immediate action.
+	logChannels map[discord.ChannelID]bool
+
+	// memberChunkGuilds have their whole member list requested over the
+	// gateway when they become available, so the names and roles rooms are
+	// named and permissioned by don't wait on REST. It needs the privileged
+	// Server Members intent.
+	memberChunkGuilds []discord.GuildID
+	// memberCacheTTL is how long a looked up member is trusted.
+	memberCacheTTL time.Duration
+	// memberCacheSize caps how many members are cached across guilds. The
+	// oldest are dropped first, from the state's cache too.
+	memberCacheSize int
+
+	// modChannels are the text channels moderation alerts are posted in, at
+	// most one per guild. Guilds without one get no alerts.
+	modChannels map[discord.ChannelID]bool
+	// bannedNameWords are words that must not appear in room names, matched
+	// case-insensitively.
+	bannedNameWords []string
+
+	// moveInterval is the least time between two member moves, across all
+	// guilds, so mass moves don't trip Discord's rate limits.
+	moveInterval time.Duration
+	// moveRetries is how often a move failing with a rate limit or server
+	// error is retried, backing off each time.
+	moveRetries int
+
+	// botNickname is the nickname the bot gives itself in every guild.
+	// Empty leaves the nickname alone.
+	botNickname string
+	// guildNicknames override botNickname per guild, read from
+	// $GUILD_NICKNAME_<guild ID>.
+	guildNicknames map[discord.GuildID]string
+
+	// observeNewGuilds puts guilds the bot is added to without any configuration
+	// in observer mode until an admin runs the setup wizard.
+	observeNewGuilds bool
+
+	// occupancyRetention is how long occupancy snapshots are kept, apart from
+	// $STATS_RETENTION since they pile up with every interval. Zero keeps them
+	// forever.
+	occupancyRetention time.Duration
+
+	// operators may use the /operator commands, which manage the bot as a whole
+	// from DMs, without needing access to any of its servers.
+	operators map[discord.UserID]bool
+
+	// hubOrphanTimeout is how long a member may wait in a hub for their room
+	// before the bot assumes the creation failed or its event was missed, and
+	// creates the room again. Zero turns this off.
+	hubOrphanTimeout time.Duration
+
+	// overlayBaseURL is where Config.HealthAddr is reachable from the outside,
+	// used to build overlay links, e.g. "https://rooms.example.com".
+	overlayBaseURL string
+
+	// partySizeSync sets a room's user limit to the maximum party size in
+	// its owner's Rich Presence, e.g. a party of 3/5 limits the room to 5.
+	// It needs the privileged presence intent.
+	partySizeSync bool
+	// partySizeDebounce is how long the party size must hold before the
+	// limit follows it.
+	partySizeDebounce time.Duration
+
+	// waitingRoomName is the voice channel people are moved to while they enter
+	// a room's password. Each guild using passwords needs one.
+	waitingRoomName string
+
+	// maxPendingRooms bounds the number of creations queued during an outage.
+	maxPendingRooms int
+
+	// presetTimeout is how long a member has to pick a preset before they get a
+	// default room.
+	presetTimeout time.Duration
+
+	// profiles are the guild event-mode profiles admins switch between with
+	// /voiceadmin profile apply.
+	profiles []profile
+
+	// protectedChannels lists channels that must never be deleted, whatever the
+	// registry says. Hub channels are always protected on top of these.
+	protectedChannels map[discord.ChannelID]bool
+
+	// roomQuotas maps role IDs to how many rooms their members may create per
+	// week, read from $ROOM_WEEKLY_QUOTAS, e.g. "everyone=5,1234=0". "everyone"
+	// applies to all members and 0 means unlimited. Members get the most generous
+	// quota among their roles; members without a listed role are unlimited.
+	roomQuotas map[discord.RoleID]int
+
+	// ownerReclaimAfter is how long a room's owner may be away while others use
+	// it before they are asked to vote on a new owner. Zero disables votes.
+	ownerReclaimAfter time.Duration
+
+	// reconcileDelay is how long after a gateway gap rooms are reconciled, so the
+	// events Discord replays on a resume, or the guilds it sends again after a
+	// new session, are in first. Zero turns reconciliation off.
+	reconcileDelay time.Duration
+
+	// recordEventsPath is a JSONL file every gateway event is appended to, to
+	// replay an incident locally later; see Replay. The events hold member names
+	// and message contents, so keep the file as private as the bot's logs.
+	recordEventsPath string
+
+	// maxReservations is how many room reservations a member may hold in a
+	// guild at once.
+	maxReservations int
+	// reservationMaxAhead is how far ahead rooms can be reserved.
+	reservationMaxAhead time.Duration
+
+	// roomInfoEnabled pins a room info embed in every new room's text chat.
+	roomInfoEnabled bool
+	// roomInfoDebounce batches bursts of setting changes into a single edit.
+	roomInfoDebounce time.Duration
+
+	// safeModeThreshold is how many channels a cleanup may delete at once before
+	// it waits for an admin to confirm it in the guild's log channel. Cleanups
+	// that big usually mean the bot's view of the guild is out of step with it,
+	// e.g. voice states missing after an outage. Zero turns safe mode off.
+	safeModeThreshold int
+
+	// selfRepairPermissions makes the bot grant its own role access to the
+	// categories it manages, if it is allowed to edit overwrites.
+	selfRepairPermissions bool
+
+	// settingsVoteTimeout is how long a vote on a room's settings stays open.
+	settingsVoteTimeout time.Duration
+
+	// stagingGuilds maps staging guilds to the production guilds whose hub
+	// configuration they mirror, from $STAGING_GUILDS, e.g. "1234:5678" to try
+	// out changes to 1234's hubs in 5678. A staging guild is served by the hubs
+	// of its production guild, follows its feature overrides and, until it
+	// applies one of its own, its profile. /voiceadmin staging sync copies the
+	// hub channels and template roles over; rooms, stats and notes stay behind.
+	stagingGuilds guildMirrors
+
+	// statsSaveDelay is how long the stats store gathers changes before writing
+	// them to its storage, so busy guilds don't have it encoded on every voice
+	// event. Zero writes every change at once.
+	statsSaveDelay time.Duration
+
+	// roomFailureAction decides what happens to a member whose room can't be
+	// created for a reason retrying won't fix: "stay" leaves them in the
+	// hub, "disconnect" removes them from voice and "fallback" moves them to
+	// the guild's fallback channel, or disconnects them if it has none.
+	roomFailureAction string
+	// roomFailureFallbacks are the voice channels stranded members are moved
+	// to, at most one per guild.
+	roomFailureFallbacks map[discord.ChannelID]bool
+
+	// nameSuffix appends live details to room names: "age" for how long the room
+	// has been open, e.g. "• 2h", or "occupancy" for who is in it, e.g. "• 3/5".
+	// Empty turns it off.
+	nameSuffix string
+
+	// summonsPerHour is how many times an owner may use /voice summon in an
+	// hour. Zero turns the command off.
+	summonsPerHour int
+	// guildSummonsPerHour overrides summonsPerHour per guild, read from
+	// $SUMMONS_PER_HOUR_<guild ID>.
+	guildSummonsPerHour map[discord.GuildID]int
+
+	// throttleRooms is how many rooms a member may create within
+	// throttleWindow before further attempts are refused. Zero disables it.
+	throttleRooms  int
+	throttleWindow time.Duration
+
+	// clearServerMutes lifts server mutes and deafens when owners join their
+	// own rooms. Discord keeps those flags across channels, so members muted
+	// for one channel long ago can end up stuck muted in their own room.
+	clearServerMutes bool
+	// clearServerMutesExempt are roles whose members stay muted, such as a
+	// role moderators hand out as a punishment.
+	clearServerMutesExempt []discord.RoleID
+
+	// voteKickTimeout is how long a vote kick stays open.
+	voteKickTimeout time.Duration
+	// voteKickBan is how long someone voted out may not reconnect.
+	voteKickBan time.Duration
+
+	// watchAllowed holds the keys of the activities /voice watch may start,
+	// read from $WATCH_ACTIVITIES. Every known activity is allowed when it
+	// is unset.
+	watchAllowed map[string]bool
+	// guildWatchAllowed overrides watchAllowed per guild, read from
+	// $WATCH_ACTIVITIES_<guild ID>. An empty list turns the command off.
+	guildWatchAllowed map[discord.GuildID]map[string]bool
+
+	// How often Manager.Run does its background work. Zero turns off the
+	// optional parts.
+	voiceLogSummaryInterval   time.Duration
+	latencySummaryInterval    time.Duration
+	runtimeReportInterval     time.Duration
+	occupancySnapshotInterval time.Duration
+	pendingRoomRetryInterval  time.Duration
+	hubOrphanScanInterval     time.Duration
+	janitorInterval           time.Duration
+	nameSuffixInterval        time.Duration
+	// statsRetention is how long room history is kept, swept every
+	// statsCleanupInterval. Zero keeps it forever.
+	statsRetention       time.Duration
+	statsCleanupInterval time.Duration
+}
+
+func readSettings(env *environment) settings {
+	cfg := settings{
+		activityStatusEnabled:   env.bool("ROOM_ACTIVITY_STATUS", false),
+		activityStatusInterval:  env.duration("ROOM_ACTIVITY_INTERVAL", time.Minute),
+		adaptiveGrace:           env.bool("ADAPTIVE_GRACE", false),
+		adaptiveGraceMin:        env.duration("ADAPTIVE_GRACE_MIN", 30*time.Second),
+		adaptiveGraceMax:        env.duration("ADAPTIVE_GRACE_MAX", 10*time.Minute),
+		adaptiveGraceSamples:    env.int("ADAPTIVE_GRACE_SAMPLES", 20),
+		adaptiveGracePercentile: env.int("ADAPTIVE_GRACE_PERCENTILE", 90),
+		teamAFKTimeout:          env.duration("TEAM_AFK_TIMEOUT", 5*time.Minute),
+		afkTimeoutGrace:         env.duration("AFK_TIMEOUT_GRACE", 0),
+		guildAFKTimeoutGraces:   parseGuildAFKTimeoutGraces(env),
+		attendanceFlushDelay:    env.duration("ROOM_ATTENDANCE_FLUSH", 10*time.Second),
+		autoModExemptRules:      env.list("AUTOMOD_EXEMPT_RULE_IDS"),
+		autoModRoomAction:       parseAutoModRoomAction(env, "AUTOMOD_ROOM_ACTION"),
+		boardChannels:           env.channelIDs("ROOMS_BOARD_CHANNEL_IDS"),
+		boardDebounce:           env.duration("ROOMS_BOARD_DEBOUNCE", 10*time.Second),
+		boosterUserLimit:        uint(env.int("BOOSTER_USER_LIMIT", 0)),
+		boosterPersistent:       env.bool("BOOSTER_PERSISTENT_ROOMS", false),
+		boosterEmoji:            env.string("BOOSTER_EMOJI", ""),
+		boosterGraceExtension:   env.duration("BOOSTER_GRACE_EXTENSION", 0),
+		boosterKeepAliveMax:     env.duration("BOOSTER_KEEPALIVE_MAX", 0),
+		creationBudget:          envBudget(env, "ROOM_CREATIONS_PER_MINUTE", time.Minute),
+		deletionBudget:          envBudget(env, "ROOM_DELETIONS_PER_MINUTE", time.Minute),
+		renameBudget:            envBudget(env, "ROOM_RENAMES_PER_WINDOW", renameWindow),
+		creationSlots:           envGuildLimit(env, "ROOM_CREATIONS_IN_FLIGHT"),
+		bypassRoles:             env.roleIDs("BYPASS_ROLES"),
+		capacityAlertPercent:    env.int("CATEGORY_CAPACITY_ALERT_PERCENT", 80),
+		transientMessageTTL:     env.duration("TRANSIENT_MESSAGE_TTL", 0),
+		commandGuilds:           env.guildIDs("COMMAND_GUILD_IDS"),
+		companionBots:           env.userIDs("COMPANION_BOT_IDS"),
+		unclaimedRoomTimeout:    env.duration("UNCLAIMED_ROOM_TIMEOUT", 5*time.Minute),
+		debugEndpoints:          env.bool("DEBUG_ENDPOINTS", false),
+		channelMissTTL:          env.duration("CHANNEL_MISS_TTL", time.Minute),
+		teamForums:              env.channelIDs("TEAMS_FORUM_CHANNEL_IDS"),
+		roomGracePeriod:         env.duration("ROOM_GRACE_PERIOD", 0),
+		keepAliveExtension:      env.duration("ROOM_KEEPALIVE_EXTENSION", 30*time.Minute),
+		keepAliveMax:            env.duration("ROOM_KEEPALIVE_MAX", 2*time.Hour),
+		guildQueueWarnDepth:     env.int("GUILD_QUEUE_WARN_DEPTH", 256),
+		registryImportFile:      env.string("REGISTRY_IMPORT_FILE", ""),
+		hookPayload:             env.string("HOOK_PAYLOAD", ""),
+		hookTimeout:             env.duration("HOOK_TIMEOUT", 10*time.Second),
+		hubIdleTimeout:          env.duration("HUB_IDLE_TIMEOUT", 0),
+		hubIdleLobbies:          env.channelIDs("HUB_IDLE_LOBBY_IDS"),
+		janitorEmptyAfter:       env.duration("JANITOR_EMPTY_AFTER", 5*time.Minute),
+		maxGuildRooms:           env.int("MAX_GUILD_ROOMS", 0),
+		verifyDeletes:           env.bool("VERIFY_DELETES", true),
+		slowRoomThreshold:       env.duration("ROOM_LATENCY_SLOW", 5*time.Second),
+		launcherChannels:        env.channelIDs("LAUNCHER_CHANNEL_IDS"),
+		launchers:               parseLaunchers(env, "LAUNCHERS"),
+		maxRoomsPerUser:         env.int("MAX_ROOMS_PER_USER", 1),
+		bounceOverLimit:         env.string("ROOM_LIMIT_ACTION", "redirect") == "bounce",
+		lobbiesFile:             env.string("LOBBIES_FILE", ""),
+		apiToken:                env.string("API_TOKEN", ""),
+		logChannels:             env.channelIDs("LOG_CHANNEL_IDS"),
+		memberChunkGuilds:       env.guildIDs("MEMBER_CHUNK_GUILD_IDS"),
+		memberCacheTTL:          env.duration("MEMBER_CACHE_TTL", 10*time.Minute),
+		memberCacheSize:         env.int("MEMBER_CACHE_SIZE", 10000),
+		modChannels:             env.channelIDs("MOD_CHANNEL_IDS"),
+		bannedNameWords:         env.list("BANNED_NAME_WORDS"),
+		moveInterval:            env.duration("MEMBER_MOVE_INTERVAL", 200*time.Millisecond),
+		moveRetries:             env.int("MEMBER_MOVE_RETRIES", 3),
+		botNickname:             env.string("BOT_NICKNAME", ""),
+		guildNicknames:          parseGuildNicknames(env),
+		observeNewGuilds:        env.bool("OBSERVE_NEW_GUILDS", true),
+		occupancyRetention:      env.duration("OCCUPANCY_RETENTION", 28*24*time.Hour),
+		operators:               env.userIDs("OPERATOR_IDS"),
+		hubOrphanTimeout:        env.duration("HUB_ORPHAN_TIMEOUT", time.Minute),
+		overlayBaseURL:          env.string("OVERLAY_BASE_URL", ""),
+		partySizeSync:           env.bool("PARTY_SIZE_SYNC", false),
+		partySizeDebounce:       env.duration("PARTY_SIZE_DEBOUNCE", 15*time.Second),
+		waitingRoomName:         env.string("WAITING_ROOM_NAME", "⏳ waiting room"),
+		maxPendingRooms:         env.int("MAX_PENDING_ROOMS", 100),
+		presetTimeout:           env.duration("PRESET_TIMEOUT", 30*time.Second),
+		profiles:                parseProfiles(env, "PROFILES"),
+		protectedChannels:       env.channelIDs("PROTECTED_CHANNEL_IDS"),
+		roomQuotas:              parseRoomQuotas(env, "ROOM_WEEKLY_QUOTAS"),
+		ownerReclaimAfter:       env.duration("OWNER_RECLAIM_AFTER", 0),
+		reconcileDelay:          env.duration("RECONCILE_DELAY", 10*time.Second),
+		recordEventsPath:        env.string("RECORD_EVENTS", ""),
+		maxReservations:         env.int("MAX_RESERVATIONS", 3),
+		reservationMaxAhead:     env.duration("RESERVATION_MAX_AHEAD", 7*24*time.Hour),
+		roomInfoEnabled:         env.bool("ROOM_INFO_EMBED", false),
+		roomInfoDebounce:        env.duration("ROOM_INFO_DEBOUNCE", 5*time.Second),
+		safeModeThreshold:       env.int("SAFE_MODE_THRESHOLD", 0),
+		selfRepairPermissions:   env.bool("SELF_REPAIR_PERMISSIONS", false),
+		settingsVoteTimeout:     env.duration("SETTINGS_VOTE_TIMEOUT", 2*time.Minute),
+		stagingGuilds:           parseStagingGuilds(env, "STAGING_GUILDS"),
+		statsSaveDelay:          env.duration("STATS_SAVE_DELAY", 2*time.Second),
+		roomFailureAction:       env.string("ROOM_FAILURE_ACTION", "stay"),
+		roomFailureFallbacks:    env.channelIDs("ROOM_FAILURE_FALLBACK_IDS"),
+		nameSuffix:              env.string("ROOM_NAME_SUFFIX", ""),
+		summonsPerHour:          env.int("SUMMONS_PER_HOUR", 3),
+		guildSummonsPerHour:     parseGuildSummonsPerHour(env),
+		throttleRooms:           env.int("THROTTLE_ROOMS", 0),
+		throttleWindow:          env.duration("THROTTLE_WINDOW", time.Minute),
+		clearServerMutes:        env.bool("CLEAR_SERVER_MUTES", false),
+		clearServerMutesExempt:  env.roleIDs("CLEAR_SERVER_MUTES_EXEMPT_ROLES"),
+		voteKickTimeout:         env.duration("VOTEKICK_TIMEOUT", 2*time.Minute),
+		voteKickBan:             env.duration("VOTEKICK_BAN_DURATION", 15*time.Minute),
+		watchAllowed:            parseWatchActivities(env, "WATCH_ACTIVITIES", env.string("WATCH_ACTIVITIES", "")),
+		guildWatchAllowed:       parseGuildWatchActivities(env),
+
+		voiceLogSummaryInterval:   env.duration("VOICE_LOG_SUMMARY_INTERVAL", time.Minute),
+		latencySummaryInterval:    env.duration("ROOM_LATENCY_SUMMARY_INTERVAL", 5*time.Minute),
+		runtimeReportInterval:     env.duration("RUNTIME_REPORT_INTERVAL", 0),
+		occupancySnapshotInterval: env.duration("OCCUPANCY_SNAPSHOT_INTERVAL", 0),
+		pendingRoomRetryInterval:  env.duration("PENDING_ROOM_RETRY_INTERVAL", 5*time.Second),
+		janitorInterval:           env.duration("JANITOR_INTERVAL", time.Minute),
+		nameSuffixInterval:        env.duration("ROOM_NAME_SUFFIX_INTERVAL", renameWindow+time.Minute),
+		statsRetention:            env.duration("STATS_RETENTION", 0),
+		statsCleanupInterval:      env.duration("STATS_CLEANUP_INTERVAL", time.Hour),
+	}
+	cfg.hubOrphanScanInterval = env.duration("HUB_ORPHAN_SCAN_INTERVAL", cfg.hubOrphanTimeout/2)
+	return cfg
+}
@@ -0,0 +1,220 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/diamondburned/arikawa/v3/utils/json/option"
+)
+
+// Custom IDs of the settings vote buttons.
+const (
+	settingsVoteYesID = "settingsvote-yes"
+	settingsVoteNoID  = "settingsvote-no"
+)
+
+// settingsVote is an open vote on renaming a room or changing its limit.
+type settingsVote struct {
+	starter discord.UserID
+	// name is the proposed name, or empty if limit is proposed instead.
+	name  string
+	limit uint
+	votes map[discord.UserID]bool
+	timer *time.Timer
+}
+
+func (v *settingsVote) proposal() string {
+	switch {
+	case v.name != "":
+		return fmt.Sprintf("rename this room to **%s**", v.name)
+	case v.limit == 0:
+		return "remove this room's user limit"
+	default:
+		return fmt.Sprintf("limit this room to %s", plural(int(v.limit), "member"))
+	}
+}
+
+// cmdVoteRename handles /voice vote rename.
+func (h *handler) cmdVoteRename(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	name := data.Options.Find("name").String()
+	if h.bannedWordIn(name) != "" {
+		return ephemeralData("That name isn't allowed here.")
+	}
+	return h.startSettingsVote(ctx, data, &settingsVote{name: name})
+}
+
+// cmdVoteLimit handles /voice vote limit.
+func (h *handler) cmdVoteLimit(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	users, err := data.Options.Find("users").IntValue()
+	if err != nil || users < 0 || users > 99 {
+		return ephemeralData("Pick a limit from 0 to 99.")
+	}
+	return h.startSettingsVote(ctx, data, &settingsVote{limit: uint(users)})
+}
+
+// startSettingsVote posts a vote on vote's proposal in the sender's room. It
+// passes once more than half of the people in the room agree.
+func (h *handler) startSettingsVote(ctx context.Context, data cmdroute.CommandData, vote *settingsVote) *api.InteractionResponseData {
+	starter := data.Event.SenderID()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	channelID := h.userVoiceStates[starter].ChannelID
+	r, ok := h.rooms[channelID]
+	if !ok {
+		return ephemeralData("You need to be in a room to do that.")
+	}
+	if len(r.settingsVotes) > 0 {
+		return ephemeralData("Wait for the current vote in this room to end first.")
+	}
+
+	vote.starter = starter
+	vote.votes = map[discord.UserID]bool{starter: true}
+	ctx = withGuild(ctx, r.guildID)
+	if h.settingsVotePassed(channelID, vote) {
+		// Alone in the room, there is nobody to ask.
+		if err := h.applySettingsVote(ctx, r, vote); err != nil {
+			slog.ErrorContext(ctx, "Failed to apply room settings", "err", err)
+			return ephemeralData("I couldn't change your room, try again.")
+		}
+		return ephemeralData("Done.")
+	}
+
+	var msgID discord.MessageID
+	err := h.call(ctx, "SendMessage", func(s *state.State) error {
+		msg, err := s.SendMessageComplex(r.chatChannel(), api.SendMessageData{
+			Content:         h.settingsVoteText(channelID, vote),
+			Components:      settingsVoteComponents(),
+			AllowedMentions: &api.AllowedMentions{},
+		})
+		if err != nil {
+			return err
+		}
+		msgID = msg.ID
+		return nil
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to post settings vote", "err", err)
+		return ephemeralData("I couldn't start the vote, try again.")
+	}
+	vote.timer = time.AfterFunc(h.cfg.settingsVoteTimeout, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		if h.rooms[channelID] != r || r.settingsVotes[msgID] != vote {
+			return
+		}
+		delete(r.settingsVotes, msgID)
+		h.endVote(withGuild(context.Background(), r.guildID), r, msgID,
+			"The vote to "+vote.proposal()+" ran out without a majority.")
+	})
+	r.settingsVotes[msgID] = vote
+	return ephemeralData("Vote started.")
+}
+
+// onSettingsVote records a vote and applies the proposal once it has a
+// majority.
+func (h *handler) onSettingsVote(agree bool) cmdroute.ComponentHandlerFunc {
+	return func(ctx context.Context, data cmdroute.ComponentData) *api.InteractionResponse {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		channelID, r := h.roomByChat(data.Event.ChannelID)
+		var vote *settingsVote
+		if r != nil {
+			vote = r.settingsVotes[data.Event.Message.ID]
+		}
+		if vote == nil {
+			return ephemeral("This vote is over.")
+		}
+		voter := data.Event.SenderID()
+		if h.userVoiceStates[voter].ChannelID != channelID {
+			return ephemeral("Only people in this room can vote.")
+		}
+		vote.votes[voter] = agree
+
+		content := h.settingsVoteText(channelID, vote)
+		components := settingsVoteComponents()
+		if h.settingsVotePassed(channelID, vote) {
+			vote.timer.Stop()
+			delete(r.settingsVotes, data.Event.Message.ID)
+			content = "The room agreed to " + vote.proposal() + "."
+			if err := h.applySettingsVote(withGuild(ctx, r.guildID), r, vote); err != nil {
+				slog.ErrorContext(ctx, "Failed to apply room settings", "err", err)
+				content = "The room agreed to " + vote.proposal() + ", but I couldn't do it."
+			}
+			components = discord.ContainerComponents{}
+			h.expireMessage(r, data.Event.Message.ID)
+		}
+		return &api.InteractionResponse{
+			Type: api.UpdateMessage,
+			Data: &api.InteractionResponseData{
+				Content:         option.NewNullableString(content),
+				Components:      &components,
+				AllowedMentions: &api.AllowedMentions{},
+			},
+		}
+	}
+}
+
+// settingsVotePassed reports whether more than half of the people in the
+// room agreed.
+func (h *handler) settingsVotePassed(channelID discord.ChannelID, vote *settingsVote) bool {
+	yes := 0
+	for voter, agree := range vote.votes {
+		if agree && h.userVoiceStates[voter].ChannelID == channelID {
+			yes++
+		}
+	}
+	return yes*2 > h.occupants(channelID)
+}
+
+// applySettingsVote makes the change the room voted for, the same way the
+// owner's /room commands do.
+func (h *handler) applySettingsVote(ctx context.Context, r *room, vote *settingsVote) error {
+	if vote.name != "" {
+		h.renameChannel(ctx, r.roomTarget(), h.decorateName(r.guildID, vote.name))
+		return nil
+	}
+	return h.setRoomLimit(ctx, r, vote.limit, "limit voted by the room")
+}
+
+func (h *handler) settingsVoteText(channelID discord.ChannelID, vote *settingsVote) string {
+	yes, no := 0, 0
+	for voter, agree := range vote.votes {
+		if h.userVoiceStates[voter].ChannelID != channelID {
+			continue
+		}
+		if agree {
+			yes++
+		} else {
+			no++
+		}
+	}
+	return fmt.Sprintf("%s wants to %s; more than half of the people here need to agree.\nYes: %d, no: %d",
+		vote.starter.Mention(), vote.proposal(), yes, no)
+}
+
+func settingsVoteComponents() discord.ContainerComponents {
+	return discord.ContainerComponents{
+		&discord.ActionRowComponent{
+			&discord.ButtonComponent{
+				Style:    discord.SuccessButtonStyle(),
+				CustomID: settingsVoteYesID,
+				Label:    "Yes",
+			},
+			&discord.ButtonComponent{
+				Style:    discord.SecondaryButtonStyle(),
+				CustomID: settingsVoteNoID,
+				Label:    "No",
+			},
+		},
+	}
+}
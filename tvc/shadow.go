@@ -0,0 +1,89 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// newShadowHub builds the shadow configuration of a hub from variables
+// prefixed with <key>_SHADOW, e.g. $BARK_SHADOW_NAME_TEMPLATE, or returns nil
+// if there are none. A shadow configuration is complete on its own: unset
+// shadow variables take their defaults, not the live values.
+func newShadowHub(env *environment, l lobbyConfig) (*hub, error) {
+	prefix := l.Key + "_SHADOW_"
+	for _, kv := range env.environ() {
+		if strings.HasPrefix(kv, prefix) {
+			l.Key += "_SHADOW"
+			return newHub(env, l)
+		}
+	}
+	return nil, nil
+}
+
+// shadowRoom logs how the hub's shadow configuration would have created the
+// room that is being created under the live one, so admins can check a new
+// configuration against real traffic before switching to it.
+func (h *handler) shadowRoom(ctx context.Context, req roomRequest, name string) {
+	shadow := req.hub.shadow
+	if shadow == nil {
+		return
+	}
+	logf := func(format string, args ...any) {
+		slog.Info("Shadow config: "+fmt.Sprintf(format, args...), "guild_id", req.hubChannel.GuildID, "hub", req.hub.label())
+	}
+
+	if len(shadow.requiredRoles) > 0 {
+		member, err := h.s.Member(req.hubChannel.GuildID, req.userID)
+		if err != nil {
+			logf("couldn't check required roles: %v", err)
+		} else {
+			var missing []string
+			for _, roleID := range shadow.requiredRoles {
+				if !hasRole(member.RoleIDs, roleID) {
+					missing = append(missing, h.roleName(req.hubChannel.GuildID, roleID))
+				}
+			}
+			if len(missing) > 0 {
+				logf("would turn %s away for missing %s", req.userID, strings.Join(missing, ", "))
+				return
+			}
+		}
+	}
+
+	shadowReq := req
+	shadowReq.hub = shadow
+	if shadowName := h.roomName(ctx, shadowReq); req.preset == nil && shadowName != name {
+		logf("would name the room %q instead of %q", shadowName, name)
+	}
+	if limit, live := h.userLimit(shadowReq), h.userLimit(req); limit != live {
+		logf("would limit the room to %d instead of %d", limit, live)
+	}
+	if shadow.region != req.hub.region {
+		logf("would use voice region %q instead of %q", shadow.region, req.hub.region)
+	}
+	if shadow.silent != req.hub.silent {
+		logf("would set silent to %t", shadow.silent)
+	}
+	if shadow.greeting != req.hub.greeting || shadow.greetAt != req.hub.greetAt {
+		logf("would greet with %q at %d members", expandTemplate(shadow.greeting, templateData{Host: req.userID.Mention()}), shadow.greetAt)
+	}
+	if !sameChannels(shadow.categories, req.hub.categories) {
+		logf("would spread rooms over categories %v", shadow.categories)
+	}
+}
+
+func sameChannels(a, b []discord.ChannelID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,210 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+// guildMirrors maps staging guilds to the production guilds they mirror.
+type guildMirrors map[discord.GuildID]discord.GuildID
+
+func parseStagingGuilds(env *environment, key string) guildMirrors {
+	guilds := make(guildMirrors)
+	for _, item := range env.list(key) {
+		production, staging, ok := strings.Cut(item, ":")
+		prodID, err1 := discord.ParseSnowflake(production)
+		stagingID, err2 := discord.ParseSnowflake(staging)
+		if !ok || err1 != nil || err2 != nil || prodID == stagingID {
+			env.configError("invalid $%s: %q isn't <production guild ID>:<staging guild ID>", key, item)
+			continue
+		}
+		if _, ok := guilds[discord.GuildID(stagingID)]; ok {
+			env.configError("invalid $%s: guild %s mirrors more than one guild", key, staging)
+			continue
+		}
+		guilds[discord.GuildID(stagingID)] = discord.GuildID(prodID)
+	}
+	return guilds
+}
+
+// productionOf returns the guild guildID mirrors if it is a staging guild,
+// and guildID otherwise.
+func (m guildMirrors) productionOf(guildID discord.GuildID) discord.GuildID {
+	if production, ok := m[guildID]; ok {
+		return production
+	}
+	return guildID
+}
+
+// mirrored returns what stands in for the production channel or role id in
+// guildID: its copy in a staging guild, or id itself.
+func (h *handler) mirrored(guildID discord.GuildID, id discord.Snowflake) discord.Snowflake {
+	if _, ok := h.cfg.stagingGuilds[guildID]; !ok {
+		return id
+	}
+	if copied, ok := h.stats.stagingCopy(guildID, id); ok {
+		return copied
+	}
+	return id
+}
+
+// cmdStagingSync handles /voiceadmin staging sync, run in a staging guild.
+// It creates the production guild's hub channels and their categories, and
+// the roles of the hub permission templates, where the staging guild has none
+// by the same name, and remembers which copy stands in for which original.
+func (h *handler) cmdStagingSync(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	stagingID := data.Event.GuildID
+	productionID, ok := h.cfg.stagingGuilds[stagingID]
+	if !ok {
+		return ephemeralData("This server isn't a staging server. Operators pick those with $STAGING_GUILDS.")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ctx = withGuild(ctx, stagingID)
+	production, err := h.fetchChannels(withGuild(ctx, productionID), productionID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to fetch production channels", "err", err)
+		return ephemeralData("I couldn't fetch the production server's channels, try again.")
+	}
+	staging, err := h.fetchChannels(ctx, stagingID)
+	if err != nil {
+		return ephemeralData("I couldn't fetch this server's channels, try again.")
+	}
+
+	// Hubs matched by name need no copy of their own, but one by channel ID
+	// does, and either needs its category.
+	wanted := make(map[discord.ChannelID]bool)
+	for i, ch := range production {
+		if ch.Type == discord.GuildVoice && h.lookupHub(&production[i]) != nil {
+			wanted[ch.ID] = true
+			if ch.ParentID.IsValid() {
+				wanted[ch.ParentID] = true
+			}
+		}
+	}
+	for _, hub := range h.hubs {
+		if hub.serves(productionID) {
+			for _, id := range hub.categories {
+				wanted[id] = true
+			}
+		}
+	}
+
+	var created, matched []string
+	var failed []string
+	copyChannel := func(ch discord.Channel) {
+		parent := discord.ChannelID(h.mirrored(stagingID, discord.Snowflake(ch.ParentID)))
+		i := slices.IndexFunc(staging, func(other discord.Channel) bool {
+			return other.Type == ch.Type && other.Name == ch.Name && (ch.Type == discord.GuildCategory || other.ParentID == parent)
+		})
+		if i >= 0 {
+			h.stats.setStagingCopy(stagingID, discord.Snowflake(ch.ID), discord.Snowflake(staging[i].ID))
+			matched = append(matched, staging[i].Mention())
+			return
+		}
+		var copied *discord.Channel
+		err := h.call(ctx, "CreateChannel", func(s *state.State) (err error) {
+			copied, err = s.CreateChannel(stagingID, api.CreateChannelData{
+				Name:           ch.Name,
+				Type:           ch.Type,
+				CategoryID:     parent,
+				AuditLogReason: "staging sync",
+			})
+			return err
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to copy channel to staging", "channel_id", ch.ID, "err", err)
+			failed = append(failed, ch.Name)
+			return
+		}
+		staging = append(staging, *copied)
+		h.stats.setStagingCopy(stagingID, discord.Snowflake(ch.ID), discord.Snowflake(copied.ID))
+		created = append(created, copied.Mention())
+	}
+	// Categories go first so the channels in them can be put in the copies.
+	for _, ch := range production {
+		if wanted[ch.ID] && ch.Type == discord.GuildCategory {
+			copyChannel(ch)
+		}
+	}
+	for _, ch := range production {
+		if wanted[ch.ID] && ch.Type != discord.GuildCategory {
+			copyChannel(ch)
+		}
+	}
+
+	roles, err := h.s.Roles(stagingID)
+	if err != nil {
+		return ephemeralData("I couldn't look up this server's roles, try again.")
+	}
+	copiedRoles := make(map[discord.Snowflake]bool)
+	for _, hub := range h.hubs {
+		if !hub.serves(productionID) {
+			continue
+		}
+		for _, o := range hub.permissions {
+			role, err := h.s.Role(productionID, discord.RoleID(o.ID))
+			if err != nil || copiedRoles[o.ID] {
+				continue
+			}
+			copiedRoles[o.ID] = true
+			if i := slices.IndexFunc(roles, func(other discord.Role) bool { return other.Name == role.Name }); i >= 0 {
+				h.stats.setStagingCopy(stagingID, o.ID, discord.Snowflake(roles[i].ID))
+				matched = append(matched, roles[i].Mention())
+				continue
+			}
+			roleData := api.CreateRoleData{
+				Name:        role.Name,
+				Permissions: role.Permissions,
+				Color:       role.Color,
+			}
+			roleData.AuditLogReason = "staging sync"
+			var copied *discord.Role
+			err = h.call(ctx, "CreateRole", func(s *state.State) (err error) {
+				copied, err = s.CreateRole(stagingID, roleData)
+				return err
+			})
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to copy role to staging", "role_id", role.ID, "err", err)
+				failed = append(failed, "@"+role.Name)
+				continue
+			}
+			roles = append(roles, *copied)
+			h.stats.setStagingCopy(stagingID, o.ID, discord.Snowflake(copied.ID))
+			created = append(created, copied.Mention())
+		}
+	}
+	slog.InfoContext(ctx, "Staging synced", "production_guild_id", productionID, "user_id", data.Event.SenderID(), "created", len(created), "matched", len(matched), "failed", len(failed))
+
+	var b strings.Builder
+	b.WriteString("Synced the production server's hubs here.")
+	if len(created) > 0 {
+		b.WriteString("\nCreated: " + strings.Join(created, ", "))
+	}
+	if len(matched) > 0 {
+		b.WriteString("\nAlready here: " + strings.Join(matched, ", "))
+	}
+	if len(failed) > 0 {
+		b.WriteString("\n⚠️ Couldn't create: " + strings.Join(failed, ", "))
+	}
+	if len(created)+len(matched)+len(failed) == 0 {
+		b.WriteString(" There was nothing to copy.")
+	}
+	content := b.String()
+	if len(content) > 1900 {
+		content = content[:strings.LastIndexByte(content[:1900], ' ')] + fmt.Sprintf(" … (%d in all)", len(created)+len(matched)+len(failed))
+	}
+	resp := ephemeralData(content)
+	resp.AllowedMentions = &api.AllowedMentions{}
+	return resp
+}
@@ -0,0 +1,1071 @@
+package tvc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// creationRecord is a room the bot created, kept after the room is deleted
+// so abuse reports can be looked into later.
+type creationRecord struct {
+	GuildID      discord.GuildID   `json:"guild_id"`
+	ChannelID    discord.ChannelID `json:"channel_id"`
+	OwnerID      discord.UserID    `json:"owner_id"`
+	Hub          string            `json:"hub"`
+	Name         string            `json:"name"`
+	CreatedAt    time.Time         `json:"created_at"`
+	DeletedAt    time.Time         `json:"deleted_at"`
+	PeakMembers  int               `json:"peak_members"`
+	Participants []discord.UserID  `json:"participants,omitempty"`
+	// Ancestors are the channels the room lived in before ChannelID, oldest
+	// first, e.g. ones failover replaced. The room stays one session across
+	// them.
+	Ancestors []discord.ChannelID `json:"ancestors,omitempty"`
+	// Notes are left by moderators and never shown in Discord outside
+	// /voiceadmin.
+	Notes []roomNote `json:"notes,omitempty"`
+	// Renames are the names the room went by after Name, oldest first, up
+	// to maxRenames of the latest.
+	Renames []roomRename `json:"renames,omitempty"`
+}
+
+// roomRename is a name a room was given.
+type roomRename struct {
+	Name string    `json:"name"`
+	At   time.Time `json:"at"`
+}
+
+// maxRenames is how many renames a room's record keeps.
+const maxRenames = 50
+
+// roomNote is a moderator's note on a room.
+type roomNote struct {
+	By   discord.UserID `json:"by"`
+	At   time.Time      `json:"at"`
+	Text string         `json:"text"`
+}
+
+// hubBan bars a member from creating rooms in a guild.
+type hubBan struct {
+	GuildID  discord.GuildID `json:"guild_id"`
+	UserID   discord.UserID  `json:"user_id"`
+	BannedBy discord.UserID  `json:"banned_by"`
+	BannedAt time.Time       `json:"banned_at"`
+}
+
+// roomBan keeps a member out of every room an owner creates in a guild.
+type roomBan struct {
+	GuildID discord.GuildID `json:"guild_id"`
+	OwnerID discord.UserID  `json:"owner_id"`
+	UserID  discord.UserID  `json:"user_id"`
+}
+
+// statsStore keeps usage and moderation records. It is saved as JSON to its
+// storage shortly after every change; without one, records only live as long
+// as the process.
+type statsStore struct {
+	storage   Storage
+	saveDelay time.Duration // see settings.statsSaveDelay
+	// saving serializes writes to storage, so an older document can't
+	// replace a newer one.
+	saving sync.Mutex
+
+	mu sync.Mutex
+	// flushTimer is the pending write of the changes made since the last
+	// one, nil if there are none.
+	flushTimer *time.Timer
+	Creations  []creationRecord `json:"creations"`
+	HubBans    []hubBan         `json:"hub_bans"`
+	RoomBans   []roomBan        `json:"room_bans"`
+	// Onboarded lists the guilds that were sent the setup message.
+	Onboarded []discord.GuildID `json:"onboarded"`
+	// Private lists the users who opted out of activity tracking.
+	Private []discord.UserID `json:"private"`
+	// Summaries lists the users who want a DM when their rooms are deleted.
+	Summaries []discord.UserID `json:"summaries"`
+	// Maintenance lists the guilds where room creation is paused.
+	Maintenance []discord.GuildID `json:"maintenance"`
+	// Observing lists the guilds in observer mode; see observeNewGuilds.
+	Observing []discord.GuildID `json:"observing,omitempty"`
+	// Killed lists the guilds where the kill switch is on; KilledAll is the
+	// global kill switch.
+	Killed    []discord.GuildID `json:"killed"`
+	KilledAll bool              `json:"killed_all"`
+	// FeatureDefaults are feature defaults changed by operators, on top of
+	// $FEATURES.
+	FeatureDefaults map[feature]bool `json:"feature_defaults"`
+	// RoomNumbers is the last {number} given out, by guild and hub, so
+	// numbering carries on after a restart.
+	RoomNumbers map[string]int `json:"room_numbers"`
+	// Tracked lists the rooms that exist right now, so they are picked up
+	// again after a restart.
+	Tracked []trackedRoom `json:"tracked"`
+	// Occupancy holds periodic snapshots of how busy rooms were, and
+	// OccupancyTicks when each round of them was taken, including rounds
+	// where no guild had rooms.
+	Occupancy      []occupancySnapshot `json:"occupancy"`
+	OccupancyTicks []time.Time         `json:"occupancy_ticks,omitempty"`
+	// Profiles are the active profile of each guild that switched to one.
+	Profiles map[discord.GuildID]string `json:"profiles,omitempty"`
+	// ScheduledProfiles are profile switches waiting for their time, at most
+	// one per guild.
+	ScheduledProfiles []scheduledProfile `json:"scheduled_profiles,omitempty"`
+	// RulesAccepted is when members accepted hub rules in each guild; see
+	// hub.rules.
+	RulesAccepted map[discord.GuildID]map[discord.UserID]time.Time `json:"rules_accepted,omitempty"`
+	// Reservations are the rooms reserved for later; see reservation.
+	Reservations []reservation `json:"reservations,omitempty"`
+	// Frozen are the rooms moderators froze, as they were when frozen.
+	Frozen map[discord.ChannelID]roomSnapshot `json:"frozen,omitempty"`
+	// Staging maps the production channels and roles copied to each staging
+	// guild to their copies; see stagingGuilds.
+	Staging map[discord.GuildID]map[discord.Snowflake]discord.Snowflake `json:"staging,omitempty"`
+	// RejoinGaps holds, per guild, how long after rooms emptied people came
+	// back, oldest first; see adaptiveGrace.
+	RejoinGaps map[discord.GuildID][]time.Duration `json:"rejoin_gaps,omitempty"`
+	// External holds the IDs of the counterparts of team rooms on other
+	// services, by the room's category and connector; see connector.
+	External map[discord.ChannelID]map[string]string `json:"external,omitempty"`
+	// PendingDeletes are the failed channel deletions the janitor retries.
+	PendingDeletes map[discord.ChannelID]deleteRetry `json:"pending_deletes,omitempty"`
+}
+
+func newStatsStore(storage Storage) (*statsStore, error) {
+	st := &statsStore{storage: storage}
+	if storage == nil {
+		return st, nil
+	}
+
+	b, err := storage.Load()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read stats: %w", err)
+	}
+	if b == nil {
+		return st, nil
+	}
+	if err := json.Unmarshal(b, st); err != nil {
+		return nil, fmt.Errorf("cannot parse stats: %w", err)
+	}
+	return st, nil
+}
+
+// recordCreation stores that a room was created.
+func (st *statsStore) recordCreation(rec creationRecord) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.Creations = append(st.Creations, rec)
+	st.save()
+}
+
+// creation returns the record of the room channelID, or nil.
+func (st *statsStore) creation(channelID discord.ChannelID) *creationRecord {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if rec := st.findCreation(channelID); rec != nil {
+		copied := *rec
+		copied.Participants = append([]discord.UserID(nil), rec.Participants...)
+		copied.Ancestors = append([]discord.ChannelID(nil), rec.Ancestors...)
+		copied.Notes = append([]roomNote(nil), rec.Notes...)
+		copied.Renames = append([]roomRename(nil), rec.Renames...)
+		return &copied
+	}
+	return nil
+}
+
+// recordParticipant notes that userID joined the room channelID.
+func (st *statsStore) recordParticipant(channelID discord.ChannelID, userID discord.UserID) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	rec := st.findCreation(channelID)
+	if rec == nil {
+		return
+	}
+	for _, id := range rec.Participants {
+		if id == userID {
+			return
+		}
+	}
+	rec.Participants = append(rec.Participants, userID)
+	st.save()
+}
+
+// addNote attaches a moderator's note to the room channelID, reporting
+// whether there is a record of it.
+func (st *statsStore) addNote(channelID discord.ChannelID, note roomNote) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	rec := st.findCreation(channelID)
+	if rec == nil {
+		return false
+	}
+	rec.Notes = append(rec.Notes, note)
+	st.save()
+	return true
+}
+
+// recordRename notes that the room channelID was renamed to name.
+func (st *statsStore) recordRename(channelID discord.ChannelID, name string, at time.Time) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	rec := st.findCreation(channelID)
+	if rec == nil {
+		return
+	}
+	rec.Renames = append(rec.Renames, roomRename{Name: name, At: at})
+	if n := len(rec.Renames); n > maxRenames {
+		rec.Renames = slices.Delete(rec.Renames, 0, n-maxRenames)
+	}
+	st.save()
+}
+
+// recordDeletion notes that the room channelID was deleted, having held at
+// most peak members at once.
+func (st *statsStore) recordDeletion(channelID discord.ChannelID, at time.Time, peak int) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if rec := st.findCreation(channelID); rec != nil && rec.DeletedAt.IsZero() {
+		rec.DeletedAt = at
+		rec.PeakMembers = peak
+		st.save()
+	}
+}
+
+// purge drops the records of rooms deleted before cutoff. Records of rooms
+// that still exist are kept however old they are. Occupancy snapshots have a
+// retention of their own; see occupancyRetention. Hub bans are moderation decisions rather than usage
+// data and are never purged.
+func (st *statsStore) purge(cutoff time.Time) int {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	kept := st.Creations[:0]
+	for _, rec := range st.Creations {
+		if !rec.DeletedAt.IsZero() && rec.DeletedAt.Before(cutoff) {
+			continue
+		}
+		kept = append(kept, rec)
+	}
+	purged := len(st.Creations) - len(kept)
+	clear(st.Creations[len(kept):])
+	st.Creations = kept
+	if purged > 0 {
+		st.save()
+	}
+	return purged
+}
+
+// cleanup purges records older than retention every interval until ctx is
+// done.
+func (st *statsStore) cleanup(ctx context.Context, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if n := st.purge(time.Now().Add(-retention)); n > 0 {
+			slog.Info("Purged old room records", "records", n, "retention", retention)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// recordOccupancy stores the occupancy snapshots taken at at, dropping
+// those older than retention unless it is zero.
+func (st *statsStore) recordOccupancy(at time.Time, snaps []occupancySnapshot, retention time.Duration) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.Occupancy = append(st.Occupancy, snaps...)
+	st.OccupancyTicks = append(st.OccupancyTicks, at)
+	if retention > 0 {
+		cutoff := at.Add(-retention)
+		st.Occupancy = slices.DeleteFunc(st.Occupancy, func(snap occupancySnapshot) bool { return snap.At.Before(cutoff) })
+		st.OccupancyTicks = slices.DeleteFunc(st.OccupancyTicks, func(t time.Time) bool { return t.Before(cutoff) })
+	}
+	st.save()
+}
+
+// occupancy returns when snapshots were taken and the snapshots of guildID,
+// or of every guild if guildID is 0.
+func (st *statsStore) occupancy(guildID discord.GuildID) ([]time.Time, []occupancySnapshot) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	var snaps []occupancySnapshot
+	for _, snap := range st.Occupancy {
+		if snap.GuildID == guildID || !guildID.IsValid() {
+			snaps = append(snaps, snap)
+		}
+	}
+	return slices.Clone(st.OccupancyTicks), snaps
+}
+
+// track records a live room, replacing what was recorded about it before.
+func (st *statsStore) track(tr trackedRoom) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	for i := range st.Tracked {
+		if st.Tracked[i].ChannelID == tr.ChannelID {
+			st.Tracked[i] = tr
+			st.save()
+			return
+		}
+	}
+	st.Tracked = append(st.Tracked, tr)
+	st.save()
+}
+
+// untrack forgets the live room channelID.
+func (st *statsStore) untrack(channelID discord.ChannelID) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	for i := range st.Tracked {
+		if st.Tracked[i].ChannelID == channelID {
+			st.Tracked = append(st.Tracked[:i], st.Tracked[i+1:]...)
+			st.save()
+			return
+		}
+	}
+}
+
+// trackedIn lists the live rooms recorded in guildID.
+func (st *statsStore) trackedIn(guildID discord.GuildID) []trackedRoom {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	var rooms []trackedRoom
+	for _, tr := range st.Tracked {
+		if tr.GuildID == guildID {
+			rooms = append(rooms, tr)
+		}
+	}
+	return rooms
+}
+
+// recorded counts the rooms on record.
+func (st *statsStore) recorded() int {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return len(st.Creations)
+}
+
+// findCreation returns the latest record of channelID. st.mu must be held.
+func (st *statsStore) findCreation(channelID discord.ChannelID) *creationRecord {
+	for i := len(st.Creations) - 1; i >= 0; i-- {
+		if rec := &st.Creations[i]; rec.ChannelID == channelID || slices.Contains(rec.Ancestors, channelID) {
+			return rec
+		}
+	}
+	return nil
+}
+
+// recordSuccession notes that the room in oldID moved on to newID, so its
+// record, and stats built from it, keep covering both channels as one
+// session.
+func (st *statsStore) recordSuccession(oldID, newID discord.ChannelID) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	rec := st.findCreation(oldID)
+	if rec == nil || rec.ChannelID != oldID {
+		return
+	}
+	rec.Ancestors = append(rec.Ancestors, oldID)
+	rec.ChannelID = newID
+	st.save()
+}
+
+// creationsSince counts the rooms userID created in guildID since t.
+func (st *statsStore) creationsSince(guildID discord.GuildID, userID discord.UserID, t time.Time) (n int, oldest time.Time) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	for _, rec := range st.Creations {
+		if rec.GuildID != guildID || rec.OwnerID != userID || rec.CreatedAt.Before(t) {
+			continue
+		}
+		if n == 0 || rec.CreatedAt.Before(oldest) {
+			oldest = rec.CreatedAt
+		}
+		n++
+	}
+	return n, oldest
+}
+
+// userStats sums up the rooms a user created and joined.
+type userStats struct {
+	created, joined int
+	hosted          time.Duration
+}
+
+// userStats returns the usage of userID. Rooms still open count as hosted up
+// to now.
+func (st *statsStore) userStats(userID discord.UserID) userStats {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	var us userStats
+	for _, rec := range st.Creations {
+		if rec.OwnerID == userID {
+			us.created++
+			end := rec.DeletedAt
+			if end.IsZero() {
+				end = time.Now()
+			}
+			us.hosted += end.Sub(rec.CreatedAt)
+			continue
+		}
+		for _, id := range rec.Participants {
+			if id == userID {
+				us.joined++
+				break
+			}
+		}
+	}
+	return us
+}
+
+// banFromHubs records a hub ban. Banning someone twice is a no-op.
+func (st *statsStore) banFromHubs(ban hubBan) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	for _, b := range st.HubBans {
+		if b.GuildID == ban.GuildID && b.UserID == ban.UserID {
+			return
+		}
+	}
+	st.HubBans = append(st.HubBans, ban)
+	st.save()
+}
+
+// hubBanned reports whether userID is banned from the guild's hubs.
+func (st *statsStore) hubBanned(guildID discord.GuildID, userID discord.UserID) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	for _, b := range st.HubBans {
+		if b.GuildID == guildID && b.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// banFromRooms records a room ban. Banning someone twice is a no-op.
+func (st *statsStore) banFromRooms(ban roomBan) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	for _, b := range st.RoomBans {
+		if b == ban {
+			return
+		}
+	}
+	st.RoomBans = append(st.RoomBans, ban)
+	st.save()
+}
+
+// unbanFromRooms lifts a room ban, reporting whether there was one.
+func (st *statsStore) unbanFromRooms(guildID discord.GuildID, ownerID, userID discord.UserID) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	for i, b := range st.RoomBans {
+		if b == (roomBan{guildID, ownerID, userID}) {
+			st.RoomBans = append(st.RoomBans[:i], st.RoomBans[i+1:]...)
+			st.save()
+			return true
+		}
+	}
+	return false
+}
+
+// roomBansOf lists who ownerID banned from their rooms in guildID.
+func (st *statsStore) roomBansOf(guildID discord.GuildID, ownerID discord.UserID) []discord.UserID {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	var users []discord.UserID
+	for _, b := range st.RoomBans {
+		if b.GuildID == guildID && b.OwnerID == ownerID {
+			users = append(users, b.UserID)
+		}
+	}
+	return users
+}
+
+// markOnboarded records that guildID was onboarded, reporting false if it
+// already was.
+func (st *statsStore) markOnboarded(guildID discord.GuildID) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	for _, id := range st.Onboarded {
+		if id == guildID {
+			return false
+		}
+	}
+	st.Onboarded = append(st.Onboarded, guildID)
+	st.save()
+	return true
+}
+
+// setPrivate records whether userID opted out of activity tracking.
+func (st *statsStore) setPrivate(userID discord.UserID, private bool) {
+	st.setListed(&st.Private, userID, private)
+}
+
+// private reports whether userID opted out of activity tracking.
+func (st *statsStore) private(userID discord.UserID) bool {
+	return st.listed(&st.Private, userID)
+}
+
+// setWantsSummary records whether userID wants summaries of their rooms.
+func (st *statsStore) setWantsSummary(userID discord.UserID, wants bool) {
+	st.setListed(&st.Summaries, userID, wants)
+}
+
+// wantsSummary reports whether userID wants summaries of their rooms.
+func (st *statsStore) wantsSummary(userID discord.UserID) bool {
+	return st.listed(&st.Summaries, userID)
+}
+
+// setMaintenance records whether room creation is paused in guildID.
+func (st *statsStore) setMaintenance(guildID discord.GuildID, on bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	for i, id := range st.Maintenance {
+		if id == guildID {
+			if !on {
+				st.Maintenance = append(st.Maintenance[:i], st.Maintenance[i+1:]...)
+				st.save()
+			}
+			return
+		}
+	}
+	if on {
+		st.Maintenance = append(st.Maintenance, guildID)
+		st.save()
+	}
+}
+
+// inMaintenance reports whether room creation is paused in guildID, or in
+// any guild if guildID is 0.
+func (st *statsStore) inMaintenance(guildID discord.GuildID) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	for _, id := range st.Maintenance {
+		if id == guildID || !guildID.IsValid() {
+			return true
+		}
+	}
+	return false
+}
+
+// setActiveProfile records the guild's active profile.
+func (st *statsStore) setActiveProfile(guildID discord.GuildID, name string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.Profiles == nil {
+		st.Profiles = make(map[discord.GuildID]string)
+	}
+	st.Profiles[guildID] = name
+	st.save()
+}
+
+// activeProfile returns the name of the guild's active profile, or "".
+func (st *statsStore) activeProfile(guildID discord.GuildID) string {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.Profiles[guildID]
+}
+
+// scheduleProfile records a profile switch, replacing the guild's earlier
+// one.
+func (st *statsStore) scheduleProfile(sp scheduledProfile) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.ScheduledProfiles = slices.DeleteFunc(st.ScheduledProfiles, func(other scheduledProfile) bool { return other.GuildID == sp.GuildID })
+	st.ScheduledProfiles = append(st.ScheduledProfiles, sp)
+	st.save()
+}
+
+// acceptRules records that userID accepted the rules of the guild's hubs.
+func (st *statsStore) acceptRules(guildID discord.GuildID, userID discord.UserID, at time.Time) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.RulesAccepted == nil {
+		st.RulesAccepted = make(map[discord.GuildID]map[discord.UserID]time.Time)
+	}
+	if st.RulesAccepted[guildID] == nil {
+		st.RulesAccepted[guildID] = make(map[discord.UserID]time.Time)
+	}
+	st.RulesAccepted[guildID][userID] = at
+	st.save()
+}
+
+// acceptedRules reports whether userID accepted the rules in the guild.
+func (st *statsStore) acceptedRules(guildID discord.GuildID, userID discord.UserID) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	_, ok := st.RulesAccepted[guildID][userID]
+	return ok
+}
+
+// reserve records a room reservation.
+func (st *statsStore) reserve(res reservation) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.Reservations = append(st.Reservations, res)
+	st.save()
+}
+
+// reservationFor returns the guild's reservation of name, matched
+// case-insensitively, or nil if there is none.
+func (st *statsStore) reservationFor(guildID discord.GuildID, name string) *reservation {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	for _, res := range st.Reservations {
+		if res.GuildID == guildID && strings.EqualFold(res.Name, name) {
+			return &res
+		}
+	}
+	return nil
+}
+
+// reservationsOf returns userID's reservations in the guild.
+func (st *statsStore) reservationsOf(guildID discord.GuildID, userID discord.UserID) []reservation {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	var out []reservation
+	for _, res := range st.Reservations {
+		if res.GuildID == guildID && res.OwnerID == userID {
+			out = append(out, res)
+		}
+	}
+	return out
+}
+
+// cancelReservation removes userID's reservation of name in the guild,
+// reporting whether they had one.
+func (st *statsStore) cancelReservation(guildID discord.GuildID, userID discord.UserID, name string) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	n := len(st.Reservations)
+	st.Reservations = slices.DeleteFunc(st.Reservations, func(res reservation) bool {
+		return res.GuildID == guildID && res.OwnerID == userID && strings.EqualFold(res.Name, name)
+	})
+	if len(st.Reservations) == n {
+		return false
+	}
+	st.save()
+	return true
+}
+
+// dueReservations removes and returns the reservations due by now.
+func (st *statsStore) dueReservations(now time.Time) []reservation {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	var due []reservation
+	st.Reservations = slices.DeleteFunc(st.Reservations, func(res reservation) bool {
+		if res.At.After(now) {
+			return false
+		}
+		due = append(due, res)
+		return true
+	})
+	if len(due) > 0 {
+		st.save()
+	}
+	return due
+}
+
+// dueProfiles removes and returns the profile switches due by now.
+func (st *statsStore) dueProfiles(now time.Time) []scheduledProfile {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	var due []scheduledProfile
+	st.ScheduledProfiles = slices.DeleteFunc(st.ScheduledProfiles, func(sp scheduledProfile) bool {
+		if sp.At.After(now) {
+			return false
+		}
+		due = append(due, sp)
+		return true
+	})
+	if len(due) > 0 {
+		st.save()
+	}
+	return due
+}
+
+// setFrozen records a frozen room.
+func (st *statsStore) setFrozen(snap roomSnapshot) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.Frozen == nil {
+		st.Frozen = make(map[discord.ChannelID]roomSnapshot)
+	}
+	st.Frozen[snap.ChannelID] = snap
+	st.save()
+}
+
+// frozen returns the snapshot of the room in channelID if it is frozen, or
+// nil.
+func (st *statsStore) frozen(channelID discord.ChannelID) *roomSnapshot {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	snap, ok := st.Frozen[channelID]
+	if !ok {
+		return nil
+	}
+	return &snap
+}
+
+// dropFrozen forgets that the room in channelID is frozen.
+func (st *statsStore) dropFrozen(channelID discord.ChannelID) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if _, ok := st.Frozen[channelID]; ok {
+		delete(st.Frozen, channelID)
+		st.save()
+	}
+}
+
+// setStagingCopy records that copied stands in for the production channel or
+// role id in the staging guild.
+func (st *statsStore) setStagingCopy(guildID discord.GuildID, id, copied discord.Snowflake) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.Staging == nil {
+		st.Staging = make(map[discord.GuildID]map[discord.Snowflake]discord.Snowflake)
+	}
+	copies := st.Staging[guildID]
+	if copies == nil {
+		copies = make(map[discord.Snowflake]discord.Snowflake)
+		st.Staging[guildID] = copies
+	}
+	if copies[id] != copied {
+		copies[id] = copied
+		st.save()
+	}
+}
+
+// stagingCopy returns what stands in for the production channel or role id
+// in the staging guild, if anything does.
+func (st *statsStore) stagingCopy(guildID discord.GuildID, id discord.Snowflake) (discord.Snowflake, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	copied, ok := st.Staging[guildID][id]
+	return copied, ok
+}
+
+// recordRejoinGap records a rejoin gap after a room emptied in guildID,
+// keeping the latest maxRejoinGaps.
+func (st *statsStore) recordRejoinGap(guildID discord.GuildID, gap time.Duration) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.RejoinGaps == nil {
+		st.RejoinGaps = make(map[discord.GuildID][]time.Duration)
+	}
+	gaps := append(st.RejoinGaps[guildID], gap)
+	if len(gaps) > maxRejoinGaps {
+		gaps = slices.Delete(gaps, 0, len(gaps)-maxRejoinGaps)
+	}
+	st.RejoinGaps[guildID] = gaps
+	st.save()
+}
+
+// rejoinGaps returns a copy of the rejoin gaps recorded in guildID.
+func (st *statsStore) rejoinGaps(guildID discord.GuildID) []time.Duration {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	return slices.Clone(st.RejoinGaps[guildID])
+}
+
+// setExternal records the ID of the team room's counterpart made by the
+// named connector.
+func (st *statsStore) setExternal(category discord.ChannelID, connector, id string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.External == nil {
+		st.External = make(map[discord.ChannelID]map[string]string)
+	}
+	if st.External[category] == nil {
+		st.External[category] = make(map[string]string)
+	}
+	st.External[category][connector] = id
+	st.save()
+}
+
+// takeExternal returns and forgets the counterparts of the team room.
+func (st *statsStore) takeExternal(category discord.ChannelID) map[string]string {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	external, ok := st.External[category]
+	if ok {
+		delete(st.External, category)
+		st.save()
+	}
+	return external
+}
+
+// setPendingDelete records the state of a deletion being retried.
+func (st *statsStore) setPendingDelete(channelID discord.ChannelID, retry deleteRetry) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.PendingDeletes == nil {
+		st.PendingDeletes = make(map[discord.ChannelID]deleteRetry)
+	}
+	st.PendingDeletes[channelID] = retry
+	st.save()
+}
+
+// dropPendingDelete forgets a deletion that went through or was abandoned.
+func (st *statsStore) dropPendingDelete(channelID discord.ChannelID) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	delete(st.PendingDeletes, channelID)
+	st.save()
+}
+
+// pendingDeletes returns the deletions being retried.
+func (st *statsStore) pendingDeletes() map[discord.ChannelID]deleteRetry {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return maps.Clone(st.PendingDeletes)
+}
+
+// setObserving records whether guildID is in observer mode.
+func (st *statsStore) setObserving(guildID discord.GuildID, on bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	i := slices.Index(st.Observing, guildID)
+	switch {
+	case on && i < 0:
+		st.Observing = append(st.Observing, guildID)
+	case !on && i >= 0:
+		st.Observing = slices.Delete(st.Observing, i, i+1)
+	default:
+		return
+	}
+	st.save()
+}
+
+// isObserving reports whether guildID is in observer mode.
+func (st *statsStore) isObserving(guildID discord.GuildID) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return slices.Contains(st.Observing, guildID)
+}
+
+// setKilled flips the kill switch of guildID, or the global one if guildID
+// is 0.
+func (st *statsStore) setKilled(guildID discord.GuildID, on bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if !guildID.IsValid() {
+		st.KilledAll = on
+		st.save()
+		return
+	}
+	for i, id := range st.Killed {
+		if id == guildID {
+			if !on {
+				st.Killed = append(st.Killed[:i], st.Killed[i+1:]...)
+				st.save()
+			}
+			return
+		}
+	}
+	if on {
+		st.Killed = append(st.Killed, guildID)
+		st.save()
+	}
+}
+
+// isKilled reports whether the global kill switch or that of guildID is on.
+// If guildID is 0, only the global one counts.
+func (st *statsStore) isKilled(guildID discord.GuildID) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.KilledAll {
+		return true
+	}
+	for _, id := range st.Killed {
+		if id == guildID && guildID.IsValid() {
+			return true
+		}
+	}
+	return false
+}
+
+// nextRoomNumber counts a new room of hub in guildID and returns its number,
+// starting at 1.
+func (st *statsStore) nextRoomNumber(guildID discord.GuildID, hub string) int {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.RoomNumbers == nil {
+		st.RoomNumbers = make(map[string]int)
+	}
+	key := guildID.String() + "/" + hub
+	st.RoomNumbers[key]++
+	st.save()
+	return st.RoomNumbers[key]
+}
+
+// setFeatureDefault records an operator's change to a feature default.
+func (st *statsStore) setFeatureDefault(feat feature, on bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.FeatureDefaults == nil {
+		st.FeatureDefaults = make(map[feature]bool)
+	}
+	st.FeatureDefaults[feat] = on
+	st.save()
+}
+
+// featureDefaults returns the feature defaults changed by operators.
+func (st *statsStore) featureDefaults() map[feature]bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	defaults := make(map[feature]bool, len(st.FeatureDefaults))
+	for feat, on := range st.FeatureDefaults {
+		defaults[feat] = on
+	}
+	return defaults
+}
+
+// setListed adds userID to or removes it from a list of users.
+func (st *statsStore) setListed(list *[]discord.UserID, userID discord.UserID, on bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	for i, id := range *list {
+		if id == userID {
+			if !on {
+				*list = append((*list)[:i], (*list)[i+1:]...)
+				st.save()
+			}
+			return
+		}
+	}
+	if on {
+		*list = append(*list, userID)
+		st.save()
+	}
+}
+
+// listed reports whether userID is in a list of users. The list is passed
+// by pointer so it is only read with st.mu held.
+func (st *statsStore) listed(list *[]discord.UserID, userID discord.UserID) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	for _, id := range *list {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// save writes the store to its storage once the save delay is up, along with
+// any other changes made until then. Errors are logged; the records stay in
+// memory. st.mu must be held.
+func (st *statsStore) save() {
+	if st.storage == nil {
+		return
+	}
+	if st.saveDelay <= 0 {
+		st.write()
+		return
+	}
+	if st.flushTimer == nil {
+		st.flushTimer = time.AfterFunc(st.saveDelay, st.flush)
+	}
+}
+
+// flush writes the changes waiting for the save delay right away.
+func (st *statsStore) flush() {
+	st.saving.Lock()
+	defer st.saving.Unlock()
+
+	st.mu.Lock()
+	if st.flushTimer == nil {
+		st.mu.Unlock()
+		return
+	}
+	st.flushTimer.Stop()
+	st.flushTimer = nil
+	b, err := json.Marshal(st)
+	st.mu.Unlock()
+
+	st.store(b, err)
+}
+
+// write encodes and stores the document at once. st.mu must be held.
+func (st *statsStore) write() {
+	b, err := json.Marshal(st)
+	st.store(b, err)
+}
+
+func (st *statsStore) store(b []byte, err error) {
+	if err != nil {
+		slog.Error("Failed to encode stats", "err", err)
+		return
+	}
+	if err := st.storage.Save(b); err != nil {
+		slog.Error("Failed to save stats", "err", err)
+	}
+}
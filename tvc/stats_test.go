@@ -0,0 +1,47 @@
+package tvc
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+func TestCreationIsACopy(t *testing.T) {
+	st, _ := newStatsStore(nil)
+	st.recordCreation(creationRecord{ChannelID: 1})
+	st.recordRename(1, "first", time.Now())
+
+	rec := st.creation(1)
+	rec.Renames[0].Name = "changed"
+	for range maxRenames {
+		st.recordRename(1, "later", time.Now())
+	}
+	if rec.Renames[0].Name != "changed" || st.creation(1).Renames[0].Name == "changed" {
+		t.Error("the record returned shares its renames with the store")
+	}
+}
+
+func TestListsReadUnderLock(t *testing.T) {
+	st, _ := newStatsStore(nil)
+	var wg sync.WaitGroup
+	for i := range 10 {
+		userID := discord.UserID(i + 1)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			st.setPrivate(userID, true)
+			st.setWantsSummary(userID, true)
+		}()
+		go func() {
+			defer wg.Done()
+			st.private(userID)
+			st.wantsSummary(userID)
+		}()
+	}
+	wg.Wait()
+	if !st.private(1) || !st.wantsSummary(10) {
+		t.Error("users added to the lists aren't in them")
+	}
+}
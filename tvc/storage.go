@@ -0,0 +1,48 @@
+package tvc
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Storage persists the records and settings the bot keeps, such as room
+// history, bans and maintenance mode, as one JSON document.
+type Storage interface {
+	// Load returns the document last saved, or nil if there is none yet.
+	Load() ([]byte, error)
+	// Save replaces the document.
+	Save(b []byte) error
+}
+
+// FileStorage stores the document in a file at the given path.
+type FileStorage string
+
+// Load implements Storage.
+func (path FileStorage) Load() ([]byte, error) {
+	b, err := os.ReadFile(string(path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return b, err
+}
+
+// Save implements Storage. The file is replaced atomically, so a crash never
+// leaves half a document behind.
+func (path FileStorage) Save(b []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(string(path)), ".stats-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), string(path))
+}
@@ -0,0 +1,42 @@
+package tvc
+
+import (
+	"context"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// strandMember deals with a member left in the hub after their room failed to
+// be created, telling them what happened first.
+func (h *handler) strandMember(ctx context.Context, req roomRequest) {
+	if h.cfg.roomFailureAction == "stay" {
+		return
+	}
+
+	target := discord.NullChannelID
+	if h.cfg.roomFailureAction == "fallback" {
+		for id := range h.cfg.roomFailureFallbacks {
+			if ch, err := h.s.Channel(id); err == nil && ch.GuildID == req.hubChannel.GuildID {
+				target = id
+			}
+		}
+	}
+
+	if !req.hub.silent {
+		msg := "Sorry, I couldn't create your room. "
+		if target.IsValid() {
+			msg += "I've moved you to " + target.Mention() + " in the meantime."
+		} else {
+			msg += "I've disconnected you from " + req.hubChannel.Mention() + "; try again later."
+		}
+		h.notify(ctx, req.userID, msg)
+	}
+
+	h.queueMove(memberMove{
+		guildID:   req.hubChannel.GuildID,
+		userID:    req.userID,
+		channelID: target,
+		reason:    "room could not be created",
+		failure:   "Failed to move stranded member out of the hub",
+	})
+}
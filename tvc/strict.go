@@ -0,0 +1,112 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+// checkStrict turns the guild's hubs off while strict mode is on there and
+// the bot lacks a permission it needs in one of them, and back on once it has
+// them again, telling the guild's admins both times. It runs whenever the
+// guild, its roles, its channels or the bot's roles change.
+func (h *handler) checkStrict(guildID discord.GuildID) {
+	var missing []string
+	if h.features.enabled(guildID, featureStrict) {
+		missing = h.missingPermissions(guildID)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ctx := withGuild(context.Background(), guildID)
+	_, off := h.strictMissing[guildID]
+	switch {
+	case len(missing) > 0 && !off:
+		h.strictMissing[guildID] = missing
+		slog.WarnContext(ctx, "Turning hubs off because permissions are missing", "missing", missing)
+		h.postStrictNotice(ctx, guildID, fmt.Sprintf(
+			"🔒 I've turned the hubs in this server off, because I lack %s. They turn back on by themselves once I have everything I need.",
+			strings.Join(missing, ", ")))
+	case len(missing) > 0:
+		h.strictMissing[guildID] = missing
+	case off:
+		delete(h.strictMissing, guildID)
+		slog.InfoContext(ctx, "Turning hubs back on")
+		if h.features.enabled(guildID, featureStrict) {
+			h.postStrictNotice(ctx, guildID, "🔓 I have the permissions I need again, so the hubs are back on.")
+		}
+	}
+}
+
+// missingPermissions lists the permissions the bot lacks in the guild's hubs,
+// e.g. "**Move Members** in #hub".
+func (h *handler) missingPermissions(guildID discord.GuildID) []string {
+	me, err := h.s.Me()
+	if err != nil {
+		return nil
+	}
+	channels, err := h.s.Channels(guildID)
+	if err != nil {
+		return nil
+	}
+
+	var missing []string
+	for i, ch := range channels {
+		if ch.Type != discord.GuildVoice || h.hubFor(&channels[i]) == nil {
+			continue
+		}
+		perms, err := h.s.Permissions(ch.ID, me.ID)
+		if err != nil {
+			continue
+		}
+		for _, need := range h.neededPermissions(guildID) {
+			if !perms.Has(need.perm) {
+				missing = append(missing, fmt.Sprintf("**%s** in %s", need.name, ch.Mention()))
+			}
+		}
+	}
+	slices.Sort(missing)
+	return missing
+}
+
+// postStrictNotice posts to the guild's log channel, or its system channel if
+// it has none.
+func (h *handler) postStrictNotice(ctx context.Context, guildID discord.GuildID, content string) {
+	if h.logChannelFor(guildID).IsValid() {
+		h.postLog(ctx, guildID, content)
+		return
+	}
+	g, err := h.s.Cabinet.Guild(guildID)
+	if err != nil || !g.SystemChannelID.IsValid() {
+		return
+	}
+	err = h.call(ctx, "SendMessage", func(s *state.State) error {
+		_, err := s.SendMessageComplex(g.SystemChannelID, api.SendMessageData{
+			Content:         content,
+			AllowedMentions: &api.AllowedMentions{},
+		})
+		return err
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to post strict mode notice", "err", err)
+	}
+}
+
+// hubsTurnedOff reports whether strict mode turned the guild's hubs off,
+// telling the member who joined one.
+func (h *handler) hubsTurnedOff(ctx context.Context, hub *hub, guildID discord.GuildID, userID discord.UserID) bool {
+	if _, off := h.strictMissing[guildID]; !off {
+		return false
+	}
+	if !hub.silent {
+		h.notify(ctx, userID, "Rooms are turned off in this server until an admin gives me the permissions I need.")
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// suffixSeparator starts the suffix, so it can be told apart from the name.
+const suffixSeparator = " • "
+
+// refreshSuffixes updates the name suffixes of every room each interval until
+// ctx is done.
+func (h *handler) refreshSuffixes(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.mu.Lock()
+			h.updateSuffixes(ctx)
+			h.mu.Unlock()
+		}
+	}
+}
+
+// updateSuffixes renames rooms whose suffix is out of date. Suffixes are
+// cosmetic, so they give way to everything else: the whole round is skipped
+// while Discord is struggling or renames are queued, and a room is skipped
+// if it was renamed at all within the rename window.
+func (h *handler) updateSuffixes(ctx context.Context) {
+	if h.breaker.open() || len(h.renames.pending) > 0 {
+		slog.Warn("Skipping room name suffixes while rate limits are tight")
+		return
+	}
+
+	for channelID, r := range h.rooms {
+		target := channelID
+		if r.category.IsValid() {
+			target = r.category
+		}
+		if h.renames.used(target) > 0 {
+			continue
+		}
+		ch, err := h.s.Channel(target)
+		if err != nil {
+			continue
+		}
+
+		base, _, _ := strings.Cut(ch.Name, suffixSeparator)
+		name := base + suffixSeparator + h.suffix(r)
+		if name != ch.Name {
+			h.renameChannel(withGuild(ctx, r.guildID), target, name)
+		}
+	}
+}
+
+// suffix renders the room's current suffix.
+func (h *handler) suffix(r *room) string {
+	switch h.cfg.nameSuffix {
+	case "age":
+		age := time.Since(r.createdAt)
+		if age < time.Hour {
+			return fmt.Sprintf("%dm", int(age/(10*time.Minute))*10)
+		}
+		return fmt.Sprintf("%dh", int(age/time.Hour))
+	case "occupancy":
+		n := h.roomOccupants(r)
+		if voice, err := h.s.Channel(r.channelID); err == nil && voice.VoiceUserLimit > 0 {
+			return fmt.Sprintf("%d/%d", n, voice.VoiceUserLimit)
+		}
+		return fmt.Sprint(n)
+	default:
+		return ""
+	}
+}
+
+// used counts the renames of channelID within the rename window.
+func (l *renameLimiter) used(channelID discord.ChannelID) int {
+	n := 0
+	for _, at := range l.recent[channelID] {
+		if time.Since(at) < renameWindow {
+			n++
+		}
+	}
+	return n
+}
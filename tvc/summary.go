@@ -0,0 +1,66 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+)
+
+// maxSummaryParticipants bounds the participants listed in a summary DM so it
+// stays under Discord's message length limit.
+const maxSummaryParticipants = 50
+
+// cmdSummary handles /voice summary, which opts the user in or out of a DM
+// summing up each of their rooms once it is deleted.
+func (h *handler) cmdSummary(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	enabled, err := data.Options.Find("enabled").BoolValue()
+	if err != nil {
+		return ephemeralData("Tell me whether to send summaries.")
+	}
+	h.stats.setWantsSummary(data.Event.SenderID(), enabled)
+	if enabled {
+		return ephemeralData("I'll DM you a summary when one of your rooms is deleted.")
+	}
+	return ephemeralData("I won't DM you room summaries anymore.")
+}
+
+// sendSummary DMs the owner of a deleted room how it went, if they asked for
+// it.
+func (h *handler) sendSummary(ctx context.Context, r *room) {
+	if !h.stats.wantsSummary(r.owner) {
+		return
+	}
+	rec := h.stats.creation(r.channelID)
+	if rec == nil || rec.DeletedAt.IsZero() {
+		return
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Your room **%s** was deleted.\n", rec.Name)
+	fmt.Fprintf(&sb, "Open for %s, with up to %s at once.\n",
+		strings.TrimSuffix(rec.DeletedAt.Sub(rec.CreatedAt).Round(time.Minute).String(), "0s"),
+		plural(rec.PeakMembers, "member"))
+
+	mentions := make([]string, 0, min(len(rec.Participants), maxSummaryParticipants))
+	for _, id := range rec.Participants {
+		if h.stats.private(id) {
+			continue
+		}
+		if len(mentions) == maxSummaryParticipants {
+			break
+		}
+		mentions = append(mentions, id.Mention())
+	}
+	if len(mentions) > 0 {
+		fmt.Fprintf(&sb, "Participants: %s", strings.Join(mentions, " "))
+		if n := len(rec.Participants) - len(mentions); n > 0 {
+			fmt.Fprintf(&sb, " and %d more", n)
+		}
+	}
+
+	h.notify(ctx, r.owner, sb.String())
+}
@@ -0,0 +1,129 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/utils/json/option"
+)
+
+// maxSummoned is how many invited members one summon pings at most.
+const maxSummoned = 25
+
+func parseGuildSummonsPerHour(env *environment) map[discord.GuildID]int {
+	const prefix = "SUMMONS_PER_HOUR_"
+	limits := make(map[discord.GuildID]int)
+	for _, kv := range env.environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		guildID, err := discord.ParseSnowflake(strings.TrimPrefix(key, prefix))
+		if err != nil {
+			env.configError("invalid guild ID in $%s: %v", key, err)
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			env.configError("invalid $%s: want a number of summons", key)
+			continue
+		}
+		limits[discord.GuildID(guildID)] = n
+	}
+	return limits
+}
+
+// summonsPerHourIn returns how many summons owners in guildID get an hour.
+func (h *handler) summonsPerHourIn(guildID discord.GuildID) int {
+	if n, ok := h.cfg.guildSummonsPerHour[guildID]; ok {
+		return n
+	}
+	return h.cfg.summonsPerHour
+}
+
+// cmdSummon handles /voice summon, which pings the people let into the
+// caller's room who aren't in it, or a mentionable role, with a button to
+// join. Owners get summonsPerHourIn of them an hour.
+func (h *handler) cmdSummon(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ownerID := data.Event.SenderID()
+	r := h.ownedRoomOf(ownerID)
+	if r == nil {
+		return ephemeralData("You need to be in a room you own to do that.")
+	}
+	limit := h.summonsPerHourIn(r.guildID)
+	if limit == 0 {
+		return ephemeralData("Summoning is turned off in this server.")
+	}
+	var recent []time.Time
+	for _, at := range h.summons[ownerID] {
+		if time.Since(at) < time.Hour {
+			recent = append(recent, at)
+		}
+	}
+	h.summons[ownerID] = recent
+	if len(recent) >= limit {
+		return ephemeralData(fmt.Sprintf("You can summon %s an hour; try again <t:%d:R>.", plural(limit, "time"), recent[0].Add(time.Hour).Unix()))
+	}
+
+	ctx = withGuild(ctx, r.guildID)
+	mentions := &api.AllowedMentions{}
+	var pinged []string
+	if roleID, err := data.Options.Find("role").SnowflakeValue(); err == nil && roleID.IsValid() {
+		role, err := h.s.Role(r.guildID, discord.RoleID(roleID))
+		if err != nil {
+			return ephemeralData("I couldn't find that role, try again.")
+		}
+		if !role.Mentionable {
+			return ephemeralData(role.Mention() + " can't be mentioned by everyone, so I won't ping it for you.")
+		}
+		mentions.Roles = []discord.RoleID{role.ID}
+		pinged = append(pinged, role.Mention())
+	} else {
+		ch, err := h.fetchChannel(ctx, r.roomTarget())
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to get room to summon to", "err", err)
+			return ephemeralData("I couldn't look up your room, try again.")
+		}
+		me, _ := h.s.Me()
+		for _, o := range ch.Overwrites {
+			userID := discord.UserID(o.ID)
+			switch {
+			case o.Type != discord.OverwriteMember || !o.Allow.Has(discord.PermissionConnect):
+			case userID == ownerID || (me != nil && userID == me.ID) || h.cfg.companionBots[userID]:
+			case h.channelMembers[r.channelID][userID]:
+			case len(mentions.Users) == maxSummoned:
+			default:
+				mentions.Users = append(mentions.Users, userID)
+				pinged = append(pinged, userID.Mention())
+			}
+		}
+		if len(pinged) == 0 {
+			return ephemeralData("Everyone you let in is already here. Pick a role to summon instead, or invite people with /room invite.")
+		}
+	}
+	h.summons[ownerID] = append(h.summons[ownerID], time.Now())
+	slog.InfoContext(ctx, "Room summon", "channel_id", r.channelID, "user_id", ownerID, "pinged", len(pinged))
+
+	return &api.InteractionResponseData{
+		Content: option.NewNullableString(fmt.Sprintf("%s, %s is calling you to %s.", strings.Join(pinged, " "), ownerID.Mention(), r.channelID.Mention())),
+		Components: &discord.ContainerComponents{
+			&discord.ActionRowComponent{
+				&discord.ButtonComponent{
+					Style: discord.LinkButtonStyle(discord.URL(fmt.Sprintf("https://discord.com/channels/%s/%s", r.guildID, r.channelID))),
+					Label: "Join",
+				},
+			},
+		},
+		AllowedMentions: mentions,
+	}
+}
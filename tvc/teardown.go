@@ -0,0 +1,87 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// teardownRoom deletes an empty room, along with its category and text channel
+// in team mode. Nothing happens if someone is still connected.
+func (h *handler) teardownRoom(ctx context.Context, beforeChannel *discord.Channel) {
+	if !h.confirmEmpty(ctx, beforeChannel.GuildID, beforeChannel.ID) {
+		return
+	}
+	if r, ok := h.rooms[beforeChannel.ID]; ok {
+		if wait := h.spend(h.cfg.deletionBudget, beforeChannel.GuildID); wait > 0 {
+			slog.InfoContext(ctx, "Room deletion budget is used up, delaying deletion", "wait", wait)
+			h.scheduleRoomDeletion(beforeChannel.ID, r, time.Now().Add(wait))
+			return
+		}
+	}
+	h.deleteRoom(ctx, beforeChannel)
+}
+
+// deleteRoom deletes a room and everything created with it, whether or not
+// anyone is connected. While the kill switch is on, rooms are kept and stay
+// tracked instead, as are frozen rooms.
+func (h *handler) deleteRoom(ctx context.Context, beforeChannel *discord.Channel) {
+	if h.stats.isKilled(beforeChannel.GuildID) {
+		slog.Info("Kill switch is on, keeping room", "guild_id", beforeChannel.GuildID, "channel_id", beforeChannel.ID)
+		return
+	}
+	if h.isFrozen(beforeChannel.ID) {
+		slog.Info("Room is frozen, keeping it", "guild_id", beforeChannel.GuildID, "channel_id", beforeChannel.ID)
+		return
+	}
+	if _, ok := h.rooms[beforeChannel.ID]; ok {
+		h.companions.publish(companionEvent{
+			Type:      "deleting",
+			GuildID:   beforeChannel.GuildID,
+			ChannelID: beforeChannel.ID,
+			At:        time.Now(),
+		})
+	}
+	if contains(h.temporaryChannels, beforeChannel.ID) {
+		// Taken off first so a deletion running alongside skips it.
+		remove(&h.temporaryChannels, beforeChannel.ID)
+		err := h.deleteChannel(ctx, beforeChannel.ID, "cleaning up")
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to delete channel", "err", err)
+		}
+		h.dropRoom(beforeChannel.ID)
+	}
+
+	categoryID := beforeChannel.ParentID
+	if categoryID != 0 && contains(h.temporaryCategories, beforeChannel.ID) {
+		category, err := h.fetchChannel(ctx, categoryID)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to get category", "err", err)
+			return
+		}
+
+		var channels []discord.Channel
+		channels, err = h.fetchChannels(ctx, category.GuildID)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to fetch channels", "err", err)
+			return
+		}
+		if !contains(h.temporaryCategories, beforeChannel.ID) {
+			// Deleted alongside while the channels were looked up.
+			return
+		}
+		remove(&h.temporaryCategories, beforeChannel.ID)
+		for _, channel := range channels {
+			if channel.ParentID == categoryID {
+				_ = h.deleteChannel(ctx, channel.ID, "cleaning up")
+			}
+		}
+		err = h.deleteChannel(ctx, category.ID, "cleaning up")
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to delete category", "err", err)
+		}
+		h.dropRoom(beforeChannel.ID)
+	}
+}
@@ -0,0 +1,403 @@
+package tvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/diamondburned/arikawa/v3/utils/json/option"
+)
+
+// Custom IDs of the permission template editor.
+const (
+	templateRoleID    = "template-role"
+	templateSaveID    = "template-save"
+	templateDiscardID = "template-discard"
+	templateModalID   = "template-modal"
+	templateAllowID   = "template-allow"
+	templateDenyID    = "template-deny"
+)
+
+// permissionRule is a role overwrite in a hub's permission template. Allow
+// and Deny name permissions from templatePermissions.
+type permissionRule struct {
+	RoleID discord.RoleID `json:"role_id"`
+	Allow  []string       `json:"allow,omitempty"`
+	Deny   []string       `json:"deny,omitempty"`
+}
+
+// templatePermission is a permission a template can set, by the name used in
+// the lobby file.
+type templatePermission struct {
+	name string
+	perm discord.Permissions
+}
+
+var templatePermissions = []templatePermission{
+	{"view_channel", discord.PermissionViewChannel},
+	{"connect", discord.PermissionConnect},
+	{"speak", discord.PermissionSpeak},
+	{"stream", discord.PermissionStream},
+	{"use_vad", discord.PermissionUseVAD},
+	{"priority_speaker", discord.PermissionPrioritySpeaker},
+	{"send_messages", discord.PermissionSendMessages},
+	{"mute_members", discord.PermissionMuteMembers},
+	{"move_members", discord.PermissionMoveMembers},
+}
+
+// parsePermissions turns permission names into permissions.
+func parsePermissions(names []string) (discord.Permissions, error) {
+	var perms discord.Permissions
+	for _, name := range names {
+		i := slices.IndexFunc(templatePermissions, func(p templatePermission) bool { return p.name == name })
+		if i < 0 {
+			return 0, fmt.Errorf("unknown permission %q", name)
+		}
+		perms |= templatePermissions[i].perm
+	}
+	return perms, nil
+}
+
+// permissionNames lists the template permissions in perms.
+func permissionNames(perms discord.Permissions) []string {
+	var names []string
+	for _, p := range templatePermissions {
+		if perms.Has(p.perm) {
+			names = append(names, p.name)
+		}
+	}
+	return names
+}
+
+// templateOverwrites turns a permission template into the overwrites rooms
+// get.
+func templateOverwrites(rules []permissionRule) ([]discord.Overwrite, error) {
+	overwrites := make([]discord.Overwrite, 0, len(rules))
+	for _, rule := range rules {
+		allow, err := parsePermissions(rule.Allow)
+		if err != nil {
+			return nil, err
+		}
+		deny, err := parsePermissions(rule.Deny)
+		if err != nil {
+			return nil, err
+		}
+		if allow&deny != 0 {
+			return nil, fmt.Errorf("role %s both allows and denies %s", rule.RoleID, strings.Join(permissionNames(allow&deny), ", "))
+		}
+		overwrites = append(overwrites, discord.Overwrite{ID: discord.Snowflake(rule.RoleID), Type: discord.OverwriteRole, Allow: allow, Deny: deny})
+	}
+	return overwrites, nil
+}
+
+// applyTemplate gives a new room, or team category, the overwrites of its
+// hub's permission template, skipping roles of other guilds. ch is updated
+// to match, so the overwrites later features add are merged into them.
+func (h *handler) applyTemplate(ctx context.Context, ch *discord.Channel, req roomRequest) {
+	for _, o := range req.hub.permissions {
+		o.ID = h.mirrored(ch.GuildID, o.ID)
+		if _, err := h.s.Role(ch.GuildID, discord.RoleID(o.ID)); err != nil {
+			continue
+		}
+		err := h.call(ctx, "EditChannelPermission", func(s *state.State) error {
+			return s.EditChannelPermission(ch.ID, o.ID, api.EditChannelPermissionData{
+				Type:           discord.OverwriteRole,
+				Allow:          o.Allow,
+				Deny:           o.Deny,
+				AuditLogReason: "hub permission template",
+			})
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to apply permission template", "err", err)
+			return
+		}
+		if i := slices.IndexFunc(ch.Overwrites, func(existing discord.Overwrite) bool { return existing.ID == o.ID }); i >= 0 {
+			ch.Overwrites[i] = o
+		} else {
+			ch.Overwrites = append(ch.Overwrites, o)
+		}
+	}
+}
+
+// templateDraft is a permission template an admin is editing.
+type templateDraft struct {
+	hub     *hub
+	guildID discord.GuildID
+	rules   []permissionRule
+	// editing is the role whose overwrite the open modal edits.
+	editing discord.RoleID
+}
+
+// cmdTemplateEdit opens the permission template editor for a hub. Changes
+// are kept in a draft until saved to the lobby file.
+func (h *handler) cmdTemplateEdit(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	if h.cfg.lobbiesFile == "" {
+		return ephemeralData("Templates are saved to the lobby file; set $LOBBIES_FILE to edit them here.")
+	}
+	name := data.Options.Find("hub").String()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	hub := h.hubs[strings.ToUpper(name)]
+	if hub == nil {
+		for _, candidate := range h.hubs {
+			if strings.EqualFold(candidate.label(), name) {
+				hub = candidate
+			}
+		}
+	}
+	if hub == nil || !hub.serves(data.Event.GuildID) {
+		return ephemeralData("There's no hub called " + name + ".")
+	}
+
+	draft := &templateDraft{hub: hub, guildID: data.Event.GuildID}
+	for _, rule := range hub.lobby.Permissions {
+		rule.Allow = slices.Clone(rule.Allow)
+		rule.Deny = slices.Clone(rule.Deny)
+		draft.rules = append(draft.rules, rule)
+	}
+	h.templateDrafts[data.Event.SenderID()] = draft
+
+	return &api.InteractionResponseData{
+		Content:         option.NewNullableString(renderTemplate(draft)),
+		Flags:           discord.EphemeralMessage,
+		Components:      templateComponents(),
+		AllowedMentions: &api.AllowedMentions{},
+	}
+}
+
+// renderTemplate shows the draft and previews the overwrites rooms would get.
+func renderTemplate(draft *templateDraft) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**Permission template of %s**\n", draft.hub.label())
+	overwrites, err := templateOverwrites(draft.rules)
+	switch {
+	case err != nil:
+		fmt.Fprintf(&b, "⚠️ %v\n", err)
+	case len(overwrites) == 0:
+		b.WriteString("Rooms get no overwrites from the template.\n")
+	default:
+		b.WriteString("Rooms get these overwrites when they're created:\n")
+		for _, o := range overwrites {
+			fmt.Fprintf(&b, "- %s:", discord.RoleID(o.ID).Mention())
+			if o.Allow != 0 {
+				fmt.Fprintf(&b, " ✅ %s", strings.Join(permissionNames(o.Allow), ", "))
+			}
+			if o.Deny != 0 {
+				fmt.Fprintf(&b, " ❌ %s", strings.Join(permissionNames(o.Deny), ", "))
+			}
+			fmt.Fprintf(&b, " (allow %d, deny %d)\n", o.Allow, o.Deny)
+		}
+		b.WriteString("Private rooms, visibility roles and room bans add their own overwrites on top.\n")
+	}
+	b.WriteString("\nPick a role to change its overwrite; leave both fields empty to remove it.")
+	return b.String()
+}
+
+func templateComponents() *discord.ContainerComponents {
+	return &discord.ContainerComponents{
+		&discord.ActionRowComponent{
+			&discord.RoleSelectComponent{
+				CustomID:    templateRoleID,
+				Placeholder: "Pick a role to edit",
+			},
+		},
+		&discord.ActionRowComponent{
+			&discord.ButtonComponent{
+				Style:    discord.SuccessButtonStyle(),
+				CustomID: templateSaveID,
+				Label:    "Save",
+			},
+			&discord.ButtonComponent{
+				Style:    discord.SecondaryButtonStyle(),
+				CustomID: templateDiscardID,
+				Label:    "Discard",
+			},
+		},
+	}
+}
+
+// onTemplateRole opens the modal editing the picked role's overwrite.
+func (h *handler) onTemplateRole(ctx context.Context, data cmdroute.ComponentData) *api.InteractionResponse {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	draft := h.templateDrafts[data.Event.SenderID()]
+	sel, ok := data.ComponentInteraction.(*discord.RoleSelectInteraction)
+	if draft == nil || !ok || len(sel.Values) == 0 {
+		return templateUpdate("This editor has expired; run the command again.")
+	}
+	draft.editing = sel.Values[0]
+
+	title := "Overwrite"
+	if role, err := h.s.Role(draft.guildID, draft.editing); err == nil {
+		title = "Overwrite for " + role.Name
+	}
+	if len(title) > 45 {
+		title = strings.ToValidUTF8(title[:44], "") + "…"
+	}
+	var rule permissionRule
+	if i := slices.IndexFunc(draft.rules, func(r permissionRule) bool { return r.RoleID == draft.editing }); i >= 0 {
+		rule = draft.rules[i]
+	}
+	var names []string
+	for _, p := range templatePermissions {
+		names = append(names, p.name)
+	}
+	placeholder := strings.Join(names, ", ")
+	if len(placeholder) > 100 {
+		placeholder = placeholder[:97] + "..."
+	}
+
+	return &api.InteractionResponse{
+		Type: api.ModalResponse,
+		Data: &api.InteractionResponseData{
+			CustomID: option.NewNullableString(templateModalID),
+			Title:    option.NewNullableString(title),
+			Components: &discord.ContainerComponents{
+				&discord.ActionRowComponent{
+					&discord.TextInputComponent{
+						CustomID:    templateAllowID,
+						Style:       discord.TextInputShortStyle,
+						Label:       "Allow",
+						Placeholder: placeholder,
+						Value:       strings.Join(rule.Allow, ", "),
+					},
+				},
+				&discord.ActionRowComponent{
+					&discord.TextInputComponent{
+						CustomID:    templateDenyID,
+						Style:       discord.TextInputShortStyle,
+						Label:       "Deny",
+						Placeholder: placeholder,
+						Value:       strings.Join(rule.Deny, ", "),
+					},
+				},
+			},
+		},
+	}
+}
+
+// onTemplateModal updates the draft with the edited overwrite.
+func (h *handler) onTemplateModal(ctx context.Context, ev *discord.InteractionEvent, data *discord.ModalInteraction) *api.InteractionResponse {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	draft := h.templateDrafts[ev.SenderID()]
+	if draft == nil || !draft.editing.IsValid() {
+		return templateUpdate("This editor has expired; run the command again.")
+	}
+	rule := permissionRule{RoleID: draft.editing}
+	for id, names := range map[discord.ComponentID]*[]string{templateAllowID: &rule.Allow, templateDenyID: &rule.Deny} {
+		if input, ok := data.Components.Find(id).(*discord.TextInputComponent); ok {
+			*names = strings.FieldsFunc(strings.ToLower(input.Value), func(r rune) bool { return r == ',' || r == ' ' })
+		}
+	}
+	if _, err := templateOverwrites([]permissionRule{rule}); err != nil {
+		return ephemeral(fmt.Sprintf("That didn't change the template: %v.", err))
+	}
+
+	i := slices.IndexFunc(draft.rules, func(r permissionRule) bool { return r.RoleID == rule.RoleID })
+	switch {
+	case len(rule.Allow) == 0 && len(rule.Deny) == 0:
+		if i >= 0 {
+			draft.rules = slices.Delete(draft.rules, i, i+1)
+		}
+	case i >= 0:
+		draft.rules[i] = rule
+	default:
+		draft.rules = append(draft.rules, rule)
+	}
+	draft.editing = 0
+
+	return &api.InteractionResponse{
+		Type: api.UpdateMessage,
+		Data: &api.InteractionResponseData{
+			Content:         option.NewNullableString(renderTemplate(draft) + "\n*Unsaved changes.*"),
+			Components:      templateComponents(),
+			AllowedMentions: &api.AllowedMentions{},
+		},
+	}
+}
+
+// onTemplateSave writes the draft to the lobby file and applies it.
+func (h *handler) onTemplateSave(ctx context.Context, data cmdroute.ComponentData) *api.InteractionResponse {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	userID := data.Event.SenderID()
+	draft := h.templateDrafts[userID]
+	if draft == nil {
+		return templateUpdate("This editor has expired; run the command again.")
+	}
+	if h.pendingReload != nil {
+		return ephemeral("A lobby reload is waiting for confirmation in the log channel; handle it first.")
+	}
+	if err := h.saveTemplate(draft, userID); err != nil {
+		slog.ErrorContext(ctx, "Failed to save permission template", "err", err)
+		return ephemeral(fmt.Sprintf("Couldn't save the template: %v.", err))
+	}
+	delete(h.templateDrafts, userID)
+	return templateUpdate("Saved the permission template of " + draft.hub.label() + ". New rooms get it; existing rooms keep their permissions.")
+}
+
+// saveTemplate writes the draft's template into the lobby file and switches
+// to the hubs it now describes, posting the change to the log channels.
+func (h *handler) saveTemplate(draft *templateDraft, userID discord.UserID) error {
+	lobbies, err := readLobbies(h.cfg.lobbiesFile)
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(lobbies, func(l lobbyConfig) bool { return l.Key == draft.hub.key })
+	if i < 0 {
+		return fmt.Errorf("the lobby file no longer has hub %s", draft.hub.key)
+	}
+	lobbies[i].Permissions = draft.rules
+	hubs, err := buildHubs(h.env, h.cfg.stagingGuilds, lobbies)
+	if err != nil {
+		return err
+	}
+	if len(h.orphanedBy(hubs)) > 0 {
+		return errors.New("the lobby file has other changes that would leave rooms without their hub; reload it first")
+	}
+	if err := writeLobbies(h.cfg.lobbiesFile, lobbies); err != nil {
+		return err
+	}
+
+	diff := diffHubs(h.hubs, hubs)
+	h.applyHubs(hubs)
+	slog.Info("Permission template saved", "user_id", userID, "hub", draft.hub.label(), "changes", diffLines(diff, discord.NullGuildID))
+	if len(diff) > 0 {
+		h.postReload("**Lobby config changed by "+userID.Mention()+"**", diff, nil, false)
+	}
+	return nil
+}
+
+// onTemplateDiscard drops the draft.
+func (h *handler) onTemplateDiscard(ctx context.Context, data cmdroute.ComponentData) *api.InteractionResponse {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.templateDrafts, data.Event.SenderID())
+	return templateUpdate("Discarded; the template is unchanged.")
+}
+
+// templateUpdate replaces the editor with content.
+func templateUpdate(content string) *api.InteractionResponse {
+	return &api.InteractionResponse{
+		Type: api.UpdateMessage,
+		Data: &api.InteractionResponseData{
+			Content:         option.NewNullableString(content),
+			Components:      &discord.ContainerComponents{},
+			AllowedMentions: &api.AllowedMentions{},
+		},
+	}
+}
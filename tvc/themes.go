@@ -0,0 +1,73 @@
+package tvc
+
+import (
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// theme decorates room names during a yearly date range.
+type theme struct {
+	// from and to are inclusive month*100+day dates. Ranges with from after to
+	// wrap around the new year.
+	from, to int
+	prefix   string
+}
+
+// seasonalThemes are read from $ROOM_THEMES, e.g.
+// ROOM_THEMES="10-01..10-31=🎃,12-20..01-01=🎄", with dates given as MM-DD
+// in UTC.
+type seasonalThemes []theme
+
+func newSeasonalThemes(env *environment) seasonalThemes {
+	var themes seasonalThemes
+	for _, item := range env.list("ROOM_THEMES") {
+		dates, prefix, ok := strings.Cut(item, "=")
+		from, to, ok2 := strings.Cut(dates, "..")
+		if !ok || !ok2 || strings.TrimSpace(prefix) == "" {
+			env.configError("invalid theme %q in $ROOM_THEMES, want MM-DD..MM-DD=prefix", item)
+			continue
+		}
+		themes = append(themes, theme{
+			from:   parseThemeDate(env, from),
+			to:     parseThemeDate(env, to),
+			prefix: strings.TrimSpace(prefix),
+		})
+	}
+	return themes
+}
+
+func parseThemeDate(env *environment, s string) int {
+	t, err := time.Parse("01-02", strings.TrimSpace(s))
+	if err != nil {
+		env.configError("invalid date %q in $ROOM_THEMES: %v", s, err)
+		return 0
+	}
+	return int(t.Month())*100 + t.Day()
+}
+
+// current returns the prefix of the first theme covering now, or "".
+func (themes seasonalThemes) current(now time.Time) string {
+	now = now.UTC()
+	date := int(now.Month())*100 + now.Day()
+	for _, t := range themes {
+		if t.from <= t.to && date >= t.from && date <= t.to ||
+			t.from > t.to && (date >= t.from || date <= t.to) {
+			return t.prefix
+		}
+	}
+	return ""
+}
+
+// decorateName prefixes a new room's name. A seasonal theme takes the place
+// of the usual emoji while it lasts.
+func (h *handler) decorateName(guildID discord.GuildID, name string) string {
+	now := time.Now()
+	if h.features.enabled(guildID, featureThemes) {
+		if prefix := h.themes.current(now); prefix != "" {
+			return prefix + " " + name
+		}
+	}
+	return h.emoji.decorate(name, now)
+}
@@ -0,0 +1,38 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// throttled reports whether userID is creating rooms too quickly. Refused
+// members are disconnected from the hub; moderators are alerted once per
+// burst.
+func (h *handler) throttled(ctx context.Context, guildID discord.GuildID, userID discord.UserID) bool {
+	if h.cfg.throttleRooms <= 0 {
+		return false
+	}
+	used, oldest := h.stats.creationsSince(guildID, userID, time.Now().Add(-h.cfg.throttleWindow))
+	if used < h.cfg.throttleRooms {
+		return false
+	}
+
+	h.queueMove(memberMove{
+		guildID:   guildID,
+		userID:    userID,
+		channelID: discord.NullChannelID,
+		reason:    "member is creating rooms too quickly",
+		failure:   "Failed to disconnect throttled member",
+	})
+
+	if !h.throttleAlerted[userID].After(oldest) {
+		h.throttleAlerted[userID] = time.Now()
+		h.postModAlert(ctx, guildID, userID, 0, fmt.Sprintf(
+			"%s created %s within %s and was throttled.",
+			userID.Mention(), plural(used, "room"), formatDuration(h.cfg.throttleWindow)))
+	}
+	return true
+}
@@ -0,0 +1,121 @@
+package tvc
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// wheelTick is how precisely the timer wheel fires; timers fire up to
+	// a tick late.
+	wheelTick = 500 * time.Millisecond
+	// wheelSlots is how many ticks the wheel goes around in. Timers further
+	// out wait for as many rounds as they need.
+	wheelSlots = 1024
+)
+
+// timerWheel runs the room deletion timers. Pending timers are entries in
+// the slots of a wheel turned by a single ticker goroutine, so tens of
+// thousands of rooms waiting out their grace periods cost a map entry each,
+// and cancelling one is a map delete.
+type timerWheel struct {
+	mu      sync.Mutex
+	slots   []map[*wheelTimer]struct{}
+	pos     int
+	started bool
+	// last is when the wheel last moved on a slot.
+	last time.Time
+}
+
+// wheelTimer is a timer on a timerWheel.
+type wheelTimer struct {
+	w    *timerWheel
+	f    func()
+	slot int
+	// rounds is how many more times the wheel passes the slot before the
+	// timer fires.
+	rounds int
+}
+
+func newTimerWheel() *timerWheel {
+	slots := make([]map[*wheelTimer]struct{}, wheelSlots)
+	for i := range slots {
+		slots[i] = make(map[*wheelTimer]struct{})
+	}
+	return &timerWheel{slots: slots}
+}
+
+// afterFunc calls f in its own goroutine once d has passed, like
+// time.AfterFunc. The wheel starts turning with its first timer.
+func (w *timerWheel) afterFunc(d time.Duration, f func()) *wheelTimer {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.started {
+		w.started = true
+		w.last = time.Now()
+		go w.run()
+	}
+	// Ticks count from the last one, which was a moment ago.
+	d = max(d, 0) + time.Since(w.last)
+	ticks := max(int((d+wheelTick-1)/wheelTick), 1)
+	t := &wheelTimer{
+		w:      w,
+		f:      f,
+		slot:   (w.pos + ticks) % wheelSlots,
+		rounds: (ticks - 1) / wheelSlots,
+	}
+	w.slots[t.slot][t] = struct{}{}
+	return t
+}
+
+// Stop cancels the timer, reporting whether it was still pending.
+func (t *wheelTimer) Stop() bool {
+	w := t.w
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, ok := w.slots[t.slot][t]; !ok {
+		return false
+	}
+	delete(w.slots[t.slot], t)
+	return true
+}
+
+// pending returns how many timers are waiting to fire.
+func (w *timerWheel) pending() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	n := 0
+	for _, slot := range w.slots {
+		n += len(slot)
+	}
+	return n
+}
+
+// run turns the wheel forever, firing the timers that are due.
+func (w *timerWheel) run() {
+	ticker := time.NewTicker(wheelTick)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		w.mu.Lock()
+		w.pos = (w.pos + 1) % wheelSlots
+		w.last = time.Now()
+		var due []*wheelTimer
+		for t := range w.slots[w.pos] {
+			if t.rounds > 0 {
+				t.rounds--
+				continue
+			}
+			delete(w.slots[w.pos], t)
+			due = append(due, t)
+		}
+		w.mu.Unlock()
+
+		for _, t := range due {
+			go t.f()
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+// scoreboardName is the name of a tournament's scoreboard channel.
+const scoreboardName = "scoreboard"
+
+// tournament is a guild's running tournament between team rooms. Scores live
+// in a bot-managed embed in a read-only channel.
+type tournament struct {
+	name      string
+	channelID discord.ChannelID
+	message   discord.MessageID
+	// scores are keyed by the voice channel of team rooms.
+	scores map[discord.ChannelID]int
+}
+
+// cmdTournamentStart handles /tournament start, which opens the scoreboard
+// channel next to the team hub.
+func (h *handler) cmdTournamentStart(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	name := data.Options.Find("name").String()
+	if name == "" {
+		name = "Tournament"
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	guildID := data.Event.GuildID
+	if t, ok := h.tournaments[guildID]; ok {
+		return ephemeralData(fmt.Sprintf("%s is already running in %s.", t.name, t.channelID.Mention()))
+	}
+	ctx = withGuild(ctx, guildID)
+
+	var parentID discord.ChannelID
+	if channels, err := h.s.Channels(guildID); err == nil {
+		for i, ch := range channels {
+			if ch.Type != discord.GuildVoice {
+				continue
+			}
+			if hub := h.lookupHub(&channels[i]); hub != nil && hub.kind == teamRoom {
+				parentID = ch.ParentID
+			}
+		}
+	}
+	me, err := h.s.Me()
+	if err != nil {
+		return ephemeralData("Something went wrong, try again.")
+	}
+
+	t := &tournament{name: name, scores: make(map[discord.ChannelID]int)}
+	err = h.call(ctx, "CreateChannel", func(s *state.State) error {
+		ch, err := s.CreateChannel(guildID, api.CreateChannelData{
+			Name:       scoreboardName,
+			Type:       discord.GuildText,
+			CategoryID: parentID,
+			Overwrites: []discord.Overwrite{
+				{ID: discord.Snowflake(guildID), Type: discord.OverwriteRole, Deny: discord.PermissionSendMessages | discord.PermissionCreatePublicThreads | discord.PermissionCreatePrivateThreads | discord.PermissionAddReactions},
+				{ID: discord.Snowflake(me.ID), Type: discord.OverwriteMember, Allow: discord.PermissionViewChannel | discord.PermissionSendMessages},
+			},
+			AuditLogReason: "tournament scoreboard",
+		})
+		if err != nil {
+			return err
+		}
+		h.created[ch.ID] = true
+		t.channelID = ch.ID
+
+		msg, err := s.SendEmbeds(ch.ID, h.scoreboardEmbed(guildID, t))
+		if err != nil {
+			return err
+		}
+		t.message = msg.ID
+		return nil
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create scoreboard", "err", err)
+		if t.channelID.IsValid() {
+			_ = h.deleteChannel(ctx, t.channelID, "cleaning up")
+			delete(h.created, t.channelID)
+		}
+		return ephemeralData("I couldn't create the scoreboard channel.")
+	}
+	h.tournaments[guildID] = t
+	return ephemeralData(fmt.Sprintf("%s started. Scores go in %s.", name, t.channelID.Mention()))
+}
+
+// cmdTournamentScore handles /tournament score, which sets a team room's
+// score on the scoreboard.
+func (h *handler) cmdTournamentScore(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	id, err := data.Options.Find("team").SnowflakeValue()
+	if err != nil {
+		return ephemeralData("Pick the team's voice channel.")
+	}
+	points, err := data.Options.Find("points").IntValue()
+	if err != nil {
+		return ephemeralData("Give the team's score.")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	t, ok := h.tournaments[data.Event.GuildID]
+	if !ok {
+		return ephemeralData("There is no tournament running. Start one with /tournament start.")
+	}
+	r, ok := h.rooms[discord.ChannelID(id)]
+	if !ok || r.guildID != data.Event.GuildID || !r.category.IsValid() {
+		return ephemeralData("That isn't a team room.")
+	}
+
+	t.scores[r.channelID] = int(points)
+	h.updateScoreboard(withGuild(ctx, r.guildID), r.guildID, t)
+	return ephemeralData(fmt.Sprintf("%s now has %s.", r.name, plural(int(points), "point")))
+}
+
+// cmdTournamentEnd handles /tournament end, which deletes the scoreboard.
+func (h *handler) cmdTournamentEnd(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	guildID := data.Event.GuildID
+	t, ok := h.tournaments[guildID]
+	if !ok {
+		return ephemeralData("There is no tournament running.")
+	}
+	delete(h.tournaments, guildID)
+
+	if err := h.deleteChannel(withGuild(ctx, guildID), t.channelID, "tournament ended"); err != nil {
+		slog.ErrorContext(ctx, "Failed to delete scoreboard", "err", err)
+	}
+	delete(h.created, t.channelID)
+	return ephemeralData(t.name + " ended.")
+}
+
+// dropScore takes a deleted team room off its guild's scoreboard.
+func (h *handler) dropScore(r *room) {
+	t, ok := h.tournaments[r.guildID]
+	if !ok {
+		return
+	}
+	if _, ok := t.scores[r.channelID]; !ok {
+		return
+	}
+	delete(t.scores, r.channelID)
+	h.updateScoreboard(withGuild(context.Background(), r.guildID), r.guildID, t)
+}
+
+// updateScoreboard edits the scoreboard embed to the current scores.
+func (h *handler) updateScoreboard(ctx context.Context, guildID discord.GuildID, t *tournament) {
+	err := h.call(ctx, "EditMessage", func(s *state.State) error {
+		_, err := s.EditEmbeds(t.channelID, t.message, h.scoreboardEmbed(guildID, t))
+		return err
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to update scoreboard", "err", err)
+	}
+}
+
+// scoreboardEmbed ranks the teams by score.
+func (h *handler) scoreboardEmbed(guildID discord.GuildID, t *tournament) discord.Embed {
+	type entry struct {
+		id    discord.ChannelID
+		score int
+	}
+	var entries []entry
+	for id, score := range t.scores {
+		entries = append(entries, entry{id, score})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].score != entries[j].score {
+			return entries[i].score > entries[j].score
+		}
+		return entries[i].id < entries[j].id
+	})
+
+	embed := discord.Embed{
+		Title:     t.name,
+		Timestamp: discord.NowTimestamp(),
+	}
+	if len(entries) == 0 {
+		embed.Description = "No scores yet."
+		return embed
+	}
+	for i, e := range entries {
+		if i == maxBoardRooms {
+			break
+		}
+		name := e.id.Mention()
+		if r, ok := h.rooms[e.id]; ok {
+			name = r.name
+		}
+		embed.Fields = append(embed.Fields, discord.EmbedField{
+			Name:  fmt.Sprintf("%d. %s", i+1, name),
+			Value: plural(e.score, "point"),
+		})
+	}
+	return embed
+}
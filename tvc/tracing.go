@@ -0,0 +1,73 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/diamondburned/arikawa/v3/utils/httputil"
+	"github.com/diamondburned/arikawa/v3/utils/httputil/httpdriver"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer is a no-op until the embedding program installs a tracer provider.
+var tracer = otel.Tracer("github.com/by-nari/temporary-voice-channel-discord-bot")
+
+// traceRateLimits wraps the request hooks of the REST client, which include the
+// rate limiter, in a span so time spent waiting for a bucket shows up
+// separately from the request itself.
+func traceRateLimits(s *state.State) {
+	hooks := s.Client.Client.OnRequest
+	s.Client.Client.OnRequest = []httputil.RequestOption{
+		func(r httpdriver.Request) error {
+			_, span := tracer.Start(r.GetContext(), "discord.rate_limit_wait",
+				trace.WithAttributes(attribute.String("discord.route", r.GetPath())))
+			defer span.End()
+
+			var err error
+			for _, hook := range hooks {
+				if hookErr := hook(r); hookErr != nil {
+					err = hookErr
+				}
+			}
+			if err != nil {
+				span.SetStatus(codes.Error, err.Error())
+			}
+			return err
+		},
+	}
+}
+
+// call runs a Discord API request named op inside a client span. The state
+// passed to fn carries the span context so rate limit waits are nested under
+// the request. If ctx holds an I/O lease, h.mu is released while it runs; see
+// releaseForIO.
+func (h *handler) call(ctx context.Context, op string, fn func(s *state.State) error) error {
+	if h.killed(guildFrom(ctx), op) {
+		return fmt.Errorf("%s: %w", op, errKilled)
+	}
+	if killedOps[op] && h.isObserving(guildFrom(ctx)) {
+		return fmt.Errorf("%s: %w", op, errObserving)
+	}
+
+	ctx, span := tracer.Start(ctx, "discord."+op, trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	var err error
+	h.unlocked(ctx, func() { err = fn(h.s.WithContext(ctx)) })
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		h.metrics.apiError(op)
+
+		if guildID := guildFrom(ctx); guildID.IsValid() {
+			if perm, ok := h.permissionAlerts.record(guildID, op, err); ok {
+				go h.alertMissingPermission(guildID, perm)
+			}
+		}
+	}
+	return err
+}
@@ -0,0 +1,45 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/diamondburned/arikawa/v3/utils/json/option"
+)
+
+// clearMutes lifts the server mute and deafen of an owner joining their own
+// room.
+func (h *handler) clearMutes(ctx context.Context, r *room, evt *gateway.VoiceStateUpdateEvent) {
+	if !h.cfg.clearServerMutes || evt.UserID != r.owner || (!evt.Mute && !evt.Deaf) {
+		return
+	}
+	if len(h.cfg.clearServerMutesExempt) > 0 {
+		member, err := h.member(ctx, r.guildID, evt)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to look up member for mute check", "err", err)
+			return
+		}
+		for _, roleID := range h.cfg.clearServerMutesExempt {
+			if hasRole(member.RoleIDs, roleID) {
+				return
+			}
+		}
+	}
+
+	data := api.ModifyMemberData{AuditLogReason: "clearing a stale server mute in the owner's room"}
+	if evt.Mute {
+		data.Mute = option.False
+	}
+	if evt.Deaf {
+		data.Deaf = option.False
+	}
+	err := h.call(ctx, "ModifyMember", func(s *state.State) error {
+		return s.ModifyMember(r.guildID, evt.UserID, data)
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to clear server mute", "err", err)
+	}
+}
@@ -0,0 +1,248 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// templatePlaceholders lists the placeholders expandTemplate understands.
+var templatePlaceholders = []string{"username", "host", "channel", "count", "number"}
+
+// checkTemplate reports unbalanced braces and unknown placeholders in tmpl.
+func checkTemplate(tmpl string) error {
+	rest := tmpl
+	for {
+		open := strings.IndexAny(rest, "{}")
+		if open < 0 {
+			return nil
+		}
+		if rest[open] == '}' {
+			return fmt.Errorf("unexpected '}' in %q", tmpl)
+		}
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			return fmt.Errorf("unclosed '{' in %q", tmpl)
+		}
+		name := rest[open+1 : open+end]
+		known := false
+		for _, p := range templatePlaceholders {
+			known = known || p == name
+		}
+		if !known {
+			return fmt.Errorf("unknown placeholder {%s} in %q, expected one of {%s}",
+				name, tmpl, strings.Join(templatePlaceholders, "}, {"))
+		}
+		rest = rest[open+end+1:]
+	}
+}
+
+// validateTemplates checks every template of every hub.
+func (h *handler) validateTemplates() []string {
+	var problems []string
+	for _, hub := range h.hubs {
+		problems = append(problems, checkHubTemplates(hub, fmt.Sprintf("Hub %q", hub.label()))...)
+		if hub.shadow != nil {
+			problems = append(problems, checkHubTemplates(hub.shadow, fmt.Sprintf("Shadow config of hub %q", hub.label()))...)
+		}
+	}
+	for _, l := range h.cfg.launchers {
+		if err := checkTemplate(l.RoomName); err != nil {
+			problems = append(problems, fmt.Sprintf("Launcher %q: %v.", l.Label, err))
+		}
+	}
+	return problems
+}
+
+// checkHubTemplates checks the templates of one hub configuration.
+func checkHubTemplates(hub *hub, label string) []string {
+	templates := []string{hub.greeting}
+	switch n := hub.naming.(type) {
+	case templateNamer:
+		templates = append(templates, string(n))
+	case wordPoolNamer:
+		templates = append(templates, n...)
+	}
+	for _, p := range hub.presets {
+		templates = append(templates, p.RoomName)
+	}
+
+	var problems []string
+	for _, tmpl := range templates {
+		if err := checkTemplate(tmpl); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v.", label, err))
+		}
+	}
+	return problems
+}
+
+// validateGuild checks that the guild's hubs exist and that the bot has the
+// permissions it needs to serve them. Each problem is a sentence telling the
+// admin what to fix.
+func (h *handler) validateGuild(guildID discord.GuildID) []string {
+	me, err := h.s.Me()
+	if err != nil {
+		return []string{fmt.Sprintf("Couldn't look up the bot user: %v.", err)}
+	}
+	channels, err := h.s.Channels(guildID)
+	if err != nil {
+		return []string{fmt.Sprintf("Couldn't list channels: %v.", err)}
+	}
+
+	var problems []string
+	for _, hub := range h.hubs {
+		if !hub.serves(guildID) || (hub.kind == teamRoom && !h.features.enabled(guildID, featureTeams)) {
+			continue
+		}
+
+		var hubChannels []discord.Channel
+		for i, ch := range channels {
+			if ch.Type == discord.GuildVoice && h.lookupHub(&channels[i]) == hub {
+				hubChannels = append(hubChannels, ch)
+			}
+		}
+		if len(hubChannels) == 0 && hub.name != "" {
+			problems = append(problems, fmt.Sprintf(
+				"There is no voice channel named %q, so that hub is inactive. Create one to enable it.", hub.name))
+			continue
+		}
+		if len(hubChannels) == 0 {
+			problems = append(problems, fmt.Sprintf(
+				"None of the channels of hub %q is a voice channel in this server, so that hub is inactive.", hub.label()))
+			continue
+		}
+		if hub.region != "" && !h.hasVoiceRegion(guildID, hub.region) {
+			problems = append(problems, fmt.Sprintf(
+				"Hub %q pins the unknown voice region %q; pick one of the guild's voice regions or unset it.", hub.label(), hub.region))
+		}
+
+		for _, ch := range hubChannels {
+			if ch.ParentID.IsValid() {
+				if _, err := h.s.Channel(ch.ParentID); err != nil {
+					problems = append(problems, fmt.Sprintf(
+						"The category of hub %s can't be found; move the hub into an existing category.", ch.Mention()))
+				}
+			}
+
+			perms, err := h.s.Permissions(ch.ID, me.ID)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("Couldn't compute permissions in %s: %v.", ch.Mention(), err))
+				continue
+			}
+			for _, need := range h.neededPermissions(guildID) {
+				if !perms.Has(need.perm) {
+					problems = append(problems, fmt.Sprintf(
+						"The bot lacks **%s** in %s, needed to %s.", need.name, ch.Mention(), need.reason))
+				}
+			}
+		}
+	}
+	if h.features.enabled(guildID, featureTeams) {
+		problems = append(problems, h.validateTeamForum(guildID, me.ID)...)
+	}
+	return problems
+}
+
+// hasVoiceRegion reports whether id is a voice region the guild may use. If
+// the regions can't be listed the region is assumed to be fine.
+func (h *handler) hasVoiceRegion(guildID discord.GuildID, id string) bool {
+	regions, err := h.s.VoiceRegionsGuild(guildID)
+	if err != nil {
+		return true
+	}
+	for _, region := range regions {
+		if region.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+type neededPermission struct {
+	perm   discord.Permissions
+	name   string
+	reason string
+}
+
+// neededPermissions lists the permissions the enabled features rely on.
+func (h *handler) neededPermissions(guildID discord.GuildID) []neededPermission {
+	needs := []neededPermission{
+		{discord.PermissionViewChannel, "View Channel", "see who joins the hub"},
+		{discord.PermissionManageChannels, "Manage Channels", "create and delete rooms"},
+		{discord.PermissionMoveMembers, "Move Members", "move members into their rooms"},
+	}
+	if h.features.enabled(guildID, featureGreetings) || h.cfg.roomGracePeriod > 0 {
+		needs = append(needs, neededPermission{discord.PermissionSendMessages, "Send Messages", "post greetings and warnings"})
+	}
+	if h.cfg.roomInfoEnabled && h.features.enabled(guildID, featureRoomInfo) {
+		needs = append(needs, neededPermission{discord.PermissionManageMessages, "Manage Messages", "pin room info"})
+	}
+	if h.features.enabled(guildID, featureNoBots) || len(h.cfg.companionBots) > 0 || len(h.cfg.bypassRoles) > 0 {
+		needs = append(needs, neededPermission{discord.PermissionManageRoles, "Manage Roles", "set room overwrites for apps, companion bots and bypass roles"})
+	}
+	if h.cfg.clearServerMutes {
+		needs = append(needs, neededPermission{discord.PermissionMuteMembers | discord.PermissionDeafenMembers, "Mute Members and Deafen Members", "clear stale server mutes"})
+	}
+	if len(h.cfg.autoModExemptRules) > 0 && h.features.enabled(guildID, featureTextPairing) {
+		needs = append(needs, neededPermission{discord.PermissionManageGuild, "Manage Server", "exempt room text channels from AutoMod rules"})
+	}
+	return needs
+}
+
+// lintGuild logs the guild's configuration problems at startup.
+func (h *handler) lintGuild(guildID discord.GuildID) {
+	for _, problem := range h.validateGuild(guildID) {
+		slog.Warn("Config problem", "guild_id", guildID, "problem", problem)
+	}
+}
+
+// validateAbandoned lists the guild's channels whose deletion was abandoned.
+func (h *handler) validateAbandoned(guildID discord.GuildID) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var problems []string
+	for channelID, retry := range h.abandonedChannels(guildID) {
+		problems = append(problems, fmt.Sprintf("**Abandoned:** I couldn't delete %s after %s (%s). I'm still trying every %s; check my permissions on it, or delete it yourself.",
+			channelID.Mention(), plural(retry.Attempts, "attempt"), retry.LastError, formatDuration(maxDeleteBackoff)))
+	}
+	slices.Sort(problems)
+	return problems
+}
+
+// cmdValidate handles /voiceadmin validate.
+func (h *handler) cmdValidate(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	problems := append(h.validateTemplates(), h.validateGuild(data.Event.GuildID)...)
+	problems = append(problems, h.validateAbandoned(data.Event.GuildID)...)
+	h.mu.Lock()
+	if _, off := h.strictMissing[data.Event.GuildID]; off {
+		problems = append(problems, "**Strict mode** has turned the hubs off until the missing permissions are granted.")
+	}
+	h.mu.Unlock()
+	if h.isObserving(data.Event.GuildID) {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		content := "I'm in observer mode here and won't create, move or delete anything until you set me up."
+		if summary := h.observeSummary(data.Event.GuildID); summary != "" {
+			content += "\n\n" + summary
+		}
+		if len(problems) > 0 {
+			content += "\n\nAlso found some problems:\n- " + strings.Join(problems, "\n- ")
+		}
+		components := h.setupComponents(data.Event.GuildID)
+		resp := ephemeralData(content)
+		resp.Components = &components
+		return resp
+	}
+	if len(problems) == 0 {
+		return ephemeralData("Everything looks good.")
+	}
+	return ephemeralData("Found some problems:\n- " + strings.Join(problems, "\n- "))
+}
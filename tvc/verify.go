@@ -0,0 +1,104 @@
+package tvc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/diamondburned/arikawa/v3/utils/httputil"
+)
+
+// maxVoiceStateLookups is how many voice state lookups confirmEmpty runs at
+// once.
+const maxVoiceStateLookups = 4
+
+// confirmEmpty is the safety check run before deleting a room. The cached
+// voice states must show nobody in the channel, and the members the state's
+// cache still has in it, which can lag behind or miss a leave, must be out of
+// it according to a fresh REST lookup, so a desynced cache can't disconnect
+// people mid-call. Any doubt, including lookup errors, keeps the channel.
+func (h *handler) confirmEmpty(ctx context.Context, guildID discord.GuildID, channelID discord.ChannelID) bool {
+	r, ok := h.rooms[channelID]
+	if !ok {
+		return h.occupants(channelID) == 0
+	}
+	if h.roomOccupants(r) > 0 {
+		return false
+	}
+
+	inRoom := func(id discord.ChannelID) bool {
+		return id == channelID || (r.afkChannel.IsValid() && id == r.afkChannel)
+	}
+	var check []discord.UserID
+	if states, err := h.s.Cabinet.VoiceStates(guildID); err == nil {
+		for _, vs := range states {
+			if inRoom(vs.ChannelID) {
+				check = append(check, vs.UserID)
+			}
+		}
+	}
+	if len(check) > 0 {
+		states, err := h.lookUpVoiceStates(ctx, guildID, check)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to verify room is empty, keeping it", "err", err)
+			return false
+		}
+		for _, vs := range states {
+			if vs != nil && inRoom(vs.ChannelID) {
+				slog.Warn("Room still has a member connected according to Discord, keeping it", "guild_id", guildID, "channel_id", channelID, "user_id", vs.UserID)
+				h.setVoiceState(*vs)
+				return false
+			}
+		}
+	}
+	// The lookups may have let go of h.mu, so check the cache again.
+	if h.rooms[channelID] != r {
+		return false
+	}
+	return h.roomOccupants(r) == 0
+}
+
+// lookUpVoiceStates gets the current voice states of the members from the
+// API, maxVoiceStateLookups at a time. Members who aren't connected get nil.
+func (h *handler) lookUpVoiceStates(ctx context.Context, guildID discord.GuildID, userIDs []discord.UserID) ([]*discord.VoiceState, error) {
+	states := make([]*discord.VoiceState, len(userIDs))
+	err := h.call(ctx, "GetVoiceState", func(s *state.State) error {
+		errs := make([]error, len(userIDs))
+		slots := make(chan struct{}, maxVoiceStateLookups)
+		var wg sync.WaitGroup
+		for i, userID := range userIDs {
+			wg.Add(1)
+			slots <- struct{}{}
+			go func() {
+				defer wg.Done()
+				states[i], errs[i] = fetchVoiceState(s, guildID, userID)
+				<-slots
+			}()
+		}
+		wg.Wait()
+		return errors.Join(errs...)
+	})
+	return states, err
+}
+
+// fetchVoiceState gets a member's current voice state from the API, returning
+// nil if they aren't connected. arikawa has no wrapper for this endpoint yet.
+func fetchVoiceState(s *state.State, guildID discord.GuildID, userID discord.UserID) (*discord.VoiceState, error) {
+	var vs discord.VoiceState
+	err := s.RequestJSON(&vs, "GET",
+		api.EndpointGuilds+guildID.String()+"/voice-states/"+userID.String())
+
+	var httpErr *httputil.HTTPError
+	if errors.As(err, &httpErr) && httpErr.Status == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &vs, nil
+}
@@ -0,0 +1,84 @@
+package tvc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/utils/httputil/httpdriver"
+)
+
+// answeringAPI answers requests whose path ends in suffix with status, and
+// passes everything else to the fake API.
+type answeringAPI struct {
+	fake   *fakeAPI
+	suffix string
+	status int
+}
+
+func (a answeringAPI) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !strings.HasSuffix(req.URL.Path, a.suffix) {
+		return a.fake.RoundTrip(req)
+	}
+	rec := httptest.NewRecorder()
+	http.Error(rec, `{"message": "test"}`, a.status)
+	return rec.Result(), nil
+}
+
+func TestConfirmEmpty(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		// status is what Discord answers looking member 7 up, 0 for their
+		// voice state.
+		status int
+		empty  bool
+	}{
+		{"member still connected", 0, false},
+		{"member gone", http.StatusNotFound, true},
+		{"lookup failing", http.StatusForbidden, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			b := newTestBot(t, "ROOM_GRACE_PERIOD=1h")
+			b.send(testGuild(nil, nil))
+			b.send(join(5, testHubID))
+			room := b.roomOf(5)
+			b.send(join(5, room), join(5, 0))
+
+			// The state's cache has member 7 in the room, but the bot
+			// missed their join.
+			b.s.Cabinet.VoiceStateSet(testGuildID, &discord.VoiceState{GuildID: testGuildID, ChannelID: room, UserID: 7}, false)
+			if test.status != 0 {
+				api := answeringAPI{fake: b.fake, suffix: "/voice-states/7", status: test.status}
+				b.s.Client.Client.Client = httpdriver.WrapClient(http.Client{Transport: api})
+			}
+
+			h := b.h
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			if empty := h.confirmEmpty(withGuild(context.Background(), testGuildID), testGuildID, room); empty != test.empty {
+				t.Errorf("room confirmed empty is %v, want %v", empty, test.empty)
+			}
+			if inRoom := h.userVoiceStates[7].ChannelID == room; inRoom != (test.status == 0) {
+				t.Errorf("member 7 is in the room as far as the bot knows is %v, want %v", inRoom, test.status == 0)
+			}
+		})
+	}
+}
+
+func TestConfirmEmptyTrustsLiveMembers(t *testing.T) {
+	b := newTestBot(t)
+	b.send(testGuild(nil, nil))
+	b.send(join(5, testHubID))
+	room := b.roomOf(5)
+	b.send(join(5, room))
+
+	h := b.h
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.confirmEmpty(withGuild(context.Background(), testGuildID), testGuildID, room) {
+		t.Error("room with member 5 in it was confirmed empty")
+	}
+}
@@ -0,0 +1,60 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+)
+
+// applyVisibility hides the new room, or team category, from everyone who
+// doesn't share one of the hub's visibility roles with its owner. Owners
+// holding none of them get an ordinary room. The roles are only looked at
+// at creation; later role changes don't affect the room.
+func (h *handler) applyVisibility(ctx context.Context, ch *discord.Channel, req roomRequest) {
+	if len(req.hub.visibilityRoles) == 0 {
+		return
+	}
+	member, err := h.lookupMember(ctx, ch.GuildID, req.userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get room owner's roles", "err", err)
+		return
+	}
+	var shared []discord.RoleID
+	for _, roleID := range req.hub.visibilityRoles {
+		if hasRole(member.RoleIDs, roleID) {
+			shared = append(shared, roleID)
+		}
+	}
+	if len(shared) == 0 {
+		return
+	}
+
+	allow := func(id discord.Snowflake, typ discord.OverwriteType) error {
+		return h.call(ctx, "EditChannelPermission", func(s *state.State) error {
+			return s.EditChannelPermission(ch.ID, id, api.EditChannelPermissionData{
+				Type:           typ,
+				Allow:          discord.PermissionViewChannel,
+				AuditLogReason: "room is visible to its owner's community",
+			})
+		})
+	}
+	err = allow(discord.Snowflake(req.userID), discord.OverwriteMember)
+	for _, roleID := range shared {
+		if err == nil {
+			err = allow(discord.Snowflake(roleID), discord.OverwriteRole)
+		}
+	}
+	if me, meErr := h.s.Me(); err == nil && meErr == nil {
+		// Keep seeing the room ourselves so it can still be cleaned up.
+		err = allow(discord.Snowflake(me.ID), discord.OverwriteMember)
+	}
+	if err == nil {
+		err = h.denyEveryone(ctx, ch, discord.PermissionViewChannel, "room is visible to its owner's community")
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to limit room visibility", "err", err)
+	}
+}
@@ -0,0 +1,62 @@
+package tvc
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// voiceLog decides which voice state updates are worth a log line. Busy guilds
+// produce thousands of updates per minute, so by default only every Nth update
+// is printed and the rest are folded into a periodic summary.
+type voiceLog struct {
+	// every logs one in every N updates. Zero disables per-event lines.
+	every uint64
+	// hubOnly restricts per-event lines to transitions touching a hub or a
+	// temporary channel.
+	hubOnly bool
+
+	seen       atomic.Uint64
+	candidates atomic.Uint64
+	logged     atomic.Uint64
+}
+
+func newVoiceLog(env *environment) *voiceLog {
+	return &voiceLog{
+		every:   uint64(max(env.int("VOICE_LOG_EVERY", 1), 0)),
+		hubOnly: env.bool("VOICE_LOG_HUB_ONLY", false),
+	}
+}
+
+// sample counts an update and reports whether it should be logged.
+func (l *voiceLog) sample(hubRelated bool) bool {
+	l.seen.Add(1)
+	if l.every == 0 || (l.hubOnly && !hubRelated) {
+		return false
+	}
+	if (l.candidates.Add(1)-1)%l.every != 0 {
+		return false
+	}
+	l.logged.Add(1)
+	return true
+}
+
+// summarize prints how many updates were processed every interval until ctx
+// is done.
+func (l *voiceLog) summarize(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			seen, logged := l.seen.Swap(0), l.logged.Swap(0)
+			if seen > 0 {
+				slog.Info("Processed voice state updates", "window", interval, "seen", seen, "logged", logged)
+			}
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package tvc
+
+import (
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+)
+
+// setVoiceState records a member's voice state, keeping the per-channel
+// occupancy in step with it, and returns the state it replaces.
+func (h *handler) setVoiceState(vs discord.VoiceState) discord.VoiceState {
+	before := h.userVoiceStates[vs.UserID]
+	if members := h.channelMembers[before.ChannelID]; members != nil {
+		delete(members, vs.UserID)
+		if len(members) == 0 {
+			delete(h.channelMembers, before.ChannelID)
+		}
+	}
+	if vs.ChannelID.IsValid() {
+		members := h.channelMembers[vs.ChannelID]
+		if members == nil {
+			members = make(map[discord.UserID]bool)
+			h.channelMembers[vs.ChannelID] = members
+		}
+		members[vs.UserID] = true
+	}
+	h.userVoiceStates[vs.UserID] = vs
+	return before
+}
+
+// seedVoiceStates replaces what is known about who is connected in the guild
+// with its snapshot. Voice states otherwise only reach the handler as they
+// change, so without this, members already connected at startup, or who left
+// while the bot was away, would be miscounted.
+func (h *handler) seedVoiceStates(evt *gateway.GuildCreateEvent) {
+	for userID, vs := range h.userVoiceStates {
+		if vs.GuildID == evt.ID && vs.ChannelID.IsValid() {
+			h.setVoiceState(discord.VoiceState{GuildID: evt.ID, UserID: userID})
+		}
+	}
+	for _, vs := range evt.VoiceStates {
+		vs.GuildID = evt.ID
+		h.setVoiceState(vs)
+	}
+}
@@ -0,0 +1,245 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/diamondburned/arikawa/v3/utils/json/option"
+)
+
+// Custom IDs of the vote kick buttons.
+const (
+	voteKickYesID = "votekick-yes"
+	voteKickNoID  = "votekick-no"
+)
+
+// kickVote is an open vote on disconnecting someone from a room.
+type kickVote struct {
+	target discord.UserID
+	// votes maps each voter to whether they want the target out.
+	votes map[discord.UserID]bool
+	timer *time.Timer
+}
+
+// cmdVoteKick handles /voice votekick, which lets the people in a room vote
+// someone out without a moderator. The owner can't be voted out; they can
+// hand the room over or be outvoted by a claim vote instead.
+func (h *handler) cmdVoteKick(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	target, err := data.Options.Find("user").SnowflakeValue()
+	if err != nil {
+		return ephemeralData("Pick who to vote out.")
+	}
+	userID := discord.UserID(target)
+	starter := data.Event.SenderID()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	channelID := h.userVoiceStates[starter].ChannelID
+	r, ok := h.rooms[channelID]
+	if !ok {
+		return ephemeralData("You need to be in a room to do that.")
+	}
+	switch {
+	case userID == starter:
+		return ephemeralData("You can just leave.")
+	case userID == r.owner:
+		return ephemeralData("The room's owner can't be voted out.")
+	case h.userVoiceStates[userID].ChannelID != channelID:
+		return ephemeralData(userID.Mention() + " isn't in this room.")
+	}
+	for _, vote := range r.kickVotes {
+		if vote.target == userID {
+			return ephemeralData("There is already a vote on " + userID.Mention() + ".")
+		}
+	}
+
+	vote := &kickVote{target: userID, votes: map[discord.UserID]bool{starter: true}}
+	ctx = withGuild(ctx, r.guildID)
+	var msgID discord.MessageID
+	err = h.call(ctx, "SendMessage", func(s *state.State) error {
+		msg, err := s.SendMessageComplex(r.chatChannel(), api.SendMessageData{
+			Content:         h.kickVoteText(channelID, vote),
+			Components:      kickVoteComponents(),
+			AllowedMentions: &api.AllowedMentions{},
+		})
+		if err != nil {
+			return err
+		}
+		msgID = msg.ID
+		return nil
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to post vote kick", "err", err)
+		return ephemeralData("I couldn't start the vote, try again.")
+	}
+	vote.timer = time.AfterFunc(h.cfg.voteKickTimeout, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		if h.rooms[channelID] != r || r.kickVotes[msgID] != vote {
+			return
+		}
+		delete(r.kickVotes, msgID)
+		h.endVote(withGuild(context.Background(), r.guildID), r, msgID,
+			"The vote on "+vote.target.Mention()+" ran out without a majority.")
+	})
+	r.kickVotes[msgID] = vote
+	return ephemeralData("Vote started.")
+}
+
+// onKickVote records a vote and disconnects the target once more than half
+// of the other people in the room want them out.
+func (h *handler) onKickVote(kick bool) cmdroute.ComponentHandlerFunc {
+	return func(ctx context.Context, data cmdroute.ComponentData) *api.InteractionResponse {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		channelID, r := h.roomByChat(data.Event.ChannelID)
+		var vote *kickVote
+		if r != nil {
+			vote = r.kickVotes[data.Event.Message.ID]
+		}
+		if vote == nil {
+			return ephemeral("This vote is over.")
+		}
+		voter := data.Event.SenderID()
+		if voter == vote.target || h.userVoiceStates[voter].ChannelID != channelID {
+			return ephemeral("Only the other people in this room can vote.")
+		}
+		vote.votes[voter] = kick
+
+		content := h.kickVoteText(channelID, vote)
+		components := kickVoteComponents()
+		if h.kickVotePassed(channelID, vote) {
+			vote.timer.Stop()
+			delete(r.kickVotes, data.Event.Message.ID)
+			h.voteOut(withGuild(ctx, r.guildID), channelID, r, vote.target)
+			content = fmt.Sprintf("%s was voted out and can't rejoin for %s.", vote.target.Mention(), formatDuration(h.cfg.voteKickBan))
+			components = discord.ContainerComponents{}
+			h.expireMessage(r, data.Event.Message.ID)
+		}
+		return &api.InteractionResponse{
+			Type: api.UpdateMessage,
+			Data: &api.InteractionResponseData{
+				Content:         option.NewNullableString(content),
+				Components:      &components,
+				AllowedMentions: &api.AllowedMentions{},
+			},
+		}
+	}
+}
+
+// kickVotePassed reports whether more than half of the people in the room,
+// not counting the target, voted to kick. Votes of people who left don't
+// count.
+func (h *handler) kickVotePassed(channelID discord.ChannelID, vote *kickVote) bool {
+	eligible := h.occupants(channelID)
+	if h.userVoiceStates[vote.target].ChannelID == channelID {
+		eligible--
+	}
+	yes := 0
+	for voter, kick := range vote.votes {
+		if kick && h.userVoiceStates[voter].ChannelID == channelID {
+			yes++
+		}
+	}
+	return yes*2 > eligible
+}
+
+// voteOut disconnects userID from the room and keeps them out for
+// voteKickBan.
+func (h *handler) voteOut(ctx context.Context, channelID discord.ChannelID, r *room, userID discord.UserID) {
+	if err := h.denyRoom(ctx, channelID, userID); err != nil {
+		slog.ErrorContext(ctx, "Failed to keep voted out member out", "err", err)
+	} else {
+		if t, ok := r.kickBans[userID]; ok {
+			t.Stop()
+		}
+		r.kickBans[userID] = time.AfterFunc(h.cfg.voteKickBan, func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+
+			if h.rooms[channelID] != r {
+				return
+			}
+			delete(r.kickBans, userID)
+			if slices.Contains(h.stats.roomBansOf(r.guildID, r.owner), userID) {
+				// The owner banned them meanwhile; keep the overwrite.
+				return
+			}
+			err := h.call(withGuild(context.Background(), r.guildID), "DeleteChannelPermission", func(s *state.State) error {
+				return s.DeleteChannelPermission(channelID, discord.Snowflake(userID), "vote kick expired")
+			})
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to let voted out member back", "err", err)
+			}
+		})
+	}
+	if h.userVoiceStates[userID].ChannelID == channelID {
+		h.queueMove(memberMove{
+			guildID:   r.guildID,
+			userID:    userID,
+			channelID: discord.NullChannelID,
+			reason:    "voted out of the room",
+			failure:   "Failed to disconnect voted out member",
+		})
+	}
+}
+
+// endVote replaces a vote's message with its outcome.
+func (h *handler) endVote(ctx context.Context, r *room, msgID discord.MessageID, outcome string) {
+	chat := r.chatChannel()
+	err := h.call(ctx, "EditMessage", func(s *state.State) error {
+		_, err := s.EditMessageComplex(chat, msgID, api.EditMessageData{
+			Content:         option.NewNullableString(outcome),
+			Components:      &discord.ContainerComponents{},
+			AllowedMentions: &api.AllowedMentions{},
+		})
+		return err
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to close vote", "err", err)
+	}
+	h.expireMessage(r, msgID)
+}
+
+func (h *handler) kickVoteText(channelID discord.ChannelID, vote *kickVote) string {
+	yes, no := 0, 0
+	for voter, kick := range vote.votes {
+		if h.userVoiceStates[voter].ChannelID != channelID {
+			continue
+		}
+		if kick {
+			yes++
+		} else {
+			no++
+		}
+	}
+	return fmt.Sprintf("Vote to disconnect %s from this room; more than half of the people here need to agree.\nKick: %d, keep: %d",
+		vote.target.Mention(), yes, no)
+}
+
+func kickVoteComponents() discord.ContainerComponents {
+	return discord.ContainerComponents{
+		&discord.ActionRowComponent{
+			&discord.ButtonComponent{
+				Style:    discord.DangerButtonStyle(),
+				CustomID: voteKickYesID,
+				Label:    "Kick",
+			},
+			&discord.ButtonComponent{
+				Style:    discord.SecondaryButtonStyle(),
+				CustomID: voteKickNoID,
+				Label:    "Keep",
+			},
+		},
+	}
+}
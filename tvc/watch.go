@@ -0,0 +1,187 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/diamondburned/arikawa/v3/utils/httputil"
+)
+
+// watchActivity is an embedded activity /voice watch can start in a room.
+type watchActivity struct {
+	key   string
+	name  string
+	appID discord.AppID
+}
+
+// watchActivities are the activities /voice watch knows, Watch Together
+// first as the default.
+var watchActivities = []watchActivity{
+	{"watch-together", "Watch Together", 880218394199220334},
+	{"sketch-heads", "Sketch Heads", 902271654783242291},
+	{"letter-league", "Letter League", 879863686565621790},
+	{"spellcast", "SpellCast", 852509694341283871},
+	{"chess", "Chess in the Park", 832012774040141894},
+	{"checkers", "Checkers in the Park", 832013003968348200},
+	{"poker", "Poker Night", 755827207812677713},
+	{"blazing-8s", "Blazing 8s", 832025144389533716},
+	{"putt-party", "Putt Party", 945737671223947305},
+	{"land-io", "Land-io", 903769130790969345},
+	{"bobble-league", "Bobble League", 947957217959759964},
+	{"know-what-i-meme", "Know What I Meme", 950505761862189096},
+}
+
+// parseWatchActivities reads a comma-separated list of activity keys, or
+// returns nil for an unset list.
+func parseWatchActivities(env *environment, key, value string) map[string]bool {
+	if _, ok := env.lookup(key); !ok {
+		return nil
+	}
+	allowed := make(map[string]bool)
+	for _, k := range strings.Split(value, ",") {
+		if k = strings.TrimSpace(k); k == "" {
+			continue
+		}
+		if findWatchActivity(k) == nil {
+			env.configError("unknown activity %q in $%s", k, key)
+			continue
+		}
+		allowed[k] = true
+	}
+	return allowed
+}
+
+func parseGuildWatchActivities(env *environment) map[discord.GuildID]map[string]bool {
+	const prefix = "WATCH_ACTIVITIES_"
+	guilds := make(map[discord.GuildID]map[string]bool)
+	for _, kv := range env.environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		guildID, err := discord.ParseSnowflake(strings.TrimPrefix(key, prefix))
+		if err != nil {
+			env.configError("invalid guild ID in $%s: %v", key, err)
+			continue
+		}
+		guilds[discord.GuildID(guildID)] = parseWatchActivities(env, key, value)
+	}
+	return guilds
+}
+
+func findWatchActivity(key string) *watchActivity {
+	for i := range watchActivities {
+		if watchActivities[i].key == key {
+			return &watchActivities[i]
+		}
+	}
+	return nil
+}
+
+// watchActivitiesIn returns the activities members of guildID may start.
+func (h *handler) watchActivitiesIn(guildID discord.GuildID) []watchActivity {
+	allowed, ok := h.cfg.guildWatchAllowed[guildID]
+	if !ok {
+		allowed = h.cfg.watchAllowed
+	}
+	if allowed == nil {
+		return watchActivities
+	}
+	var activities []watchActivity
+	for _, a := range watchActivities {
+		if allowed[a.key] {
+			activities = append(activities, a)
+		}
+	}
+	return activities
+}
+
+func watchChoices() []discord.StringChoice {
+	choices := make([]discord.StringChoice, len(watchActivities))
+	for i, a := range watchActivities {
+		choices[i] = discord.StringChoice{Name: a.name, Value: a.key}
+	}
+	return choices
+}
+
+// cmdWatch handles /voice watch, which starts an embedded activity, Watch
+// Together unless another is picked, in the caller's room and posts its
+// invite link to the room's chat.
+func (h *handler) cmdWatch(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	channelID := h.userVoiceStates[data.Event.SenderID()].ChannelID
+	r, ok := h.rooms[channelID]
+	if !ok {
+		return ephemeralData("You need to be in a room to do that.")
+	}
+	activities := h.watchActivitiesIn(r.guildID)
+	if len(activities) == 0 {
+		return ephemeralData("Activities are turned off in this server.")
+	}
+	activity := &activities[0]
+	if key := data.Options.Find("activity").String(); key != "" {
+		activity = nil
+		for i := range activities {
+			if activities[i].key == key {
+				activity = &activities[i]
+			}
+		}
+		if activity == nil {
+			names := make([]string, len(activities))
+			for i, a := range activities {
+				names[i] = a.name
+			}
+			return ephemeralData("That activity isn't available in this server. Try " + strings.Join(names, ", ") + ".")
+		}
+	}
+
+	ctx = withGuild(ctx, r.guildID)
+	var inv discord.Invite
+	err := h.call(ctx, "CreateInvite", func(s *state.State) error {
+		return createActivityInvite(s, channelID, activity.appID, &inv)
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to start activity", "activity", activity.key, "err", err)
+		return ephemeralData("Sorry, I couldn't start that activity. Try again later.")
+	}
+
+	link := "https://discord.gg/" + inv.Code
+	chat := r.chatChannel()
+	err = h.call(ctx, "SendMessage", func(s *state.State) error {
+		_, err := s.SendMessageComplex(chat, api.SendMessageData{
+			Content:         fmt.Sprintf("%s started %s: %s", data.Event.SenderID().Mention(), activity.name, link),
+			AllowedMentions: &api.AllowedMentions{},
+		})
+		return err
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to post activity invite", "err", err)
+		return ephemeralData(fmt.Sprintf("Started %s: %s", activity.name, link))
+	}
+	return ephemeralData(fmt.Sprintf("Started %s in %s.", activity.name, channelID.Mention()))
+}
+
+// embeddedAppInvite is the invite target type of embedded activities.
+const embeddedAppInvite = 2
+
+// createActivityInvite creates an invite to play appID's activity in the
+// voice channel. arikawa's CreateInvite can't target an application yet.
+func createActivityInvite(s *state.State, channelID discord.ChannelID, appID discord.AppID, inv *discord.Invite) error {
+	return s.RequestJSON(
+		inv, "POST", api.EndpointChannels+channelID.String()+"/invites",
+		httputil.WithJSONBody(struct {
+			MaxAge              uint          `json:"max_age"`
+			TargetType          int           `json:"target_type"`
+			TargetApplicationID discord.AppID `json:"target_application_id"`
+		}{3600, embeddedAppInvite, appID}),
+		httputil.WithHeaders(api.AuditLogReason("room activity").Header()),
+	)
+}
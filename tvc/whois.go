@@ -0,0 +1,97 @@
+package tvc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/api/cmdroute"
+	"github.com/diamondburned/arikawa/v3/discord"
+)
+
+// cmdWhois handles /voiceadmin whois, which looks up a room in the stats
+// store. The channel is given as text since deleted channels can't be picked.
+func (h *handler) cmdWhois(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	arg := data.Options.Find("channel").String()
+	id, err := parseChannelMention(arg)
+	if err != nil {
+		return ephemeralData(fmt.Sprintf("%q isn't a channel mention or ID.", arg))
+	}
+
+	rec := h.stats.creation(id)
+	if rec == nil || rec.GuildID != data.Event.GuildID {
+		return ephemeralData("There is no record of a room with that ID.")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%s** (%s)\n", rec.Name, rec.ChannelID.Mention())
+	fmt.Fprintf(&b, "Created by %s (%s) from %s <t:%d:f>\n", rec.OwnerID.Mention(), rec.OwnerID, rec.Hub, rec.CreatedAt.Unix())
+	if len(rec.Ancestors) > 0 {
+		b.WriteString("Recreated from:")
+		for _, id := range rec.Ancestors {
+			fmt.Fprintf(&b, " %s", id)
+		}
+		b.WriteString("\n")
+	}
+	if !rec.DeletedAt.IsZero() {
+		fmt.Fprintf(&b, "Deleted <t:%d:f>\n", rec.DeletedAt.Unix())
+	}
+	if len(rec.Renames) > 0 {
+		b.WriteString("Names:")
+		for _, rename := range append([]roomRename{{Name: rec.Name, At: rec.CreatedAt}}, rec.Renames...) {
+			flag := ""
+			if h.bannedWordIn(rename.Name) != "" {
+				flag = " 🚩"
+			}
+			fmt.Fprintf(&b, "\n- %s <t:%d:f>%s", rename.Name, rename.At.Unix(), flag)
+		}
+		b.WriteString("\n")
+	}
+	for _, note := range rec.Notes {
+		fmt.Fprintf(&b, "📝 %s <t:%d:f>: %s\n", note.By.Mention(), note.At.Unix(), note.Text)
+	}
+	b.WriteString("Participants:")
+	for _, userID := range rec.Participants {
+		line := fmt.Sprintf("\n- %s (%s)", userID.Mention(), userID)
+		if b.Len()+len(line) > 1900 {
+			b.WriteString("\n- …")
+			break
+		}
+		b.WriteString(line)
+	}
+
+	resp := ephemeralData(b.String())
+	resp.AllowedMentions = &api.AllowedMentions{}
+	return resp
+}
+
+// cmdNote handles /voiceadmin note, which attaches a note for moderators to a
+// room's record. The room's channel is left alone.
+func (h *handler) cmdNote(ctx context.Context, data cmdroute.CommandData) *api.InteractionResponseData {
+	arg := data.Options.Find("channel").String()
+	id, err := parseChannelMention(arg)
+	if err != nil {
+		return ephemeralData(fmt.Sprintf("%q isn't a channel mention or ID.", arg))
+	}
+	if rec := h.stats.creation(id); rec == nil || rec.GuildID != data.Event.GuildID {
+		return ephemeralData("There is no record of a room with that ID.")
+	}
+
+	note := roomNote{
+		By:   data.Event.SenderID(),
+		At:   time.Now(),
+		Text: strings.TrimSpace(data.Options.Find("text").String()),
+	}
+	if note.Text == "" || !h.stats.addNote(id, note) {
+		return ephemeralData("There is no record of a room with that ID.")
+	}
+	return ephemeralData("Noted. It shows up in /voiceadmin whois.")
+}
+
+// parseChannelMention parses a channel given as a mention or a bare ID.
+func parseChannelMention(arg string) (discord.ChannelID, error) {
+	id, err := discord.ParseSnowflake(strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(arg), "<#"), ">"))
+	return discord.ChannelID(id), err
+}